@@ -134,7 +134,7 @@ func CLI(
 		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
 
-		res, err := client.Raw(ctx, query)
+		res, contentType, err := client.RawTyped(ctx, query)
 		if err != nil {
 			if errors.Is(err, context.Canceled) {
 				continue
@@ -154,7 +154,12 @@ func CLI(
 			continue
 		}
 
-		resBytes := database.WrapOK(string(processInput([]byte(res))))
+		// Only the structured results are flattened, the text values are printed as is.
+		if contentType == database.ContentJSON || contentType == database.ContentList {
+			res = string(processInput([]byte(res)))
+		}
+
+		resBytes := database.WrapOK(res)
 		if _, err = rl.Write(append([]byte(resBytes), '\n')); err != nil {
 			return errors.Join(ErrWriteLineFailed, err)
 		}
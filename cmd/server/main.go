@@ -4,12 +4,15 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/neekrasov/kvdb/internal/application"
 	"github.com/neekrasov/kvdb/internal/config"
+	"github.com/neekrasov/kvdb/pkg/logger"
 	"github.com/spf13/cobra"
+	"go.uber.org/zap"
 )
 
 var (
@@ -59,7 +62,32 @@ func startServer(cfgPath string) {
 		log.Fatalf("failed to get config: %s", err)
 	}
 
-	if err := application.New(&cfg).Start(ctx); err != nil {
+	app := application.New(&cfg, application.WithVersion(version))
+	go reloadOnSignal(ctx, cfgPath, app)
+
+	if err := app.Start(ctx); err != nil {
 		log.Fatalf("application error: %s", err)
 	}
 }
+
+// reloadOnSignal - re-reads the config on SIGHUP and applies it to the running application.
+func reloadOnSignal(ctx context.Context, cfgPath string, app *application.Application) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-hup:
+			cfg, err := config.GetConfig(cfgPath)
+			if err != nil {
+				logger.Warn("config reload failed", zap.Error(err))
+				continue
+			}
+
+			app.Reload(&cfg)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -3,44 +3,80 @@ package application
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/neekrasov/kvdb/internal/config"
 	"github.com/neekrasov/kvdb/internal/database"
 	"github.com/neekrasov/kvdb/internal/database/compute"
-	"github.com/neekrasov/kvdb/internal/database/identity"
 	"github.com/neekrasov/kvdb/internal/database/storage"
 	"github.com/neekrasov/kvdb/internal/database/storage/replication"
+	"github.com/neekrasov/kvdb/internal/delivery/http"
 	"github.com/neekrasov/kvdb/internal/delivery/tcp"
 	"github.com/neekrasov/kvdb/pkg/logger"
 	"github.com/neekrasov/kvdb/pkg/sizeutil"
 	"go.uber.org/zap"
 )
 
+// walStopTimeout - limits the wait for the final WAL flush on shutdown.
+const walStopTimeout = 10 * time.Second
+
 // Application - represents the main application that starts the server and handles signals.
 type Application struct {
-	cfg *config.Config
+	cfg     *config.Config
+	version string
+
+	// mu - guards the components reconfigured by Reload.
+	mu      sync.Mutex
+	server  *tcp.Server
+	storage *storage.Storage
+}
+
+// Option - is a functional option type for configuring an Application instance.
+type Option func(*Application)
+
+// WithVersion - sets the server version reported to the clients.
+func WithVersion(version string) Option {
+	return func(a *Application) {
+		a.version = version
+	}
 }
 
 // New - creates and returns a new instance of Application.
-func New(cfg *config.Config) *Application {
-	return &Application{cfg: cfg}
+func New(cfg *config.Config, opts ...Option) *Application {
+	app := &Application{cfg: cfg}
+	for _, opt := range opts {
+		opt(app)
+	}
+
+	return app
 }
 
 // Start - initializes configuration, logger, database, and server, then starts the server and handles termination signals.
 func (a *Application) Start(ctx context.Context) error {
-	logger.InitLogger(a.cfg.Logging.Level, a.cfg.Logging.Output)
+	logger.InitLogger(a.cfg.Logging.Level, a.cfg.Logging.Output, a.cfg.Logging.Format)
+
+	if err := checkRootCredentials(a.cfg); err != nil {
+		return err
+	}
 
 	engine, err := initEngine(a.cfg.Engine)
 	if err != nil {
 		return fmt.Errorf("initialize engine failed: %w", err)
 	}
 
-	wal, err := initWAL(a.cfg.WAL)
+	wal, err := initWAL(a.cfg.WAL, a.cfg.Replication)
 	if err != nil {
 		return fmt.Errorf("initialize wal failed: %w", err)
 	}
 	defer func() {
+		// The context is done on shutdown, so the final flush is limited by its own timeout.
+		stopCtx, cancel := context.WithTimeout(context.Background(), walStopTimeout)
+		defer cancel()
+		if err := wal.Stop(stopCtx); err != nil {
+			logger.Error("failed to flush wal on stop", zap.Error(err))
+		}
+
 		if err := wal.Close(); err != nil {
 			logger.Debug("failed to close wal", zap.Error(err))
 		}
@@ -81,16 +117,48 @@ func (a *Application) Start(ctx context.Context) error {
 	if cfg := a.cfg.CleanupConfig; cfg != nil {
 		options = append(options,
 			storage.WithCleanupPeriod(cfg.Period),
+			storage.WithCleanupJitter(cfg.Jitter),
 			storage.WithCleanupBatchSize(cfg.BatchSize),
 		)
 		logger.Debug("init background cleanup",
 			zap.Stringer("period", a.cfg.CleanupConfig.Period),
+			zap.Stringer("jitter", a.cfg.CleanupConfig.Jitter),
 			zap.Int("batch_size", a.cfg.CleanupConfig.BatchSize),
 		)
 	}
 
-	if a.cfg.StatEnabled {
+	if idle := a.cfg.Engine.IdleEviction; idle > 0 {
+		options = append(options, storage.WithIdleEviction(idle))
+		logger.Debug("init idle keys eviction", zap.Stringer("idle_eviction", idle))
+	}
+
+	if sep := a.cfg.Engine.KeySeparator; sep != "" {
+		options = append(options, storage.WithKeySeparator(sep))
+		logger.Debug("set key separator", zap.String("key_separator", sep))
+	}
+
+	if a.cfg.Engine.StatisticsEnabled() {
 		options = append(options, storage.WithStatistics())
+	} else {
+		logger.Debug("storage statistics disabled")
+	}
+
+	if msize := a.cfg.Engine.MaxValueSize; msize != "" {
+		size, err := sizeutil.ParseSize(msize)
+		if err != nil {
+			return fmt.Errorf("parse max value size failed: %w", err)
+		}
+
+		logger.Debug("set max_value_size bytes", zap.Int("max_value_size", size))
+		options = append(options, storage.WithMaxValueSize(size))
+	}
+
+	snapshotter, err := initSnapshotter(a.cfg.WAL)
+	if err != nil {
+		return fmt.Errorf("initialize snapshotter failed: %w", err)
+	}
+	if snapshotter != nil {
+		options = append(options, storage.WithSnapshotter(snapshotter, a.cfg.WAL.SnapshotPeriod))
 	}
 
 	dstorage, err := storage.NewStorage(ctx, engine, options...)
@@ -117,6 +185,26 @@ func (a *Application) Start(ctx context.Context) error {
 		tcpServerOpts = append(tcpServerOpts, tcp.WithServerIdleTimeout(timeout))
 	}
 
+	if timeout := a.cfg.Network.MaxIdleTimeout; timeout != 0 {
+		logger.Debug("set tcp max idle timeout", zap.Stringer("max_idle_timeout", timeout))
+		tcpServerOpts = append(tcpServerOpts, tcp.WithServerMaxIdleTimeout(timeout))
+	}
+
+	if timeout := a.cfg.Network.CommandTimeout; timeout != 0 {
+		logger.Debug("set tcp command timeout", zap.Stringer("command_timeout", timeout))
+		tcpServerOpts = append(tcpServerOpts, tcp.WithServerCommandTimeout(timeout))
+	}
+
+	if a.cfg.Security != nil && a.cfg.Security.AuthTimeout != 0 {
+		logger.Debug("set tcp auth timeout", zap.Stringer("auth_timeout", a.cfg.Security.AuthTimeout))
+		tcpServerOpts = append(tcpServerOpts, tcp.WithServerAuthTimeout(a.cfg.Security.AuthTimeout))
+	}
+
+	if a.cfg.Network.Compression {
+		logger.Debug("enable tcp response compression")
+		tcpServerOpts = append(tcpServerOpts, tcp.WithServerCompression(true))
+	}
+
 	if mcons := a.cfg.Network.MaxConnections; mcons != 0 {
 		logger.Debug("set tcp max connections", zap.Int("max_connections", int(mcons)))
 		tcpServerOpts = append(tcpServerOpts, tcp.WithServerMaxConnectionsNumber(mcons))
@@ -135,15 +223,79 @@ func (a *Application) Start(ctx context.Context) error {
 		bufferSize = size
 	}
 
+	if name := a.cfg.Network.Protocol; name != "" {
+		protocol, err := tcp.ParseProtocol(name)
+		if err != nil {
+			return err
+		}
+
+		logger.Debug("set tcp protocol", zap.String("protocol", name))
+		tcpServerOpts = append(tcpServerOpts, tcp.WithServerProtocol(protocol))
+	}
+
 	var sessionLifeTime time.Duration
 	if a.cfg.PwdPolicyConfig != nil {
 		sessionLifeTime = a.cfg.PwdPolicyConfig.SessionLifeTime
 	}
 
+	sessions := initSessionStorage(ctx, sessionLifeTime, a.cfg.Security)
+
+	var server *tcp.Server
+	dbOpts := []database.Option{
+		database.WithServerVersion(a.version),
+		database.WithSessionCloser(func(sessionID string) {
+			if !server.CloseSession(sessionID) {
+				logger.Debug("connection for killed session not found",
+					zap.String("session", sessionID))
+			}
+		}),
+		database.WithConnectionsLister(func() []database.ConnInfo {
+			conns := server.Connections()
+			infos := make([]database.ConnInfo, 0, len(conns))
+			for _, conn := range conns {
+				infos = append(infos, database.ConnInfo{
+					SessionID:    conn.SessionID,
+					RemoteAddr:   conn.RemoteAddr,
+					ConnectedAt:  conn.ConnectedAt,
+					BytesRead:    conn.BytesRead,
+					BytesWritten: conn.BytesWritten,
+					Command:      conn.Command,
+				})
+			}
+			return infos
+		}),
+	}
+	if threshold := a.cfg.Logging.SlowQueryThreshold; threshold != 0 {
+		logger.Debug("set slow query threshold", zap.Stringer("slow_query_threshold", threshold))
+		dbOpts = append(dbOpts, database.WithSlowQueryThreshold(threshold))
+	}
+	if a.cfg.Security != nil && a.cfg.Security.RateLimit > 0 {
+		logger.Debug("set session rate limit",
+			zap.Float64("rate_limit", a.cfg.Security.RateLimit),
+			zap.Int("rate_burst", a.cfg.Security.RateBurst))
+		dbOpts = append(dbOpts, database.WithRateLimit(a.cfg.Security.RateLimit, a.cfg.Security.RateBurst))
+	}
+	if a.cfg.Security != nil && a.cfg.Security.TokenTTL != 0 {
+		dbOpts = append(dbOpts, database.WithTokenTTL(a.cfg.Security.TokenTTL))
+	}
+
+	validators, err := initValueValidators(a.cfg)
+	if err != nil {
+		return fmt.Errorf("initialize value validators failed: %w", err)
+	}
+	if len(validators) > 0 {
+		dbOpts = append(dbOpts, database.WithValueValidators(validators))
+	}
+
+	trie, err := initCommandTrie(a.cfg.CommandAliases)
+	if err != nil {
+		return err
+	}
+
 	db := database.New(
-		compute.NewParser(initCommandTrie()), dstorage,
+		compute.NewParser(trie), dstorage,
 		usersStorage, namespaceStorage, rolesStorage,
-		identity.NewSessionStorage(sessionLifeTime), a.cfg.Root,
+		sessions, a.cfg.Root, dbOpts...,
 	)
 
 	onConnectHandler := initOnConnectHandler(bufferSize, db)
@@ -152,13 +304,36 @@ func (a *Application) Start(ctx context.Context) error {
 	tcpServerOpts = append(tcpServerOpts,
 		tcp.WithConnectionHandler(onConnectHandler),
 		tcp.WithDisconnectionHandler(onDisconnectHandler),
+		tcp.WithServerHealthCheck(func() []byte { return []byte(db.Health()) }),
 	)
 
-	server, err := tcp.NewServer(a.cfg.Network.Address, tcpServerOpts...)
+	server, err = tcp.NewServer(a.cfg.Network.Address, tcpServerOpts...)
 	if err != nil {
 		return fmt.Errorf("init tcp server failed: %w", err)
 	}
 
+	a.mu.Lock()
+	a.server, a.storage = server, dstorage
+	a.mu.Unlock()
+
+	if a.cfg.Metrics != nil && a.cfg.Metrics.Address != "" {
+		metricsServer, err := http.NewServer(a.cfg.Metrics.Address,
+			http.WithStats(dstorage),
+			http.WithSessions(sessions),
+			http.WithConnections(server),
+		)
+		if err != nil {
+			return fmt.Errorf("init metrics server failed: %w", err)
+		}
+		defer func() {
+			if err := metricsServer.Close(); err != nil {
+				logger.Debug("failed to close metrics server", zap.Error(err))
+			}
+		}()
+
+		go metricsServer.Start(ctx)
+	}
+
 	server.Start(ctx, initQueryHandler(db))
 	if err = server.Close(); err != nil {
 		return fmt.Errorf("failed to close server: %w", err)
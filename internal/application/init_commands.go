@@ -1,34 +1,108 @@
 package application
 
 import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
 	"github.com/neekrasov/kvdb/internal/database/compute"
+	"github.com/neekrasov/kvdb/pkg/logger"
+	"go.uber.org/zap"
 )
 
-func initCommandTrie() *compute.TrieNode {
+// initCommandTrie - builds the trie of the commands and registers the aliases of the commands.
+func initCommandTrie(aliases map[string]string) (*compute.TrieNode, error) {
+	root := commandTrie()
+	for _, alias := range slices.Sorted(maps.Keys(aliases)) {
+		command := compute.CommandType(strings.Join(strings.Fields(strings.ToLower(aliases[alias])), " "))
+		if err := root.Alias(alias, command); err != nil {
+			return nil, fmt.Errorf("register command alias failed: %w", err)
+		}
+
+		logger.Debug("register command alias", zap.String("alias", alias), zap.Stringer("command", command))
+	}
+
+	return root, nil
+}
+
+func commandTrie() *compute.TrieNode {
 	root := compute.NewTrieNode()
 	root.Insert(compute.CommandSET, map[string]compute.CommandParam{
-		compute.KeyArg:   {Required: true, Positional: true, Position: 0},
-		compute.ValueArg: {Required: true, Positional: true, Position: 1},
+		compute.KeyArg:        {Required: true, Positional: true, Position: 0},
+		compute.ValueArg:      {Required: true, Positional: true, Position: 1},
+		compute.TTLArg:        {Required: false, Positional: false},
+		compute.NSArg:         {Required: false, Positional: false},
+		compute.GetFlagArg:    {Required: false, Flag: true},
+		compute.Base64FlagArg: {Required: false, Flag: true},
+	})
+	root.Insert(compute.CommandSETEX, map[string]compute.CommandParam{
+		compute.KeyArg:     {Required: true, Positional: true, Position: 0},
+		compute.SecondsArg: {Required: true, Positional: true, Position: 1},
+		compute.ValueArg:   {Required: true, Positional: true, Position: 2},
+		compute.NSArg:      {Required: false, Positional: false},
+	})
+	root.Insert(compute.CommandGET, map[string]compute.CommandParam{
+		compute.KeyArg:            {Required: true, Positional: true, Position: 0},
+		compute.TTLArg:            {Required: false, Positional: false},
+		compute.NSArg:             {Required: false, Positional: false},
+		compute.DefaultArg:        {Required: false, Positional: false},
+		compute.SetDefaultFlagArg: {Required: false, Flag: true},
+		compute.Base64FlagArg:     {Required: false, Flag: true},
+	})
+	root.Insert(compute.CommandDEL, map[string]compute.CommandParam{
+		compute.KeyArg:   {Required: true, Positional: true, Position: 0, Variadic: true},
 		compute.TTLArg:   {Required: false, Positional: false},
 		compute.NSArg:    {Required: false, Positional: false},
+		compute.CountArg: {Required: false, Flag: true},
 	})
-	root.Insert(compute.CommandGET, map[string]compute.CommandParam{
+	root.Insert(compute.CommandDELPREFIX, map[string]compute.CommandParam{
+		compute.PrefixArg: {Required: true, Positional: true, Position: 0},
+		compute.NSArg:     {Required: false, Positional: false},
+	})
+	root.Insert(compute.CommandMSETNX, map[string]compute.CommandParam{
+		compute.PairsArg: {Required: true, Positional: true, Position: 0, Variadic: true},
+		compute.NSArg:    {Required: false, Positional: false},
+	})
+	root.Insert(compute.CommandGETDEL, map[string]compute.CommandParam{
+		compute.KeyArg:        {Required: true, Positional: true, Position: 0},
+		compute.NSArg:         {Required: false, Positional: false},
+		compute.Base64FlagArg: {Required: false, Flag: true},
+	})
+	root.Insert(compute.CommandTYPE, map[string]compute.CommandParam{
 		compute.KeyArg: {Required: true, Positional: true, Position: 0},
-		compute.TTLArg: {Required: false, Positional: false},
 		compute.NSArg:  {Required: false, Positional: false},
 	})
-	root.Insert(compute.CommandDEL, map[string]compute.CommandParam{
+	root.Insert(compute.CommandRENAMENX, map[string]compute.CommandParam{
+		compute.KeyArg:    {Required: true, Positional: true, Position: 0},
+		compute.NewKeyArg: {Required: true, Positional: true, Position: 1},
+		compute.NSArg:     {Required: false, Positional: false},
+	})
+	root.Insert(compute.CommandSCAN, map[string]compute.CommandParam{
+		compute.CursorArg: {Required: true, Positional: true, Position: 0},
+		compute.MatchArg:  {Required: false, Positional: false},
+		compute.CountArg:  {Required: false, Positional: false},
+		compute.NSArg:     {Required: false, Positional: false},
+	})
+	root.Insert(compute.CommandEXPIRING, map[string]compute.CommandParam{
+		compute.WithinArg: {Required: true, Positional: true, Position: 0},
+		compute.NSArg:     {Required: false, Positional: false},
+	})
+	root.Insert(compute.CommandLASTACCESS, map[string]compute.CommandParam{
 		compute.KeyArg: {Required: true, Positional: true, Position: 0},
-		compute.TTLArg: {Required: false, Positional: false},
 		compute.NSArg:  {Required: false, Positional: false},
 	})
 	root.Insert(compute.CommandAUTH, map[string]compute.CommandParam{
 		compute.UsernameArg: {Required: true, Positional: true, Position: 0},
 		compute.PasswordArg: {Required: true, Positional: true, Position: 1},
 	})
+	root.Insert(compute.CommandLOGINTOKEN, map[string]compute.CommandParam{
+		compute.TokenArg: {Required: true, Positional: true, Position: 0},
+	})
 	root.Insert(compute.CommandCREATEUSER, map[string]compute.CommandParam{
 		compute.UsernameArg: {Required: true, Positional: true, Position: 0},
 		compute.PasswordArg: {Required: true, Positional: true, Position: 1},
+		compute.NSArg:       {Required: false, Positional: false},
 	})
 	root.Insert(compute.CommandGETUSER, map[string]compute.CommandParam{
 		compute.UsernameArg: {Required: true, Positional: true, Position: 0},
@@ -44,37 +118,111 @@ func initCommandTrie() *compute.TrieNode {
 		compute.UsernameArg: {Required: true, Positional: true, Position: 0},
 		compute.RoleArg:     {Required: true, Positional: true, Position: 1},
 	})
+	root.Insert(compute.CommandDEDUPROLES, map[string]compute.CommandParam{
+		compute.UsernameArg: {Required: false, Positional: true, Position: 0},
+	})
 	root.Insert(compute.CommandCREATEROLE, map[string]compute.CommandParam{
 		compute.RoleNameArg:    {Required: true, Positional: true, Position: 0},
 		compute.PermissionsArg: {Required: true, Positional: true, Position: 1},
 		compute.NamespaceArg:   {Required: true, Positional: true, Position: 2},
 	})
+	root.Insert(compute.CommandUPDATEROLE, map[string]compute.CommandParam{
+		compute.RoleNameArg:    {Required: true, Positional: true, Position: 0},
+		compute.PermissionsArg: {Required: true, Positional: true, Position: 1},
+		compute.NamespaceArg:   {Required: false, Positional: true, Position: 2},
+	})
 	root.Insert(compute.CommandGETROLE, map[string]compute.CommandParam{
 		compute.RoleNameArg: {Required: true, Positional: true, Position: 0},
 	})
+	root.Insert(compute.CommandROLEUSERS, map[string]compute.CommandParam{
+		compute.RoleNameArg: {Required: true, Positional: true, Position: 0},
+	})
 	root.Insert(compute.CommandDELETEROLE, map[string]compute.CommandParam{
 		compute.RoleNameArg: {Required: true, Positional: true, Position: 0},
 	})
 	root.Insert(compute.CommandCREATENAMESPACE, map[string]compute.CommandParam{
-		compute.NamespaceArg: {Required: true, Positional: true, Position: 0},
+		compute.NamespaceArg:  {Required: true, Positional: true, Position: 0},
+		compute.DefaultTTLArg: {Required: false, Positional: false},
 	})
 	root.Insert(compute.CommandDELETENAMESPACE, map[string]compute.CommandParam{
 		compute.NamespaceArg: {Required: true, Positional: true, Position: 0},
 	})
+	root.Insert(compute.CommandGETNAMESPACE, map[string]compute.CommandParam{
+		compute.NamespaceArg: {Required: true, Positional: true, Position: 0},
+	})
+	root.Insert(compute.CommandFLUSHNS, map[string]compute.CommandParam{
+		compute.NamespaceArg: {Required: true, Positional: true, Position: 0},
+	})
 	root.Insert(compute.CommandSETNS, map[string]compute.CommandParam{
 		compute.NamespaceArg: {Required: true, Positional: true, Position: 0},
 	})
-	root.Insert(compute.CommandUSERS, nil)
+	root.Insert(compute.CommandSETROLE, map[string]compute.CommandParam{
+		compute.RoleNameArg: {Required: true, Positional: true, Position: 0},
+	})
+	root.Insert(compute.CommandKILLSESSION, map[string]compute.CommandParam{
+		compute.SessionIDArg: {Required: true, Positional: true, Position: 0},
+	})
+	root.Insert(compute.CommandUSERS, map[string]compute.CommandParam{
+		compute.OffsetArg: {Required: false, Positional: false},
+		compute.LimitArg:  {Required: false, Positional: false},
+	})
 	root.Insert(compute.CommandME, nil)
-	root.Insert(compute.CommandROLES, nil)
+	root.Insert(compute.CommandSESSIONINFO, nil)
+	root.Insert(compute.CommandCAPS, nil)
+	root.Insert(compute.CommandTOKEN, nil)
+	root.Insert(compute.CommandLOGOUT, nil)
+	root.Insert(compute.CommandVERSION, nil)
+	root.Insert(compute.CommandEXPORT, map[string]compute.CommandParam{
+		compute.NamespaceArg: {Required: true, Positional: true, Position: 0},
+	})
+	root.Insert(compute.CommandIMPORT, map[string]compute.CommandParam{
+		compute.NamespaceArg: {Required: true, Positional: true, Position: 0},
+		compute.DataArg:      {Required: true, Positional: true, Position: 1},
+	})
+	root.Insert(compute.CommandROLES, map[string]compute.CommandParam{
+		compute.OffsetArg: {Required: false, Positional: false},
+		compute.LimitArg:  {Required: false, Positional: false},
+	})
 	root.Insert(compute.CommandNAMESPACES, nil)
 	root.Insert(compute.CommandSESSIONS, nil)
+	root.Insert(compute.CommandCONNECTIONS, nil)
 	root.Insert(compute.CommandHELP, nil)
+	root.Insert(compute.CommandHEALTH, nil)
+	root.Insert(compute.CommandPUBLISH, map[string]compute.CommandParam{
+		compute.ChannelArg: {Required: true, Positional: true, Position: 0},
+		compute.MessageArg: {Required: true, Positional: true, Position: 1},
+		compute.NSArg:      {Required: false, Positional: false},
+	})
+	root.Insert(compute.CommandSUBSCRIBE, map[string]compute.CommandParam{
+		compute.ChannelArg: {Required: true, Positional: true, Position: 0},
+		compute.NSArg:      {Required: false, Positional: false},
+	})
+	root.Insert(compute.CommandLISTEN, map[string]compute.CommandParam{
+		compute.ChannelArg: {Required: true, Positional: true, Position: 0},
+		compute.NSArg:      {Required: false, Positional: false},
+	})
 	root.Insert(compute.CommandWATCH, map[string]compute.CommandParam{
 		compute.KeyArg: {Required: true, Positional: true, Position: 0},
 		compute.NSArg:  {Required: false, Positional: false},
 	})
 	root.Insert(compute.CommandSTAT, nil)
+	root.Insert(compute.CommandDBSIZE, nil)
+	root.Insert(compute.CommandWALLATENCY, nil)
+	root.Insert(compute.CommandCOMPACT, nil)
+	root.Insert(compute.CommandFSYNC, nil)
+	root.Insert(compute.CommandSETMAXSIZE, map[string]compute.CommandParam{
+		compute.PatternArg: {Required: true, Positional: true, Position: 0},
+		compute.SizeArg:    {Required: true, Positional: true, Position: 1},
+		compute.NSArg:      {Required: false, Positional: false},
+	})
+	root.Insert(compute.CommandDUMP, map[string]compute.CommandParam{
+		compute.KeyArg: {Required: true, Positional: true, Position: 0},
+		compute.NSArg:  {Required: false, Positional: false},
+	})
+	root.Insert(compute.CommandGETMAXSIZE, map[string]compute.CommandParam{
+		compute.KeyArg: {Required: true, Positional: true, Position: 0},
+		compute.NSArg:  {Required: false, Positional: false},
+	})
 
 	return root
 }
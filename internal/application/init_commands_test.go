@@ -0,0 +1,57 @@
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/neekrasov/kvdb/internal/config"
+	"github.com/neekrasov/kvdb/internal/database"
+	"github.com/neekrasov/kvdb/internal/database/compute"
+	"github.com/neekrasov/kvdb/internal/database/identity"
+	"github.com/neekrasov/kvdb/internal/database/identity/models"
+	"github.com/neekrasov/kvdb/internal/database/storage"
+	"github.com/neekrasov/kvdb/internal/database/storage/engine"
+	"github.com/neekrasov/kvdb/internal/database/storage/wal"
+	"github.com/neekrasov/kvdb/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitCommandTrie_Aliases(t *testing.T) {
+	logger.MockLogger()
+
+	trie, err := initCommandTrie(map[string]string{"put": "set", "rm": "DEL"})
+	require.NoError(t, err)
+
+	parser := compute.NewParser(trie)
+	aliased, err := parser.Parse("put key value ttl 10s")
+	require.NoError(t, err)
+	original, err := parser.Parse("set key value ttl 10s")
+	require.NoError(t, err)
+	assert.Equal(t, original, aliased)
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{
+		Username:   "user",
+		ActiveRole: models.DefaultRole,
+	}))
+
+	db := database.New(parser, dstorage, identity.NewUsersStorage(dstorage),
+		identity.NewNamespaceStorage(dstorage), identity.NewRolesStorage(dstorage), sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	assert.Equal(t, db.HandleQuery(ctx, "session", "set a value"), db.HandleQuery(ctx, "session", "put b value"))
+	assert.Equal(t, db.HandleQuery(ctx, "session", "get a"), db.HandleQuery(ctx, "session", "get b"))
+	assert.Equal(t, db.HandleQuery(ctx, "session", "del a count"), db.HandleQuery(ctx, "session", "rm b count"))
+	assert.Equal(t, db.HandleQuery(ctx, "session", "get a"), db.HandleQuery(ctx, "session", "get b"))
+
+	_, err = initCommandTrie(map[string]string{"get": "set"})
+	assert.ErrorIs(t, err, compute.ErrInvalidCommand)
+
+	_, err = initCommandTrie(map[string]string{"put": "unknown"})
+	assert.ErrorIs(t, err, compute.ErrInvalidCommand)
+}
@@ -9,19 +9,29 @@ import (
 	"go.uber.org/zap"
 )
 
+// defaultShardedPartitionNum - number of the partitions of the sharded engine if it's not configured.
+const defaultShardedPartitionNum = 16
+
 func initEngine(cfg *config.EngineConfig) (*engine.Engine, error) {
 	if cfg == nil {
 		return nil, errors.New("empty engine config")
 	}
 
-	var partitionNum int
-	if cfg.PartitionNum == 0 {
+	partitionNum := cfg.PartitionNum
+	if partitionNum == 0 {
 		partitionNum = 1
-	} else {
-		partitionNum = cfg.PartitionNum
+		if cfg.Type == config.EngineTypeSharded {
+			partitionNum = defaultShardedPartitionNum
+		}
 	}
 
-	logger.Debug("init engine", zap.Int("partition_num", partitionNum))
+	logger.Debug("init engine", zap.String("type", cfg.Type), zap.Int("partition_num", partitionNum),
+		zap.Bool("track_access", cfg.TrackAccess))
+
+	options := []engine.Option{engine.WithPartitionNum(partitionNum)}
+	if cfg.TrackAccess {
+		options = append(options, engine.WithAccessTracking())
+	}
 
-	return engine.New(engine.WithPartitionNum(cfg.PartitionNum)), nil
+	return engine.New(options...), nil
 }
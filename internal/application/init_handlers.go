@@ -2,6 +2,7 @@ package application
 
 import (
 	"context"
+	"fmt"
 	"net"
 
 	"github.com/neekrasov/kvdb/internal/database"
@@ -18,12 +19,12 @@ func initOnConnectHandler(bufferSize int, db *database.Database) tcp.ConnectionH
 
 		_, err = db.Login(ctx, sessionID, string(buffer[:n]))
 		if err != nil {
-			_, err = conn.Write([]byte(database.WrapError(err)))
-			if err != nil {
-				return err
+			if _, werr := conn.Write([]byte(database.WrapError(err))); werr != nil {
+				return werr
 			}
 
-			return nil
+			// The failed login is reported to the server, it closes the connection under the auth timeout.
+			return fmt.Errorf("login failed: %w", err)
 		}
 
 		_, err = conn.Write([]byte(database.WrapOK("authentication successful")))
@@ -36,7 +37,7 @@ func initOnConnectHandler(bufferSize int, db *database.Database) tcp.ConnectionH
 }
 func initOnDisconnectHandler(db *database.Database) tcp.ConnectionHandler {
 	return func(ctx context.Context, sessionID string, conn net.Conn) error {
-		db.Logout(ctx, sessionID)
+		db.Disconnect(ctx, sessionID)
 		return nil
 	}
 }
@@ -3,8 +3,10 @@ package application
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/neekrasov/kvdb/internal/config"
+	"github.com/neekrasov/kvdb/internal/database"
 	"github.com/neekrasov/kvdb/internal/database/identity"
 	"github.com/neekrasov/kvdb/internal/database/identity/models"
 	"github.com/neekrasov/kvdb/internal/database/storage"
@@ -22,7 +24,7 @@ func initNamespacesStorage(
 		return nsStorage, nil
 	}
 
-	err := nsStorage.Save(ctx, models.DefaultNameSpace)
+	err := nsStorage.Save(ctx, &models.Namespace{Name: models.DefaultNameSpace})
 	if err != nil {
 		logger.Warn("save default namespace failed",
 			zap.Error(err), zap.String("name", models.DefaultNameSpace))
@@ -33,7 +35,13 @@ func initNamespacesStorage(
 			return nil, errors.New("invalid namaspace name in default list")
 		}
 
-		err := nsStorage.Save(ctx, namespace.Name)
+		if models.IsSystemNamespace(namespace.Name) {
+			return nil, fmt.Errorf("reserved namespace name '%s' in default list", namespace.Name)
+		}
+
+		err := nsStorage.Save(ctx, &models.Namespace{
+			Name: namespace.Name, DefaultTTL: namespace.DefaultTTL,
+		})
 		if err != nil {
 			logger.Warn("save namespace in default list failed",
 				zap.Error(err), zap.String("name", namespace.Name))
@@ -50,3 +58,24 @@ func initNamespacesStorage(
 
 	return nsStorage, nil
 }
+
+// initValueValidators - creates the validators of the values of the default namespaces with a value schema.
+func initValueValidators(cfg *config.Config) (map[string]database.ValueValidator, error) {
+	validators := make(map[string]database.ValueValidator)
+	for _, namespace := range cfg.DefaultNamespaces {
+		if namespace.ValueSchema == "" {
+			continue
+		}
+
+		validator, err := database.ParseValueSchema(namespace.ValueSchema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value schema of namespace '%s': %w", namespace.Name, err)
+		}
+		validators[namespace.Name] = validator
+
+		logger.Debug("set namespace value schema",
+			zap.String("name", namespace.Name), zap.String("value_schema", namespace.ValueSchema))
+	}
+
+	return validators, nil
+}
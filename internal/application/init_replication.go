@@ -19,8 +19,8 @@ import (
 )
 
 const (
-	masterType = "master"
-	slaveType  = "slave"
+	masterType = config.ReplicaTypeMaster
+	slaveType  = config.ReplicaTypeSlave
 )
 
 const (
@@ -90,6 +90,8 @@ func initReplica(
 			tcp.WithServerIdleTimeout(idleTimeout),
 			tcp.WithServerBufferSize(uint(maxMessageSize)),
 			tcp.WithServerMaxConnectionsNumber(uint(maxReplicasNumber)),
+			// The encoded segments may contain newlines, so the replication is framed.
+			tcp.WithServerProtocol(tcp.ProtocolBinary),
 		)
 		if err != nil {
 			return nil, err
@@ -117,6 +119,7 @@ func initReplica(
 	var options []tcp.ClientOption
 	options = append(options, tcp.WithClientIdleTimeout(idleTimeout))
 	options = append(options, tcp.WithClientBufferSize(uint(maxMessageSize)))
+	options = append(options, tcp.WithClientProtocol(tcp.ProtocolBinary))
 	client, err := tcp.NewClient(masterAddress, options...)
 	if err != nil {
 		return nil, err
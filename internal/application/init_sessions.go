@@ -0,0 +1,42 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/neekrasov/kvdb/internal/config"
+	"github.com/neekrasov/kvdb/internal/database/identity"
+	"github.com/neekrasov/kvdb/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const defaultSessionCleanupPeriod = time.Minute
+
+func initSessionStorage(
+	ctx context.Context,
+	sessionLifeTime time.Duration,
+	cfg *config.SecurityConfig,
+) *identity.SessionStorage {
+	opts := make([]identity.SessionStorageOpt, 0)
+	cleanupPeriod := defaultSessionCleanupPeriod
+	if cfg != nil {
+		if cfg.SessionTTL != 0 {
+			opts = append(opts, identity.WithSessionTTL(cfg.SessionTTL))
+		}
+
+		if cfg.SessionCleanupPeriod != 0 {
+			cleanupPeriod = cfg.SessionCleanupPeriod
+		}
+	}
+
+	sessions := identity.NewSessionStorage(sessionLifeTime, opts...)
+	if sessionLifeTime != 0 || (cfg != nil && cfg.SessionTTL != 0) {
+		logger.Debug("init sessions cleanup",
+			zap.Stringer("session_lifetime", sessionLifeTime),
+			zap.Stringer("cleanup_period", cleanupPeriod),
+		)
+		go sessions.StartCleanup(ctx, cleanupPeriod)
+	}
+
+	return sessions
+}
@@ -13,12 +13,42 @@ import (
 	"go.uber.org/zap"
 )
 
+// errDefaultAdmin - is returned on start with a weak root password when it's forbidden by the config.
+var errDefaultAdmin = errors.New("root password is empty or a well-known default, " +
+	"set root.password or disable security.forbid_default_admin")
+
+// checkRootCredentials - warns about the weak root password and refuses it if security.forbid_default_admin is set.
+func checkRootCredentials(cfg *config.Config) error {
+	if !cfg.Root.WeakPassword() {
+		return nil
+	}
+
+	if cfg.Security != nil && cfg.Security.ForbidDefaultAdmin {
+		return errDefaultAdmin
+	}
+
+	var username string
+	if cfg.Root != nil {
+		username = cfg.Root.Username
+	}
+	logger.Warn("root user has an empty or a well-known default password, change it before exposing the server",
+		zap.String("username", username))
+
+	return nil
+}
+
 func initUserStorage(
 	ctx context.Context,
 	storage *storage.Storage,
 	cfg *config.Config,
 ) (*identity.UsersStorage, error) {
-	usersStorage := identity.NewUsersStorage(storage)
+	var opts []identity.UsersStorageOpt
+	if cfg.Security != nil && cfg.Security.BcryptCost != 0 {
+		logger.Debug("set bcrypt cost", zap.Int("bcrypt_cost", cfg.Security.BcryptCost))
+		opts = append(opts, identity.WithBcryptCost(cfg.Security.BcryptCost))
+	}
+
+	usersStorage := identity.NewUsersStorage(storage, opts...)
 	if cfg.Replication != nil && cfg.Replication.ReplicaType == slaveType {
 		return usersStorage, nil
 	}
@@ -0,0 +1,31 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/neekrasov/kvdb/internal/config"
+	"github.com/neekrasov/kvdb/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCheckRootCredentials(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	logger.Init(core)
+	t.Cleanup(logger.MockLogger)
+
+	weak := &config.Config{Root: &config.RootConfig{Username: "root", Password: "root"}}
+	assert.NoError(t, checkRootCredentials(weak))
+	assert.Equal(t, 1, logs.FilterMessageSnippet("well-known default password").Len())
+
+	weak.Security = &config.SecurityConfig{ForbidDefaultAdmin: true}
+	assert.ErrorIs(t, checkRootCredentials(weak), errDefaultAdmin)
+
+	strong := &config.Config{
+		Root:     &config.RootConfig{Username: "root", Password: "s3cr3t-passphrase"},
+		Security: &config.SecurityConfig{ForbidDefaultAdmin: true},
+	}
+	assert.NoError(t, checkRootCredentials(strong))
+	assert.Equal(t, 1, logs.Len())
+}
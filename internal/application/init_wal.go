@@ -5,6 +5,7 @@ import (
 
 	"github.com/neekrasov/kvdb/internal/config"
 	"github.com/neekrasov/kvdb/internal/database/compression"
+	"github.com/neekrasov/kvdb/internal/database/storage/snapshot"
 	"github.com/neekrasov/kvdb/internal/database/storage/wal"
 	"github.com/neekrasov/kvdb/internal/database/storage/wal/filesystem"
 	"github.com/neekrasov/kvdb/internal/database/storage/wal/segment"
@@ -18,7 +19,7 @@ const (
 	defaultDataDir              = "/var/lib/kvdb"
 )
 
-func initWAL(cfg *config.WALConfig) (*wal.WAL, error) {
+func initWAL(cfg *config.WALConfig, replicationCfg *config.ReplicationConfig) (*wal.WAL, error) {
 	if cfg == nil {
 		logger.Warn("empty wal config")
 		return nil, nil
@@ -57,6 +58,12 @@ func initWAL(cfg *config.WALConfig) (*wal.WAL, error) {
 	}
 	segmentManagerOpts = append(segmentManagerOpts, wal.WithCompressor(compressor))
 
+	syncMode, err := wal.ParseSyncMode(cfg.SyncMode)
+	if err != nil {
+		return nil, err
+	}
+	segmentManagerOpts = append(segmentManagerOpts, wal.WithSyncMode(syncMode))
+
 	segmentManager, err := wal.NewFileSegmentManager(
 		segmentStorage, segmentManagerOpts...)
 	if err != nil {
@@ -73,11 +80,93 @@ func initWAL(cfg *config.WALConfig) (*wal.WAL, error) {
 		batchSize = cfg.FlushingBatchSize
 	}
 
+	walOpts := make([]wal.WALOpt, 0)
+	if interval := cfg.RecoveryProgressInterval; interval != 0 {
+		walOpts = append(walOpts, wal.WithRecoveryProgressInterval(interval))
+	}
+	if cfg.WriteRetries > 0 {
+		walOpts = append(walOpts, wal.WithWriteRetries(cfg.WriteRetries, cfg.WriteRetryDelay))
+	}
+	if cfg.FlushWorkers > 0 {
+		walOpts = append(walOpts, wal.WithFlushWorkers(cfg.FlushWorkers))
+	}
+	var maxBatchBytes int
+	if cfg.MaxBatchBytes != "" {
+		maxBatchBytes, err = sizeutil.ParseSize(cfg.MaxBatchBytes)
+		if err != nil {
+			return nil, err
+		}
+		walOpts = append(walOpts, wal.WithMaxBatchBytes(maxBatchBytes))
+	}
+	var maxTotalSize int
+	if cfg.MaxTotalSize != "" {
+		maxTotalSize, err = sizeutil.ParseSize(cfg.MaxTotalSize)
+		if err != nil {
+			return nil, err
+		}
+		fullPolicy, err := wal.ParseFullPolicy(cfg.FullPolicy)
+		if err != nil {
+			return nil, err
+		}
+		walOpts = append(walOpts, wal.WithMaxTotalSize(int64(maxTotalSize), fullPolicy))
+	}
+	if cfg.CompactionPeriod != 0 || cfg.CompactionSegmentsThreshold != 0 {
+		// Replicas fetch segments by number and apply the deletes from them,
+		// so compacted away segments would break the replication.
+		if replicationCfg != nil {
+			logger.Warn("wal compaction is disabled with replication")
+			maxTotalSizeWithoutCompaction(cfg)
+		} else if cfg.SnapshotPeriod != 0 {
+			// Compaction drops deletes of the keys, which would resurrect
+			// the deleted keys restored from an older snapshot.
+			logger.Warn("wal compaction is disabled with snapshots")
+			maxTotalSizeWithoutCompaction(cfg)
+		} else {
+			walOpts = append(walOpts, wal.WithCompaction(cfg.CompactionPeriod, cfg.CompactionSegmentsThreshold))
+		}
+	}
+
 	logger.Debug("init wal",
 		zap.Stringer("flushing_batch_timeout", flushingBatchTimeout),
 		zap.Int("flushing_batch_size", batchSize),
 		zap.String("compression", string(cfg.Compression)),
+		zap.String("sync_mode", string(syncMode)),
+		zap.Stringer("recovery_progress_interval", cfg.RecoveryProgressInterval),
+		zap.Stringer("compaction_period", cfg.CompactionPeriod),
+		zap.Int("compaction_segments_threshold", cfg.CompactionSegmentsThreshold),
+		zap.Stringer("snapshot_period", cfg.SnapshotPeriod),
+		zap.Int("write_retries", cfg.WriteRetries),
+		zap.Int("flush_workers", cfg.FlushWorkers),
+		zap.Int("max_batch_bytes", maxBatchBytes),
+		zap.Int("max_total_size", maxTotalSize),
+		zap.String("full_policy", cfg.FullPolicy),
+	)
+
+	return wal.NewWAL(segmentManager, batchSize, flushingBatchTimeout, walOpts...), nil
+}
+
+// maxTotalSizeWithoutCompaction - warns that the full log is never freed, since only the compaction frees the space.
+func maxTotalSizeWithoutCompaction(cfg *config.WALConfig) {
+	if cfg.MaxTotalSize != "" {
+		logger.Warn("wal max total size is set without compaction, the full log is never freed",
+			zap.String("max_total_size", cfg.MaxTotalSize), zap.String("full_policy", cfg.FullPolicy))
+	}
+}
+
+func initSnapshotter(cfg *config.WALConfig) (*snapshot.FileSnapshotter, error) {
+	if cfg == nil || cfg.SnapshotPeriod == 0 {
+		return nil, nil
+	}
+
+	dataDir := cfg.DataDir
+	if cfg.DataDir == "" {
+		dataDir = defaultDataDir
+	}
+
+	logger.Debug("init snapshotter",
+		zap.String("data_directory", dataDir),
+		zap.Stringer("snapshot_period", cfg.SnapshotPeriod),
 	)
 
-	return wal.NewWAL(segmentManager, batchSize, flushingBatchTimeout), nil
+	return snapshot.NewFileSnapshotter(dataDir)
 }
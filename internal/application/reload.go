@@ -0,0 +1,120 @@
+package application
+
+import (
+	"reflect"
+
+	"github.com/neekrasov/kvdb/internal/config"
+	"github.com/neekrasov/kvdb/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Reload - applies the settings of the config that are safe to change at runtime: the logging level,
+// the max connections, the idle timeout and the cleanup period. Changes of other settings are logged as ignored
+// and take effect after the restart.
+func (a *Application) Reload(cfg *config.Config) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// The applied settings are stored in a copy, the running config keeps the settings ignored until the restart,
+	// so every reload compares against the settings in effect.
+	applied := *a.cfg
+
+	if cfg.Logging != nil && cfg.Logging.Level != "" {
+		if err := logger.SetLevel(cfg.Logging.Level); err != nil {
+			logger.Warn("reload logging level failed", zap.Error(err))
+		} else {
+			logger.Info("logging level reloaded", zap.String("level", cfg.Logging.Level))
+
+			logging := config.LoggingConfig{}
+			if applied.Logging != nil {
+				logging = *applied.Logging
+			}
+			logging.Level = cfg.Logging.Level
+			applied.Logging = &logging
+		}
+	}
+
+	if a.server != nil && cfg.Network != nil {
+		if mcons := cfg.Network.MaxConnections; mcons != a.server.MaxConnections() {
+			a.server.SetMaxConnections(mcons)
+			logger.Info("max connections reloaded", zap.Uint("max_connections", mcons))
+		}
+
+		a.server.SetIdleTimeout(cfg.Network.IdleTimeout)
+		logger.Info("idle timeout reloaded", zap.Stringer("idle_timeout", a.server.IdleTimeout()))
+
+		network := config.NetworkConfig{}
+		if applied.Network != nil {
+			network = *applied.Network
+		}
+		network.MaxConnections = cfg.Network.MaxConnections
+		network.IdleTimeout = cfg.Network.IdleTimeout
+		applied.Network = &network
+	}
+
+	if a.storage != nil && cfg.CleanupConfig != nil && cfg.CleanupConfig.Period != 0 {
+		if err := a.storage.SetCleanupPeriod(cfg.CleanupConfig.Period); err != nil {
+			logger.Warn("reload cleanup period failed", zap.Error(err))
+		} else {
+			logger.Info("cleanup period reloaded", zap.Stringer("period", cfg.CleanupConfig.Period))
+
+			cleanup := config.CleanupConfig{}
+			if applied.CleanupConfig != nil {
+				cleanup = *applied.CleanupConfig
+			}
+			cleanup.Period = cfg.CleanupConfig.Period
+			applied.CleanupConfig = &cleanup
+		}
+	}
+
+	for _, name := range ignoredChanges(a.cfg, cfg) {
+		logger.Warn("setting can't be changed at runtime, ignored until restart", zap.String("setting", name))
+	}
+	a.cfg = &applied
+}
+
+// ignoredChanges - returns the names of the changed settings that Reload can't apply.
+func ignoredChanges(prev, next *config.Config) []string {
+	var changed []string
+	if !reflect.DeepEqual(prev.Engine, next.Engine) {
+		changed = append(changed, "engine")
+	}
+
+	if !reflect.DeepEqual(prev.WAL, next.WAL) {
+		changed = append(changed, "wal")
+	}
+
+	if !reflect.DeepEqual(prev.Replication, next.Replication) {
+		changed = append(changed, "replication")
+	}
+
+	if prev.Logging != nil && next.Logging != nil {
+		if prev.Logging.Output != next.Logging.Output {
+			changed = append(changed, "logging.output")
+		}
+
+		if prev.Logging.Format != next.Logging.Format {
+			changed = append(changed, "logging.format")
+		}
+	}
+
+	if prev.Network != nil && next.Network != nil {
+		if prev.Network.Address != next.Network.Address {
+			changed = append(changed, "network.address")
+		}
+
+		if prev.Network.Protocol != next.Network.Protocol {
+			changed = append(changed, "network.protocol")
+		}
+
+		if prev.Network.MaxMessageSize != next.Network.MaxMessageSize {
+			changed = append(changed, "network.max_message_size")
+		}
+	}
+
+	if !reflect.DeepEqual(prev.Security, next.Security) {
+		changed = append(changed, "security")
+	}
+
+	return changed
+}
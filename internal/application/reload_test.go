@@ -0,0 +1,42 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/neekrasov/kvdb/internal/config"
+	"github.com/neekrasov/kvdb/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestApplication_Reload(t *testing.T) {
+	logger.InitLogger("info", "", logger.FormatConsole)
+	t.Cleanup(logger.MockLogger)
+
+	cfg := &config.Config{
+		Engine:  &config.EngineConfig{Type: "in_memory"},
+		Network: &config.NetworkConfig{Address: "127.0.0.1:3223"},
+		Logging: &config.LoggingConfig{Level: "info"},
+	}
+	app := New(cfg)
+	assert.Equal(t, zapcore.InfoLevel, logger.Level())
+
+	reloaded := &config.Config{
+		Engine:  &config.EngineConfig{Type: "sharded"},
+		Network: &config.NetworkConfig{Address: "127.0.0.1:3224"},
+		Logging: &config.LoggingConfig{Level: "debug"},
+	}
+	app.Reload(reloaded)
+	assert.Equal(t, zapcore.DebugLevel, logger.Level())
+	assert.Equal(t, []string{"engine", "network.address"}, ignoredChanges(cfg, reloaded))
+
+	// The applied level is kept in the running config, the ignored settings are not.
+	assert.Equal(t, "debug", app.cfg.Logging.Level)
+	assert.Equal(t, "in_memory", app.cfg.Engine.Type)
+	assert.Equal(t, "127.0.0.1:3223", app.cfg.Network.Address)
+	assert.Equal(t, "info", cfg.Logging.Level)
+
+	app.Reload(&config.Config{Logging: &config.LoggingConfig{Level: "loud"}})
+	assert.Equal(t, zapcore.DebugLevel, logger.Level())
+	assert.Equal(t, "debug", app.cfg.Logging.Level)
+}
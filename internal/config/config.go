@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -22,7 +23,12 @@ type (
 		Replication     *ReplicationConfig `yaml:"replication" json:"replication" xml:"replication"`
 		CleanupConfig   *CleanupConfig     `yaml:"cleanup" json:"cleanup" xml:"cleanup"`
 		PwdPolicyConfig *PwdPolicyConfig   `yaml:"pwd" json:"pwd" xml:"pwd"`
-		StatEnabled     bool               `yaml:"stat_enabled" json:"stat_enabled" xml:"stat_enabled"`
+		Security        *SecurityConfig    `yaml:"security" json:"security" xml:"security"`
+		Metrics         *MetricsConfig     `yaml:"metrics" json:"metrics" xml:"metrics"`
+		// CommandAliases - alternate names of the commands, e.g. 'put: set', the aliases must not shadow the commands.
+		CommandAliases map[string]string `yaml:"command_aliases" json:"command_aliases" xml:"command_aliases"`
+		// Deprecated: the statistics are collected unless engine.stats_enabled is false.
+		StatEnabled bool `yaml:"stat_enabled" json:"stat_enabled" xml:"stat_enabled"`
 
 		// -- default optional params
 		DefaultRoles      []RoleConfig      `yaml:"default_roles" json:"default_roles" xml:"default_roles"`
@@ -39,11 +45,15 @@ type (
 	}
 
 	NamespaceConfig struct {
-		Name string `yaml:"name" json:"name" xml:"name"`
+		Name       string        `yaml:"name" json:"name" xml:"name"`
+		DefaultTTL time.Duration `yaml:"default_ttl" json:"default_ttl" xml:"default_ttl"`
+		// ValueSchema - rejects the values not matching the schema, "json" or "regex:<pattern>", empty accepts any.
+		ValueSchema string `yaml:"value_schema" json:"value_schema" xml:"value_schema"`
 	}
 
 	CleanupConfig struct {
 		Period    time.Duration `yaml:"period" json:"period" xml:"period"`
+		Jitter    time.Duration `yaml:"jitter" json:"jitter" xml:"jitter"`
 		BatchSize int           `yaml:"batch_size" json:"batch_size" xml:"batch_size"`
 	}
 
@@ -51,6 +61,22 @@ type (
 		SessionLifeTime time.Duration `yaml:"session_lifetime" json:"session_lifetime" xml:"session_lifetime"`
 	}
 
+	SecurityConfig struct {
+		SessionTTL           time.Duration `yaml:"session_ttl" json:"session_ttl" xml:"session_ttl"`
+		SessionCleanupPeriod time.Duration `yaml:"session_cleanup_period" json:"session_cleanup_period" xml:"session_cleanup_period"`
+		TokenTTL             time.Duration `yaml:"token_ttl" json:"token_ttl" xml:"token_ttl"`
+		// AuthTimeout - time the connected client has to log in, zero disables the timeout.
+		AuthTimeout time.Duration `yaml:"auth_timeout" json:"auth_timeout" xml:"auth_timeout"`
+		// RateLimit - commands per second allowed to a session, zero disables the limit.
+		RateLimit float64 `yaml:"rate_limit" json:"rate_limit" xml:"rate_limit"`
+		// RateBurst - commands a session can send at once, defaults to the rate limit rounded up.
+		RateBurst int `yaml:"rate_burst" json:"rate_burst" xml:"rate_burst"`
+		// BcryptCost - bcrypt cost of the password hashes, zero keeps the bcrypt default.
+		BcryptCost int `yaml:"bcrypt_cost" json:"bcrypt_cost" xml:"bcrypt_cost"`
+		// ForbidDefaultAdmin - refuses to start with an empty or a well-known root password.
+		ForbidDefaultAdmin bool `yaml:"forbid_default_admin" json:"forbid_default_admin" xml:"forbid_default_admin"`
+	}
+
 	UserConfig struct {
 		Username string   `yaml:"username" json:"username" xml:"username"`
 		Password string   `yaml:"password" json:"password" xml:"password"`
@@ -58,8 +84,23 @@ type (
 	}
 
 	EngineConfig struct {
-		Type         string `yaml:"type" json:"type" xml:"type"`
-		PartitionNum int    `yaml:"partition_num" json:"partition_num" xml:"partition_num"`
+		// Type - "in_memory" keeps the keys in a single map by default, "sharded" spreads them over the partitions.
+		Type string `yaml:"type" json:"type" xml:"type"`
+		// PartitionNum - number of the key hash partitions with their own locks, zero means 1 for "in_memory"
+		// and 16 for "sharded".
+		PartitionNum int `yaml:"partition_num" json:"partition_num" xml:"partition_num"`
+		// MaxValueSize - limits the size of the stored values, e.g. "1MB", empty means unlimited.
+		MaxValueSize string `yaml:"max_value_size" json:"max_value_size" xml:"max_value_size"`
+		// KeySeparator - separates the namespace and the key name in the storage keys, empty means ":".
+		KeySeparator string `yaml:"key_separator" json:"key_separator" xml:"key_separator"`
+		// StatsEnabled - collects the storage statistics reported by STAT, unset means enabled.
+		// Disabling it saves the atomic counters updates on every operation.
+		StatsEnabled *bool `yaml:"stats_enabled" json:"stats_enabled" xml:"stats_enabled"`
+		// TrackAccess - records the time of the last read or write of every key reported by LASTACCESS.
+		TrackAccess bool `yaml:"track_access" json:"track_access" xml:"track_access"`
+		// IdleEviction - removes the keys not read or written within the duration on every cleanup,
+		// unlike the TTL it's counted from the last access. Requires the access tracking and the cleanup.
+		IdleEviction time.Duration `yaml:"idle_eviction" json:"idle_eviction" xml:"idle_eviction"`
 	}
 
 	ReplicationConfig struct {
@@ -74,27 +115,71 @@ type (
 		MaxConnections uint          `yaml:"max_connections" json:"max_connections" xml:"max_connections"`
 		MaxMessageSize string        `yaml:"max_message_size" json:"max_message_size" xml:"max_message_size"`
 		IdleTimeout    time.Duration `yaml:"idle_timeout" json:"idle_timeout" xml:"idle_timeout"`
+		// MaxIdleTimeout - the longest idle timeout a client may request, zero lets the clients only shorten it.
+		MaxIdleTimeout time.Duration `yaml:"max_idle_timeout" json:"max_idle_timeout" xml:"max_idle_timeout"`
+		Protocol       string        `yaml:"protocol" json:"protocol" xml:"protocol"`
+		CommandTimeout time.Duration `yaml:"command_timeout" json:"command_timeout" xml:"command_timeout"`
+		Compression    bool          `yaml:"compression" json:"compression" xml:"compression"`
+	}
+
+	MetricsConfig struct {
+		Address string `yaml:"address" json:"address" xml:"address"`
 	}
 
 	LoggingConfig struct {
 		Level  string `yaml:"level" json:"level" xml:"level"`
 		Output string `yaml:"output" json:"output" xml:"output"`
+		Format string `yaml:"format" json:"format" xml:"format"`
+
+		SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" json:"slow_query_threshold" xml:"slow_query_threshold"`
 	}
 
 	WALConfig struct {
-		FlushingBatchSize    int           `yaml:"flushing_batch_size" json:"flushing_batch_size" xml:"flushing_batch_size"`
-		FlushingBatchTimeout time.Duration `yaml:"flushing_batch_timeout" json:"flushing_batch_timeout" xml:"flushing_batch_timeout"`
-		MaxSegmentSize       string        `yaml:"max_segment_size" json:"max_segment_size" xml:"max_segment_size"`
-		Compression          string        `yaml:"compression" json:"compression" xml:"compression"`
-		DataDir              string        `yaml:"data_directory" json:"data_directory" xml:"data_directory"`
+		FlushingBatchSize           int           `yaml:"flushing_batch_size" json:"flushing_batch_size" xml:"flushing_batch_size"`
+		FlushingBatchTimeout        time.Duration `yaml:"flushing_batch_timeout" json:"flushing_batch_timeout" xml:"flushing_batch_timeout"`
+		MaxSegmentSize              string        `yaml:"max_segment_size" json:"max_segment_size" xml:"max_segment_size"`
+		Compression                 string        `yaml:"compression" json:"compression" xml:"compression"`
+		DataDir                     string        `yaml:"data_directory" json:"data_directory" xml:"data_directory"`
+		RecoveryProgressInterval    time.Duration `yaml:"recovery_progress_interval" json:"recovery_progress_interval" xml:"recovery_progress_interval"`
+		CompactionPeriod            time.Duration `yaml:"compaction_period" json:"compaction_period" xml:"compaction_period"`
+		CompactionSegmentsThreshold int           `yaml:"compaction_segments_threshold" json:"compaction_segments_threshold" xml:"compaction_segments_threshold"`
+		SnapshotPeriod              time.Duration `yaml:"snapshot_period" json:"snapshot_period" xml:"snapshot_period"`
+		SyncMode                    string        `yaml:"sync_mode" json:"sync_mode" xml:"sync_mode"`
+		WriteRetries                int           `yaml:"write_retries" json:"write_retries" xml:"write_retries"`
+		WriteRetryDelay             time.Duration `yaml:"write_retry_delay" json:"write_retry_delay" xml:"write_retry_delay"`
+		FlushWorkers                int           `yaml:"flush_workers" json:"flush_workers" xml:"flush_workers"`
+		MaxBatchBytes               string        `yaml:"max_batch_bytes" json:"max_batch_bytes" xml:"max_batch_bytes"`
+		MaxTotalSize                string        `yaml:"max_total_size" json:"max_total_size" xml:"max_total_size"`
+		FullPolicy                  string        `yaml:"full_policy" json:"full_policy" xml:"full_policy"`
 	}
 
 	RootConfig struct {
-		Username string `yaml:"username" json:"username" xml:"username"`
-		Password string `yaml:"password" json:"password" xml:"password"`
+		Username     string `yaml:"username" json:"username" xml:"username"`
+		Password     string `yaml:"password" json:"password" xml:"password"`
+		PasswordFile string `yaml:"password_file" json:"password_file" xml:"password_file"`
 	}
 )
 
+// weakRootPasswords - well-known default passwords, they are the first guesses of an attacker.
+var weakRootPasswords = []string{"root", "admin", "password", "kvdb", "123456", "changeme"}
+
+// WeakPassword - reports whether the root password is empty, equal to the username or a well-known default.
+func (c *RootConfig) WeakPassword() bool {
+	if c == nil || c.Password == "" || c.Password == c.Username {
+		return true
+	}
+
+	return slices.Contains(weakRootPasswords, strings.ToLower(c.Password))
+}
+
+// StatisticsEnabled - reports whether the storage statistics are collected, they are enabled by default.
+func (c *EngineConfig) StatisticsEnabled() bool {
+	return c == nil || c.StatsEnabled == nil || *c.StatsEnabled
+}
+
+// RootPasswordEnv - environment variable with the root password, it overrides the password file and the config.
+const RootPasswordEnv = "KVDB_ROOT_PASSWORD"
+
 func GetConfig(path string) (Config, error) {
 	configContent, err := GetConfigReader(path)
 	if err != nil {
@@ -128,9 +213,47 @@ func GetConfig(path string) (Config, error) {
 		config.Logging.Output = envLog
 	}
 
+	if err := resolveRootPassword(&config); err != nil {
+		return Config{}, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config: %w", err)
+	}
+
 	return config, nil
 }
 
+// resolveRootPassword - resolves the root password with the precedence: config < password file < environment.
+func resolveRootPassword(config *Config) error {
+	if envPassword := os.Getenv(RootPasswordEnv); envPassword != "" {
+		if config.Root == nil {
+			config.Root = &RootConfig{}
+		}
+		config.Root.Password = envPassword
+
+		return nil
+	}
+
+	if config.Root == nil || config.Root.PasswordFile == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(config.Root.PasswordFile)
+	if err != nil {
+		return fmt.Errorf("read root password file failed: %w", err)
+	}
+
+	// Editors usually end the file with a newline, it is not a part of the password.
+	password := strings.TrimRight(string(content), "\r\n")
+	if password == "" {
+		return errors.New("root password file is empty")
+	}
+	config.Root.Password = password
+
+	return nil
+}
+
 func ParseConfig(input io.ReadCloser) (Config, error) {
 	defer input.Close()
 
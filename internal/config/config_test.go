@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/neekrasov/kvdb/internal/config"
+	"github.com/neekrasov/kvdb/pkg/sizeutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -200,3 +203,131 @@ func TestGetConfig_InvalidFileContent(t *testing.T) {
 	_, err = config.GetConfig(tmpFile.Name())
 	assert.Error(t, err)
 }
+
+func TestParseConfig_WALMaxSegmentSize(t *testing.T) {
+	t.Parallel()
+
+	content := `
+wal:
+  data_directory: "/data/kvdb/wal"
+  max_segment_size: "8KB"
+`
+	cfg, err := config.ParseConfig(io.NopCloser(bytes.NewReader([]byte(content))))
+	require.NoError(t, err)
+	require.NotNil(t, cfg.WAL)
+	assert.Equal(t, "8KB", cfg.WAL.MaxSegmentSize)
+
+	size, err := sizeutil.ParseSize(cfg.WAL.MaxSegmentSize)
+	require.NoError(t, err)
+	assert.Equal(t, 8<<10, size)
+}
+
+func TestGetConfig_RootPassword(t *testing.T) {
+	dir := t.TempDir()
+
+	passwordFile := filepath.Join(dir, "root_password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("file-password\n"), 0o600))
+
+	emptyFile := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(emptyFile, []byte("\n"), 0o600))
+
+	writeConfig := func(t *testing.T, root string) string {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("network:\n  address: \"127.0.0.1:3223\"\nroot:\n"+root), 0o600))
+		return path
+	}
+
+	tests := []struct {
+		name        string
+		root        string
+		env         string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "config",
+			root:     "  username: root\n  password: config-password\n",
+			expected: "config-password",
+		},
+		{
+			name:     "file overrides config",
+			root:     "  username: root\n  password: config-password\n  password_file: " + passwordFile + "\n",
+			expected: "file-password",
+		},
+		{
+			name:     "env overrides file and config",
+			root:     "  username: root\n  password: config-password\n  password_file: " + passwordFile + "\n",
+			env:      "env-password",
+			expected: "env-password",
+		},
+		{
+			name:     "env without password in config",
+			root:     "  username: root\n",
+			env:      "env-password",
+			expected: "env-password",
+		},
+		{
+			name:        "missing file",
+			root:        "  username: root\n  password_file: " + filepath.Join(dir, "missing") + "\n",
+			expectError: true,
+		},
+		{
+			name:        "empty file",
+			root:        "  username: root\n  password_file: " + emptyFile + "\n",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(config.RootPasswordEnv, tt.env)
+
+			cfg, err := config.GetConfig(writeConfig(t, tt.root))
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, cfg.Root)
+			assert.Equal(t, "root", cfg.Root.Username)
+			assert.Equal(t, tt.expected, cfg.Root.Password)
+		})
+	}
+}
+
+func TestRootConfig_WeakPassword(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		root     *config.RootConfig
+		expected bool
+	}{
+		{name: "missing root", root: nil, expected: true},
+		{name: "empty password", root: &config.RootConfig{Username: "root"}, expected: true},
+		{name: "password equal to the username", root: &config.RootConfig{Username: "kvadmin", Password: "kvadmin"}, expected: true},
+		{name: "well-known default", root: &config.RootConfig{Username: "root", Password: "Admin"}, expected: true},
+		{name: "strong password", root: &config.RootConfig{Username: "root", Password: "s3cr3t-passphrase"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.root.WeakPassword())
+		})
+	}
+}
+
+func TestEngineConfig_StatisticsEnabled(t *testing.T) {
+	t.Parallel()
+
+	enabled, disabled := true, false
+	assert.True(t, (*config.EngineConfig)(nil).StatisticsEnabled())
+	assert.True(t, (&config.EngineConfig{}).StatisticsEnabled())
+	assert.True(t, (&config.EngineConfig{StatsEnabled: &enabled}).StatisticsEnabled())
+	assert.False(t, (&config.EngineConfig{StatsEnabled: &disabled}).StatisticsEnabled())
+
+	cfg, err := config.ParseConfig(io.NopCloser(strings.NewReader("engine:\n  stats_enabled: false\n")))
+	require.NoError(t, err)
+	assert.False(t, cfg.Engine.StatisticsEnabled())
+}
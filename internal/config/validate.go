@@ -0,0 +1,172 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/neekrasov/kvdb/pkg/sizeutil"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Replica types of the replication config.
+const (
+	ReplicaTypeMaster = "master"
+	ReplicaTypeSlave  = "slave"
+)
+
+// Types of the engine config.
+const (
+	EngineTypeInMemory = "in_memory"
+	EngineTypeSharded  = "sharded"
+)
+
+// Policies of the full wal.
+const (
+	WALFullPolicyReject       = "reject"
+	WALFullPolicyBackpressure = "backpressure"
+)
+
+// Formats of the logging config.
+const (
+	LogFormatConsole = "console"
+	LogFormatJSON    = "json"
+)
+
+// Validate - checks the config for missing and conflicting values, all found problems are joined into the error.
+func (c *Config) Validate() error {
+	var errs []error
+	negative := func(name string, value time.Duration) {
+		if value < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative, got %s", name, value))
+		}
+	}
+
+	if c.Engine != nil {
+		switch c.Engine.Type {
+		case "", EngineTypeInMemory, EngineTypeSharded:
+		default:
+			errs = append(errs, fmt.Errorf("engine.type must be '%s' or '%s', got '%s'",
+				EngineTypeInMemory, EngineTypeSharded, c.Engine.Type))
+		}
+		if c.Engine.PartitionNum < 0 {
+			errs = append(errs, fmt.Errorf("engine.partition_num must not be negative, got %d", c.Engine.PartitionNum))
+		}
+		negative("engine.idle_eviction", c.Engine.IdleEviction)
+		if c.Engine.IdleEviction > 0 {
+			if !c.Engine.TrackAccess {
+				errs = append(errs, errors.New("engine.idle_eviction requires engine.track_access"))
+			}
+			if c.CleanupConfig == nil || c.CleanupConfig.Period <= 0 {
+				errs = append(errs, errors.New("engine.idle_eviction requires cleanup.period"))
+			}
+		}
+	}
+	if c.Engine != nil && c.Engine.MaxValueSize != "" {
+		if _, err := sizeutil.ParseSize(c.Engine.MaxValueSize); err != nil {
+			errs = append(errs, fmt.Errorf("engine.max_value_size is invalid: %w", err))
+		}
+	}
+	if c.Engine != nil && strings.IndexFunc(c.Engine.KeySeparator, unicode.IsSpace) >= 0 {
+		errs = append(errs, fmt.Errorf("engine.key_separator must not contain whitespaces, got '%s'",
+			c.Engine.KeySeparator))
+	}
+
+	if c.Network == nil || c.Network.Address == "" {
+		errs = append(errs, errors.New("network.address must not be empty"))
+	}
+	if c.Network != nil {
+		negative("network.idle_timeout", c.Network.IdleTimeout)
+		negative("network.max_idle_timeout", c.Network.MaxIdleTimeout)
+		negative("network.command_timeout", c.Network.CommandTimeout)
+	}
+
+	if c.Logging != nil {
+		switch c.Logging.Format {
+		case "", LogFormatConsole, LogFormatJSON:
+		default:
+			errs = append(errs, fmt.Errorf("logging.format must be '%s' or '%s', got '%s'",
+				LogFormatConsole, LogFormatJSON, c.Logging.Format))
+		}
+		negative("logging.slow_query_threshold", c.Logging.SlowQueryThreshold)
+	}
+
+	if c.Replication != nil {
+		switch c.Replication.ReplicaType {
+		case ReplicaTypeMaster:
+			if c.Replication.MasterAddress == "" {
+				errs = append(errs, errors.New("replication.master_address must be set for the master to listen on"))
+			}
+		case ReplicaTypeSlave:
+			if c.Replication.MasterAddress == "" {
+				errs = append(errs, errors.New("replication.master_address must be set for the slave to sync from"))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("replication.replica_type must be '%s' or '%s', got '%s'",
+				ReplicaTypeMaster, ReplicaTypeSlave, c.Replication.ReplicaType))
+		}
+
+		if c.WAL == nil {
+			errs = append(errs, errors.New("replication requires the wal config"))
+		}
+		negative("replication.sync_interval", c.Replication.SyncInterval)
+	}
+
+	if c.CleanupConfig != nil {
+		negative("cleanup.period", c.CleanupConfig.Period)
+		negative("cleanup.jitter", c.CleanupConfig.Jitter)
+		if c.CleanupConfig.Period > 0 && c.CleanupConfig.BatchSize <= 0 {
+			errs = append(errs, errors.New("cleanup.batch_size must be positive when cleanup.period is set"))
+		}
+	}
+
+	if c.WAL != nil {
+		negative("wal.flushing_batch_timeout", c.WAL.FlushingBatchTimeout)
+		negative("wal.compaction_period", c.WAL.CompactionPeriod)
+		negative("wal.snapshot_period", c.WAL.SnapshotPeriod)
+		negative("wal.write_retry_delay", c.WAL.WriteRetryDelay)
+		if c.WAL.WriteRetries < 0 {
+			errs = append(errs, fmt.Errorf("wal.write_retries must not be negative, got %d", c.WAL.WriteRetries))
+		}
+		if c.WAL.FlushWorkers < 0 {
+			errs = append(errs, fmt.Errorf("wal.flush_workers must not be negative, got %d", c.WAL.FlushWorkers))
+		}
+		if c.WAL.MaxTotalSize != "" {
+			if _, err := sizeutil.ParseSize(c.WAL.MaxTotalSize); err != nil {
+				errs = append(errs, fmt.Errorf("wal.max_total_size is invalid: %w", err))
+			}
+		}
+		switch c.WAL.FullPolicy {
+		case "", WALFullPolicyReject:
+		case WALFullPolicyBackpressure:
+			// Only the compaction frees the space, without it the writes would wait forever.
+			if c.WAL.MaxTotalSize != "" && c.WAL.CompactionPeriod == 0 && c.WAL.CompactionSegmentsThreshold == 0 {
+				errs = append(errs, errors.New("wal.full_policy 'backpressure' requires the wal compaction"))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("wal.full_policy must be '%s' or '%s', got '%s'",
+				WALFullPolicyReject, WALFullPolicyBackpressure, c.WAL.FullPolicy))
+		}
+	}
+
+	if c.Security != nil {
+		negative("security.session_ttl", c.Security.SessionTTL)
+		negative("security.session_cleanup_period", c.Security.SessionCleanupPeriod)
+		negative("security.token_ttl", c.Security.TokenTTL)
+		negative("security.auth_timeout", c.Security.AuthTimeout)
+		if c.Security.RateLimit < 0 {
+			errs = append(errs, fmt.Errorf("security.rate_limit must not be negative, got %g", c.Security.RateLimit))
+		}
+		if c.Security.RateBurst < 0 {
+			errs = append(errs, fmt.Errorf("security.rate_burst must not be negative, got %d", c.Security.RateBurst))
+		}
+		if cost := c.Security.BcryptCost; cost != 0 && (cost < bcrypt.MinCost || cost > bcrypt.MaxCost) {
+			errs = append(errs, fmt.Errorf("security.bcrypt_cost must be between %d and %d, got %d",
+				bcrypt.MinCost, bcrypt.MaxCost, cost))
+		}
+	}
+
+	return errors.Join(errs...)
+}
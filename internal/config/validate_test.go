@@ -0,0 +1,239 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/neekrasov/kvdb/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	network := &config.NetworkConfig{Address: "127.0.0.1:3223"}
+
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		expected []string
+	}{
+		{
+			name: "valid",
+			cfg: config.Config{
+				Network:       network,
+				WAL:           &config.WALConfig{},
+				Replication:   &config.ReplicationConfig{ReplicaType: "master", MasterAddress: "127.0.0.1:3232"},
+				CleanupConfig: &config.CleanupConfig{Period: time.Minute, BatchSize: 10},
+			},
+		},
+		{
+			name:     "empty network address",
+			cfg:      config.Config{Network: &config.NetworkConfig{}},
+			expected: []string{"network.address must not be empty"},
+		},
+		{
+			name:     "missing network",
+			cfg:      config.Config{},
+			expected: []string{"network.address must not be empty"},
+		},
+		{
+			name: "negative timeouts",
+			cfg: config.Config{
+				Network: &config.NetworkConfig{
+					Address:        "127.0.0.1:3223",
+					IdleTimeout:    -time.Second,
+					CommandTimeout: -time.Minute,
+				},
+				Security: &config.SecurityConfig{
+					TokenTTL: -time.Minute, AuthTimeout: -time.Second, RateLimit: -0.5, RateBurst: -1,
+				},
+			},
+			expected: []string{
+				"network.idle_timeout must not be negative, got -1s",
+				"network.command_timeout must not be negative, got -1m0s",
+				"security.token_ttl must not be negative, got -1m0s",
+				"security.auth_timeout must not be negative, got -1s",
+				"security.rate_limit must not be negative, got -0.5",
+				"security.rate_burst must not be negative, got -1",
+			},
+		},
+		{
+			name: "master without listen address",
+			cfg: config.Config{
+				Network:     network,
+				WAL:         &config.WALConfig{},
+				Replication: &config.ReplicationConfig{ReplicaType: "master"},
+			},
+			expected: []string{"replication.master_address must be set for the master to listen on"},
+		},
+		{
+			name: "slave without master address",
+			cfg: config.Config{
+				Network:     network,
+				WAL:         &config.WALConfig{},
+				Replication: &config.ReplicationConfig{ReplicaType: "slave"},
+			},
+			expected: []string{"replication.master_address must be set for the slave to sync from"},
+		},
+		{
+			name: "unknown replica type without wal",
+			cfg: config.Config{
+				Network:     network,
+				Replication: &config.ReplicationConfig{ReplicaType: "leader", MasterAddress: "127.0.0.1:3232"},
+			},
+			expected: []string{
+				"replication.replica_type must be 'master' or 'slave', got 'leader'",
+				"replication requires the wal config",
+			},
+		},
+		{
+			name: "cleanup without batch size",
+			cfg: config.Config{
+				Network:       network,
+				CleanupConfig: &config.CleanupConfig{Period: time.Minute},
+			},
+			expected: []string{"cleanup.batch_size must be positive when cleanup.period is set"},
+		},
+		{
+			name: "negative cleanup jitter",
+			cfg: config.Config{
+				Network:       network,
+				CleanupConfig: &config.CleanupConfig{Period: time.Minute, Jitter: -time.Second, BatchSize: 10},
+			},
+			expected: []string{"cleanup.jitter must not be negative, got -1s"},
+		},
+		{
+			name: "unknown log format",
+			cfg: config.Config{
+				Network: network,
+				Logging: &config.LoggingConfig{Format: "xml", SlowQueryThreshold: -time.Second},
+			},
+			expected: []string{
+				"logging.format must be 'console' or 'json', got 'xml'",
+				"logging.slow_query_threshold must not be negative, got -1s",
+			},
+		},
+		{
+			name: "invalid max value size",
+			cfg: config.Config{
+				Engine:  &config.EngineConfig{MaxValueSize: "ten"},
+				Network: network,
+			},
+			expected: []string{"engine.max_value_size is invalid: incorrect size"},
+		},
+		{
+			name: "unknown engine type",
+			cfg: config.Config{
+				Engine:  &config.EngineConfig{Type: "on_disk"},
+				Network: network,
+			},
+			expected: []string{"engine.type must be 'in_memory' or 'sharded', got 'on_disk'"},
+		},
+		{
+			name: "negative partition number",
+			cfg: config.Config{
+				Engine:  &config.EngineConfig{Type: config.EngineTypeSharded, PartitionNum: -1},
+				Network: network,
+			},
+			expected: []string{"engine.partition_num must not be negative, got -1"},
+		},
+		{
+			name: "idle eviction without access tracking and cleanup",
+			cfg: config.Config{
+				Engine:  &config.EngineConfig{IdleEviction: time.Hour},
+				Network: network,
+			},
+			expected: []string{
+				"engine.idle_eviction requires engine.track_access",
+				"engine.idle_eviction requires cleanup.period",
+			},
+		},
+		{
+			name: "key separator with whitespace",
+			cfg: config.Config{
+				Engine:  &config.EngineConfig{KeySeparator: " "},
+				Network: network,
+			},
+			expected: []string{"engine.key_separator must not contain whitespaces, got ' '"},
+		},
+		{
+			name: "negative wal retries",
+			cfg: config.Config{
+				Network: network,
+				WAL:     &config.WALConfig{WriteRetries: -1},
+			},
+			expected: []string{"wal.write_retries must not be negative, got -1"},
+		},
+		{
+			name: "negative wal flush workers",
+			cfg: config.Config{
+				Network: network,
+				WAL:     &config.WALConfig{FlushWorkers: -1},
+			},
+			expected: []string{"wal.flush_workers must not be negative, got -1"},
+		},
+		{
+			name: "invalid wal max total size and full policy",
+			cfg: config.Config{
+				Network: network,
+				WAL:     &config.WALConfig{MaxTotalSize: "big", FullPolicy: "drop"},
+			},
+			expected: []string{
+				"wal.max_total_size is invalid: incorrect size",
+				"wal.full_policy must be 'reject' or 'backpressure', got 'drop'",
+			},
+		},
+		{
+			name: "wal backpressure without compaction",
+			cfg: config.Config{
+				Network: network,
+				WAL:     &config.WALConfig{MaxTotalSize: "1GB", FullPolicy: "backpressure"},
+			},
+			expected: []string{"wal.full_policy 'backpressure' requires the wal compaction"},
+		},
+		{
+			name: "bcrypt cost out of range",
+			cfg: config.Config{
+				Network:  network,
+				Security: &config.SecurityConfig{BcryptCost: 3},
+			},
+			expected: []string{"security.bcrypt_cost must be between 4 and 31, got 3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if len(tt.expected) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			for _, msg := range tt.expected {
+				assert.ErrorContains(t, err, msg)
+			}
+		})
+	}
+}
+
+func TestGetConfig_Invalid(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+network:
+  address: "127.0.0.1:3223"
+replication:
+  replica_type: "slave"
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	_, err := config.GetConfig(path)
+	assert.EqualError(t, err, "invalid config: replication.master_address must be set for the slave to sync from\n"+
+		"replication requires the wal config")
+}
@@ -2,69 +2,131 @@ package compute
 
 import (
 	"errors"
+	"strconv"
 	"strings"
 )
 
 const (
 	AdminHelpText = `
-Available commands for admins:
+Available commands for admins (command names are case-insensitive, keys and values are case-sensitive):
 
   Operation commands:
-    get <key> [ns namespace] - Retrieve the value associated with a key.
-    set <key> <value> [ttl duration] [ns namespace] - Store a value for a given key. Example TTL: 10s, 5m, 1h.
-    del <key> [ns namespace] - Remove a key and its value from the storage.
+    get <key> [ns namespace] [default value] [setdefault] [b64] - Retrieve the value associated with a key. The default is returned for a missing key without storing it, the setdefault flag stores it. The b64 flag returns the value base64-encoded and decodes the default.
+    set <key> <value> [ttl duration] [ns namespace] [get] [b64] - Store a value for a given key. Example TTL: 10s, 5m, 1h. Values with spaces are double-quoted, a backslash escapes the next character. The get flag returns the previous value in JSON. The b64 flag stores the base64-decoded value, so binary values are sent encoded.
+    setex <key> <seconds> <value> [ns namespace] - Store a value for a given key expiring after the seconds, the same as set with the ttl.
+    msetnx <key> <value> [key value ...] [ns namespace] - Store all the pairs only if none of the keys exist, returns true if they are stored.
+    del <key> [key ...] [ns namespace] [count] - Remove keys and their values from the storage. The count flag returns 1 if the key existed, otherwise 0, several keys always return the number of removed keys. Keys named as the parameters, e.g. "count", are double-quoted.
+    delprefix <prefix> [ns namespace] - Remove all keys starting with the prefix and return the number of removed keys, flush ns removes all keys.
+    getdel <key> [ns namespace] [b64] - Retrieve the value of a key and remove the key. The b64 flag returns the value base64-encoded.
+    renamenx <key> <new_key> [ns namespace] - Rename a key only if the new key does not exist.
+    type <key> [ns namespace] - Report how the value is interpreted: string, int or none if the key does not exist.
+    scan <cursor> [match pattern] [count n] [ns namespace] - Iterate keys in batches starting from the cursor, 0 starts and ends the iteration.
+    expiring <within> [ns namespace] - List the keys expiring within the duration in JSON, the soonest first. Example: expiring 30s.
+    lastaccess <key> [ns namespace] - Display the RFC3339 time the key was last read or written, requires engine.track_access.
 
   User commands:
 	login <username> <password> - Authenticate a user.
-	create user <username> <password> - Create a new user.
+	login_token <token> - Authenticate a user with a session token.
+	logout - Close the current session, the connection can log in again.
+	token - Issue a short-lived session token to re-authenticate without the password.
+	create user <username> <password> [ns namespace] - Create a new user, the namespace is the one the user starts in.
 	get user <username> - Display information about the requested user.
 	delete user <username>  - Delete a user.
 	assign role <username> <role> - Assign a role to a user.
 	divest role <username> <role> - Divest a role from user.
-	users - List all usernames.
+	dedup roles [username] - Remove duplicate roles of the user or of all users.
+	users [offset n] [limit n] - List usernames, the offset and limit select a page of the list.
+	sessions - List all active sessions.
+	kill session <session_id> - Terminate another session and close its connection.
+	connections - List client connections with traffic and the command in progress.
 	me - Display information about the current user.
+	sessioninfo - Display the current session in JSON.
+	caps - List the commands the current session may execute in JSON.
 
   Roles commands:
   	get role <role_name> - Display information about the requested role.
-  	create role <role_name> <permissions> <namespace> - Create a new role.
+  	create role <role_name> <permissions> <namespace> - Create a new role, the namespace * grants the permissions in all namespaces.
     delete role <role_name> - Delete a role.
-    roles - List all roles.
+    update role <role_name> <permissions> [namespace] - Update the permissions and the namespace of a role in place.
+    roles [offset n] [limit n] - List roles, the offset and limit select a page of the list.
+    roleusers <role_name> - List the usernames assigned the role in JSON.
 
   Namespaces commands:
-    create ns <namespace> - Create a new namespace.
+    create ns <namespace> [default_ttl duration] - Create a new namespace, keys are expired after default TTL if set.
     delete ns <namespace> - Delete a namespace.
+    flush ns <namespace> - Delete all keys in a namespace.
+    get ns <namespace> - Display the namespace metadata in JSON.
+    export <namespace> - Dump all keys of a namespace into a compressed base64 blob.
+    import <namespace> <data> - Load the blob created by export into a namespace.
     ns - List all namespaces.
     set ns <namespace> - Set the current namespace for the user.
+    set role <role_name> - Activate one of your roles, e.g. to choose between several roles of a namespace.
 
   Help command:
     help - Display this help message.
+    health - Display the health and readiness of the database.
+    version - Display the server and protocol versions in JSON.
+    explain <query> - Display the parsed command of the query in JSON without executing it.
 
   Other commands:
     watch <key> [ns namespace] - Watches the key and returns the value if it has changed.
+    publish <channel> <message> [ns namespace] - Send the message to the current subscribers of the channel, returns the number of subscribers.
+    subscribe <channel> [ns namespace] - Subscribe the session to the channel, the messages are kept until they are listened.
+    listen <channel> [ns namespace] - Wait for the messages of the subscribed channel and return them in JSON.
     stat - Displays database statistics.
+    dbsize - Displays the number of keys per namespace.
+    wal latency - Displays the WAL write latency percentiles.
+    compact - Runs a WAL compaction pass and displays the reclaimed bytes and the removed segments in JSON.
+    fsync - Writes the pending WAL batch and commits the log to the disk, returns once the acknowledged writes are durable.
+    setmaxsize <pattern> <size> [ns namespace] - Set the maximum value size for keys matching the pattern, 0 removes the override. Example size: 512B, 4KB, 1MB.
+    getmaxsize <key> [ns namespace] - Displays the maximum value size for the key, 0 means unlimited.
+    dump <key> [ns namespace] - Display the stored value of the key with its expiry and engine metadata in JSON.
 `
 
 	UserHelpText = `
-Available commands for users:
+Available commands for users (command names are case-insensitive, keys and values are case-sensitive):
 
   Operation commands:
-    get <key> [ns namespace] - Retrieve the value associated with a key.
-    set <key> <value> [ttl duration] [ns namespace] - Store a value for a given key.
-    del <key> [ns namespace] - Remove a key and its value from the storage.
+    get <key> [ns namespace] [default value] [setdefault] [b64] - Retrieve the value associated with a key. The default is returned for a missing key without storing it, the setdefault flag stores it. The b64 flag returns the value base64-encoded and decodes the default.
+    set <key> <value> [ttl duration] [ns namespace] [get] [b64] - Store a value for a given key. Values with spaces are double-quoted: set greeting "hello world". The get flag returns the previous value in JSON. The b64 flag stores the base64-decoded value, so binary values are sent encoded.
+    setex <key> <seconds> <value> [ns namespace] - Store a value for a given key expiring after the seconds, the same as set with the ttl.
+    msetnx <key> <value> [key value ...] [ns namespace] - Store all the pairs only if none of the keys exist, returns true if they are stored.
+    del <key> [key ...] [ns namespace] [count] - Remove keys and their values from the storage. The count flag returns 1 if the key existed, otherwise 0, several keys always return the number of removed keys. Keys named as the parameters, e.g. "count", are double-quoted.
+    delprefix <prefix> [ns namespace] - Remove all keys starting with the prefix and return the number of removed keys, flush ns removes all keys.
+    getdel <key> [ns namespace] [b64] - Retrieve the value of a key and remove the key. The b64 flag returns the value base64-encoded.
+    renamenx <key> <new_key> [ns namespace] - Rename a key only if the new key does not exist.
+    type <key> [ns namespace] - Report how the value is interpreted: string, int or none if the key does not exist.
+    scan <cursor> [match pattern] [count n] [ns namespace] - Iterate keys in batches starting from the cursor, 0 starts and ends the iteration.
+    expiring <within> [ns namespace] - List the keys expiring within the duration in JSON, the soonest first. Example: expiring 30s.
+    lastaccess <key> [ns namespace] - Display the RFC3339 time the key was last read or written, requires engine.track_access.
 
   User commands:
     login <username> <password> - Authenticate a user.
+    login_token <token> - Authenticate a user with a session token.
+    logout - Close the current session, the connection can log in again.
+    token - Issue a short-lived session token to re-authenticate without the password.
     me - Display information about the current user.
+    sessioninfo - Display the current session in JSON.
+    caps - List the commands the current session may execute in JSON.
 
   Namespaces commands:
+    get ns <namespace> - Display the metadata of a namespace of your roles in JSON.
     ns - List all namespaces.
     set ns <namespace> - Set the current namespace for the user.
+    set role <role_name> - Activate one of your roles, e.g. to choose between several roles of a namespace.
 
   Help command:
     help - Display this help message.
+    health - Display the health and readiness of the database.
+    version - Display the server and protocol versions in JSON.
+    explain <query> - Display the parsed command of the query in JSON without executing it.
 
   Other commands:
     watch <key> [ns namespace] - Watches the key and returns the value if it has changed.
+    publish <channel> <message> [ns namespace] - Send the message to the current subscribers of the channel, returns the number of subscribers.
+    subscribe <channel> [ns namespace] - Subscribe the session to the channel, the messages are kept until they are listened.
+    listen <channel> [ns namespace] - Wait for the messages of the subscribed channel and return them in JSON.
+    getmaxsize <key> [ns namespace] - Displays the maximum value size for the key, 0 means unlimited.
 `
 )
 
@@ -76,19 +138,42 @@ const (
 	SetCommandID
 	GetCommandID
 	DelCommandID
+	RenameNXCommandID
 )
 
 const (
-	KeyArg         = "key"
-	ValueArg       = "value"
-	TTLArg         = "ttl"
-	NSArg          = "ns"
-	UsernameArg    = "username"
-	PasswordArg    = "password"
-	RoleArg        = "role"
-	RoleNameArg    = "role_name"
-	PermissionsArg = "permissions"
-	NamespaceArg   = "namespace"
+	KeyArg            = "key"
+	NewKeyArg         = "new_key"
+	ValueArg          = "value"
+	TTLArg            = "ttl"
+	SecondsArg        = "seconds"
+	DefaultTTLArg     = "default_ttl"
+	NSArg             = "ns"
+	UsernameArg       = "username"
+	PasswordArg       = "password"
+	RoleArg           = "role"
+	RoleNameArg       = "role_name"
+	PermissionsArg    = "permissions"
+	NamespaceArg      = "namespace"
+	SessionIDArg      = "session_id"
+	PatternArg        = "pattern"
+	PrefixArg         = "prefix"
+	SizeArg           = "size"
+	TokenArg          = "token"
+	CursorArg         = "cursor"
+	MatchArg          = "match"
+	CountArg          = "count"
+	OffsetArg         = "offset"
+	LimitArg          = "limit"
+	DataArg           = "data"
+	WithinArg         = "within"
+	PairsArg          = "pairs"
+	ChannelArg        = "channel"
+	MessageArg        = "message"
+	DefaultArg        = "default"
+	GetFlagArg        = "get"
+	SetDefaultFlagArg = "setdefault"
+	Base64FlagArg     = "b64"
 )
 
 var (
@@ -113,38 +198,90 @@ const (
 	CommandDEL CommandType = "del"
 	CommandSET CommandType = "set"
 
+	// CommandSETEX - shorthand of set with the ttl in seconds.
+	CommandSETEX CommandType = "setex"
+
+	CommandMSETNX   CommandType = "msetnx"
+	CommandGETDEL   CommandType = "getdel"
+	CommandRENAMENX CommandType = "renamenx"
+	CommandTYPE     CommandType = "type"
+	CommandSCAN     CommandType = "scan"
+	CommandEXPIRING CommandType = "expiring"
+
+	CommandLASTACCESS CommandType = "lastaccess"
+	CommandDELPREFIX  CommandType = "delprefix"
+
 	// User commands
-	CommandAUTH       CommandType = "login"
-	CommandGETUSER    CommandType = "get user"
-	CommandCREATEUSER CommandType = "create user"
-	CommandDELETEUSER CommandType = "delete user"
-	CommandUSERS      CommandType = "users"
-	CommandSESSIONS   CommandType = "sessions"
-	CommandME         CommandType = "me"
+	CommandAUTH        CommandType = "login"
+	CommandLOGINTOKEN  CommandType = "login_token"
+	CommandLOGOUT      CommandType = "logout"
+	CommandTOKEN       CommandType = "token"
+	CommandGETUSER     CommandType = "get user"
+	CommandCREATEUSER  CommandType = "create user"
+	CommandDELETEUSER  CommandType = "delete user"
+	CommandUSERS       CommandType = "users"
+	CommandSESSIONS    CommandType = "sessions"
+	CommandKILLSESSION CommandType = "kill session"
+	CommandCONNECTIONS CommandType = "connections"
+	CommandME          CommandType = "me"
+	CommandSESSIONINFO CommandType = "sessioninfo"
+	CommandCAPS        CommandType = "caps"
 
 	// Roles commands
 	CommandGETROLE    CommandType = "get role"
 	CommandCREATEROLE CommandType = "create role"
 	CommandDELETEROLE CommandType = "delete role"
+	CommandUPDATEROLE CommandType = "update role"
 	CommandROLES      CommandType = "roles"
+	CommandROLEUSERS  CommandType = "roleusers"
 	CommandASSIGNROLE CommandType = "assign role"
 	CommandDIVESTROLE CommandType = "divest role"
+	CommandDEDUPROLES CommandType = "dedup roles"
+	CommandSETROLE    CommandType = "set role"
 
 	// Namespaces commands
 	CommandCREATENAMESPACE CommandType = "create ns"
 	CommandDELETENAMESPACE CommandType = "delete ns"
 	CommandGETNAMESPACE    CommandType = "get ns"
+	CommandFLUSHNS         CommandType = "flush ns"
 	CommandNAMESPACES      CommandType = "ns"
 	CommandSETNS           CommandType = "set ns"
+	CommandEXPORT          CommandType = "export"
+	CommandIMPORT          CommandType = "import"
 
 	// Help command
 	CommandHELP CommandType = "help"
 
+	// Health command
+	CommandHEALTH CommandType = "health"
+
+	// Version command
+	CommandVERSION CommandType = "version"
+
+	// Explain command, the rest of the query is parsed without the execution.
+	CommandEXPLAIN CommandType = "explain"
+
 	// Watch command
 	CommandWATCH CommandType = "watch"
 
+	// Pub-sub commands
+	CommandPUBLISH   CommandType = "publish"
+	CommandSUBSCRIBE CommandType = "subscribe"
+	CommandLISTEN    CommandType = "listen"
+
 	// Stat command
-	CommandSTAT CommandType = "stat"
+	CommandSTAT       CommandType = "stat"
+	CommandDBSIZE     CommandType = "dbsize"
+	CommandWALLATENCY CommandType = "wal latency"
+	CommandCOMPACT    CommandType = "compact"
+	CommandFSYNC      CommandType = "fsync"
+
+	// Size limits commands
+	CommandSETMAXSIZE CommandType = "setmaxsize"
+	CommandGETMAXSIZE CommandType = "getmaxsize"
+
+	// Debug commands
+	CommandDUMP CommandType = "dump"
 )
 
 // String - convert CommandType into string/
@@ -172,6 +309,34 @@ type CommandParam struct {
 	Required   bool
 	Positional bool
 	Position   int
+	// Flag - the named parameter is given without a value, a present flag is set to an empty string.
+	Flag bool
+	// Variadic - the last positional parameter takes the following tokens up to the first named parameter,
+	// the extra values are stored under the names made by VariadicArgName. A value equal to a parameter
+	// name must be quoted.
+	Variadic bool
+}
+
+// VariadicArgName - returns the name of the n-th extra value of the variadic parameter.
+func VariadicArgName(name string, n int) string {
+	return name + "." + strconv.Itoa(n)
+}
+
+// ArgValues - returns the values of the variadic parameter in the query order.
+func ArgValues(args map[string]string, name string) []string {
+	val, ok := args[name]
+	if !ok {
+		return nil
+	}
+
+	values := []string{val}
+	for n := 1; ; n++ {
+		val, ok := args[VariadicArgName(name, n)]
+		if !ok {
+			return values
+		}
+		values = append(values, val)
+	}
 }
 
 // NewCommand - creates a new instance of Command.
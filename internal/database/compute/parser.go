@@ -3,6 +3,7 @@ package compute
 import (
 	"fmt"
 	"strings"
+	"unicode"
 
 	"github.com/neekrasov/kvdb/pkg/logger"
 	"go.uber.org/zap"
@@ -25,7 +26,11 @@ func (p *Parser) Parse(query string) (*Command, error) {
 		return nil, fmt.Errorf("%w: query cannot be empty", ErrInvalidSyntax)
 	}
 
-	tokens := strings.Fields(query)
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(tokens) == 0 {
 		return nil, fmt.Errorf("%w: query cannot be empty", ErrInvalidSyntax)
 	}
@@ -41,3 +46,61 @@ func (p *Parser) Parse(query string) (*Command, error) {
 
 	return NewCommand(commandType, args)
 }
+
+// token - a single word of the query.
+type token struct {
+	value  string
+	quoted bool // The token has a double-quoted part, it is never taken as a parameter name.
+}
+
+// tokenize - splits the query into tokens by whitespaces. Double-quoted strings are kept as a single token
+// with the whitespaces, a backslash escapes the next character both inside and outside the quotes,
+// so `"say \"hi\""` is the token `say "hi"`.
+func tokenize(query string) ([]token, error) {
+	var (
+		tokens  []token
+		current token
+		builder strings.Builder
+		inToken bool
+		quoted  bool
+		escaped bool
+	)
+
+	for _, r := range query {
+		switch {
+		case escaped:
+			builder.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped, inToken = true, true
+		case r == '"':
+			quoted, inToken, current.quoted = !quoted, true, true
+		case unicode.IsSpace(r) && !quoted:
+			if inToken {
+				current.value = builder.String()
+				tokens = append(tokens, current)
+				current = token{}
+				builder.Reset()
+				inToken = false
+			}
+		default:
+			builder.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	if escaped {
+		return nil, fmt.Errorf("%w: unterminated escape", ErrInvalidSyntax)
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("%w: unterminated quote", ErrInvalidSyntax)
+	}
+
+	if inToken {
+		current.value = builder.String()
+		tokens = append(tokens, current)
+	}
+
+	return tokens, nil
+}
@@ -12,10 +12,17 @@ import (
 func initCommandTrie() *TrieNode {
 	root := NewTrieNode()
 	root.Insert(CommandSET, map[string]CommandParam{
-		KeyArg:   {Required: true, Positional: true, Position: 0},
-		ValueArg: {Required: true, Positional: true, Position: 1},
-		TTLArg:   {Required: false, Positional: false},
-		NSArg:    {Required: false, Positional: false},
+		KeyArg:     {Required: true, Positional: true, Position: 0},
+		ValueArg:   {Required: true, Positional: true, Position: 1},
+		TTLArg:     {Required: false, Positional: false},
+		NSArg:      {Required: false, Positional: false},
+		GetFlagArg: {Required: false, Flag: true},
+	})
+	root.Insert(CommandSETEX, map[string]CommandParam{
+		KeyArg:     {Required: true, Positional: true, Position: 0},
+		SecondsArg: {Required: true, Positional: true, Position: 1},
+		ValueArg:   {Required: true, Positional: true, Position: 2},
+		NSArg:      {Required: false, Positional: false},
 	})
 	root.Insert(CommandGET, map[string]CommandParam{
 		KeyArg: {Required: true, Positional: true, Position: 0},
@@ -23,9 +30,10 @@ func initCommandTrie() *TrieNode {
 		NSArg:  {Required: false, Positional: false},
 	})
 	root.Insert(CommandDEL, map[string]CommandParam{
-		KeyArg: {Required: true, Positional: true, Position: 0},
-		TTLArg: {Required: false, Positional: false},
-		NSArg:  {Required: false, Positional: false},
+		KeyArg:   {Required: true, Positional: true, Position: 0, Variadic: true},
+		TTLArg:   {Required: false, Positional: false},
+		NSArg:    {Required: false, Positional: false},
+		CountArg: {Required: false, Flag: true},
 	})
 	root.Insert(CommandAUTH, map[string]CommandParam{
 		UsernameArg: {Required: true, Positional: true, Position: 0},
@@ -135,6 +143,93 @@ func TestParse(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		{
+			name:  "Valid SET Query (With Flag)",
+			query: fmt.Sprintf("%s mykey myvalue get", CommandSET),
+			expectedCmd: &Command{
+				Type: CommandSET,
+				Args: map[string]string{
+					KeyArg:     "mykey",
+					ValueArg:   "myvalue",
+					GetFlagArg: "",
+				},
+			},
+			expectedErr: nil,
+		},
+		{
+			name:  "Valid SET Query (Flag Between Named Args)",
+			query: fmt.Sprintf("%s mykey myvalue ttl 10s GET ns testing", CommandSET),
+			expectedCmd: &Command{
+				Type: CommandSET,
+				Args: map[string]string{
+					KeyArg:     "mykey",
+					ValueArg:   "myvalue",
+					TTLArg:     "10s",
+					GetFlagArg: "",
+					NSArg:      "testing",
+				},
+			},
+			expectedErr: nil,
+		},
+		{
+			name:  "Valid SETEX Query",
+			query: fmt.Sprintf("%s mykey 10 myvalue ns testing", CommandSETEX),
+			expectedCmd: &Command{
+				Type: CommandSETEX,
+				Args: map[string]string{
+					KeyArg:     "mykey",
+					SecondsArg: "10",
+					ValueArg:   "myvalue",
+					NSArg:      "testing",
+				},
+			},
+			expectedErr: nil,
+		},
+		{
+			name:  "Valid DEL Query (Variadic Keys)",
+			query: fmt.Sprintf("%s k1 k2 k3 NS testing count", CommandDEL),
+			expectedCmd: &Command{
+				Type: CommandDEL,
+				Args: map[string]string{
+					KeyArg:                     "k1",
+					VariadicArgName(KeyArg, 1): "k2",
+					VariadicArgName(KeyArg, 2): "k3",
+					NSArg:                      "testing",
+					CountArg:                   "",
+				},
+			},
+			expectedErr: nil,
+		},
+		{
+			name:  "Valid DEL Query (Keys Named As Parameters)",
+			query: fmt.Sprintf(`%s a "count" "ns" count ns testing`, CommandDEL),
+			expectedCmd: &Command{
+				Type: CommandDEL,
+				Args: map[string]string{
+					KeyArg:                     "a",
+					VariadicArgName(KeyArg, 1): "count",
+					VariadicArgName(KeyArg, 2): "ns",
+					NSArg:                      "testing",
+					CountArg:                   "",
+				},
+			},
+			expectedErr: nil,
+		},
+		{
+			name:        "Invalid DEL Query (Quoted Parameter Name)",
+			query:       fmt.Sprintf(`%s a count "ns" testing`, CommandDEL),
+			expectedCmd: nil,
+			expectedErr: fmt.Errorf("%w: unknown parameter '%s'", ErrInvalidSyntax, NSArg),
+		},
+		{
+			name:  "Valid DEL Query (Single Key)",
+			query: fmt.Sprintf("%s k1", CommandDEL),
+			expectedCmd: &Command{
+				Type: CommandDEL,
+				Args: map[string]string{KeyArg: "k1"},
+			},
+			expectedErr: nil,
+		},
 		{
 			name:  "Valid GET Query",
 			query: fmt.Sprintf("%s somekey", CommandGET),
@@ -200,6 +295,12 @@ func TestParse(t *testing.T) {
 			expectedCmd: nil,
 			expectedErr: fmt.Errorf("%w: missing required parameter '%s'", ErrInvalidSyntax, ValueArg),
 		},
+		{
+			name:        "SETEX Missing Value Arg",
+			query:       fmt.Sprintf("%s mykey 10", CommandSETEX),
+			expectedCmd: nil,
+			expectedErr: fmt.Errorf("%w: missing required parameter '%s'", ErrInvalidSyntax, ValueArg),
+		},
 		{
 			name:        "GET Missing Key Arg",
 			query:       CommandGET.String(),
@@ -230,6 +331,119 @@ func TestParse(t *testing.T) {
 			expectedCmd: nil,
 			expectedErr: fmt.Errorf("%w: unknown command", ErrInvalidCommand),
 		},
+		{
+			name:  "SET Quoted Value",
+			query: fmt.Sprintf(`%s greeting "hello world"`, CommandSET),
+			expectedCmd: &Command{
+				Type: CommandSET,
+				Args: map[string]string{
+					KeyArg:   "greeting",
+					ValueArg: "hello world",
+				},
+			},
+		},
+		{
+			name:  "SET Quoted Value With Named Args",
+			query: fmt.Sprintf(`%s "my key" "  padded  " ttl 10s ns "testing"`, CommandSET),
+			expectedCmd: &Command{
+				Type: CommandSET,
+				Args: map[string]string{
+					KeyArg:   "my key",
+					ValueArg: "  padded  ",
+					TTLArg:   "10s",
+					NSArg:    "testing",
+				},
+			},
+		},
+		{
+			name:  "SET Embedded Quotes And Escapes",
+			query: fmt.Sprintf(`%s quote "say \"hi\" to C:\\temp" `, CommandSET),
+			expectedCmd: &Command{
+				Type: CommandSET,
+				Args: map[string]string{
+					KeyArg:   "quote",
+					ValueArg: `say "hi" to C:\temp`,
+				},
+			},
+		},
+		{
+			name:  "SET Escaped Space Outside Quotes",
+			query: fmt.Sprintf(`%s key hello\ world`, CommandSET),
+			expectedCmd: &Command{
+				Type: CommandSET,
+				Args: map[string]string{
+					KeyArg:   "key",
+					ValueArg: "hello world",
+				},
+			},
+		},
+		{
+			name:  "SET Empty Quoted Value",
+			query: fmt.Sprintf(`%s key ""`, CommandSET),
+			expectedCmd: &Command{
+				Type: CommandSET,
+				Args: map[string]string{
+					KeyArg:   "key",
+					ValueArg: "",
+				},
+			},
+		},
+		{
+			name:        "SET Unterminated Quote",
+			query:       fmt.Sprintf(`%s key "hello world`, CommandSET),
+			expectedErr: fmt.Errorf("%w: unterminated quote", ErrInvalidSyntax),
+		},
+		{
+			name:        "SET Unterminated Escape",
+			query:       fmt.Sprintf(`%s key value\`, CommandSET),
+			expectedErr: fmt.Errorf("%w: unterminated escape", ErrInvalidSyntax),
+		},
+		{
+			name:  "Uppercase Command Keyword",
+			query: "GET x",
+			expectedCmd: &Command{
+				Type: CommandGET,
+				Args: map[string]string{KeyArg: "x"},
+			},
+		},
+		{
+			name:  "Mixed Case Command Keyword",
+			query: "Get x",
+			expectedCmd: &Command{
+				Type: CommandGET,
+				Args: map[string]string{KeyArg: "x"},
+			},
+		},
+		{
+			name:  "Lowercase Command Keyword",
+			query: "get x",
+			expectedCmd: &Command{
+				Type: CommandGET,
+				Args: map[string]string{KeyArg: "x"},
+			},
+		},
+		{
+			name:  "Uppercase Multi-Word Command Keeps Argument Case",
+			query: "CREATE User NewUser SecurePass",
+			expectedCmd: &Command{
+				Type: CommandCREATEUSER,
+				Args: map[string]string{
+					UsernameArg: "NewUser",
+					PasswordArg: "SecurePass",
+				},
+			},
+		},
+		{
+			name:  "SET Uppercase Keeps Key And Value Case",
+			query: "SET MyKey MyValue",
+			expectedCmd: &Command{
+				Type: CommandSET,
+				Args: map[string]string{
+					KeyArg:   "MyKey",
+					ValueArg: "MyValue",
+				},
+			},
+		},
 		{
 			name:        "Named Arg Before Positional Completed",
 			query:       fmt.Sprintf("%s key TTL 10s value", CommandSET),
@@ -257,3 +471,52 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestArgValues(t *testing.T) {
+	t.Parallel()
+
+	args := map[string]string{KeyArg: "a", VariadicArgName(KeyArg, 1): "b", VariadicArgName(KeyArg, 2): "c"}
+	assert.Equal(t, []string{"a", "b", "c"}, ArgValues(args, KeyArg))
+	assert.Equal(t, []string{"a"}, ArgValues(map[string]string{KeyArg: "a"}, KeyArg))
+	assert.Nil(t, ArgValues(map[string]string{}, KeyArg))
+}
+
+func TestTrieNode_Alias(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	root := initCommandTrie()
+	require.NoError(t, root.Alias("put", CommandSET))
+	require.NoError(t, root.Alias("Remove Keys", CommandDEL))
+
+	parser := NewParser(root)
+	cmd, err := parser.Parse("PUT mykey myvalue ttl 10s")
+	require.NoError(t, err)
+	assert.Equal(t, &Command{
+		Type: CommandSET,
+		Args: map[string]string{KeyArg: "mykey", ValueArg: "myvalue", TTLArg: "10s"},
+	}, cmd)
+
+	cmd, err = parser.Parse("remove keys k1 k2")
+	require.NoError(t, err)
+	assert.Equal(t, &Command{
+		Type: CommandDEL,
+		Args: map[string]string{KeyArg: "k1", VariadicArgName(KeyArg, 1): "k2"},
+	}, cmd)
+
+	for alias, cmdType := range map[string]CommandType{
+		"get":     CommandSET, // shadows the command
+		"set foo": CommandGET, // shadows the 'set' command with the key 'foo'
+		"ns":      CommandGET,
+		"":        CommandGET,
+		"fetch":   "fetch", // unknown command
+		"alias":   "put",   // alias of the alias
+	} {
+		assert.ErrorIs(t, root.Alias(alias, cmdType), ErrInvalidCommand, alias)
+	}
+
+	// The rejected alias leaves the trie unchanged.
+	cmd, err = parser.Parse("set foo bar")
+	require.NoError(t, err)
+	assert.Equal(t, CommandSET, cmd.Type)
+}
@@ -31,13 +31,56 @@ func (t *TrieNode) Insert(cmdType CommandType, params map[string]CommandParam) {
 	current.params = params
 }
 
-func (t *TrieNode) Search(tokens []string) (CommandType, map[string]string, error) {
+// Alias - registers the alias running the command with its parameters, the alias may have several words.
+// The alias of another alias is rejected, as well as the alias shadowing a command, e.g. 'set' or 'set foo'.
+func (t *TrieNode) Alias(alias string, cmdType CommandType) error {
+	target := t
+	for _, part := range cmdType.Split() {
+		if target = target.children[part]; target == nil {
+			break
+		}
+	}
+	if target == nil || target.command != cmdType {
+		return fmt.Errorf("%w: unknown command '%s' of alias '%s'", ErrInvalidCommand, cmdType, alias)
+	}
+
+	parts := strings.Fields(strings.ToLower(alias))
+	if len(parts) == 0 {
+		return fmt.Errorf("%w: empty alias of command '%s'", ErrInvalidCommand, cmdType)
+	}
+
+	// The path is checked before the insert, so the rejected alias leaves the trie unchanged.
+	current := t
+	for _, part := range parts {
+		if current = current.children[part]; current == nil {
+			break
+		}
+		if current.command != "" {
+			return fmt.Errorf("%w: alias '%s' shadows command '%s'", ErrInvalidCommand, alias, current.command)
+		}
+	}
+
+	current = t
+	for _, part := range parts {
+		if _, exists := current.children[part]; !exists {
+			current.children[part] = NewTrieNode()
+		}
+		current = current.children[part]
+	}
+	current.command = target.command
+	current.params = target.params
+
+	return nil
+}
+
+func (t *TrieNode) Search(tokens []token) (CommandType, map[string]string, error) {
 	current := t
 	consumedTokens := 0
 
-	// Traverse the trie with tokens
+	// Traverse the trie with tokens, the command keywords are case-insensitive,
+	// the arguments following the command keep their case.
 	for _, token := range tokens {
-		if next, exists := current.children[token]; exists {
+		if next, exists := current.children[strings.ToLower(token.value)]; exists {
 			current = next
 			consumedTokens++
 		} else {
@@ -76,26 +119,44 @@ func (t *TrieNode) Search(tokens []string) (CommandType, map[string]string, erro
 			if len(remainingTokens) == 0 {
 				break
 			}
-			args[pp.name] = remainingTokens[0]
+			args[pp.name] = remainingTokens[0].value
 			remainingTokens = remainingTokens[1:]
+
+			if !pp.param.Variadic {
+				continue
+			}
+
+			for n := 1; len(remainingTokens) > 0 && !current.isNamedParam(remainingTokens[0]); n++ {
+				args[VariadicArgName(pp.name, n)] = remainingTokens[0].value
+				remainingTokens = remainingTokens[1:]
+			}
 		}
 	}
 
 	// Process named parameters
 	for i := 0; i < len(remainingTokens); {
+		paramName := strings.ToLower(remainingTokens[i].value)
+		param, exists := current.params[paramName]
+		if remainingTokens[i].quoted {
+			exists = false
+		}
+		if exists && param.Flag {
+			args[paramName] = ""
+			i++
+			continue
+		}
+
 		if i+1 >= len(remainingTokens) {
 			return "", nil, fmt.Errorf("%w: missing value for parameter '%s'",
-				ErrInvalidSyntax, remainingTokens[i])
+				ErrInvalidSyntax, remainingTokens[i].value)
 		}
 
-		paramName := strings.ToLower(remainingTokens[i])
-		param, exists := current.params[paramName]
 		if !exists || param.Positional {
 			return "", nil, fmt.Errorf("%w: unknown parameter '%s'",
-				ErrInvalidSyntax, remainingTokens[i])
+				ErrInvalidSyntax, remainingTokens[i].value)
 		}
 
-		args[paramName] = remainingTokens[i+1]
+		args[paramName] = remainingTokens[i+1].value
 		i += 2
 	}
 
@@ -111,3 +172,14 @@ func (t *TrieNode) Search(tokens []string) (CommandType, map[string]string, erro
 
 	return current.command, args, nil
 }
+
+// isNamedParam - checks whether the token is the name of a named parameter of the command,
+// a quoted token is always a value.
+func (t *TrieNode) isNamedParam(token token) bool {
+	if token.quoted {
+		return false
+	}
+
+	param, exists := t.params[strings.ToLower(token.value)]
+	return exists && !param.Positional
+}
@@ -2,27 +2,174 @@ package database
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/neekrasov/kvdb/internal/config"
 	"github.com/neekrasov/kvdb/internal/database/compute"
 	"github.com/neekrasov/kvdb/internal/database/identity/models"
+	"github.com/neekrasov/kvdb/internal/database/pubsub"
 	"github.com/neekrasov/kvdb/internal/database/storage"
+	"github.com/neekrasov/kvdb/internal/database/storage/engine"
+	"github.com/neekrasov/kvdb/internal/database/storage/snapshot"
+	"github.com/neekrasov/kvdb/internal/database/storage/wal"
 	pkgsync "github.com/neekrasov/kvdb/pkg/sync"
 )
 
 // Stats - structure for storing statistical databases.
+// Storage fields are zero and listed in Unavailable when the storage statistics are not collected.
 type Stats struct {
 	Uptime          float64 `json:"uptime"`           // Server uptime.
 	TotalCommands   int64   `json:"total_commands"`   // Total number of commands executed.
-	GetCommands     int64   `json:"get_commands"`     // Number of GET commands.
+	GetCommands     int64   `json:"get_commands"`     // Number of GET, GETSET and GETDEL commands.
 	SetCommands     int64   `json:"set_commands"`     // Number of SET commands.
 	DelCommands     int64   `json:"del_commands"`     // Number of DEL commands.
 	TotalKeys       int64   `json:"total_keys"`       // Total number of keys in the storage (approximate).
 	ExpiredKeys     int64   `json:"expired_keys"`     // Number of expired keys (deleted).
+	EvictedKeys     int64   `json:"evicted_keys"`     // Number of keys evicted as idle (deleted).
+	Hits            int64   `json:"hits"`             // Number of GET, GETSET and GETDEL commands that found the key.
+	Misses          int64   `json:"misses"`           // Number of GET, GETSET and GETDEL commands that did not find the key.
 	ActiveSessions  int64   `json:"active_sessions"`  // Number of active sessions.
 	TotalNamespaces int64   `json:"total_namespaces"` // Number of namespaces.
 	TotalRoles      int64   `json:"total_roles"`      // Number of roles.
 	TotalUsers      int64   `json:"total_users"`      // Number of users.
+	WALWriteErrors  int64   `json:"wal_write_errors"` // Number of WAL batches failed to be written.
+
+	CompactionsTotal int64      `json:"compactions_total"`         // Number of completed WAL compaction passes.
+	LastCompaction   *time.Time `json:"last_compaction,omitempty"` // Completion time of the last WAL compaction pass.
+
+	SlowCommands *SlowCommands `json:"slow_commands,omitempty"` // Commands exceeding the slow query threshold.
+
+	Unavailable []string `json:"unavailable,omitempty"` // Fields that are not collected (e.g. storage statistics disabled).
+}
+
+// SlowCommands - summary of the commands exceeding the slow query threshold.
+type SlowCommands struct {
+	Count        int64   `json:"count"`         // Number of slow commands.
+	TotalSeconds float64 `json:"total_seconds"` // Total execution time of slow commands.
+	MaxSeconds   float64 `json:"max_seconds"`   // Longest execution time of a slow command.
+}
+
+// slowCommandsCounter - collects the summary of the slow commands.
+type slowCommandsCounter struct {
+	count atomic.Int64
+	total atomic.Int64
+	max   atomic.Int64
+}
+
+// record - adds the execution time of a slow command to the summary.
+func (c *slowCommandsCounter) record(elapsed time.Duration) {
+	c.count.Add(1)
+	c.total.Add(int64(elapsed))
+	for {
+		current := c.max.Load()
+		if int64(elapsed) <= current || c.max.CompareAndSwap(current, int64(elapsed)) {
+			return
+		}
+	}
+}
+
+// summary - returns the collected summary of the slow commands.
+func (c *slowCommandsCounter) summary() *SlowCommands {
+	return &SlowCommands{
+		Count:        c.count.Load(),
+		TotalSeconds: time.Duration(c.total.Load()).Seconds(),
+		MaxSeconds:   time.Duration(c.max.Load()).Seconds(),
+	}
+}
+
+// SessionInfo - structured information about the current session.
+type SessionInfo struct {
+	Username  string     `json:"username"`             // Name of the session user.
+	Roles     []string   `json:"roles"`                // Roles assigned to the user.
+	Namespace string     `json:"namespace"`            // Namespace of the active role.
+	Get       bool       `json:"get"`                  // Read permission of the active role.
+	Set       bool       `json:"set"`                  // Write permission of the active role.
+	Del       bool       `json:"del"`                  // Delete permission of the active role.
+	CreatedAt time.Time  `json:"created_at"`           // Session creation time.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Session expiration time, omitted if the session does not expire.
+}
+
+// ConnInfo - information about a client connection returned by the connections command.
+type ConnInfo struct {
+	SessionID    string    `json:"session"`            // Session bound to the connection.
+	Username     string    `json:"username,omitempty"` // Name of the session user, omitted until the login.
+	RemoteAddr   string    `json:"remote_addr"`        // Network address of the client.
+	ConnectedAt  time.Time `json:"connected_at"`       // Time the connection was accepted.
+	BytesRead    int64     `json:"bytes_read"`         // Bytes received from the client.
+	BytesWritten int64     `json:"bytes_written"`      // Bytes sent to the client.
+	Command      string    `json:"command,omitempty"`  // Name of the command in progress, omitted if the connection is idle.
+}
+
+// EntryDump - stored value of a key with its metadata returned by the dump command.
+type EntryDump struct {
+	Key        string     `json:"key"`                   // Storage key including the namespace.
+	Value      string     `json:"value"`                 // Stored value.
+	Raw        []byte     `json:"raw"`                   // Exact stored bytes of the value, base64-encoded in JSON.
+	Expiry     int64      `json:"expiry"`                // Unix expiration time, 0 if the key does not expire.
+	LastAccess *time.Time `json:"last_access,omitempty"` // Time of the last read or write, omitted unless the access is tracked.
+	Partition  int        `json:"partition"`             // Engine partition holding the key.
+}
+
+// HealthyStatus - status of the health command response, it's followed by the readiness in JSON.
+const HealthyStatus = "healthy"
+
+// Health - readiness of the database reported by the health command.
+type Health struct {
+	Ready         bool `json:"ready"`          // The database is ready to serve queries.
+	WALRecovered  bool `json:"wal_recovered"`  // The state is restored from the snapshot and the WAL.
+	ReplicaSynced bool `json:"replica_synced"` // The slave has synced with the master at least once.
+}
+
+// ProtocolVersion - version of the client-server protocol, clients check the major version for the compatibility.
+const ProtocolVersion = "2.1"
+
+// defaultServerVersion - version reported by the server built without the version.
+const defaultServerVersion = "dev"
+
+// VersionInfo - versions reported by the version command.
+type VersionInfo struct {
+	Version  string `json:"version"`  // Semantic version of the server.
+	Protocol string `json:"protocol"` // Version of the client-server protocol.
+}
+
+// ExplainResult - parsed command returned by the explain command.
+type ExplainResult struct {
+	Type compute.CommandType `json:"type"` // Type of the parsed command.
+	Args map[string]string   `json:"args"` // Arguments of the parsed command.
+}
+
+// NamespaceInfo - metadata of a namespace returned by the get ns command.
+type NamespaceInfo struct {
+	Name       string `json:"name"`                  // Name of the namespace.
+	Keys       int    `json:"keys"`                  // Number of keys stored in the namespace.
+	DefaultTTL string `json:"default_ttl,omitempty"` // TTL applied to keys written without a TTL, omitted if not set.
+}
+
+// PrevValue - previous value of the key returned by the set command with the get flag.
+type PrevValue struct {
+	Value  string `json:"value"`  // Previous value, empty if the key did not exist.
+	Exists bool   `json:"exists"` // Whether the key existed before the set.
+}
+
+// Kinds of the values reported by the type command.
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeNone   = "none"
+)
+
+// ScanResult - batch of keys returned by the scan command.
+type ScanResult struct {
+	Keys   []string `json:"keys"`   // Keys of the batch without the namespace.
+	Cursor uint64   `json:"cursor"` // Cursor of the next batch, 0 when the iteration is done.
+}
+
+// storageStatsFields - names of the fields derived from the storage statistics.
+var storageStatsFields = []string{
+	"uptime", "total_commands", "get_commands", "set_commands", "del_commands",
+	"total_keys", "expired_keys", "evicted_keys", "hits", "misses",
 }
 
 // Parser - parses user queries into executable commands.
@@ -37,18 +184,60 @@ type Storage interface {
 	Set(ctx context.Context, key, value string) error
 	// Get - retrieves the value associated with a given key.
 	Get(ctx context.Context, key string) (string, error)
-	// Del - removes a key and its value from the storage.
-	Del(ctx context.Context, key string) error
+	// LastAccess - returns the time the key was last read or written.
+	LastAccess(ctx context.Context, key string) (time.Time, error)
+	// Entry - returns the stored value of the key with its metadata.
+	Entry(ctx context.Context, key string) (engine.Entry, error)
+	// MSetNX - stores all the key-value pairs only if none of the keys exist.
+	MSetNX(ctx context.Context, keys, values []string) (bool, error)
+	// Del - removes a key and its value from the storage, returns whether the key existed.
+	Del(ctx context.Context, key string) (bool, error)
+	// DelMany - removes the keys and returns the number of removed existing keys.
+	DelMany(ctx context.Context, keys []string) (int, error)
+	// GetDel - retrieves the value associated with a given key and removes the key.
+	GetDel(ctx context.Context, key string) (string, error)
+	// GetSet - stores a key-value pair and returns the previous value and whether the key existed.
+	GetSet(ctx context.Context, key, value string) (string, bool, error)
+	// RenameNX - renames a key only if the new key does not exist.
+	RenameNX(ctx context.Context, oldKey, newKey string) (bool, error)
 	// Watch - watches the key and returns the value if it has changed.
 	Watch(ctx context.Context, key string) pkgsync.FutureString
 	// Stats - returns the collected database statistics.
 	Stats() (*storage.Stats, error)
+	// CountByPrefix - returns the number of keys starting with the prefix.
+	CountByPrefix(prefix string) int
+	// WALLatency - returns the WAL write latency percentiles.
+	WALLatency() wal.LatencyStats
+	// WALWriteErrors - returns the number of WAL batches that failed to be written.
+	WALWriteErrors() int64
+	// CompactWAL - synchronously runs a WAL compaction pass.
+	CompactWAL() (wal.CompactionResult, error)
+	// SyncWAL - writes the pending WAL batch and commits the log to the disk.
+	SyncWAL() error
+	// WALCompactions - returns the summary of the completed WAL compaction passes.
+	WALCompactions() wal.CompactionStats
+	// DelByPrefix - removes all keys starting with the prefix.
+	DelByPrefix(ctx context.Context, prefix string) (int, error)
+	// SetMaxSize - stores the maximum value size override for keys matching the pattern.
+	SetMaxSize(ctx context.Context, pattern string, size int) error
+	// MaxSize - returns the maximum value size for the key.
+	MaxSize(key string) int
+	// Readiness - returns whether the state is recovered and the replica is synced.
+	Readiness() storage.Readiness
+	// Scan - returns a batch of keys starting with the prefix and the next cursor.
+	Scan(prefix string, cursor uint64, pattern string, count int) ([]string, uint64, error)
+	// Export - returns the entries of the keys starting with the prefix.
+	Export(prefix string) []snapshot.Entry
+	// Expiring - returns the keys starting with the prefix and expiring within the duration.
+	Expiring(prefix string, within time.Duration) []string
 }
 
 // NamespacesStorage - interface for managing namespaces.
 type NamespacesStorage interface {
 	// Save - Saves a namespace
-	Save(ctx context.Context, namespace string) error
+	Save(ctx context.Context, namespace *models.Namespace) error
+	// Get - Retrieves a namespace metadata.
+	Get(ctx context.Context, namespace string) (*models.Namespace, error)
 	// Exists - Checks if a namespace exists.
 	Exists(ctx context.Context, namespace string) bool
 	// Delete - Deletes a namespace.
@@ -64,7 +253,7 @@ type UsersStorage interface {
 	// Authenticate - authenticates a user by username and password.
 	Authenticate(ctx context.Context, username, password string) (*models.User, error)
 	// Create - creates a new user.
-	Create(ctx context.Context, username, password string) (*models.User, error)
+	Create(ctx context.Context, username, password, namespace string) (*models.User, error)
 	// Get - retrieves a user by username.
 	Get(ctx context.Context, username string) (*models.User, error)
 	// SaveRaw - saves a user object directly to storage.
@@ -73,8 +262,14 @@ type UsersStorage interface {
 	Delete(ctx context.Context, username string) error
 	// AssignRole - assigns a role to a user.
 	AssignRole(ctx context.Context, username string, role string) error
+	// DivestRole - divests a role from a user.
+	DivestRole(ctx context.Context, username string, role string) error
+	// DedupRoles - removes duplicate roles assigned to a user.
+	DedupRoles(ctx context.Context, username string) (bool, error)
 	// ListUsernames - retrieves a list of all usernames.
 	ListUsernames(ctx context.Context) ([]string, error)
+	// RoleUsers - retrieves the usernames assigned the role.
+	RoleUsers(ctx context.Context, role string) ([]string, error)
 	// Append - adds a username to the list of users.
 	Append(ctx context.Context, username string) ([]string, error)
 	// Remove - remove username from the list of all users in the system.
@@ -87,6 +282,8 @@ type RolesStorage interface {
 	Save(ctx context.Context, role *models.Role) error
 	// Get - retrieves a role by name.
 	Get(ctx context.Context, name string) (*models.Role, error)
+	// Update - overwrites an existing role.
+	Update(ctx context.Context, role *models.Role) error
 	// Delete - deletes a role by name.
 	Delete(ctx context.Context, name string) error
 	// List - retrieves a list of all roles.
@@ -105,6 +302,12 @@ type CommandHandler struct {
 	AdminOnly bool
 }
 
+// MissHandler - loads the values of the keys missing in the storage for the read-through get.
+type MissHandler interface {
+	// Load - returns the value of the key in the namespace and whether the key was found.
+	Load(ctx context.Context, namespace, key string) (string, bool, error)
+}
+
 // SessionStorage - interface for managing user sessions.
 type SessionStorage interface {
 	// Create - creates a new session for a user.
@@ -126,7 +329,33 @@ type Database struct {
 	rolesStorage     RolesStorage
 	sessions         SessionStorage
 	cfg              *config.RootConfig
+	sessionCloser    SessionCloser
+	connections      ConnectionsLister
+	missHandler      MissHandler // nil when the read-through get is disabled.
 	registry         map[compute.CommandType]CommandHandler
+	tokens           *tokenSigner
+	broker           *pubsub.Broker
+	serverVersion    string
+	keySeparator     string // Separator of the namespace and the key name in the storage keys.
+
+	namespaceTTLs sync.Map // namespace -> default TTL, resolved on the first SET.
+
+	slowQueryThreshold time.Duration
+	slowCommands       slowCommandsCounter
+
+	limiter *rateLimiter // nil when the rate limit is disabled.
+
+	validators map[string]ValueValidator // namespace -> validator of the stored values.
+}
+
+// keySeparator - returns the key separator of the storage.
+func keySeparator(s Storage) string {
+	return storage.KeySeparatorOf(s)
+}
+
+// makeKey - constructs the storage key of the key name in the namespace.
+func (db *Database) makeKey(namespace, key string) string {
+	return storage.JoinKey(db.keySeparator, namespace, key)
 }
 
 // New - creates and initializes a new instance of Database.
@@ -137,6 +366,7 @@ func New(
 	rolesStorage RolesStorage,
 	sessions SessionStorage,
 	cfg *config.RootConfig,
+	opts ...Option,
 ) *Database {
 	db := Database{
 		parser:           parser,
@@ -146,6 +376,14 @@ func New(
 		rolesStorage:     rolesStorage,
 		sessions:         sessions,
 		cfg:              cfg,
+		tokens:           newTokenSigner(),
+		broker:           pubsub.NewBroker(pubsub.DefaultBufferSize),
+		serverVersion:    defaultServerVersion,
+		keySeparator:     keySeparator(storage),
+	}
+
+	for _, opt := range opts {
+		opt(&db)
 	}
 
 	db.registry = map[compute.CommandType]CommandHandler{
@@ -153,24 +391,59 @@ func New(
 		compute.CommandASSIGNROLE:      {Func: db.assignRole, AdminOnly: true},
 		compute.CommandCREATEROLE:      {Func: db.createRole, AdminOnly: true},
 		compute.CommandDELETEROLE:      {Func: db.delRole, AdminOnly: true},
+		compute.CommandUPDATEROLE:      {Func: db.updateRole, AdminOnly: true},
 		compute.CommandROLES:           {Func: db.listRoles, AdminOnly: true},
 		compute.CommandGETROLE:         {Func: db.getRole, AdminOnly: true},
+		compute.CommandROLEUSERS:       {Func: db.roleUsers, AdminOnly: true},
 		compute.CommandUSERS:           {Func: db.users, AdminOnly: true},
 		compute.CommandGETUSER:         {Func: db.getUser, AdminOnly: true},
 		compute.CommandCREATENAMESPACE: {Func: db.createNS, AdminOnly: true},
 		compute.CommandDELETENAMESPACE: {Func: db.deleteNS, AdminOnly: true},
+		compute.CommandFLUSHNS:         {Func: db.flushNS, AdminOnly: true},
+		compute.CommandEXPORT:          {Func: db.export, AdminOnly: true},
+		compute.CommandIMPORT:          {Func: db.importNS, AdminOnly: true},
 		compute.CommandSESSIONS:        {Func: db.listSessions, AdminOnly: true},
+		compute.CommandKILLSESSION:     {Func: db.killSession, AdminOnly: true},
+		compute.CommandCONNECTIONS:     {Func: db.listConnections, AdminOnly: true},
 		compute.CommandDELETEUSER:      {Func: db.deleteUser, AdminOnly: true},
 		compute.CommandDIVESTROLE:      {Func: db.divestRole, AdminOnly: true},
+		compute.CommandDEDUPROLES:      {Func: db.dedupRoles, AdminOnly: true},
 		compute.CommandSTAT:            {Func: db.stat, AdminOnly: true},
+		compute.CommandDBSIZE:          {Func: db.dbSize, AdminOnly: true},
+		compute.CommandWALLATENCY:      {Func: db.walLatency, AdminOnly: true},
+		compute.CommandCOMPACT:         {Func: db.compact, AdminOnly: true},
+		compute.CommandFSYNC:           {Func: db.fsync, AdminOnly: true},
+		compute.CommandSETMAXSIZE:      {Func: db.setMaxSize, AdminOnly: true},
 		compute.CommandNAMESPACES:      {Func: db.ns},
+		compute.CommandGETNAMESPACE:    {Func: db.getNS},
 		compute.CommandHELP:            {Func: db.help},
 		compute.CommandSETNS:           {Func: db.setNamespace},
+		compute.CommandSETROLE:         {Func: db.setRole},
 		compute.CommandME:              {Func: db.me},
+		compute.CommandSESSIONINFO:     {Func: db.sessionInfo},
+		compute.CommandCAPS:            {Func: db.caps},
+		compute.CommandTOKEN:           {Func: db.token},
+		compute.CommandLOGOUT:          {Func: db.logout},
+		compute.CommandHEALTH:          {Func: db.health},
+		compute.CommandVERSION:         {Func: db.version},
 		compute.CommandGET:             {Func: db.get},
 		compute.CommandSET:             {Func: db.set},
+		compute.CommandSETEX:           {Func: db.setEX},
 		compute.CommandDEL:             {Func: db.del},
+		compute.CommandDELPREFIX:       {Func: db.delPrefix},
+		compute.CommandMSETNX:          {Func: db.msetNX},
+		compute.CommandGETDEL:          {Func: db.getDel},
+		compute.CommandRENAMENX:        {Func: db.renameNX},
+		compute.CommandTYPE:            {Func: db.valueType},
+		compute.CommandSCAN:            {Func: db.scan},
+		compute.CommandEXPIRING:        {Func: db.expiring},
+		compute.CommandLASTACCESS:      {Func: db.lastAccess},
 		compute.CommandWATCH:           {Func: db.watch},
+		compute.CommandPUBLISH:         {Func: db.publish},
+		compute.CommandSUBSCRIBE:       {Func: db.subscribe},
+		compute.CommandLISTEN:          {Func: db.listen},
+		compute.CommandGETMAXSIZE:      {Func: db.getMaxSize},
+		compute.CommandDUMP:            {Func: db.dump, AdminOnly: true},
 	}
 
 	return &db
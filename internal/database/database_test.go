@@ -1,9 +1,16 @@
 package database
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,13 +18,21 @@ import (
 	"github.com/neekrasov/kvdb/internal/database/compute"
 	"github.com/neekrasov/kvdb/internal/database/identity"
 	"github.com/neekrasov/kvdb/internal/database/identity/models"
+	"github.com/neekrasov/kvdb/internal/database/pubsub"
 	"github.com/neekrasov/kvdb/internal/database/storage"
+	"github.com/neekrasov/kvdb/internal/database/storage/engine"
+	"github.com/neekrasov/kvdb/internal/database/storage/wal"
+	"github.com/neekrasov/kvdb/internal/database/storage/wal/filesystem"
+	"github.com/neekrasov/kvdb/internal/database/storage/wal/segment"
 	dbMock "github.com/neekrasov/kvdb/internal/mocks/database"
+	"github.com/neekrasov/kvdb/pkg/ctxutil"
 	"github.com/neekrasov/kvdb/pkg/logger"
 	pkgsync "github.com/neekrasov/kvdb/pkg/sync"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -134,9 +149,120 @@ func TestDatabase_HandleQuery(t *testing.T) {
 							compute.ValueArg: "value",
 						},
 					}, nil).Once()
+				ns.On("Get", mock.Anything, "default").Return(&models.Namespace{Name: "default"}, nil).Once()
 				s.On("Set", mock.Anything, "default:key", "value").Return(nil).Once()
 			},
 		},
+		{
+			name:     "set command applies namespace default TTL",
+			query:    compute.CommandSET.Make("key", "value"),
+			expected: okPrefix,
+			prepareMocks: func(p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(adminSession, nil).Once()
+				p.On("Parse", compute.CommandSET.Make("key", "value")).Return(
+					&compute.Command{
+						Type: compute.CommandSET,
+						Args: map[string]string{
+							compute.KeyArg:   "key",
+							compute.ValueArg: "value",
+						},
+					}, nil).Once()
+				ns.On("Get", mock.Anything, "default").Return(
+					&models.Namespace{Name: "default", DefaultTTL: time.Minute}, nil).Once()
+				s.On("Set", mock.MatchedBy(func(ctx context.Context) bool {
+					return ctxutil.ExtractTTL(ctx) == time.Minute.String()
+				}), "default:key", "value").Return(nil).Once()
+			},
+		},
+		{
+			name:     "set command explicit TTL overrides namespace default TTL",
+			query:    compute.CommandSET.Make("key", "value", "ttl", "10s"),
+			expected: okPrefix,
+			prepareMocks: func(p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(adminSession, nil).Once()
+				p.On("Parse", compute.CommandSET.Make("key", "value", "ttl", "10s")).Return(
+					&compute.Command{
+						Type: compute.CommandSET,
+						Args: map[string]string{
+							compute.KeyArg:   "key",
+							compute.ValueArg: "value",
+							compute.TTLArg:   "10s",
+						},
+					}, nil).Once()
+				s.On("Set", mock.MatchedBy(func(ctx context.Context) bool {
+					return ctxutil.ExtractTTL(ctx) == "10s"
+				}), "default:key", "value").Return(nil).Once()
+			},
+		},
+		{
+			name:     "create ns command with default TTL",
+			query:    compute.CommandCREATENAMESPACE.Make("tenant", "default_ttl", "1m"),
+			expected: okPrefix,
+			prepareMocks: func(
+				p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(adminSession, nil).Once()
+				p.On("Parse", compute.CommandCREATENAMESPACE.Make("tenant", "default_ttl", "1m")).Return(
+					&compute.Command{
+						Type: compute.CommandCREATENAMESPACE,
+						Args: map[string]string{
+							compute.NamespaceArg:  "tenant",
+							compute.DefaultTTLArg: "1m",
+						},
+					}, nil).Once()
+				ns.On("Save", mock.Anything, &models.Namespace{
+					Name: "tenant", DefaultTTL: time.Minute,
+				}).Return(nil).Once()
+				ns.On("Append", mock.Anything, "tenant").Return(nil, nil).Once()
+			},
+		},
+		{
+			name:     "create ns command with not positive default TTL",
+			query:    compute.CommandCREATENAMESPACE.Make("tenant", "default_ttl", "0s"),
+			expected: WrapError(ErrInvalidDefaultTTL),
+			prepareMocks: func(
+				p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(adminSession, nil).Once()
+				p.On("Parse", compute.CommandCREATENAMESPACE.Make("tenant", "default_ttl", "0s")).Return(
+					&compute.Command{
+						Type: compute.CommandCREATENAMESPACE,
+						Args: map[string]string{
+							compute.NamespaceArg:  "tenant",
+							compute.DefaultTTLArg: "0s",
+						},
+					}, nil).Once()
+			},
+		},
+		{
+			name:     "create ns command with system namespace",
+			query:    compute.CommandCREATENAMESPACE.Make(models.SystemMaxSizeNameSpace),
+			expected: fmt.Sprintf("%s system namespace cannot be modified", errPrefix),
+			prepareMocks: func(
+				p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(adminSession, nil).Once()
+				p.On("Parse", compute.CommandCREATENAMESPACE.Make(models.SystemMaxSizeNameSpace)).Return(
+					&compute.Command{
+						Type: compute.CommandCREATENAMESPACE,
+						Args: map[string]string{
+							compute.NamespaceArg: models.SystemMaxSizeNameSpace,
+						},
+					}, nil).Once()
+			},
+		},
 		{
 			name:     "successful del command",
 			query:    compute.CommandDEL.Make("key"),
@@ -154,7 +280,171 @@ func TestDatabase_HandleQuery(t *testing.T) {
 							compute.KeyArg: "key",
 						},
 					}, nil).Once()
-				s.On("Del", mock.Anything, "default:key").Return(nil).Once()
+				s.On("Del", mock.Anything, "default:key").Return(true, nil).Once()
+			},
+		},
+		{
+			name:     "successful renamenx command",
+			query:    compute.CommandRENAMENX.Make("old", "new"),
+			expected: okPrefix + " true",
+			prepareMocks: func(
+				p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(adminSession, nil).Once()
+				p.On("Parse", compute.CommandRENAMENX.Make("old", "new")).Return(
+					&compute.Command{
+						Type: compute.CommandRENAMENX,
+						Args: map[string]string{
+							compute.KeyArg:    "old",
+							compute.NewKeyArg: "new",
+						},
+					}, nil).Once()
+				s.On("RenameNX", mock.Anything, "default:old", "default:new").Return(true, nil).Once()
+			},
+		},
+		{
+			name:     "renamenx command with existing new key",
+			query:    compute.CommandRENAMENX.Make("old", "exists"),
+			expected: okPrefix + " false",
+			prepareMocks: func(
+				p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(adminSession, nil).Once()
+				p.On("Parse", compute.CommandRENAMENX.Make("old", "exists")).Return(
+					&compute.Command{
+						Type: compute.CommandRENAMENX,
+						Args: map[string]string{
+							compute.KeyArg:    "old",
+							compute.NewKeyArg: "exists",
+						},
+					}, nil).Once()
+				s.On("RenameNX", mock.Anything, "default:old", "default:exists").Return(false, nil).Once()
+			},
+		},
+		{
+			name:     "renamenx command without del permission",
+			query:    compute.CommandRENAMENX.Make("old", "new"),
+			expected: fmt.Sprintf("%s permission denied", errPrefix),
+			prepareMocks: func(
+				p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(userSession, nil).Once()
+				p.On("Parse", compute.CommandRENAMENX.Make("old", "new")).Return(
+					&compute.Command{
+						Type: compute.CommandRENAMENX,
+						Args: map[string]string{
+							compute.KeyArg:    "old",
+							compute.NewKeyArg: "new",
+						},
+					}, nil).Once()
+			},
+		},
+		{
+			name:     "successful setmaxsize command",
+			query:    compute.CommandSETMAXSIZE.Make("large:*", "1MB"),
+			expected: okPrefix,
+			prepareMocks: func(
+				p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(adminSession, nil).Once()
+				p.On("Parse", compute.CommandSETMAXSIZE.Make("large:*", "1MB")).Return(
+					&compute.Command{
+						Type: compute.CommandSETMAXSIZE,
+						Args: map[string]string{
+							compute.PatternArg: "large:*",
+							compute.SizeArg:    "1MB",
+						},
+					}, nil).Once()
+				s.On("SetMaxSize", mock.Anything, "default:large:*", 1<<20).Return(nil).Once()
+			},
+		},
+		{
+			name:     "setmaxsize command with invalid size",
+			query:    compute.CommandSETMAXSIZE.Make("large:*", "big"),
+			expected: fmt.Sprintf("%s incorrect size", errPrefix),
+			prepareMocks: func(
+				p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(adminSession, nil).Once()
+				p.On("Parse", compute.CommandSETMAXSIZE.Make("large:*", "big")).Return(
+					&compute.Command{
+						Type: compute.CommandSETMAXSIZE,
+						Args: map[string]string{
+							compute.PatternArg: "large:*",
+							compute.SizeArg:    "big",
+						},
+					}, nil).Once()
+			},
+		},
+		{
+			name:     "setmaxsize command by non-admin",
+			query:    compute.CommandSETMAXSIZE.Make("large:*", "1MB"),
+			expected: fmt.Sprintf("%s permission denied", errPrefix),
+			prepareMocks: func(
+				p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(userSession, nil).Once()
+				p.On("Parse", compute.CommandSETMAXSIZE.Make("large:*", "1MB")).Return(
+					&compute.Command{
+						Type: compute.CommandSETMAXSIZE,
+						Args: map[string]string{
+							compute.PatternArg: "large:*",
+							compute.SizeArg:    "1MB",
+						},
+					}, nil).Once()
+			},
+		},
+		{
+			name:     "successful getmaxsize command",
+			query:    compute.CommandGETMAXSIZE.Make("large:1"),
+			expected: WrapOKType(ContentInt, "1048576"),
+			prepareMocks: func(
+				p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(userSession, nil).Once()
+				p.On("Parse", compute.CommandGETMAXSIZE.Make("large:1")).Return(
+					&compute.Command{
+						Type: compute.CommandGETMAXSIZE,
+						Args: map[string]string{
+							compute.KeyArg: "large:1",
+						},
+					}, nil).Once()
+				s.On("MaxSize", "default:large:1").Return(1 << 20).Once()
+			},
+		},
+		{
+			name:     "successful wal latency command",
+			query:    compute.CommandWALLATENCY.String(),
+			expected: WrapOKType(ContentJSON, `{"count":10,"p50":1000,"p95":2000,"p99":3500,"max":3500}`),
+			prepareMocks: func(
+				p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(adminSession, nil).Once()
+				p.On("Parse", compute.CommandWALLATENCY.String()).Return(
+					&compute.Command{
+						Type: compute.CommandWALLATENCY,
+						Args: map[string]string{},
+					}, nil).Once()
+				s.On("WALLatency").Return(wal.LatencyStats{
+					Count: 10, P50: time.Microsecond, P95: 2 * time.Microsecond,
+					P99: 3500 * time.Nanosecond, Max: 3500 * time.Nanosecond,
+				}).Once()
 			},
 		},
 		{
@@ -228,7 +518,7 @@ func TestDatabase_HandleQuery(t *testing.T) {
 							compute.PasswordArg: "password",
 						},
 					}, nil).Once()
-				us.On("Create", mock.Anything, "username", "password").Return(&models.User{Username: "username"}, nil).Once()
+				us.On("Create", mock.Anything, "username", "password", "").Return(&models.User{Username: "username"}, nil).Once()
 				us.On("Append", mock.Anything, "username").Return(nil, nil).Once()
 			},
 		},
@@ -256,7 +546,7 @@ func TestDatabase_HandleQuery(t *testing.T) {
 		{
 			name:     "successful users command",
 			query:    compute.CommandUSERS.String(),
-			expected: fmt.Sprintf("%s [\"user1\",\"user2\"]", okPrefix),
+			expected: WrapOKType(ContentList, "[\"user1\",\"user2\"]"),
 			prepareMocks: func(
 				p *dbMock.Parser, s *dbMock.Storage,
 				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
@@ -316,15 +606,34 @@ func TestDatabase_HandleQuery(t *testing.T) {
 							compute.RoleNameArg: "role",
 						},
 					}, nil).Once()
-				us.On("ListUsernames", mock.Anything).Return([]string{"test"}, nil).Once()
-				us.On("Get", mock.Anything, "test").Return(&models.User{Username: "test", Roles: []string{}}, nil).Once()
+				us.On("RoleUsers", mock.Anything, "role").Return([]string{}, nil).Once()
 				rs.On("Delete", mock.Anything, "role").Return(nil).Once()
 			},
 		},
+		{
+			name:     "delete assigned role command",
+			query:    compute.CommandDELETEROLE.Make("role"),
+			expected: errPrefix + " cannot delete role 'role': still assigned to user 'first'",
+			prepareMocks: func(
+				p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(adminSession, nil).Once()
+				p.On("Parse", compute.CommandDELETEROLE.Make("role")).Return(
+					&compute.Command{
+						Type: compute.CommandDELETEROLE,
+						Args: map[string]string{
+							compute.RoleNameArg: "role",
+						},
+					}, nil).Once()
+				us.On("RoleUsers", mock.Anything, "role").Return([]string{"first", "second"}, nil).Once()
+			},
+		},
 		{
 			name:     "successful roles command",
 			query:    compute.CommandROLES.String(),
-			expected: fmt.Sprintf("%s [\"role1\",\"role2\"]", okPrefix),
+			expected: WrapOKType(ContentList, "[\"role1\",\"role2\"]"),
 			prepareMocks: func(
 				p *dbMock.Parser, s *dbMock.Storage,
 				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
@@ -367,9 +676,41 @@ func TestDatabase_HandleQuery(t *testing.T) {
 					}, nil).Once()
 			},
 		},
+		{
+			name:  "successful session info command",
+			query: compute.CommandSESSIONINFO.String(),
+			expected: WrapOKType(ContentJSON, `{"username":"user","roles":["role1"],"namespace":"default","get":true,"set":false,"del":true,`+
+				`"created_at":"2025-04-14T00:00:00Z","expires_at":"2025-04-14T00:30:00Z"}`),
+			prepareMocks: func(
+				p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				createdAt := time.Date(2025, 4, 14, 0, 0, 0, 0, time.UTC)
+				session := &models.Session{
+					ID: sessionID,
+					User: &models.User{
+						Username: "user",
+						Roles:    []string{"role1"},
+						ActiveRole: models.Role{
+							Namespace: "default",
+							Get:       true, Del: true,
+						},
+					},
+					CreatedAt: createdAt,
+					ExpiresAt: createdAt.Add(30 * time.Minute),
+				}
+				ss.On("Get", sessionID).Return(session, nil).Twice()
+				p.On("Parse", compute.CommandSESSIONINFO.String()).Return(
+					&compute.Command{
+						Type: compute.CommandSESSIONINFO,
+						Args: map[string]string{},
+					}, nil).Once()
+			},
+		},
 		{
 			name:     "successful create ns command",
-			query:    compute.CommandCREATENAMESPACE.Make("namespace"),
+			query:    compute.CommandCREATENAMESPACE.Make("tenant"),
 			expected: okPrefix,
 			prepareMocks: func(
 				p *dbMock.Parser, s *dbMock.Storage,
@@ -377,15 +718,15 @@ func TestDatabase_HandleQuery(t *testing.T) {
 				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
 			) {
 				ss.On("Get", sessionID).Return(adminSession, nil).Once()
-				p.On("Parse", compute.CommandCREATENAMESPACE.Make("namespace")).Return(
+				p.On("Parse", compute.CommandCREATENAMESPACE.Make("tenant")).Return(
 					&compute.Command{
 						Type: compute.CommandCREATENAMESPACE,
 						Args: map[string]string{
-							compute.NamespaceArg: "namespace",
+							compute.NamespaceArg: "tenant",
 						},
 					}, nil).Once()
-				ns.On("Save", mock.Anything, "namespace").Return(nil).Once()
-				ns.On("Append", mock.Anything, "namespace").Return(nil, nil).Once()
+				ns.On("Save", mock.Anything, &models.Namespace{Name: "tenant"}).Return(nil).Once()
+				ns.On("Append", mock.Anything, "tenant").Return(nil, nil).Once()
 			},
 		},
 		{
@@ -450,7 +791,7 @@ func TestDatabase_HandleQuery(t *testing.T) {
 						},
 					}, nil).Once()
 				us.On("Delete", mock.Anything, "username").Return(nil).Once()
-				us.On("Remove", mock.Anything, "admin").Return(nil, nil).Once()
+				us.On("Remove", mock.Anything, "username").Return(nil, nil).Once()
 			},
 		},
 		{
@@ -471,13 +812,53 @@ func TestDatabase_HandleQuery(t *testing.T) {
 							compute.RoleArg:     "role",
 						},
 					}, nil).Once()
-				us.On("AssignRole", mock.Anything, "username", "role").Return(nil).Once()
+				us.On("DivestRole", mock.Anything, "username", "role").Return(nil).Once()
+			},
+		},
+		{
+			name:     "dedup roles command for one user",
+			query:    compute.CommandDEDUPROLES.Make("username"),
+			expected: WrapOKType(ContentInt, "1"),
+			prepareMocks: func(
+				p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(adminSession, nil).Once()
+				p.On("Parse", compute.CommandDEDUPROLES.Make("username")).Return(
+					&compute.Command{
+						Type: compute.CommandDEDUPROLES,
+						Args: map[string]string{
+							compute.UsernameArg: "username",
+						},
+					}, nil).Once()
+				us.On("DedupRoles", mock.Anything, "username").Return(true, nil).Once()
+			},
+		},
+		{
+			name:     "dedup roles command for all users",
+			query:    compute.CommandDEDUPROLES.String(),
+			expected: WrapOKType(ContentInt, "1"),
+			prepareMocks: func(
+				p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(adminSession, nil).Once()
+				p.On("Parse", compute.CommandDEDUPROLES.String()).Return(
+					&compute.Command{
+						Type: compute.CommandDEDUPROLES,
+						Args: map[string]string{},
+					}, nil).Once()
+				us.On("ListUsernames", mock.Anything).Return([]string{"user1", "user2"}, nil).Once()
+				us.On("DedupRoles", mock.Anything, "user1").Return(false, nil).Once()
+				us.On("DedupRoles", mock.Anything, "user2").Return(true, nil).Once()
 			},
 		},
 		{
 			name:     "get user command",
 			query:    compute.CommandGETUSER.Make("username"),
-			expected: okPrefix + ` {"username":"username","roles":["role1"],"role":{"name":"","get":false,"set":false,"del":false,"namespace":""}}`,
+			expected: WrapOKType(ContentJSON, `{"username":"username","roles":["role1"],"role":{"name":"","get":false,"set":false,"del":false,"namespace":""}}`),
 			prepareMocks: func(
 				p *dbMock.Parser, s *dbMock.Storage,
 				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
@@ -500,7 +881,7 @@ func TestDatabase_HandleQuery(t *testing.T) {
 		{
 			name:     "get role command",
 			query:    compute.CommandGETROLE.Make("role"),
-			expected: okPrefix + ` {"name":"role","get":true,"set":true,"del":true,"namespace":"default"}`,
+			expected: WrapOKType(ContentJSON, `{"name":"role","get":true,"set":true,"del":true,"namespace":"default"}`),
 			prepareMocks: func(
 				p *dbMock.Parser, s *dbMock.Storage,
 				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
@@ -549,7 +930,7 @@ func TestDatabase_HandleQuery(t *testing.T) {
 		{
 			name:     "stat command success",
 			query:    compute.CommandSTAT.String(),
-			contains: `total_commands":100,"get_commands":50,"set_commands":30,"del_commands":20,"total_keys":1000,"expired_keys":50,"active_sessions":1,"total_namespaces":2,"total_roles":3,"total_users":4}`,
+			contains: `total_commands":100,"get_commands":50,"set_commands":30,"del_commands":20,"total_keys":1000,"expired_keys":50,"evicted_keys":0,"hits":40,"misses":10,"active_sessions":1,"total_namespaces":2,"total_roles":3,"total_users":4,"wal_write_errors":2,"compactions_total":3,"last_compaction":"2025-04-14T00:23:29.042785+03:00","unavailable":["slow_commands"]}`,
 			prepareMocks: func(
 				p *dbMock.Parser, s *dbMock.Storage,
 				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
@@ -568,29 +949,59 @@ func TestDatabase_HandleQuery(t *testing.T) {
 				stats.DelCommands.Store(20)
 				stats.TotalKeys.Store(1000)
 				stats.ExpiredKeys.Store(50)
+				stats.Hits.Store(40)
+				stats.Misses.Store(10)
 				stats.StartTime, _ = time.Parse(time.RFC3339Nano, "2025-04-14T00:23:29.042785+03:00")
 				s.On("Stats").Return(stats, nil).Once()
 				ns.On("List", mock.Anything).Return([]string{"ns1", "ns2"}, nil).Once()
 				rs.On("List", mock.Anything).Return([]string{"r1", "r2", "r3"}, nil).Once()
 				us.On("ListUsernames", mock.Anything).Return([]string{"u1", "u2", "u3", "u4"}, nil).Once()
+				s.On("WALWriteErrors").Return(int64(2)).Once()
+				s.On("WALCompactions").Return(wal.CompactionStats{Total: 3, Last: stats.StartTime}).Once()
 				ss.On("List").Return([]models.Session{
 					{User: nil, ExpiresAt: time.Now(), CreatedAt: time.Now()},
 				}).Once()
 			},
 		},
 		{
-			name:     "list sessions command",
-			query:    compute.CommandSESSIONS.String(),
-			expected: okPrefix + ` [{"user":null,"expires_at":"2025-04-14T00:23:29.042785+03:00","created_at":"2025-04-14T00:23:29.042785+03:00"}]`,
+			name:     "stat command with storage statistics disabled",
+			query:    compute.CommandSTAT.String(),
+			expected: WrapOKType(ContentJSON, `{"uptime":0,"total_commands":0,"get_commands":0,"set_commands":0,"del_commands":0,"total_keys":0,"expired_keys":0,"evicted_keys":0,"hits":0,"misses":0,"active_sessions":1,"total_namespaces":2,"total_roles":3,"total_users":4,"wal_write_errors":0,"compactions_total":0,"unavailable":["uptime","total_commands","get_commands","set_commands","del_commands","total_keys","expired_keys","evicted_keys","hits","misses","slow_commands"]}`),
 			prepareMocks: func(
 				p *dbMock.Parser, s *dbMock.Storage,
 				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
 				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
 			) {
 				ss.On("Get", sessionID).Return(adminSession, nil).Once()
-				p.On("Parse", compute.CommandSESSIONS.String()).Return(
+				p.On("Parse", compute.CommandSTAT.String()).Return(
 					&compute.Command{
-						Type: compute.CommandSESSIONS,
+						Type: compute.CommandSTAT,
+						Args: map[string]string{},
+					}, nil).Once()
+				s.On("Stats").Return(nil, storage.ErrStatsDisabled).Once()
+				ns.On("List", mock.Anything).Return([]string{"ns1", "ns2"}, nil).Once()
+				rs.On("List", mock.Anything).Return([]string{"r1", "r2", "r3"}, nil).Once()
+				us.On("ListUsernames", mock.Anything).Return([]string{"u1", "u2", "u3", "u4"}, nil).Once()
+				s.On("WALWriteErrors").Return(int64(0)).Once()
+				s.On("WALCompactions").Return(wal.CompactionStats{}).Once()
+				ss.On("List").Return([]models.Session{
+					{User: nil, ExpiresAt: time.Now(), CreatedAt: time.Now()},
+				}).Once()
+			},
+		},
+		{
+			name:     "list sessions command",
+			query:    compute.CommandSESSIONS.String(),
+			expected: WrapOKType(ContentList, `[{"id":"","user":null,"expires_at":"2025-04-14T00:23:29.042785+03:00","created_at":"2025-04-14T00:23:29.042785+03:00"}]`),
+			prepareMocks: func(
+				p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(adminSession, nil).Once()
+				p.On("Parse", compute.CommandSESSIONS.String()).Return(
+					&compute.Command{
+						Type: compute.CommandSESSIONS,
 						Args: map[string]string{},
 					}, nil).Once()
 
@@ -602,6 +1013,41 @@ func TestDatabase_HandleQuery(t *testing.T) {
 				}).Once()
 			},
 		},
+		{
+			name:     "kill unknown session",
+			query:    compute.CommandKILLSESSION.Make("unknown"),
+			expected: fmt.Sprintf("%s session not found", errPrefix),
+			prepareMocks: func(
+				p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(adminSession, nil).Once()
+				p.On("Parse", compute.CommandKILLSESSION.Make("unknown")).Return(
+					&compute.Command{
+						Type: compute.CommandKILLSESSION,
+						Args: map[string]string{compute.SessionIDArg: "unknown"},
+					}, nil).Once()
+				ss.On("Get", "unknown").Return(nil, identity.ErrExpiresSession).Once()
+			},
+		},
+		{
+			name:     "kill own session",
+			query:    compute.CommandKILLSESSION.Make(sessionID),
+			expected: WrapError(ErrKillOwnSession),
+			prepareMocks: func(
+				p *dbMock.Parser, s *dbMock.Storage,
+				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
+				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
+			) {
+				ss.On("Get", sessionID).Return(adminSession, nil).Once()
+				p.On("Parse", compute.CommandKILLSESSION.Make(sessionID)).Return(
+					&compute.Command{
+						Type: compute.CommandKILLSESSION,
+						Args: map[string]string{compute.SessionIDArg: sessionID},
+					}, nil).Once()
+			},
+		},
 		{
 			name:     "namespace not found error",
 			query:    compute.CommandSET.Make("key", "value") + " NS notfound",
@@ -627,7 +1073,7 @@ func TestDatabase_HandleQuery(t *testing.T) {
 		{
 			name:     "role not found error when setting namespace",
 			query:    compute.CommandSETNS.Make("namespace"),
-			expected: fmt.Sprintf("%s permission denied", errPrefix),
+			expected: fmt.Sprintf("%s namespace not accessible", errPrefix),
 			prepareMocks: func(
 				p *dbMock.Parser, s *dbMock.Storage,
 				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
@@ -692,7 +1138,7 @@ func TestDatabase_HandleQuery(t *testing.T) {
 		},
 		{
 			name:     "error creating namespace",
-			query:    compute.CommandCREATENAMESPACE.Make("namespace"),
+			query:    compute.CommandCREATENAMESPACE.Make("tenant"),
 			expected: fmt.Sprintf("%s internal error", errPrefix),
 			prepareMocks: func(
 				p *dbMock.Parser, s *dbMock.Storage,
@@ -700,14 +1146,14 @@ func TestDatabase_HandleQuery(t *testing.T) {
 				rs *dbMock.RolesStorage, ss *dbMock.SessionStorage,
 			) {
 				ss.On("Get", sessionID).Return(adminSession, nil).Once()
-				p.On("Parse", compute.CommandCREATENAMESPACE.Make("namespace")).Return(
+				p.On("Parse", compute.CommandCREATENAMESPACE.Make("tenant")).Return(
 					&compute.Command{
 						Type: compute.CommandCREATENAMESPACE,
 						Args: map[string]string{
-							compute.NamespaceArg: "namespace",
+							compute.NamespaceArg: "tenant",
 						},
 					}, nil).Once()
-				ns.On("Save", mock.Anything, "namespace").Return(errors.New("internal error")).Once()
+				ns.On("Save", mock.Anything, &models.Namespace{Name: "tenant"}).Return(errors.New("internal error")).Once()
 			},
 		},
 		{
@@ -757,7 +1203,7 @@ func TestDatabase_HandleQuery(t *testing.T) {
 		{
 			name:     "successful listing namespaces",
 			query:    compute.CommandNAMESPACES.String(),
-			expected: okPrefix + ` ["ns1","ns2","ns3"]`,
+			expected: WrapOKType(ContentList, `["ns1","ns2","ns3"]`),
 			prepareMocks: func(
 				p *dbMock.Parser, s *dbMock.Storage,
 				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
@@ -775,7 +1221,7 @@ func TestDatabase_HandleQuery(t *testing.T) {
 		{
 			name:     "successful listing namespaces",
 			query:    compute.CommandNAMESPACES.String(),
-			expected: okPrefix + ` ["default"]`,
+			expected: WrapOKType(ContentList, `["default"]`),
 			prepareMocks: func(
 				p *dbMock.Parser, s *dbMock.Storage,
 				us *dbMock.UsersStorage, ns *dbMock.NamespacesStorage,
@@ -826,7 +1272,7 @@ func TestDatabase_HandleQuery(t *testing.T) {
 							compute.PasswordArg: "password",
 						},
 					}, nil).Once()
-				us.On("Create", mock.Anything, "username", "password").Return(nil, errors.New("internal error")).Once()
+				us.On("Create", mock.Anything, "username", "password", "").Return(nil, errors.New("internal error")).Once()
 			},
 		},
 		{
@@ -961,152 +1407,2610 @@ func TestDatabase_HandleQuery(t *testing.T) {
 	}
 }
 
-func TestDatabase_Login(t *testing.T) {
+func TestDatabase_KeySeparator(t *testing.T) {
 	t.Parallel()
 	logger.MockLogger()
 
-	tests := []struct {
-		name         string
-		query        string
-		user         *models.User
-		expectedUser *models.User
-		expectedErr  error
-		prepareMocks func(p *dbMock.Parser, us *dbMock.UsersStorage, ss *dbMock.SessionStorage)
-	}{
-		{
-			name:        "parse error",
-			query:       "invalid query",
-			expectedErr: fmt.Errorf("parse input failed: %w", errors.New("invalid command: unrecognized command")),
-			prepareMocks: func(
-				p *dbMock.Parser, us *dbMock.UsersStorage,
-				ss *dbMock.SessionStorage,
-			) {
-				p.On("Parse", "invalid query").Return(nil, errors.New("invalid command: unrecognized command")).Once()
-			},
-		},
-		{
-			name:        "authentication failed (wrong password)",
-			query:       compute.CommandAUTH.Make("username", "password"),
-			expectedErr: errors.New("authentication failed"),
-			prepareMocks: func(
-				p *dbMock.Parser, us *dbMock.UsersStorage,
-				ss *dbMock.SessionStorage,
-			) {
-				p.On("Parse", compute.CommandAUTH.Make("username", "password")).Return(
-					&compute.Command{
-						Type: compute.CommandAUTH,
-						Args: map[string]string{
-							compute.UsernameArg: "username",
-							compute.PasswordArg: "password",
-						},
-					}, nil).Once()
-				us.On("Authenticate", mock.Anything, "username", "password").Return(nil, identity.ErrAuthenticationFailed).Once()
-			},
-		},
-		{
-			name:        "authentication failed (user not found or internal error)",
-			query:       compute.CommandAUTH.Make("username", "password"),
-			expectedErr: ErrAuthenticationRequired,
-			prepareMocks: func(
-				p *dbMock.Parser, us *dbMock.UsersStorage,
-				ss *dbMock.SessionStorage,
-			) {
-				p.On("Parse", compute.CommandAUTH.Make("username", "password")).Return(
-					&compute.Command{
-						Type: compute.CommandAUTH,
-						Args: map[string]string{
-							compute.UsernameArg: "username",
-							compute.PasswordArg: "password",
-						},
-					}, nil).Once()
-				us.On("Authenticate", mock.Anything, "username", "password").Return(nil, nil).Once()
-			},
-		},
-		{
-			name:         "successful login",
-			query:        compute.CommandAUTH.Make("username", "password"),
-			user:         &models.User{Username: "username"},
-			expectedUser: &models.User{Username: "username"},
-			expectedErr:  nil,
-			prepareMocks: func(
-				p *dbMock.Parser, us *dbMock.UsersStorage,
-				ss *dbMock.SessionStorage,
-			) {
-				user := &models.User{Username: "username"}
-				p.On("Parse", compute.CommandAUTH.Make("username", "password")).Return(
-					&compute.Command{
-						Type: compute.CommandAUTH,
-						Args: map[string]string{
-							compute.UsernameArg: "username",
-							compute.PasswordArg: "password",
-						},
-					}, nil).Once()
-				us.On("Authenticate", mock.Anything, "username", "password").Return(user, nil).Once()
-				ss.On("Create", "1", user).Return(nil).Once()
-			},
-		},
-		{
-			name:        "login command missing password",
-			query:       compute.CommandAUTH.String() + " username",
-			expectedErr: fmt.Errorf("parse input failed: %w", fmt.Errorf("%w: missing required parameter '%s'", compute.ErrInvalidSyntax, compute.PasswordArg)),
-			prepareMocks: func(
-				p *dbMock.Parser, us *dbMock.UsersStorage,
-				ss *dbMock.SessionStorage,
-			) {
-				parseError := fmt.Errorf("%w: missing required parameter '%s'", compute.ErrInvalidSyntax, compute.PasswordArg)
-				p.On("Parse", compute.CommandAUTH.String()+" username").Return(nil, parseError).Once()
-			},
-		},
-	}
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(),
+		storage.WithWALOpt((*wal.WAL)(nil)), storage.WithKeySeparator("|"))
+	require.NoError(t, err)
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			t.Parallel()
-			ctx := context.Background()
+	nsStorage := identity.NewNamespaceStorage(dstorage)
+	require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: "ns1"}))
+	_, err = dstorage.Get(ctx, dstorage.MakeKey(models.SystemNamespaceNameSpace, "ns1"))
+	require.NoError(t, err)
 
-			mockParser := dbMock.NewParser(t)
-			mockUserStorage := dbMock.NewUsersStorage(t)
-			mockSessionStorage := dbMock.NewSessionStorage(t)
-			tt.prepareMocks(mockParser, mockUserStorage, mockSessionStorage)
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{
+		Username:   "user",
+		ActiveRole: models.Role{Get: true, Set: true, Del: true, Namespace: "ns1"},
+	}))
 
-			db := &Database{
-				parser:      mockParser,
-				userStorage: mockUserStorage,
-				sessions:    mockSessionStorage,
-			}
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandSET, map[string]compute.CommandParam{
+		compute.KeyArg:   {Required: true, Positional: true, Position: 0},
+		compute.ValueArg: {Required: true, Positional: true, Position: 1},
+	})
+	db := New(compute.NewParser(trie), dstorage, nil, nsStorage, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
 
-			user, err := db.Login(ctx, "1", tt.query)
+	assert.Equal(t, okPrefix, db.HandleQuery(ctx, "session", compute.CommandSET.Make("a:b", "value")))
+	val, err := dstorage.Get(ctx, "ns1|a:b")
+	require.NoError(t, err)
+	assert.Equal(t, "value", val)
+}
 
-			if tt.expectedErr != nil {
-				require.Error(t, err)
-				assert.EqualError(t, err, tt.expectedErr.Error())
-				assert.Nil(t, user)
-			} else {
-				require.NoError(t, err)
-				require.NotNil(t, user)
-				assert.Equal(t, tt.expectedUser.Username, user.Username)
-			}
+func TestDatabase_NamespaceDefaultTTL(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
 
-			mockParser.AssertExpectations(t)
-			mockUserStorage.AssertExpectations(t)
-			mockSessionStorage.AssertExpectations(t)
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	nsStorage := identity.NewNamespaceStorage(dstorage)
+	require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: "ttl", DefaultTTL: time.Second}))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{
+		Username:   "admin",
+		ActiveRole: models.Role{Get: true, Set: true, Del: true, Namespace: "ttl"},
+	}))
+
+	setQuery := compute.CommandSET.Make("key", "value")
+	getQuery := compute.CommandGET.Make("key")
+	mockParser := dbMock.NewParser(t)
+	mockParser.On("Parse", setQuery).Return(&compute.Command{
+		Type: compute.CommandSET,
+		Args: map[string]string{compute.KeyArg: "key", compute.ValueArg: "value"},
+	}, nil).Once()
+	mockParser.On("Parse", getQuery).Return(&compute.Command{
+		Type: compute.CommandGET,
+		Args: map[string]string{compute.KeyArg: "key"},
+	}, nil)
+
+	db := New(mockParser, dstorage, nil, nsStorage, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	assert.Equal(t, okPrefix, db.HandleQuery(ctx, "session", setQuery))
+	assert.Equal(t, WrapOK("value"), db.HandleQuery(ctx, "session", getQuery))
+
+	assert.Eventually(t, func() bool {
+		return db.HandleQuery(ctx, "session", getQuery) == WrapError(storage.ErrKeyNotFound)
+	}, 3*time.Second, 100*time.Millisecond)
+
+	mockParser.AssertExpectations(t)
+}
+
+func TestDatabase_NamespaceDefaultTTLCached(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{
+		Username:   "admin",
+		ActiveRole: models.Role{Get: true, Set: true, Del: true, Namespace: "ttl"},
+	}))
+
+	setQuery := compute.CommandSET.Make("key", "value")
+	createQuery := compute.CommandCREATENAMESPACE.Make("ttl", "default_ttl", "1m")
+	mockParser := dbMock.NewParser(t)
+	mockParser.On("Parse", setQuery).Return(&compute.Command{
+		Type: compute.CommandSET,
+		Args: map[string]string{compute.KeyArg: "key", compute.ValueArg: "value"},
+	}, nil)
+	mockParser.On("Parse", createQuery).Return(&compute.Command{
+		Type: compute.CommandCREATENAMESPACE,
+		Args: map[string]string{compute.NamespaceArg: "ttl", compute.DefaultTTLArg: "1m"},
+	}, nil).Once()
+
+	withTTL := func(ttl time.Duration) any {
+		return mock.MatchedBy(func(ctx context.Context) bool {
+			return ctxutil.ExtractTTL(ctx) == ttl.String()
 		})
 	}
+
+	mockStorage := dbMock.NewStorage(t)
+	mockNS := dbMock.NewNamespacesStorage(t)
+	mockNS.On("Get", mock.Anything, "ttl").Return(
+		&models.Namespace{Name: "ttl", DefaultTTL: time.Second}, nil).Once()
+	mockStorage.On("Set", withTTL(time.Second), "ttl:key", "value").Return(nil).Twice()
+
+	db := New(mockParser, mockStorage, nil, mockNS, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	assert.Equal(t, okPrefix, db.HandleQuery(ctx, "session", setQuery))
+	assert.Equal(t, okPrefix, db.HandleQuery(ctx, "session", setQuery))
+
+	mockNS.On("Save", mock.Anything, &models.Namespace{Name: "ttl", DefaultTTL: time.Minute}).Return(nil).Once()
+	mockNS.On("Append", mock.Anything, "ttl").Return(nil, nil).Once()
+	assert.Equal(t, okPrefix, db.HandleQuery(ctx, "session", createQuery))
+
+	mockNS.On("Get", mock.Anything, "ttl").Return(
+		&models.Namespace{Name: "ttl", DefaultTTL: time.Minute}, nil).Once()
+	mockStorage.On("Set", withTTL(time.Minute), "ttl:key", "value").Return(nil).Once()
+	assert.Equal(t, okPrefix, db.HandleQuery(ctx, "session", setQuery))
+
+	mockNS.AssertExpectations(t)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestDatabase_DBSize(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	nsStorage := identity.NewNamespaceStorage(dstorage)
+	for _, namespace := range []string{"ns1", "ns2"} {
+		require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: namespace}))
+		_, err := nsStorage.Append(ctx, namespace)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey("ns1", "a"), "1"))
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey("ns1", "b"), "2"))
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey("ns2", "a"), "1"))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "admin"}))
+
+	mockParser := dbMock.NewParser(t)
+	mockParser.On("Parse", compute.CommandDBSIZE.String()).Return(&compute.Command{
+		Type: compute.CommandDBSIZE,
+		Args: map[string]string{},
+	}, nil).Once()
+
+	db := New(mockParser, dstorage, nil, nsStorage, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	result := db.HandleQuery(ctx, "session", compute.CommandDBSIZE.String())
+	assert.Equal(t, WrapOKType(ContentJSON, `{"default":0,"ns1":2,"ns2":1}`), result)
+
+	mockParser.AssertExpectations(t)
 }
 
-func TestDatabase_Logout(t *testing.T) {
+func TestDatabase_Scan(t *testing.T) {
 	t.Parallel()
+	logger.MockLogger()
 
-	mockSessionStorage := dbMock.NewSessionStorage(t)
-	db := &Database{sessions: mockSessionStorage}
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(engine.WithPartitionNum(4)), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	nsStorage := identity.NewNamespaceStorage(dstorage)
+	require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: "ns1"}))
+	_, err = nsStorage.Append(ctx, "ns1")
+	require.NoError(t, err)
+
+	expected := make([]string, 0, 23)
+	for i := range 23 {
+		key := fmt.Sprintf("user:%d", i)
+		require.NoError(t, dstorage.Set(ctx, storage.MakeKey("ns1", key), "1"))
+		expected = append(expected, key)
+	}
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey("ns1", "order:1"), "1"))
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey(models.DefaultNameSpace, "user:100"), "1"))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{
+		Username:   "reader",
+		ActiveRole: models.Role{Name: "reader", Get: true, Namespace: "ns1"},
+	}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandSCAN, map[string]compute.CommandParam{
+		compute.CursorArg: {Required: true, Positional: true, Position: 0},
+		compute.MatchArg:  {Required: false, Positional: false},
+		compute.CountArg:  {Required: false, Positional: false},
+		compute.NSArg:     {Required: false, Positional: false},
+	})
+
+	db := New(compute.NewParser(trie), dstorage, nil, nsStorage, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	t.Run("iterates all keys across pages", func(t *testing.T) {
+		var (
+			keys   []string
+			cursor uint64
+			pages  int
+		)
+		for {
+			query := compute.CommandSCAN.Make(strconv.FormatUint(cursor, 10),
+				compute.MatchArg, "user:*", compute.CountArg, "5", compute.NSArg, "ns1")
+			result := db.HandleQuery(ctx, "session", query)
+			require.False(t, IsError(result), result)
+
+			payload, _ := CutOK(result)
+			var page ScanResult
+			require.NoError(t, json.Unmarshal([]byte(payload), &page))
+			assert.LessOrEqual(t, len(page.Keys), 5)
+			keys = append(keys, page.Keys...)
+			pages++
+
+			if page.Cursor == 0 {
+				break
+			}
+			cursor = page.Cursor
+		}
+
+		assert.Equal(t, 5, pages)
+		assert.ElementsMatch(t, expected, keys)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		result := db.HandleQuery(ctx, "session", compute.CommandSCAN.Make("abc", compute.NSArg, "ns1"))
+		assert.Equal(t, WrapError(fmt.Errorf("%w: invalid cursor", compute.ErrInvalidSyntax)), result)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		result := db.HandleQuery(ctx, "session", compute.CommandSCAN.Make("0", compute.MatchArg, "[", compute.NSArg, "ns1"))
+		assert.True(t, IsError(result))
+	})
+}
+
+func TestDatabase_FlushNS(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
 
 	ctx := context.Background()
-	sessionID := "1"
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
 
-	mockSessionStorage.On("Delete", sessionID).Return(nil).Once()
+	nsStorage := identity.NewNamespaceStorage(dstorage)
+	for _, namespace := range []string{"ns1", "ns2"} {
+		require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: namespace}))
+		_, err := nsStorage.Append(ctx, namespace)
+		require.NoError(t, err)
+	}
 
-	result := db.Logout(ctx, sessionID)
-	assert.Equal(t, okPrefix, result)
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey("ns1", "a"), "1"))
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey("ns1", "b"), "2"))
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey("ns2", "a"), "1"))
 
-	mockSessionStorage.AssertExpectations(t)
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "admin"}))
+
+	mockParser := dbMock.NewParser(t)
+	for _, namespace := range []string{"ns1", models.SystemUserNameSpace} {
+		mockParser.On("Parse", compute.CommandFLUSHNS.Make(namespace)).Return(&compute.Command{
+			Type: compute.CommandFLUSHNS,
+			Args: map[string]string{compute.NamespaceArg: namespace},
+		}, nil).Once()
+	}
+
+	db := New(mockParser, dstorage, nil, nsStorage, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	result := db.HandleQuery(ctx, "session", compute.CommandFLUSHNS.Make("ns1"))
+	assert.Equal(t, WrapOKType(ContentInt, "2"), result)
+	assert.Equal(t, 0, dstorage.CountByPrefix(storage.MakeKey("ns1", "")))
+	assert.Equal(t, 1, dstorage.CountByPrefix(storage.MakeKey("ns2", "")))
+	assert.True(t, nsStorage.Exists(ctx, "ns1"))
+
+	result = db.HandleQuery(ctx, "session", compute.CommandFLUSHNS.Make(models.SystemUserNameSpace))
+	assert.Equal(t, WrapError(ErrSystemNamespace), result)
+
+	mockParser.AssertExpectations(t)
+}
+
+func TestDatabase_GetNS(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	nsStorage := identity.NewNamespaceStorage(dstorage)
+	require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: "ns1", DefaultTTL: time.Minute}))
+	require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: "ns2"}))
+
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey("ns1", "a"), "1"))
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey("ns1", "b"), "2"))
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("admin", &models.User{Username: "admin", Password: string(hashedPassword)}))
+	require.NoError(t, sessions.Create("user", &models.User{
+		Username:   "user",
+		ActiveRole: models.Role{Get: true, Namespace: "ns1"},
+	}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandGETNAMESPACE, map[string]compute.CommandParam{
+		compute.NamespaceArg: {Required: true, Positional: true, Position: 0},
+	})
+
+	db := New(compute.NewParser(trie), dstorage, nil, nsStorage, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	result := db.HandleQuery(ctx, "admin", compute.CommandGETNAMESPACE.Make("ns1"))
+	assert.Equal(t, WrapOKType(ContentJSON, `{"name":"ns1","keys":2,"default_ttl":"1m0s"}`), result)
+
+	result = db.HandleQuery(ctx, "admin", compute.CommandGETNAMESPACE.Make("ns2"))
+	assert.Equal(t, WrapOKType(ContentJSON, `{"name":"ns2","keys":0}`), result)
+
+	result = db.HandleQuery(ctx, "admin", compute.CommandGETNAMESPACE.Make("missing"))
+	assert.Equal(t, WrapError(identity.ErrNamespaceNotFound), result)
+
+	result = db.HandleQuery(ctx, "admin", compute.CommandGETNAMESPACE.Make(models.SystemUserNameSpace))
+	assert.Equal(t, WrapError(ErrSystemNamespace), result)
+
+	result = db.HandleQuery(ctx, "user", compute.CommandGETNAMESPACE.Make("ns1"))
+	assert.Equal(t, WrapOKType(ContentJSON, `{"name":"ns1","keys":2,"default_ttl":"1m0s"}`), result)
+
+	result = db.HandleQuery(ctx, "user", compute.CommandGETNAMESPACE.Make("ns2"))
+	assert.Equal(t, WrapError(ErrNamespaceNotAccessible), result)
+}
+
+func TestDatabase_Pagination(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	usersStorage := identity.NewUsersStorage(dstorage)
+	rolesStorage := identity.NewRolesStorage(dstorage)
+	for _, name := range []string{"a", "b", "c"} {
+		_, err := usersStorage.Append(ctx, name)
+		require.NoError(t, err)
+		_, err = rolesStorage.Append(ctx, name)
+		require.NoError(t, err)
+	}
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "admin"}))
+
+	trie := compute.NewTrieNode()
+	for _, cmd := range []compute.CommandType{compute.CommandUSERS, compute.CommandROLES} {
+		trie.Insert(cmd, map[string]compute.CommandParam{
+			compute.OffsetArg: {Required: false, Positional: false},
+			compute.LimitArg:  {Required: false, Positional: false},
+		})
+	}
+
+	db := New(compute.NewParser(trie), dstorage, usersStorage, nil, rolesStorage, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{name: "no args", expected: WrapOKType(ContentList, `["a","b","c"]`)},
+		{name: "limit", args: []string{compute.LimitArg, "2"}, expected: WrapOKType(ContentList, `["a","b"]`)},
+		{name: "offset", args: []string{compute.OffsetArg, "1"}, expected: WrapOKType(ContentList, `["b","c"]`)},
+		{
+			name:     "offset and limit",
+			args:     []string{compute.OffsetArg, "1", compute.LimitArg, "1"},
+			expected: WrapOKType(ContentList, `["b"]`),
+		},
+		{
+			name:     "limit past the end",
+			args:     []string{compute.OffsetArg, "2", compute.LimitArg, "10"},
+			expected: WrapOKType(ContentList, `["c"]`),
+		},
+		{name: "offset at the end", args: []string{compute.OffsetArg, "3"}, expected: WrapOKType(ContentList, `[]`)},
+		{name: "offset past the end", args: []string{compute.OffsetArg, "100"}, expected: WrapOKType(ContentList, `[]`)},
+		{
+			name:     "negative offset",
+			args:     []string{compute.OffsetArg, "-1"},
+			expected: WrapError(fmt.Errorf("%w: invalid offset", compute.ErrInvalidSyntax)),
+		},
+		{
+			name:     "zero limit",
+			args:     []string{compute.LimitArg, "0"},
+			expected: WrapError(fmt.Errorf("%w: invalid limit", compute.ErrInvalidSyntax)),
+		},
+		{
+			name:     "invalid limit",
+			args:     []string{compute.LimitArg, "ten"},
+			expected: WrapError(fmt.Errorf("%w: invalid limit", compute.ErrInvalidSyntax)),
+		},
+	}
+
+	for _, tt := range tests {
+		for _, cmd := range []compute.CommandType{compute.CommandUSERS, compute.CommandROLES} {
+			t.Run(cmd.String()+" "+tt.name, func(t *testing.T) {
+				result := db.HandleQuery(ctx, "session", cmd.Make(tt.args...))
+				assert.Equal(t, tt.expected, result)
+			})
+		}
+	}
+}
+
+func TestDatabase_UpdateRole(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	nsStorage := identity.NewNamespaceStorage(dstorage)
+	for _, namespace := range []string{"ns1", "ns2"} {
+		require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: namespace}))
+	}
+
+	rolesStorage := identity.NewRolesStorage(dstorage)
+	require.NoError(t, rolesStorage.Save(ctx, &models.Role{Name: "writer", Get: true, Namespace: "ns1"}))
+
+	assigned := &models.User{Username: "assigned", Roles: []string{"writer"}, ActiveRole: models.DefaultRole}
+	active := &models.User{
+		Username:   "active",
+		Roles:      []string{"writer"},
+		ActiveRole: models.Role{Name: "writer", Get: true, Namespace: "ns1"},
+	}
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("admin", &models.User{Username: "admin"}))
+	require.NoError(t, sessions.Create("active", active))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandUPDATEROLE, map[string]compute.CommandParam{
+		compute.RoleNameArg:    {Required: true, Positional: true, Position: 0},
+		compute.PermissionsArg: {Required: true, Positional: true, Position: 1},
+		compute.NamespaceArg:   {Required: false, Positional: true, Position: 2},
+	})
+
+	db := New(compute.NewParser(trie), dstorage, nil, nsStorage, rolesStorage, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	role := db.checkPermissions(ctx, assigned, "ns1")
+	require.NotNil(t, role)
+	assert.False(t, role.Set)
+
+	result := db.HandleQuery(ctx, "admin", compute.CommandUPDATEROLE.Make("writer", "rw"))
+	assert.Equal(t, okPrefix, result)
+
+	role = db.checkPermissions(ctx, assigned, "ns1")
+	require.NotNil(t, role)
+	assert.True(t, role.Get)
+	assert.True(t, role.Set)
+	assert.False(t, role.Del)
+
+	role = db.checkPermissions(ctx, active, "ns1")
+	require.NotNil(t, role)
+	assert.True(t, role.Set)
+
+	result = db.HandleQuery(ctx, "admin", compute.CommandUPDATEROLE.Make("writer", "r", "ns2"))
+	assert.Equal(t, okPrefix, result)
+
+	assert.Nil(t, db.checkPermissions(ctx, assigned, "ns1"))
+	role = db.checkPermissions(ctx, assigned, "ns2")
+	require.NotNil(t, role)
+	assert.False(t, role.Set)
+	assert.Equal(t, "ns2", active.ActiveRole.Namespace)
+
+	result = db.HandleQuery(ctx, "admin", compute.CommandUPDATEROLE.Make("writer", "r", "missing"))
+	assert.Equal(t, WrapError(identity.ErrNamespaceNotFound), result)
+
+	result = db.HandleQuery(ctx, "admin", compute.CommandUPDATEROLE.Make("missing", "r"))
+	assert.Equal(t, WrapError(identity.ErrRoleNotFound), result)
+
+	result = db.HandleQuery(ctx, "admin", compute.CommandUPDATEROLE.Make("writer", "rx"))
+	assert.Equal(t, WrapError(models.ErrInvalidPerms), result)
+}
+
+func TestDatabase_AllNamespacesRole(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	nsStorage := identity.NewNamespaceStorage(dstorage)
+	for _, namespace := range []string{"ns1", "ns2"} {
+		require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: namespace}))
+		require.NoError(t, dstorage.Set(ctx, storage.MakeKey(namespace, "key"), namespace))
+	}
+
+	rolesStorage := identity.NewRolesStorage(dstorage)
+	require.NoError(t, rolesStorage.Save(ctx, &models.Role{Name: "reader", Get: true, Namespace: models.AllNamespaces}))
+	require.NoError(t, rolesStorage.Save(ctx, &models.Role{Name: "ns2_writer", Get: true, Set: true, Namespace: "ns2"}))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{
+		Username:   "user",
+		Roles:      []string{"reader", "ns2_writer"},
+		ActiveRole: models.DefaultRole,
+	}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandGET, map[string]compute.CommandParam{
+		compute.KeyArg: {Required: true, Positional: true, Position: 0},
+		compute.NSArg:  {Required: false, Positional: false},
+	})
+	trie.Insert(compute.CommandSET, map[string]compute.CommandParam{
+		compute.KeyArg:   {Required: true, Positional: true, Position: 0},
+		compute.ValueArg: {Required: true, Positional: true, Position: 1},
+		compute.NSArg:    {Required: false, Positional: false},
+	})
+	trie.Insert(compute.CommandSETNS, map[string]compute.CommandParam{
+		compute.NamespaceArg: {Required: true, Positional: true, Position: 0},
+	})
+
+	db := New(compute.NewParser(trie), dstorage, nil, nsStorage, rolesStorage, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	for _, namespace := range []string{"ns1", "ns2"} {
+		result := db.HandleQuery(ctx, "session", compute.CommandGET.Make("key", compute.NSArg, namespace))
+		assert.Equal(t, WrapOK(namespace), result)
+	}
+
+	// The role of all namespaces is read-only, the role of the namespace takes precedence in ns2.
+	result := db.HandleQuery(ctx, "session", compute.CommandSET.Make("key", "value", compute.NSArg, "ns1"))
+	assert.Equal(t, WrapError(ErrPermissionDenied), result)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandSET.Make("key", "value", compute.NSArg, "ns2"))
+	assert.Equal(t, okPrefix, result)
+
+	// The activated role of all namespaces is bound to the chosen namespace.
+	result = db.HandleQuery(ctx, "session", compute.CommandSETNS.Make("ns1"))
+	assert.Equal(t, okPrefix, result)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandGET.Make("key"))
+	assert.Equal(t, WrapOK("ns1"), result)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandSET.Make("key", "value"))
+	assert.Equal(t, WrapError(ErrPermissionDenied), result)
+
+	user := &models.User{Username: "user", Roles: []string{"reader"}}
+	assert.Nil(t, db.checkPermissions(ctx, user, models.SystemUserNameSpace))
+}
+
+func TestDatabase_SetRole(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	nsStorage := identity.NewNamespaceStorage(dstorage)
+	require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: "ns1"}))
+
+	rolesStorage := identity.NewRolesStorage(dstorage)
+	require.NoError(t, rolesStorage.Save(ctx, &models.Role{Name: "reader", Get: true, Namespace: "ns1"}))
+	require.NoError(t, rolesStorage.Save(ctx, &models.Role{Name: "writer", Get: true, Set: true, Namespace: "ns1"}))
+	require.NoError(t, rolesStorage.Save(ctx, &models.Role{Name: "other", Get: true, Set: true, Namespace: "ns1"}))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{
+		Username:   "user",
+		Roles:      []string{"reader", "writer"},
+		ActiveRole: models.DefaultRole,
+	}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandSET, map[string]compute.CommandParam{
+		compute.KeyArg:   {Required: true, Positional: true, Position: 0},
+		compute.ValueArg: {Required: true, Positional: true, Position: 1},
+		compute.NSArg:    {Required: false, Positional: false},
+	})
+	trie.Insert(compute.CommandSETNS, map[string]compute.CommandParam{
+		compute.NamespaceArg: {Required: true, Positional: true, Position: 0},
+	})
+	trie.Insert(compute.CommandSETROLE, map[string]compute.CommandParam{
+		compute.RoleNameArg: {Required: true, Positional: true, Position: 0},
+	})
+
+	db := New(compute.NewParser(trie), dstorage, nil, nsStorage, rolesStorage, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	// The first role of the namespace is activated by set ns.
+	assert.Equal(t, okPrefix, db.HandleQuery(ctx, "session", compute.CommandSETNS.Make("ns1")))
+	assert.Equal(t, WrapError(ErrPermissionDenied), db.HandleQuery(ctx, "session", compute.CommandSET.Make("key", "value")))
+
+	assert.Equal(t, okPrefix, db.HandleQuery(ctx, "session", compute.CommandSETROLE.Make("writer")))
+	assert.Equal(t, okPrefix, db.HandleQuery(ctx, "session", compute.CommandSET.Make("key", "value")))
+
+	assert.Equal(t, okPrefix, db.HandleQuery(ctx, "session", compute.CommandSETROLE.Make("reader")))
+	assert.Equal(t, WrapError(ErrPermissionDenied), db.HandleQuery(ctx, "session", compute.CommandSET.Make("key", "value")))
+
+	// Only the assigned roles are activated.
+	assert.Equal(t, WrapError(ErrRoleNotAssigned), db.HandleQuery(ctx, "session", compute.CommandSETROLE.Make("other")))
+	assert.Equal(t, WrapError(ErrPermissionDenied), db.HandleQuery(ctx, "session", compute.CommandSET.Make("key", "value")))
+}
+
+func TestDatabase_GetDel(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey(models.DefaultNameSpace, "job"), "payload"))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user", ActiveRole: models.DefaultRole}))
+	require.NoError(t, sessions.Create("reader", &models.User{
+		Username:   "reader",
+		ActiveRole: models.Role{Name: "reader", Get: true, Namespace: models.DefaultNameSpace},
+	}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandGETDEL, map[string]compute.CommandParam{
+		compute.KeyArg: {Required: true, Positional: true, Position: 0},
+		compute.NSArg:  {Required: false, Positional: false},
+	})
+
+	db := New(compute.NewParser(trie), dstorage, nil, nil, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	result := db.HandleQuery(ctx, "reader", compute.CommandGETDEL.Make("job"))
+	assert.Equal(t, WrapError(ErrPermissionDenied), result)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandGETDEL.Make("job"))
+	assert.Equal(t, WrapOK("payload"), result)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandGETDEL.Make("job"))
+	assert.Equal(t, WrapError(storage.ErrKeyNotFound), result)
+
+	_, err = dstorage.Get(ctx, storage.MakeKey(models.DefaultNameSpace, "job"))
+	assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+}
+
+// stubLoader - miss handler loading the keys from the map and counting the calls.
+type stubLoader struct {
+	values map[string]string
+	err    error
+	calls  int
+}
+
+func (l *stubLoader) Load(_ context.Context, namespace, key string) (string, bool, error) {
+	l.calls++
+	if l.err != nil {
+		return "", false, l.err
+	}
+
+	val, ok := l.values[namespace+"/"+key]
+	return val, ok, nil
+}
+
+func TestDatabase_GetMissHandler(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user", ActiveRole: models.DefaultRole}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandGET, map[string]compute.CommandParam{
+		compute.KeyArg: {Required: true, Positional: true, Position: 0},
+		compute.NSArg:  {Required: false, Positional: false},
+	})
+
+	newDB := func(t *testing.T, opts ...Option) (*Database, *storage.Storage) {
+		dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+		require.NoError(t, err)
+
+		return New(compute.NewParser(trie), dstorage, nil, identity.NewNamespaceStorage(dstorage), nil, sessions,
+			&config.RootConfig{Username: "admin", Password: "password"}, opts...), dstorage
+	}
+
+	t.Run("Populates the key on miss", func(t *testing.T) {
+		loader := &stubLoader{values: map[string]string{models.DefaultNameSpace + "/user:1": "alice"}}
+		db, dstorage := newDB(t, WithMissHandler(loader))
+
+		result := db.HandleQuery(ctx, "session", compute.CommandGET.Make("user:1"))
+		assert.Equal(t, WrapOK("alice"), result)
+
+		val, err := dstorage.Get(ctx, storage.MakeKey(models.DefaultNameSpace, "user:1"))
+		require.NoError(t, err)
+		assert.Equal(t, "alice", val)
+
+		// The stored key is served without the loader.
+		result = db.HandleQuery(ctx, "session", compute.CommandGET.Make("user:1"))
+		assert.Equal(t, WrapOK("alice"), result)
+		assert.Equal(t, 1, loader.calls)
+	})
+
+	t.Run("Key missing in the loader", func(t *testing.T) {
+		loader := &stubLoader{}
+		db, dstorage := newDB(t, WithMissHandler(loader))
+
+		result := db.HandleQuery(ctx, "session", compute.CommandGET.Make("missing"))
+		assert.Equal(t, WrapError(storage.ErrKeyNotFound), result)
+		assert.Zero(t, dstorage.CountByPrefix(""))
+	})
+
+	t.Run("Loader error", func(t *testing.T) {
+		loader := &stubLoader{err: errors.New("backend unavailable")}
+		db, _ := newDB(t, WithMissHandler(loader))
+
+		result := db.HandleQuery(ctx, "session", compute.CommandGET.Make("missing"))
+		assert.Equal(t, WrapError(errors.New("load missing key failed: backend unavailable")), result)
+	})
+
+	t.Run("Loaded key expires with the namespace default TTL", func(t *testing.T) {
+		loader := &stubLoader{values: map[string]string{models.DefaultNameSpace + "/user:1": "alice"}}
+		db, dstorage := newDB(t, WithMissHandler(loader))
+		require.NoError(t, identity.NewNamespaceStorage(dstorage).Save(ctx,
+			&models.Namespace{Name: models.DefaultNameSpace, DefaultTTL: time.Second}))
+
+		assert.Equal(t, WrapOK("alice"), db.HandleQuery(ctx, "session", compute.CommandGET.Make("user:1")))
+		assert.Eventually(t, func() bool {
+			_, err := dstorage.Get(ctx, storage.MakeKey(models.DefaultNameSpace, "user:1"))
+			return errors.Is(err, storage.ErrKeyNotFound)
+		}, 3*time.Second, 100*time.Millisecond)
+	})
+
+	t.Run("Loaded value rejected by the validator", func(t *testing.T) {
+		loader := &stubLoader{values: map[string]string{models.DefaultNameSpace + "/doc": "not json"}}
+		db, dstorage := newDB(t, WithMissHandler(loader),
+			WithValueValidators(map[string]ValueValidator{models.DefaultNameSpace: JSONValidator{}}))
+
+		result := db.HandleQuery(ctx, "session", compute.CommandGET.Make("doc"))
+		assert.Equal(t, WrapError(fmt.Errorf("load missing key failed: %w", ErrValueValidation)), result)
+		assert.Zero(t, dstorage.CountByPrefix(storage.MakeKey(models.DefaultNameSpace, "")))
+	})
+
+	t.Run("Without the handler", func(t *testing.T) {
+		db, _ := newDB(t)
+
+		result := db.HandleQuery(ctx, "session", compute.CommandGET.Make("missing"))
+		assert.Equal(t, WrapError(storage.ErrKeyNotFound), result)
+	})
+}
+
+func TestDatabase_Base64Values(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user", ActiveRole: models.DefaultRole}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandSET, map[string]compute.CommandParam{
+		compute.KeyArg:        {Required: true, Positional: true, Position: 0},
+		compute.ValueArg:      {Required: true, Positional: true, Position: 1},
+		compute.GetFlagArg:    {Required: false, Flag: true},
+		compute.Base64FlagArg: {Required: false, Flag: true},
+	})
+	trie.Insert(compute.CommandGET, map[string]compute.CommandParam{
+		compute.KeyArg:        {Required: true, Positional: true, Position: 0},
+		compute.Base64FlagArg: {Required: false, Flag: true},
+	})
+
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+	db := New(compute.NewParser(trie), dstorage, nil, identity.NewNamespaceStorage(dstorage), nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	blob := "\x00binary\nvalue with spaces\r\n\xff"
+	encoded := base64.StdEncoding.EncodeToString([]byte(blob))
+
+	result := db.HandleQuery(ctx, "session", compute.CommandSET.Make("blob", encoded, compute.Base64FlagArg))
+	require.Equal(t, okPrefix, result)
+
+	val, err := dstorage.Get(ctx, storage.MakeKey(models.DefaultNameSpace, "blob"))
+	require.NoError(t, err)
+	assert.Equal(t, blob, val)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandGET.Make("blob", compute.Base64FlagArg))
+	assert.Equal(t, WrapOK(encoded), result)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandSET.Make("blob", encoded, compute.GetFlagArg, compute.Base64FlagArg))
+	assert.Equal(t, WrapOKType(ContentJSON, `{"value":"`+encoded+`","exists":true}`), result)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandSET.Make("blob", "not*base64", compute.Base64FlagArg))
+	assert.Equal(t, WrapError(fmt.Errorf("%w: value is not base64-encoded", compute.ErrInvalidSyntax)), result)
+}
+
+func TestDatabase_SetGet(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user", ActiveRole: models.DefaultRole}))
+	require.NoError(t, sessions.Create("writer", &models.User{
+		Username:   "writer",
+		ActiveRole: models.Role{Name: "writer", Set: true, Namespace: models.DefaultNameSpace},
+	}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandSET, map[string]compute.CommandParam{
+		compute.KeyArg:     {Required: true, Positional: true, Position: 0},
+		compute.ValueArg:   {Required: true, Positional: true, Position: 1},
+		compute.TTLArg:     {Required: false, Positional: false},
+		compute.GetFlagArg: {Required: false, Flag: true},
+	})
+
+	db := New(compute.NewParser(trie), dstorage, nil, identity.NewNamespaceStorage(dstorage), nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	result := db.HandleQuery(ctx, "session", compute.CommandSET.Make("key", "v1", compute.GetFlagArg))
+	assert.Equal(t, WrapOKType(ContentJSON, `{"value":"","exists":false}`), result)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandSET.Make("key", "v2"))
+	assert.Equal(t, okPrefix, result)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandSET.Make("key", "v3", compute.GetFlagArg, "ttl", "1m"))
+	assert.Equal(t, WrapOKType(ContentJSON, `{"value":"v2","exists":true}`), result)
+
+	val, err := dstorage.Get(ctx, storage.MakeKey(models.DefaultNameSpace, "key"))
+	require.NoError(t, err)
+	assert.Equal(t, "v3", val)
+
+	result = db.HandleQuery(ctx, "writer", compute.CommandSET.Make("key", "v4", compute.GetFlagArg))
+	assert.Equal(t, WrapError(ErrPermissionDenied), result)
+
+	result = db.HandleQuery(ctx, "writer", compute.CommandSET.Make("key", "v4"))
+	assert.Equal(t, okPrefix, result)
+}
+
+func TestDatabase_Type(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey(models.DefaultNameSpace, "counter"), "-42"))
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey(models.DefaultNameSpace, "name"), "kvdb"))
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey(models.DefaultNameSpace, "ratio"), "1.5"))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user", ActiveRole: models.DefaultRole}))
+	require.NoError(t, sessions.Create("writer", &models.User{
+		Username:   "writer",
+		ActiveRole: models.Role{Name: "writer", Set: true, Namespace: models.DefaultNameSpace},
+	}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandTYPE, map[string]compute.CommandParam{
+		compute.KeyArg: {Required: true, Positional: true, Position: 0},
+		compute.NSArg:  {Required: false, Positional: false},
+	})
+
+	db := New(compute.NewParser(trie), dstorage, nil, nil, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	for key, kind := range map[string]string{
+		"counter": TypeInt,
+		"name":    TypeString,
+		"ratio":   TypeString,
+		"missing": TypeNone,
+	} {
+		result := db.HandleQuery(ctx, "session", compute.CommandTYPE.Make(key))
+		assert.Equal(t, WrapOK(kind), result, key)
+	}
+
+	result := db.HandleQuery(ctx, "writer", compute.CommandTYPE.Make("counter"))
+	assert.Equal(t, WrapError(ErrPermissionDenied), result)
+}
+
+func TestDatabase_InvalidNames(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	nsStorage := identity.NewNamespaceStorage(dstorage)
+	require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: "ns1"}))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("admin", &models.User{Username: "admin"}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandCREATEUSER, map[string]compute.CommandParam{
+		compute.UsernameArg: {Required: true, Positional: true, Position: 0},
+		compute.PasswordArg: {Required: true, Positional: true, Position: 1},
+	})
+	trie.Insert(compute.CommandCREATEROLE, map[string]compute.CommandParam{
+		compute.RoleNameArg:    {Required: true, Positional: true, Position: 0},
+		compute.PermissionsArg: {Required: true, Positional: true, Position: 1},
+		compute.NamespaceArg:   {Required: true, Positional: true, Position: 2},
+	})
+	trie.Insert(compute.CommandCREATENAMESPACE, map[string]compute.CommandParam{
+		compute.NamespaceArg: {Required: true, Positional: true, Position: 0},
+	})
+
+	db := New(compute.NewParser(trie), dstorage, identity.NewUsersStorage(dstorage), nsStorage,
+		identity.NewRolesStorage(dstorage), sessions, &config.RootConfig{Username: "admin", Password: "password"})
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{query: compute.CommandCREATEUSER.Make(`"bad name"`, "password"), expected: errPrefix + " invalid name"},
+		{query: compute.CommandCREATEUSER.Make("bad:name", "password"), expected: errPrefix + " invalid name"},
+		{query: compute.CommandCREATEUSER.Make("пользователь", "password"), expected: okPrefix},
+		{query: compute.CommandCREATEROLE.Make("\"bad\trole\"", "r", "ns1"), expected: errPrefix + " invalid name"},
+		{query: compute.CommandCREATEROLE.Make("читатель", "r", "ns1"), expected: okPrefix},
+		{query: compute.CommandCREATENAMESPACE.Make("bad:ns"), expected: errPrefix + " invalid name"},
+		{query: compute.CommandCREATENAMESPACE.Make(`"bad ns"`), expected: errPrefix + " invalid name"},
+		{query: compute.CommandCREATENAMESPACE.Make("espacio_ñ"), expected: okPrefix},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, db.HandleQuery(ctx, "admin", tt.query), tt.query)
+	}
+}
+
+func TestDatabase_DelCount(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+	for _, key := range []string{"a", "b"} {
+		require.NoError(t, dstorage.Set(ctx, storage.MakeKey(models.DefaultNameSpace, key), "value"))
+	}
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user", ActiveRole: models.DefaultRole}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandDEL, map[string]compute.CommandParam{
+		compute.KeyArg:   {Required: true, Positional: true, Position: 0},
+		compute.NSArg:    {Required: false, Positional: false},
+		compute.CountArg: {Required: false, Flag: true},
+	})
+
+	db := New(compute.NewParser(trie), dstorage, nil, nil, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	result := db.HandleQuery(ctx, "session", compute.CommandDEL.Make("a", compute.CountArg))
+	assert.Equal(t, WrapOKType(ContentInt, "1"), result)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandDEL.Make("a", compute.CountArg))
+	assert.Equal(t, WrapOKType(ContentInt, "0"), result)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandDEL.Make("b"))
+	assert.Equal(t, okPrefix, result)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandDEL.Make("missing"))
+	assert.Equal(t, okPrefix, result)
+
+	assert.Zero(t, dstorage.CountByPrefix(storage.MakeKey(models.DefaultNameSpace, "")))
+}
+
+func TestDatabase_DelMany(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+	for _, key := range []string{"a", "b", "c"} {
+		require.NoError(t, dstorage.Set(ctx, storage.MakeKey(models.DefaultNameSpace, key), "value"))
+	}
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey("ns1", "a"), "value"))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user", ActiveRole: models.DefaultRole}))
+	require.NoError(t, sessions.Create("reader", &models.User{
+		Username:   "reader",
+		ActiveRole: models.Role{Name: "reader", Get: true, Namespace: models.DefaultNameSpace},
+	}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandDEL, map[string]compute.CommandParam{
+		compute.KeyArg:   {Required: true, Positional: true, Position: 0, Variadic: true},
+		compute.NSArg:    {Required: false, Positional: false},
+		compute.CountArg: {Required: false, Flag: true},
+	})
+
+	db := New(compute.NewParser(trie), dstorage, nil, nil, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	result := db.HandleQuery(ctx, "reader", compute.CommandDEL.Make("a", "b"))
+	assert.Equal(t, WrapError(ErrPermissionDenied), result)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandDEL.Make("a", "missing", "b", "a"))
+	assert.Equal(t, WrapOKType(ContentInt, "2"), result)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandDEL.Make("a", "c", compute.CountArg))
+	assert.Equal(t, WrapOKType(ContentInt, "1"), result)
+
+	assert.Zero(t, dstorage.CountByPrefix(storage.MakeKey(models.DefaultNameSpace, "")))
+	assert.Equal(t, 1, dstorage.CountByPrefix(storage.MakeKey("ns1", "")))
+}
+
+func TestDatabase_Compact(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	segments, err := segment.NewFileSegmentStorage(new(filesystem.LocalFileSystem), t.TempDir())
+	require.NoError(t, err)
+	manager, err := wal.NewFileSegmentManager(segments, wal.WithMaxSegmentSize(256))
+	require.NoError(t, err)
+	w := wal.NewWAL(manager, 1, time.Millisecond, wal.WithCompaction(time.Hour, 0))
+	w.Start(ctx)
+	defer w.Close()
+
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt(w))
+	require.NoError(t, err)
+	for i := range 30 {
+		key := storage.MakeKey(models.DefaultNameSpace, fmt.Sprintf("key%d", i%3))
+		require.NoError(t, dstorage.Set(ctx, key, fmt.Sprintf("value%d", i)))
+	}
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("admin", &models.User{Username: "admin"}))
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user", ActiveRole: models.DefaultRole}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandCOMPACT, nil)
+
+	db := New(compute.NewParser(trie), dstorage, nil, nil, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	result := db.HandleQuery(ctx, "session", compute.CommandCOMPACT.String())
+	assert.Equal(t, WrapError(ErrPermissionDenied), result)
+
+	before := manager.SegmentsCount()
+	result = db.HandleQuery(ctx, "admin", compute.CommandCOMPACT.String())
+	payload, ok := CutOK(result)
+	require.True(t, ok, result)
+	assert.Less(t, manager.SegmentsCount(), before)
+
+	var compaction wal.CompactionResult
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(payload)), &compaction))
+	assert.Equal(t, before, compaction.SegmentsRemoved)
+	assert.Positive(t, compaction.BytesReclaimed)
+
+	stats := dstorage.WALCompactions()
+	assert.Equal(t, int64(1), stats.Total)
+	assert.False(t, stats.Last.IsZero())
+}
+
+func TestDatabase_MaxValueSize(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(),
+		storage.WithWALOpt((*wal.WAL)(nil)), storage.WithMaxValueSize(8))
+	require.NoError(t, err)
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user", ActiveRole: models.DefaultRole}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandSET, map[string]compute.CommandParam{
+		compute.KeyArg:   {Required: true, Positional: true, Position: 0},
+		compute.ValueArg: {Required: true, Positional: true, Position: 1},
+	})
+
+	db := New(compute.NewParser(trie), dstorage, nil, identity.NewNamespaceStorage(dstorage), nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	result := db.HandleQuery(ctx, "session", compute.CommandSET.Make("key", strings.Repeat("a", 8)))
+	assert.Equal(t, okPrefix, result)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandSET.Make("key", strings.Repeat("a", 9)))
+	assert.Equal(t, errPrefix+" value too large", result)
+
+	val, err := dstorage.Get(ctx, storage.MakeKey(models.DefaultNameSpace, "key"))
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("a", 8), val)
+}
+
+func TestDatabase_DeleteUser(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	usersStorage := identity.NewUsersStorage(dstorage)
+	for _, username := range []string{"admin", "user"} {
+		_, err := usersStorage.Create(ctx, username, "password", "")
+		require.NoError(t, err)
+		_, err = usersStorage.Append(ctx, username)
+		require.NoError(t, err)
+	}
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "admin"}))
+
+	deleteQuery := compute.CommandDELETEUSER.Make("user")
+	mockParser := dbMock.NewParser(t)
+	mockParser.On("Parse", deleteQuery).Return(&compute.Command{
+		Type: compute.CommandDELETEUSER,
+		Args: map[string]string{compute.UsernameArg: "user"},
+	}, nil).Once()
+	mockParser.On("Parse", compute.CommandUSERS.String()).Return(&compute.Command{
+		Type: compute.CommandUSERS,
+		Args: map[string]string{},
+	}, nil).Once()
+
+	db := New(mockParser, dstorage, usersStorage, nil, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	assert.Equal(t, okPrefix, db.HandleQuery(ctx, "session", deleteQuery))
+	assert.Equal(t, WrapOKType(ContentList, `["admin"]`), db.HandleQuery(ctx, "session", compute.CommandUSERS.String()))
+
+	mockParser.AssertExpectations(t)
+}
+
+func TestDatabase_DivestRole(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	rolesStorage := identity.NewRolesStorage(dstorage)
+	for _, role := range []string{"reader", "writer"} {
+		require.NoError(t, rolesStorage.Save(ctx, &models.Role{Name: role, Get: true, Namespace: "default"}))
+	}
+
+	usersStorage := identity.NewUsersStorage(dstorage)
+	_, err = usersStorage.Create(ctx, "user", "password", "")
+	require.NoError(t, err)
+	require.NoError(t, usersStorage.AssignRole(ctx, "user", "reader"))
+	require.NoError(t, usersStorage.AssignRole(ctx, "user", "writer"))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "admin"}))
+
+	divestQuery := compute.CommandDIVESTROLE.Make("user", "writer")
+	mockParser := dbMock.NewParser(t)
+	mockParser.On("Parse", divestQuery).Return(&compute.Command{
+		Type: compute.CommandDIVESTROLE,
+		Args: map[string]string{compute.UsernameArg: "user", compute.RoleArg: "writer"},
+	}, nil).Once()
+
+	db := New(mockParser, dstorage, usersStorage, nil, rolesStorage, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	before, err := usersStorage.Get(ctx, "user")
+	require.NoError(t, err)
+
+	result := db.HandleQuery(ctx, "session", divestQuery)
+	assert.Equal(t, okPrefix, result)
+
+	after, err := usersStorage.Get(ctx, "user")
+	require.NoError(t, err)
+	assert.Len(t, after.Roles, len(before.Roles)-1)
+	assert.NotContains(t, after.Roles, "writer")
+	assert.Contains(t, after.Roles, "reader")
+
+	mockParser.AssertExpectations(t)
+}
+
+func TestDatabase_KillSession(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	cfg := &config.RootConfig{Username: "admin", Password: "password"}
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("admin-session", &models.User{Username: "admin"}))
+	require.NoError(t, sessions.Create("user-session", &models.User{Username: "user"}))
+
+	killQuery := compute.CommandKILLSESSION.Make("user-session")
+	mockParser := dbMock.NewParser(t)
+	mockParser.On("Parse", killQuery).Return(
+		&compute.Command{
+			Type: compute.CommandKILLSESSION,
+			Args: map[string]string{compute.SessionIDArg: "user-session"},
+		}, nil).Once()
+
+	var closed []string
+	db := New(mockParser, nil, nil, nil, nil, sessions, cfg,
+		WithSessionCloser(func(sessionID string) {
+			closed = append(closed, sessionID)
+		}),
+	)
+
+	result := db.HandleQuery(ctx, "admin-session", killQuery)
+	assert.Equal(t, okPrefix, result)
+	assert.Equal(t, []string{"user-session"}, closed)
+
+	result = db.HandleQuery(ctx, "user-session", compute.CommandHELP.String())
+	assert.Equal(t, fmt.Sprintf("%s get current session failed: %v",
+		errPrefix, identity.ErrExpiresSession), result)
+
+	mockParser.AssertExpectations(t)
+}
+
+func TestDatabase_Login(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	tests := []struct {
+		name         string
+		query        string
+		user         *models.User
+		expectedUser *models.User
+		expectedErr  error
+		prepareMocks func(p *dbMock.Parser, us *dbMock.UsersStorage, ss *dbMock.SessionStorage)
+	}{
+		{
+			name:        "parse error",
+			query:       "invalid query",
+			expectedErr: fmt.Errorf("parse input failed: %w", errors.New("invalid command: unrecognized command")),
+			prepareMocks: func(
+				p *dbMock.Parser, us *dbMock.UsersStorage,
+				ss *dbMock.SessionStorage,
+			) {
+				p.On("Parse", "invalid query").Return(nil, errors.New("invalid command: unrecognized command")).Once()
+			},
+		},
+		{
+			name:        "authentication failed (wrong password)",
+			query:       compute.CommandAUTH.Make("username", "password"),
+			expectedErr: errors.New("authentication failed"),
+			prepareMocks: func(
+				p *dbMock.Parser, us *dbMock.UsersStorage,
+				ss *dbMock.SessionStorage,
+			) {
+				p.On("Parse", compute.CommandAUTH.Make("username", "password")).Return(
+					&compute.Command{
+						Type: compute.CommandAUTH,
+						Args: map[string]string{
+							compute.UsernameArg: "username",
+							compute.PasswordArg: "password",
+						},
+					}, nil).Once()
+				us.On("Authenticate", mock.Anything, "username", "password").Return(nil, identity.ErrAuthenticationFailed).Once()
+			},
+		},
+		{
+			name:        "authentication failed (user not found or internal error)",
+			query:       compute.CommandAUTH.Make("username", "password"),
+			expectedErr: ErrAuthenticationRequired,
+			prepareMocks: func(
+				p *dbMock.Parser, us *dbMock.UsersStorage,
+				ss *dbMock.SessionStorage,
+			) {
+				p.On("Parse", compute.CommandAUTH.Make("username", "password")).Return(
+					&compute.Command{
+						Type: compute.CommandAUTH,
+						Args: map[string]string{
+							compute.UsernameArg: "username",
+							compute.PasswordArg: "password",
+						},
+					}, nil).Once()
+				us.On("Authenticate", mock.Anything, "username", "password").Return(nil, nil).Once()
+			},
+		},
+		{
+			name:         "successful login",
+			query:        compute.CommandAUTH.Make("username", "password"),
+			user:         &models.User{Username: "username"},
+			expectedUser: &models.User{Username: "username"},
+			expectedErr:  nil,
+			prepareMocks: func(
+				p *dbMock.Parser, us *dbMock.UsersStorage,
+				ss *dbMock.SessionStorage,
+			) {
+				user := &models.User{Username: "username"}
+				p.On("Parse", compute.CommandAUTH.Make("username", "password")).Return(
+					&compute.Command{
+						Type: compute.CommandAUTH,
+						Args: map[string]string{
+							compute.UsernameArg: "username",
+							compute.PasswordArg: "password",
+						},
+					}, nil).Once()
+				us.On("Authenticate", mock.Anything, "username", "password").Return(user, nil).Once()
+				ss.On("Create", "1", user).Return(nil).Once()
+			},
+		},
+		{
+			name:        "login command missing password",
+			query:       compute.CommandAUTH.String() + " username",
+			expectedErr: fmt.Errorf("parse input failed: %w", fmt.Errorf("%w: missing required parameter '%s'", compute.ErrInvalidSyntax, compute.PasswordArg)),
+			prepareMocks: func(
+				p *dbMock.Parser, us *dbMock.UsersStorage,
+				ss *dbMock.SessionStorage,
+			) {
+				parseError := fmt.Errorf("%w: missing required parameter '%s'", compute.ErrInvalidSyntax, compute.PasswordArg)
+				p.On("Parse", compute.CommandAUTH.String()+" username").Return(nil, parseError).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			mockParser := dbMock.NewParser(t)
+			mockUserStorage := dbMock.NewUsersStorage(t)
+			mockSessionStorage := dbMock.NewSessionStorage(t)
+			tt.prepareMocks(mockParser, mockUserStorage, mockSessionStorage)
+
+			db := &Database{
+				parser:      mockParser,
+				userStorage: mockUserStorage,
+				sessions:    mockSessionStorage,
+			}
+
+			user, err := db.Login(ctx, "1", tt.query)
+
+			if tt.expectedErr != nil {
+				require.Error(t, err)
+				assert.EqualError(t, err, tt.expectedErr.Error())
+				assert.Nil(t, user)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, user)
+				assert.Equal(t, tt.expectedUser.Username, user.Username)
+			}
+
+			mockParser.AssertExpectations(t)
+			mockUserStorage.AssertExpectations(t)
+			mockSessionStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDatabase_LoginToken(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	user := &models.User{Username: "username"}
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", user))
+
+	mockParser := dbMock.NewParser(t)
+	mockParser.On("Parse", compute.CommandTOKEN.String()).Return(&compute.Command{
+		Type: compute.CommandTOKEN,
+		Args: map[string]string{},
+	}, nil).Once()
+
+	mockUsers := dbMock.NewUsersStorage(t)
+	db := New(mockParser, nil, mockUsers, nil, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	result := db.HandleQuery(ctx, "session", compute.CommandTOKEN.String())
+	token, ok := CutOK(result)
+	require.True(t, ok, result)
+	token = strings.TrimSpace(token)
+
+	login := func(token string) (*models.User, error) {
+		query := compute.CommandLOGINTOKEN.Make(token)
+		mockParser.On("Parse", query).Return(&compute.Command{
+			Type: compute.CommandLOGINTOKEN,
+			Args: map[string]string{compute.TokenArg: token},
+		}, nil).Once()
+
+		return db.Login(ctx, "new-session", query)
+	}
+
+	// The token is verified without the password, the user is loaded again.
+	mockUsers.On("Get", mock.Anything, "username").Return(user, nil).Once()
+	loggedIn, err := login(token)
+	require.NoError(t, err)
+	assert.Equal(t, user, loggedIn)
+
+	session, err := sessions.Get("new-session")
+	require.NoError(t, err)
+	assert.Equal(t, user, session.User)
+
+	_, err = login(token + "x")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	mockUsers.On("Get", mock.Anything, "username").Return(nil, identity.ErrUserNotFound).Once()
+	_, err = login(token)
+	assert.ErrorIs(t, err, identity.ErrAuthenticationFailed)
+
+	mockParser.AssertExpectations(t)
+	mockUsers.AssertExpectations(t)
+}
+
+func TestDatabase_Logout(t *testing.T) {
+	t.Parallel()
+
+	mockSessionStorage := dbMock.NewSessionStorage(t)
+	db := &Database{sessions: mockSessionStorage}
+
+	ctx := context.Background()
+	sessionID := "1"
+
+	mockSessionStorage.On("Delete", sessionID).Return(nil).Once()
+
+	result := db.Logout(ctx, sessionID)
+	assert.Equal(t, okPrefix, result)
+
+	mockSessionStorage.AssertExpectations(t)
+}
+
+func TestDatabase_Health(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		readiness storage.Readiness
+		expected  string
+	}{
+		{
+			name:      "ready",
+			readiness: storage.Readiness{WALRecovered: true, ReplicaSynced: true},
+			expected:  WrapOK(`healthy {"ready":true,"wal_recovered":true,"replica_synced":true}`),
+		},
+		{
+			name:      "slave before the first sync",
+			readiness: storage.Readiness{WALRecovered: true},
+			expected:  WrapOK(`healthy {"ready":false,"wal_recovered":true,"replica_synced":false}`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := dbMock.NewStorage(t)
+			mockStorage.On("Readiness").Return(tt.readiness).Once()
+
+			db := &Database{storage: mockStorage}
+			assert.Equal(t, tt.expected, db.Health())
+		})
+	}
+}
+
+func TestDatabase_SlowQuery(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	logger.Init(core)
+	defer logger.MockLogger()
+
+	ctx := context.Background()
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user"}))
+
+	fastQuery := compute.CommandGET.Make("fast")
+	slowQuery := compute.CommandGET.Make("slow")
+	mockParser := dbMock.NewParser(t)
+	mockParser.On("Parse", fastQuery).Return(&compute.Command{
+		Type: compute.CommandGET, Args: map[string]string{compute.KeyArg: "fast"},
+	}, nil).Once()
+	mockParser.On("Parse", slowQuery).Return(&compute.Command{
+		Type: compute.CommandGET, Args: map[string]string{compute.KeyArg: "slow"},
+	}, nil).Once()
+
+	db := New(mockParser, nil, nil, nil, nil, sessions,
+		&config.RootConfig{Username: "admin"}, WithSlowQueryThreshold(50*time.Millisecond))
+	db.registry[compute.CommandGET] = CommandHandler{
+		Func: func(_ context.Context, _ *models.User, args Args) string {
+			if args[compute.KeyArg] == "slow" {
+				time.Sleep(100 * time.Millisecond)
+			}
+			return WrapOK("value")
+		},
+	}
+
+	assert.Equal(t, WrapOK("value"), db.HandleQuery(ctx, "session", fastQuery))
+	assert.Zero(t, logs.FilterMessage("slow query").Len())
+
+	assert.Equal(t, WrapOK("value"), db.HandleQuery(ctx, "session", slowQuery))
+	entries := logs.FilterMessage("slow query").All()
+	require.Len(t, entries, 1)
+
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "get", fields["cmd_type"])
+	assert.Equal(t, "session", fields["session"])
+	assert.GreaterOrEqual(t, fields["duration"], 100*time.Millisecond)
+
+	summary := db.slowCommands.summary()
+	assert.Equal(t, int64(1), summary.Count)
+	assert.GreaterOrEqual(t, summary.MaxSeconds, 0.1)
+	assert.Equal(t, summary.MaxSeconds, summary.TotalSeconds)
+}
+
+func TestDatabase_Relogin(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "alice"}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandAUTH, map[string]compute.CommandParam{
+		compute.UsernameArg: {Required: true, Positional: true, Position: 0},
+		compute.PasswordArg: {Required: true, Positional: true, Position: 1},
+	})
+	trie.Insert(compute.CommandLOGOUT, nil)
+	trie.Insert(compute.CommandME, nil)
+
+	mockUserStorage := dbMock.NewUsersStorage(t)
+	mockUserStorage.On("Authenticate", mock.Anything, "bob", "wrong").
+		Return(nil, identity.ErrAuthenticationFailed).Once()
+	mockUserStorage.On("Authenticate", mock.Anything, "bob", "secret").
+		Return(&models.User{Username: "bob"}, nil).Twice()
+
+	db := New(compute.NewParser(trie), nil, mockUserStorage, nil, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	me := compute.CommandME.String()
+	assert.Contains(t, db.HandleQuery(ctx, "session", me), "user: 'alice'")
+
+	// Failed login keeps the current session.
+	assert.Equal(t, WrapError(identity.ErrAuthenticationFailed),
+		db.HandleQuery(ctx, "session", compute.CommandAUTH.Make("bob", "wrong")))
+	assert.Contains(t, db.HandleQuery(ctx, "session", me), "user: 'alice'")
+
+	// Login replaces the session user.
+	assert.Equal(t, WrapOK("authentication successful"),
+		db.HandleQuery(ctx, "session", compute.CommandAUTH.Make("bob", "secret")))
+	assert.Contains(t, db.HandleQuery(ctx, "session", me), "user: 'bob'")
+
+	// Logout invalidates the session.
+	assert.Equal(t, okPrefix, db.HandleQuery(ctx, "session", compute.CommandLOGOUT.String()))
+	assert.Equal(t, fmt.Sprintf("%s get current session failed: %v", errPrefix, identity.ErrExpiresSession),
+		db.HandleQuery(ctx, "session", me))
+
+	// Logged out connection logs in again.
+	assert.Equal(t, WrapOK("authentication successful"),
+		db.HandleQuery(ctx, "session", compute.CommandAUTH.Make("bob", "secret")))
+	assert.Contains(t, db.HandleQuery(ctx, "session", me), "user: 'bob'")
+}
+
+func TestDatabase_Version(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user"}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandVERSION, nil)
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected VersionInfo
+	}{
+		{
+			name:     "default version",
+			expected: VersionInfo{Version: "dev", Protocol: ProtocolVersion},
+		},
+		{
+			name:     "configured version",
+			opts:     []Option{WithServerVersion("1.4.0")},
+			expected: VersionInfo{Version: "1.4.0", Protocol: ProtocolVersion},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := New(compute.NewParser(trie), nil, nil, nil, nil, sessions,
+				&config.RootConfig{Username: "admin"}, tt.opts...)
+
+			payload, ok := CutOK(db.HandleQuery(context.Background(), "session", compute.CommandVERSION.String()))
+			require.True(t, ok)
+
+			var info VersionInfo
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(payload)), &info))
+			assert.Equal(t, tt.expected, info)
+		})
+	}
+}
+
+func TestDatabase_ExportImport(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	nsStorage := identity.NewNamespaceStorage(dstorage)
+	for _, name := range []string{"src", "dst"} {
+		require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: name}))
+	}
+
+	data := map[string]string{"user:1": "alice", "user:2": "bob smith", "empty": ""}
+	for key, value := range data {
+		require.NoError(t, dstorage.Set(ctx, storage.MakeKey("src", key), value))
+	}
+	require.NoError(t, dstorage.Set(ctxutil.InjectTTL(ctx, "1h"), storage.MakeKey("src", "session"), "token"))
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey("other", "user:1"), "carol"))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "admin"}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandEXPORT, map[string]compute.CommandParam{
+		compute.NamespaceArg: {Required: true, Positional: true, Position: 0},
+	})
+	trie.Insert(compute.CommandIMPORT, map[string]compute.CommandParam{
+		compute.NamespaceArg: {Required: true, Positional: true, Position: 0},
+		compute.DataArg:      {Required: true, Positional: true, Position: 1},
+	})
+
+	db := New(compute.NewParser(trie), dstorage, nil, nsStorage, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	dump, ok := CutOK(db.HandleQuery(ctx, "session", compute.CommandEXPORT.Make("src")))
+	require.True(t, ok)
+	dump = strings.TrimSpace(dump)
+
+	assert.Equal(t, WrapOKType(ContentInt, "4"), db.HandleQuery(ctx, "session", compute.CommandIMPORT.Make("dst", dump)))
+	for key, value := range data {
+		got, err := dstorage.Get(ctx, storage.MakeKey("dst", key))
+		require.NoError(t, err)
+		assert.Equal(t, value, got)
+	}
+
+	got, err := dstorage.Get(ctx, storage.MakeKey("dst", "session"))
+	require.NoError(t, err)
+	assert.Equal(t, "token", got)
+	assert.Equal(t, 4, dstorage.CountByPrefix(storage.MakeKey("dst", "")))
+
+	assert.Equal(t, WrapError(ErrSystemNamespace),
+		db.HandleQuery(ctx, "session", compute.CommandEXPORT.Make(models.SystemUserNameSpace)))
+	assert.Equal(t, WrapError(identity.ErrNamespaceNotFound),
+		db.HandleQuery(ctx, "session", compute.CommandIMPORT.Make("missing", dump)))
+	assert.True(t, strings.HasPrefix(
+		db.HandleQuery(ctx, "session", compute.CommandIMPORT.Make("dst", "bm90IGEgZHVtcA==")),
+		WrapError(ErrInvalidDump)))
+
+	t.Run("validation and default ttl", func(t *testing.T) {
+		for _, name := range []string{"json", "docs"} {
+			require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: name, DefaultTTL: time.Hour}))
+		}
+		db := New(compute.NewParser(trie), dstorage, nil, nsStorage, nil, sessions,
+			&config.RootConfig{Username: "admin", Password: "password"},
+			WithValueValidators(map[string]ValueValidator{"docs": JSONValidator{}}))
+
+		require.NoError(t, dstorage.Set(ctx, storage.MakeKey("json", "doc"), `{"a":1}`))
+		require.NoError(t, dstorage.Set(ctx, storage.MakeKey("json", "note"), "plain"))
+		export := func() string {
+			dump, ok := CutOK(db.HandleQuery(ctx, "session", compute.CommandEXPORT.Make("json")))
+			require.True(t, ok)
+			return strings.TrimSpace(dump)
+		}
+
+		// A rejected value fails the import before any key is written.
+		assert.Equal(t, WrapError(fmt.Errorf("key 'note': %w", ErrValueValidation)),
+			db.HandleQuery(ctx, "session", compute.CommandIMPORT.Make("docs", export())))
+		assert.Zero(t, dstorage.CountByPrefix(storage.MakeKey("docs", "")))
+
+		// The keys without a TTL get the default TTL of the namespace.
+		_, err := dstorage.Del(ctx, storage.MakeKey("json", "note"))
+		require.NoError(t, err)
+		assert.Equal(t, WrapOKType(ContentInt, "1"),
+			db.HandleQuery(ctx, "session", compute.CommandIMPORT.Make("docs", export())))
+
+		dump, ok := CutOK(db.HandleQuery(ctx, "session", compute.CommandEXPORT.Make("docs")))
+		require.True(t, ok)
+		entries, err := decodeDump(strings.TrimSpace(dump))
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, `{"a":1}`, entries[0].Value)
+		assert.Positive(t, entries[0].TTL)
+	})
+}
+
+func TestDatabase_Explain(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user"}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandSET, map[string]compute.CommandParam{
+		compute.KeyArg:   {Required: true, Positional: true, Position: 0},
+		compute.ValueArg: {Required: true, Positional: true, Position: 1},
+		compute.TTLArg:   {Required: false, Positional: false},
+		compute.NSArg:    {Required: false, Positional: false},
+	})
+	trie.Insert(compute.CommandUSERS, nil)
+
+	// The storage mock fails the test if the explained command is executed.
+	mockStorage := dbMock.NewStorage(t)
+	db := New(compute.NewParser(trie), mockStorage, nil, nil, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "set with ttl",
+			query:    "explain set k v ttl 10s",
+			expected: WrapOKType(ContentJSON, `{"type":"set","args":{"key":"k","ttl":"10s","value":"v"}}`),
+		},
+		{
+			name:     "admin command without args",
+			query:    "explain users",
+			expected: WrapOKType(ContentJSON, `{"type":"users","args":{}}`),
+		},
+		{
+			name:     "parse error",
+			query:    "explain set k",
+			expected: WrapError(fmt.Errorf("parse input failed: %w", compute.ErrInvalidSyntax)),
+		},
+		{
+			name:     "empty query",
+			query:    "explain",
+			expected: WrapError(fmt.Errorf("parse input failed: %w: query cannot be empty", compute.ErrInvalidSyntax)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := db.HandleQuery(context.Background(), "session", tt.query)
+			if IsError(tt.expected) {
+				assert.True(t, strings.HasPrefix(result, tt.expected), result)
+				return
+			}
+
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestDatabase_Connections(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("s1", &models.User{Username: "admin"}))
+	require.NoError(t, sessions.Create("s2", &models.User{Username: "user"}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandCONNECTIONS, nil)
+	cfg := &config.RootConfig{Username: "admin", Password: "password"}
+
+	db := New(compute.NewParser(trie), nil, nil, nil, nil, sessions, cfg)
+	result := db.HandleQuery(ctx, "s1", compute.CommandCONNECTIONS.String())
+	assert.Equal(t, WrapError(ErrEmptyResult), result)
+
+	connectedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	db = New(compute.NewParser(trie), nil, nil, nil, nil, sessions, cfg,
+		WithConnectionsLister(func() []ConnInfo {
+			return []ConnInfo{
+				{SessionID: "s1", RemoteAddr: "127.0.0.1:5000", ConnectedAt: connectedAt,
+					BytesRead: 11, BytesWritten: 4, Command: "connections"},
+				{SessionID: "s3", RemoteAddr: "127.0.0.1:5001", ConnectedAt: connectedAt},
+			}
+		}))
+
+	result = db.HandleQuery(ctx, "s1", compute.CommandCONNECTIONS.String())
+	assert.Equal(t, WrapOKType(ContentList, `[{"session":"s1","username":"admin","remote_addr":"127.0.0.1:5000",`+
+		`"connected_at":"2024-01-02T03:04:05Z","bytes_read":11,"bytes_written":4,"command":"connections"},`+
+		`{"session":"s3","remote_addr":"127.0.0.1:5001","connected_at":"2024-01-02T03:04:05Z",`+
+		`"bytes_read":0,"bytes_written":0}]`), result)
+
+	result = db.HandleQuery(ctx, "s2", compute.CommandCONNECTIONS.String())
+	assert.Equal(t, WrapError(ErrPermissionDenied), result)
+}
+
+func TestDatabase_Caps(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("admin", &models.User{Username: "admin"}))
+	require.NoError(t, sessions.Create("user", &models.User{Username: "user"}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandCAPS, nil)
+	db := New(compute.NewParser(trie), nil, nil, nil, nil, sessions, &config.RootConfig{Username: "admin"})
+
+	caps := func(sessionID string) []string {
+		payload, ok := CutOK(db.HandleQuery(ctx, sessionID, compute.CommandCAPS.String()))
+		require.True(t, ok)
+
+		var commands []string
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(payload)), &commands))
+		return commands
+	}
+
+	adminCaps, userCaps := caps("admin"), caps("user")
+	assert.Len(t, adminCaps, len(db.registry)+1)
+	assert.True(t, slices.IsSorted(userCaps))
+	assert.Subset(t, adminCaps, userCaps)
+	assert.Contains(t, userCaps, compute.CommandGET.String())
+	assert.Contains(t, userCaps, compute.CommandCAPS.String())
+	assert.Contains(t, userCaps, compute.CommandEXPLAIN.String())
+	assert.NotContains(t, userCaps, compute.CommandCREATEUSER.String())
+	assert.Contains(t, adminCaps, compute.CommandCREATEUSER.String())
+
+	for cmdType, handler := range db.registry {
+		assert.Equal(t, !handler.AdminOnly, slices.Contains(userCaps, cmdType.String()), cmdType)
+	}
+}
+
+func TestDatabase_NamespaceNotAccessible(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	nsStorage := identity.NewNamespaceStorage(dstorage)
+	require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: "ns1"}))
+	require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: "ns2"}))
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey("ns1", "key"), "value"))
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey("ns2", "key"), "value"))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{
+		Username:   "user",
+		ActiveRole: models.Role{Set: true, Namespace: "ns1"},
+	}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandGET, map[string]compute.CommandParam{
+		compute.KeyArg: {Required: true, Positional: true, Position: 0},
+		compute.NSArg:  {},
+	})
+
+	db := New(compute.NewParser(trie), dstorage, nil, nsStorage, identity.NewRolesStorage(dstorage), sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	// The role of the namespace lacks the get permission.
+	result := db.HandleQuery(ctx, "session", compute.CommandGET.Make("key", "ns", "ns1"))
+	assert.Equal(t, WrapError(ErrPermissionDenied), result)
+
+	// The user has no role in the namespace.
+	result = db.HandleQuery(ctx, "session", compute.CommandGET.Make("key", "ns", "ns2"))
+	assert.Equal(t, WrapError(ErrNamespaceNotAccessible), result)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandGET.Make("key", "ns", "missing"))
+	assert.Equal(t, WrapError(identity.ErrNamespaceNotFound), result)
+}
+
+func TestDatabase_Expiring(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	e := engine.New()
+	now := time.Now()
+	e.Set(ctx, storage.MakeKey("ns1", "persistent"), "value", 0)
+	e.Set(ctx, storage.MakeKey("ns1", "soon"), "value", now.Add(10*time.Second).Unix())
+	e.Set(ctx, storage.MakeKey("ns1", "later"), "value", now.Add(time.Hour).Unix())
+	e.Set(ctx, storage.MakeKey("ns2", "soon"), "value", now.Add(10*time.Second).Unix())
+
+	dstorage, err := storage.NewStorage(ctx, e, storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	nsStorage := identity.NewNamespaceStorage(dstorage)
+	require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: "ns1"}))
+	require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: "ns2"}))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("reader", &models.User{
+		Username:   "reader",
+		ActiveRole: models.Role{Get: true, Namespace: "ns1"},
+	}))
+	require.NoError(t, sessions.Create("writer", &models.User{
+		Username:   "writer",
+		ActiveRole: models.Role{Set: true, Namespace: "ns1"},
+	}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandEXPIRING, map[string]compute.CommandParam{
+		compute.WithinArg: {Required: true, Positional: true, Position: 0},
+		compute.NSArg:     {},
+	})
+
+	db := New(compute.NewParser(trie), dstorage, nil, nsStorage, identity.NewRolesStorage(dstorage), sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	result := db.HandleQuery(ctx, "reader", compute.CommandEXPIRING.Make("1m"))
+	assert.Equal(t, WrapOKType(ContentList, `["soon"]`), result)
+
+	result = db.HandleQuery(ctx, "reader", compute.CommandEXPIRING.Make("2h"))
+	assert.Equal(t, WrapOKType(ContentList, `["soon","later"]`), result)
+
+	result = db.HandleQuery(ctx, "reader", compute.CommandEXPIRING.Make("1s"))
+	assert.Equal(t, WrapOKType(ContentList, `[]`), result)
+
+	result = db.HandleQuery(ctx, "reader", compute.CommandEXPIRING.Make("soon"))
+	assert.Equal(t, WrapError(fmt.Errorf("%w: invalid duration", compute.ErrInvalidSyntax)), result)
+
+	result = db.HandleQuery(ctx, "writer", compute.CommandEXPIRING.Make("1m"))
+	assert.Equal(t, WrapError(ErrPermissionDenied), result)
+
+	result = db.HandleQuery(ctx, "reader", compute.CommandEXPIRING.Make("1m", "ns", "ns2"))
+	assert.Equal(t, WrapError(ErrNamespaceNotAccessible), result)
+}
+
+func TestDatabase_StatDisabled(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey("default", "key"), "value"))
+
+	_, err = dstorage.Stats()
+	require.ErrorIs(t, err, storage.ErrStatsDisabled)
+
+	nsStorage, rolesStorage := identity.NewNamespaceStorage(dstorage), identity.NewRolesStorage(dstorage)
+	usersStorage := identity.NewUsersStorage(dstorage)
+	_, err = nsStorage.Append(ctx, "ns1")
+	require.NoError(t, err)
+	_, err = rolesStorage.Append(ctx, "reader")
+	require.NoError(t, err)
+	_, err = usersStorage.Append(ctx, "user")
+	require.NoError(t, err)
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("admin", &models.User{Username: "admin"}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandSTAT, nil)
+	db := New(compute.NewParser(trie), dstorage, usersStorage, nsStorage, rolesStorage, sessions,
+		&config.RootConfig{Username: "admin"})
+
+	payload, ok := CutOK(db.HandleQuery(ctx, "admin", compute.CommandSTAT.String()))
+	require.True(t, ok)
+
+	var stats Stats
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(payload)), &stats))
+	assert.Zero(t, stats.TotalCommands)
+	assert.Zero(t, stats.TotalKeys)
+	assert.Equal(t, int64(1), stats.ActiveSessions)
+	assert.Subset(t, stats.Unavailable, []string{"uptime", "total_commands", "get_commands",
+		"set_commands", "del_commands", "total_keys", "expired_keys"})
+}
+
+func TestDatabase_StatHitsMisses(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(),
+		storage.WithWALOpt((*wal.WAL)(nil)), storage.WithStatistics())
+	require.NoError(t, err)
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey(models.DefaultNameSpace, "key"), "value"))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("admin", &models.User{Username: "admin"}))
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user", ActiveRole: models.DefaultRole}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandSTAT, nil)
+	trie.Insert(compute.CommandGET, map[string]compute.CommandParam{
+		compute.KeyArg: {Required: true, Positional: true, Position: 0},
+	})
+	nsStorage, rolesStorage := identity.NewNamespaceStorage(dstorage), identity.NewRolesStorage(dstorage)
+	usersStorage := identity.NewUsersStorage(dstorage)
+	_, err = nsStorage.Append(ctx, "ns1")
+	require.NoError(t, err)
+	_, err = rolesStorage.Append(ctx, "reader")
+	require.NoError(t, err)
+	_, err = usersStorage.Append(ctx, "user")
+	require.NoError(t, err)
+
+	db := New(compute.NewParser(trie), dstorage, usersStorage, nsStorage, rolesStorage, sessions,
+		&config.RootConfig{Username: "admin"})
+
+	// The identity storages read the keys too, so only the change of the counters is checked.
+	counters, err := dstorage.Stats()
+	require.NoError(t, err)
+	hits, misses := counters.Hits.Load(), counters.Misses.Load()
+
+	for range 3 {
+		assert.Equal(t, WrapOK("value"), db.HandleQuery(ctx, "session", compute.CommandGET.Make("key")))
+	}
+	assert.Equal(t, WrapError(storage.ErrKeyNotFound), db.HandleQuery(ctx, "session", compute.CommandGET.Make("missing")))
+	assert.Equal(t, int64(3), counters.Hits.Load()-hits)
+	assert.Equal(t, int64(1), counters.Misses.Load()-misses)
+
+	payload, ok := CutOK(db.HandleQuery(ctx, "admin", compute.CommandSTAT.String()))
+	require.True(t, ok)
+
+	var stats Stats
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(payload)), &stats))
+	assert.Equal(t, counters.Hits.Load(), stats.Hits)
+	assert.Equal(t, counters.Misses.Load(), stats.Misses)
+	assert.Equal(t, stats.Hits+stats.Misses, stats.GetCommands)
+	assert.NotContains(t, stats.Unavailable, "hits")
+}
+
+func TestDatabase_FSync(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	segments, err := segment.NewFileSegmentStorage(new(filesystem.LocalFileSystem), t.TempDir())
+	require.NoError(t, err)
+	manager, err := wal.NewFileSegmentManager(segments, wal.WithMaxSegmentSize(1024))
+	require.NoError(t, err)
+	// The flush workers are not started, so the writes wait for the fsync command.
+	w := wal.NewWAL(manager, 100, time.Hour)
+	defer w.Close()
+
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt(w))
+	require.NoError(t, err)
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("admin", &models.User{Username: "admin"}))
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user", ActiveRole: models.DefaultRole}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandFSYNC, nil)
+	db := New(compute.NewParser(trie), dstorage, nil, nil, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	written := make(chan error, 1)
+	go func() {
+		written <- dstorage.Set(ctx, storage.MakeKey(models.DefaultNameSpace, "key"), "value")
+	}()
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, written)
+
+	result := db.HandleQuery(ctx, "session", compute.CommandFSYNC.String())
+	assert.Equal(t, WrapError(ErrPermissionDenied), result)
+
+	result = db.HandleQuery(ctx, "admin", compute.CommandFSYNC.String())
+	assert.Equal(t, okPrefix, result)
+	select {
+	case err := <-written:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		require.FailNow(t, "the write is not acknowledged after the fsync")
+	}
+
+	// The write is in the current segment file after the fsync.
+	current, err := segments.Open(1)
+	require.NoError(t, err)
+	defer current.Close()
+	data, err := io.ReadAll(current)
+	require.NoError(t, err)
+
+	var entry wal.LogEntry
+	require.NoError(t, entry.Decode(bytes.NewBuffer(data)))
+	assert.Equal(t, []string{storage.MakeKey(models.DefaultNameSpace, "key"), "value"}, entry.Args)
+
+	memory, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+	db = New(compute.NewParser(trie), memory, nil, nil, nil, sessions, &config.RootConfig{Username: "admin"})
+	result = db.HandleQuery(ctx, "admin", compute.CommandFSYNC.String())
+	assert.Equal(t, WrapError(wal.ErrSyncUnavailable), result)
+}
+
+func TestDatabase_CreateUserNamespace(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	nsStorage := identity.NewNamespaceStorage(dstorage)
+	require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: "myns"}))
+	usersStorage := identity.NewUsersStorage(dstorage, identity.WithBcryptCost(bcrypt.MinCost))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("admin", &models.User{Username: "admin"}))
+
+	trie := compute.NewTrieNode()
+	credentials := map[string]compute.CommandParam{
+		compute.UsernameArg: {Required: true, Positional: true, Position: 0},
+		compute.PasswordArg: {Required: true, Positional: true, Position: 1},
+	}
+	trie.Insert(compute.CommandAUTH, credentials)
+	trie.Insert(compute.CommandCREATEUSER, map[string]compute.CommandParam{
+		compute.UsernameArg: {Required: true, Positional: true, Position: 0},
+		compute.PasswordArg: {Required: true, Positional: true, Position: 1},
+		compute.NSArg:       {Required: false, Positional: false},
+	})
+	trie.Insert(compute.CommandSET, map[string]compute.CommandParam{
+		compute.KeyArg:   {Required: true, Positional: true, Position: 0},
+		compute.ValueArg: {Required: true, Positional: true, Position: 1},
+	})
+	db := New(compute.NewParser(trie), dstorage, usersStorage, nsStorage, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	result := db.HandleQuery(ctx, "admin", "create user alice secret ns missing")
+	assert.Equal(t, WrapError(identity.ErrNamespaceNotFound), result)
+	_, err = usersStorage.Get(ctx, "alice")
+	require.Error(t, err)
+
+	result = db.HandleQuery(ctx, "admin", "create user alice secret ns myns")
+	require.Equal(t, okPrefix, result)
+
+	user, err := db.Login(ctx, "alice-session", "login alice secret")
+	require.NoError(t, err)
+	assert.Equal(t, models.Role{
+		Name: models.DefaultRoleName, Get: true, Set: true, Del: true, Namespace: "myns",
+	}, user.ActiveRole)
+
+	// The keys without the namespace argument are written to the default namespace of the user.
+	require.Equal(t, okPrefix, db.HandleQuery(ctx, "alice-session", "set key value"))
+	value, err := dstorage.Get(ctx, storage.MakeKey("myns", "key"))
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	result = db.HandleQuery(ctx, "admin", "create user bob secret")
+	require.Equal(t, okPrefix, result)
+	user, err = db.Login(ctx, "bob-session", "login bob secret")
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultRole, user.ActiveRole)
+}
+
+func TestDatabase_LastAccess(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(engine.WithAccessTracking()),
+		storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+	key := storage.MakeKey(models.DefaultNameSpace, "key")
+	require.NoError(t, dstorage.Set(ctx, key, "value"))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user", ActiveRole: models.DefaultRole}))
+	require.NoError(t, sessions.Create("writer", &models.User{
+		Username:   "writer",
+		ActiveRole: models.Role{Name: "writer", Set: true, Namespace: models.DefaultNameSpace},
+	}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandLASTACCESS, map[string]compute.CommandParam{
+		compute.KeyArg: {Required: true, Positional: true, Position: 0},
+		compute.NSArg:  {Required: false, Positional: false},
+	})
+	db := New(compute.NewParser(trie), dstorage, nil, nil, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	_, err = dstorage.Get(ctx, key)
+	require.NoError(t, err)
+	accessed, err := dstorage.LastAccess(ctx, key)
+	require.NoError(t, err)
+
+	result := db.HandleQuery(ctx, "session", compute.CommandLASTACCESS.Make("key"))
+	assert.Equal(t, WrapOK(accessed.UTC().Format(time.RFC3339)), result)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandLASTACCESS.Make("missing"))
+	assert.Equal(t, WrapError(storage.ErrKeyNotFound), result)
+
+	result = db.HandleQuery(ctx, "writer", compute.CommandLASTACCESS.Make("key"))
+	assert.Equal(t, WrapError(ErrPermissionDenied), result)
+
+	untracked, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+	require.NoError(t, untracked.Set(ctx, key, "value"))
+	db = New(compute.NewParser(trie), untracked, nil, nil, nil, sessions, &config.RootConfig{Username: "admin"})
+	result = db.HandleQuery(ctx, "session", compute.CommandLASTACCESS.Make("key"))
+	assert.Equal(t, WrapError(storage.ErrAccessNotTracked), result)
+}
+
+func TestDatabase_MSetNX(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey(models.DefaultNameSpace, "exists"), "old"))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user", ActiveRole: models.DefaultRole}))
+	require.NoError(t, sessions.Create("reader", &models.User{
+		Username:   "reader",
+		ActiveRole: models.Role{Name: "reader", Get: true, Namespace: models.DefaultNameSpace},
+	}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandMSETNX, map[string]compute.CommandParam{
+		compute.PairsArg: {Required: true, Positional: true, Position: 0, Variadic: true},
+		compute.NSArg:    {Required: false, Positional: false},
+	})
+	db := New(compute.NewParser(trie), dstorage, nil, identity.NewNamespaceStorage(dstorage), nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	result := db.HandleQuery(ctx, "session", compute.CommandMSETNX.Make("a", "1", "exists", "new"))
+	assert.Equal(t, WrapOK("false"), result)
+	_, err = dstorage.Get(ctx, storage.MakeKey(models.DefaultNameSpace, "a"))
+	assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+	val, err := dstorage.Get(ctx, storage.MakeKey(models.DefaultNameSpace, "exists"))
+	require.NoError(t, err)
+	assert.Equal(t, "old", val)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandMSETNX.Make("a", "1", "b", "2"))
+	assert.Equal(t, WrapOK("true"), result)
+	for key, expected := range map[string]string{"a": "1", "b": "2"} {
+		val, err := dstorage.Get(ctx, storage.MakeKey(models.DefaultNameSpace, key))
+		require.NoError(t, err)
+		assert.Equal(t, expected, val)
+	}
+
+	result = db.HandleQuery(ctx, "session", compute.CommandMSETNX.Make("c", "3", "d"))
+	assert.Equal(t, WrapError(fmt.Errorf("%w: the key 'd' has no value", compute.ErrInvalidSyntax)), result)
+
+	result = db.HandleQuery(ctx, "reader", compute.CommandMSETNX.Make("c", "3"))
+	assert.Equal(t, WrapError(ErrPermissionDenied), result)
+}
+
+func TestDatabase_SetValueValidation(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	nsStorage := identity.NewNamespaceStorage(dstorage)
+	sessions := identity.NewSessionStorage(0)
+	for _, namespace := range []string{"docs", "codes"} {
+		require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: namespace}))
+		require.NoError(t, sessions.Create(namespace, &models.User{
+			Username:   namespace,
+			ActiveRole: models.Role{Name: namespace, Get: true, Set: true, Namespace: namespace},
+		}))
+	}
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user", ActiveRole: models.DefaultRole}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandSET, map[string]compute.CommandParam{
+		compute.KeyArg:        {Required: true, Positional: true, Position: 0},
+		compute.ValueArg:      {Required: true, Positional: true, Position: 1},
+		compute.NSArg:         {Required: false, Positional: false},
+		compute.Base64FlagArg: {Required: false, Flag: true},
+	})
+	trie.Insert(compute.CommandMSETNX, map[string]compute.CommandParam{
+		compute.PairsArg: {Required: true, Positional: true, Position: 0, Variadic: true},
+		compute.NSArg:    {Required: false, Positional: false},
+	})
+
+	codes, err := ParseValueSchema("regex:[A-Z]{3}-[0-9]+")
+	require.NoError(t, err)
+	db := New(compute.NewParser(trie), dstorage, nil, nsStorage, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"},
+		WithValueValidators(map[string]ValueValidator{"docs": JSONValidator{}, "codes": codes}))
+
+	t.Run("JSON-only namespace", func(t *testing.T) {
+		result := db.HandleQuery(ctx, "docs", compute.CommandSET.Make("doc", `{\"a\":1}`))
+		assert.Equal(t, okPrefix, result)
+
+		result = db.HandleQuery(ctx, "docs", compute.CommandSET.Make("doc", `{\"a\":`))
+		assert.Equal(t, WrapError(ErrValueValidation), result)
+		val, err := dstorage.Get(ctx, storage.MakeKey("docs", "doc"))
+		require.NoError(t, err)
+		assert.Equal(t, `{"a":1}`, val)
+
+		// The decoded value of the binary set is validated.
+		result = db.HandleQuery(ctx, "docs", compute.CommandSET.Make("doc", "bm90IGpzb24=", compute.Base64FlagArg))
+		assert.Equal(t, WrapError(ErrValueValidation), result)
+
+		result = db.HandleQuery(ctx, "docs", compute.CommandMSETNX.Make("one", "1", "two", "two"))
+		assert.Equal(t, WrapError(ErrValueValidation), result)
+		_, err = dstorage.Get(ctx, storage.MakeKey("docs", "one"))
+		assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+	})
+
+	t.Run("regex-constrained namespace", func(t *testing.T) {
+		result := db.HandleQuery(ctx, "codes", compute.CommandSET.Make("code", "ABC-123"))
+		assert.Equal(t, okPrefix, result)
+
+		for _, value := range []string{"abc-123", "ABC-", "XABC-123"} {
+			result = db.HandleQuery(ctx, "codes", compute.CommandSET.Make("code", value))
+			assert.Equal(t, WrapError(ErrValueValidation), result, value)
+		}
+	})
+
+	t.Run("namespace without validator", func(t *testing.T) {
+		result := db.HandleQuery(ctx, "session", compute.CommandSET.Make("any", "plain"))
+		assert.Equal(t, okPrefix, result)
+	})
+}
+
+func TestDatabase_Dump(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	key := storage.MakeKey(models.DefaultNameSpace, "key")
+	before := time.Now()
+	require.NoError(t, dstorage.Set(ctxutil.InjectTTL(ctx, "1h"), key, "hello world"))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("admin", &models.User{Username: "admin", ActiveRole: models.DefaultRole}))
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user", ActiveRole: models.DefaultRole}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandDUMP, map[string]compute.CommandParam{
+		compute.KeyArg: {Required: true, Positional: true, Position: 0},
+		compute.NSArg:  {Required: false, Positional: false},
+	})
+	db := New(compute.NewParser(trie), dstorage, nil, identity.NewNamespaceStorage(dstorage), nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	res, ok := CutOK(db.HandleQuery(ctx, "admin", compute.CommandDUMP.Make("key")))
+	require.True(t, ok)
+
+	var dump EntryDump
+	require.NoError(t, json.Unmarshal([]byte(res), &dump))
+	assert.Equal(t, key, dump.Key)
+	assert.Equal(t, "hello world", dump.Value)
+	assert.Equal(t, []byte("hello world"), dump.Raw)
+	assert.GreaterOrEqual(t, dump.Expiry, before.Add(time.Hour).Unix())
+	assert.LessOrEqual(t, dump.Expiry, time.Now().Add(time.Hour).Unix())
+	assert.Nil(t, dump.LastAccess, "the access is not tracked")
+
+	result := db.HandleQuery(ctx, "admin", compute.CommandDUMP.Make("missing"))
+	assert.Equal(t, WrapError(storage.ErrKeyNotFound), result)
+
+	result = db.HandleQuery(ctx, "session", compute.CommandDUMP.Make("key"))
+	assert.Equal(t, WrapError(ErrPermissionDenied), result)
+}
+
+func TestDatabase_PubSub(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	sessions := identity.NewSessionStorage(0)
+	for _, session := range []string{"publisher", "first", "second"} {
+		require.NoError(t, sessions.Create(session, &models.User{Username: session, ActiveRole: models.DefaultRole}))
+	}
+	require.NoError(t, sessions.Create("writer", &models.User{
+		Username:   "writer",
+		ActiveRole: models.Role{Name: "writer", Set: true, Namespace: models.DefaultNameSpace},
+	}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandPUBLISH, map[string]compute.CommandParam{
+		compute.ChannelArg: {Required: true, Positional: true, Position: 0},
+		compute.MessageArg: {Required: true, Positional: true, Position: 1},
+		compute.NSArg:      {Required: false, Positional: false},
+	})
+	trie.Insert(compute.CommandSUBSCRIBE, map[string]compute.CommandParam{
+		compute.ChannelArg: {Required: true, Positional: true, Position: 0},
+		compute.NSArg:      {Required: false, Positional: false},
+	})
+	trie.Insert(compute.CommandLISTEN, map[string]compute.CommandParam{
+		compute.ChannelArg: {Required: true, Positional: true, Position: 0},
+		compute.NSArg:      {Required: false, Positional: false},
+	})
+	db := New(compute.NewParser(trie), dstorage, nil, identity.NewNamespaceStorage(dstorage), nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	for _, session := range []string{"first", "second"} {
+		assert.Equal(t, okPrefix, db.HandleQuery(ctx, session, compute.CommandSUBSCRIBE.Make("events")))
+	}
+
+	// Both subscribers are waiting for the message when it's published.
+	received := make(chan string, 2)
+	for _, session := range []string{"first", "second"} {
+		go func() { received <- db.HandleQuery(ctx, session, compute.CommandLISTEN.Make("events")) }()
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	assert.Equal(t, WrapOKType(ContentInt, "2"), db.HandleQuery(ctx, "publisher", compute.CommandPUBLISH.Make("events", "deployed")))
+	for range 2 {
+		select {
+		case result := <-received:
+			assert.Equal(t, WrapOKType(ContentList, `["deployed"]`), result)
+		case <-time.After(time.Second):
+			t.Fatal("message is not delivered")
+		}
+	}
+
+	// The publisher is not subscribed, the messages are not persisted.
+	result := db.HandleQuery(ctx, "publisher", compute.CommandLISTEN.Make("events"))
+	assert.Equal(t, WrapError(pubsub.ErrNotSubscribed), result)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, WrapOKType(ContentList, "[]"), db.HandleQuery(timeoutCtx, "first", compute.CommandLISTEN.Make("events")))
+
+	// The logout ends the subscriptions of the session.
+	db.Logout(ctx, "second")
+	assert.Equal(t, WrapOKType(ContentInt, "1"), db.HandleQuery(ctx, "publisher", compute.CommandPUBLISH.Make("events", "again")))
+
+	result = db.HandleQuery(ctx, "writer", compute.CommandSUBSCRIBE.Make("events"))
+	assert.Equal(t, WrapError(ErrPermissionDenied), result)
+}
+
+func TestDatabase_GetDefault(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey(models.DefaultNameSpace, "present"), "stored"))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user", ActiveRole: models.DefaultRole}))
+	require.NoError(t, sessions.Create("reader", &models.User{
+		Username:   "reader",
+		ActiveRole: models.Role{Name: "reader", Get: true, Namespace: models.DefaultNameSpace},
+	}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandGET, map[string]compute.CommandParam{
+		compute.KeyArg:            {Required: true, Positional: true, Position: 0},
+		compute.NSArg:             {Required: false, Positional: false},
+		compute.DefaultArg:        {Required: false, Positional: false},
+		compute.SetDefaultFlagArg: {Required: false, Flag: true},
+		compute.Base64FlagArg:     {Required: false, Flag: true},
+	})
+	db := New(compute.NewParser(trie), dstorage, nil, identity.NewNamespaceStorage(dstorage), nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	missing := storage.MakeKey(models.DefaultNameSpace, "missing")
+
+	t.Run("present key ignores the default", func(t *testing.T) {
+		result := db.HandleQuery(ctx, "session", compute.CommandGET.Make("present", compute.DefaultArg, "fallback"))
+		assert.Equal(t, WrapOK("stored"), result)
+	})
+
+	t.Run("absent key returns the default without storing it", func(t *testing.T) {
+		result := db.HandleQuery(ctx, "reader", compute.CommandGET.Make("missing", compute.DefaultArg, "fallback"))
+		assert.Equal(t, WrapOK("fallback"), result)
+		_, err := dstorage.Get(ctx, missing)
+		assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+
+		result = db.HandleQuery(ctx, "session", compute.CommandGET.Make("missing",
+			compute.DefaultArg, base64.StdEncoding.EncodeToString([]byte("binary")), compute.Base64FlagArg))
+		assert.Equal(t, WrapOK(base64.StdEncoding.EncodeToString([]byte("binary"))), result)
+	})
+
+	t.Run("setdefault stores the default", func(t *testing.T) {
+		result := db.HandleQuery(ctx, "reader", compute.CommandGET.Make("missing",
+			compute.DefaultArg, "fallback", compute.SetDefaultFlagArg))
+		assert.Equal(t, WrapError(ErrPermissionDenied), result)
+
+		result = db.HandleQuery(ctx, "session", compute.CommandGET.Make("missing",
+			compute.DefaultArg, "fallback", compute.SetDefaultFlagArg))
+		assert.Equal(t, WrapOK("fallback"), result)
+		val, err := dstorage.Get(ctx, missing)
+		require.NoError(t, err)
+		assert.Equal(t, "fallback", val)
+
+		// The stored default is returned instead of the new one.
+		result = db.HandleQuery(ctx, "session", compute.CommandGET.Make("missing",
+			compute.DefaultArg, "other", compute.SetDefaultFlagArg))
+		assert.Equal(t, WrapOK("fallback"), result)
+	})
+}
+
+func TestDatabase_SetEX(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	nsStorage := identity.NewNamespaceStorage(dstorage)
+	require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: "ns1"}))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("writer", &models.User{
+		Username:   "writer",
+		ActiveRole: models.Role{Get: true, Set: true, Namespace: "ns1"},
+	}))
+	require.NoError(t, sessions.Create("reader", &models.User{
+		Username:   "reader",
+		ActiveRole: models.Role{Get: true, Namespace: "ns1"},
+	}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandSETEX, map[string]compute.CommandParam{
+		compute.KeyArg:     {Required: true, Positional: true, Position: 0},
+		compute.SecondsArg: {Required: true, Positional: true, Position: 1},
+		compute.ValueArg:   {Required: true, Positional: true, Position: 2},
+		compute.NSArg:      {},
+	})
+
+	db := New(compute.NewParser(trie), dstorage, nil, nsStorage, identity.NewRolesStorage(dstorage), sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	result := db.HandleQuery(ctx, "writer", compute.CommandSETEX.Make("key", "10", "value", compute.NSArg, "ns1"))
+	assert.Equal(t, okPrefix, result)
+
+	value, err := dstorage.Get(ctx, storage.MakeKey("ns1", "key"))
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	// The key expires within the seconds of the command.
+	assert.Empty(t, dstorage.Expiring(storage.MakeKey("ns1", ""), 5*time.Second))
+	assert.Equal(t, []string{"key"}, dstorage.Expiring(storage.MakeKey("ns1", ""), 11*time.Second))
+
+	for _, seconds := range []string{"0", "-1", "10s", "ten"} {
+		result = db.HandleQuery(ctx, "writer", compute.CommandSETEX.Make("key", seconds, "value", compute.NSArg, "ns1"))
+		assert.Equal(t, WrapError(fmt.Errorf("%w: invalid expire time", compute.ErrInvalidSyntax)), result, seconds)
+	}
+
+	result = db.HandleQuery(ctx, "reader", compute.CommandSETEX.Make("key", "10", "value", compute.NSArg, "ns1"))
+	assert.Equal(t, WrapError(ErrPermissionDenied), result)
+}
+
+func TestDatabase_DelPrefix(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	nsStorage := identity.NewNamespaceStorage(dstorage)
+	for _, namespace := range []string{"ns1", "ns2"} {
+		require.NoError(t, nsStorage.Save(ctx, &models.Namespace{Name: namespace}))
+	}
+
+	for _, key := range []string{"session:1", "session:2", "session:3", "user:1", "sessions"} {
+		require.NoError(t, dstorage.Set(ctx, storage.MakeKey("ns1", key), "1"))
+	}
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey("ns2", "session:1"), "1"))
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("deleter", &models.User{
+		Username:   "deleter",
+		ActiveRole: models.Role{Del: true, Namespace: "ns1"},
+	}))
+	require.NoError(t, sessions.Create("writer", &models.User{
+		Username:   "writer",
+		ActiveRole: models.Role{Get: true, Set: true, Namespace: "ns1"},
+	}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandDELPREFIX, map[string]compute.CommandParam{
+		compute.PrefixArg: {Required: true, Positional: true, Position: 0},
+		compute.NSArg:     {},
+	})
+
+	db := New(compute.NewParser(trie), dstorage, nil, nsStorage, identity.NewRolesStorage(dstorage), sessions,
+		&config.RootConfig{Username: "admin", Password: "password"})
+
+	result := db.HandleQuery(ctx, "writer", compute.CommandDELPREFIX.Make("session:", compute.NSArg, "ns1"))
+	assert.Equal(t, WrapError(ErrPermissionDenied), result)
+
+	result = db.HandleQuery(ctx, "deleter", compute.CommandDELPREFIX.Make(`""`, compute.NSArg, "ns1"))
+	assert.Equal(t, WrapError(fmt.Errorf("%w: empty prefix", compute.ErrInvalidSyntax)), result)
+
+	result = db.HandleQuery(ctx, "deleter", compute.CommandDELPREFIX.Make("session:", compute.NSArg, "ns1"))
+	assert.Equal(t, WrapOKType(ContentInt, "3"), result)
+
+	// Only the keys of the namespace starting with the prefix are deleted.
+	for _, key := range []string{"user:1", "sessions"} {
+		_, err := dstorage.Get(ctx, storage.MakeKey("ns1", key))
+		assert.NoError(t, err, key)
+	}
+	_, err = dstorage.Get(ctx, storage.MakeKey("ns2", "session:1"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, dstorage.CountByPrefix(storage.MakeKey("ns1", "")))
+
+	result = db.HandleQuery(ctx, "deleter", compute.CommandDELPREFIX.Make("session:", compute.NSArg, "ns1"))
+	assert.Equal(t, WrapOKType(ContentInt, "0"), result)
+
+	result = db.HandleQuery(ctx, "deleter", compute.CommandDELPREFIX.Make("session:", compute.NSArg, "ns2"))
+	assert.Equal(t, WrapError(ErrNamespaceNotAccessible), result)
 }
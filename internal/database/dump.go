@@ -0,0 +1,121 @@
+package database
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/neekrasov/kvdb/internal/database/compression"
+	"github.com/neekrasov/kvdb/internal/database/storage/snapshot"
+)
+
+// ErrInvalidDump - is returned when the imported dump is malformed or has an unsupported version.
+var ErrInvalidDump = errors.New("invalid dump")
+
+const (
+	dumpVersion byte = 1
+	// dumpCompression - codec the exported frames are compressed with.
+	dumpCompression = compression.Gzip
+)
+
+// dumpEncoding - encoding of the dump in the export and import commands, the dump is sent as a single argument.
+var dumpEncoding = base64.StdEncoding
+
+// encodeDump - encodes the entries into the dump "<version><codec len><codec><frames>", the frames are compressed
+// with the codec. Every entry is framed as "<key len><key><value len><value><ttl>" with varint lengths and ttl.
+func encodeDump(entries []snapshot.Entry) (string, error) {
+	var frames bytes.Buffer
+	for _, entry := range entries {
+		frames.Write(binary.AppendUvarint(nil, uint64(len(entry.Key))))
+		frames.WriteString(entry.Key)
+		frames.Write(binary.AppendUvarint(nil, uint64(len(entry.Value))))
+		frames.WriteString(entry.Value)
+		frames.Write(binary.AppendVarint(nil, entry.TTL))
+	}
+
+	compressor, err := compression.New(string(dumpCompression))
+	if err != nil {
+		return "", err
+	}
+
+	compressed, err := compressor.Compress(frames.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("failed to compress dump: %w", err)
+	}
+
+	dump := []byte{dumpVersion, byte(len(dumpCompression))}
+	dump = append(dump, dumpCompression...)
+	dump = append(dump, compressed...)
+
+	return dumpEncoding.EncodeToString(dump), nil
+}
+
+// decodeDump - decodes the entries of the dump created by encodeDump.
+func decodeDump(data string) ([]snapshot.Entry, error) {
+	dump, err := dumpEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDump, err)
+	}
+
+	if len(dump) < 2 || dump[0] != dumpVersion || len(dump) < 2+int(dump[1]) {
+		return nil, ErrInvalidDump
+	}
+
+	codec, compressed := dump[2:2+int(dump[1])], dump[2+int(dump[1]):]
+	compressor, err := compression.New(string(codec))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDump, err)
+	}
+
+	frames, err := compressor.Decompress(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDump, err)
+	}
+
+	var (
+		entries []snapshot.Entry
+		reader  = bytes.NewReader(frames)
+	)
+	for reader.Len() > 0 {
+		key, err := readFrame(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := readFrame(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		ttl, err := binary.ReadVarint(reader)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidDump, err)
+		}
+
+		entries = append(entries, snapshot.Entry{Key: key, Value: value, TTL: ttl})
+	}
+
+	return entries, nil
+}
+
+// readFrame - reads the length-prefixed string of the dump.
+func readFrame(reader *bytes.Reader) (string, error) {
+	size, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidDump, err)
+	}
+
+	if size > uint64(reader.Len()) {
+		return "", fmt.Errorf("%w: %w", ErrInvalidDump, io.ErrUnexpectedEOF)
+	}
+
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(reader, frame); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidDump, err)
+	}
+
+	return string(frame), nil
+}
@@ -2,10 +2,13 @@ package database
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,14 +18,24 @@ import (
 	"github.com/neekrasov/kvdb/internal/database/storage"
 	"github.com/neekrasov/kvdb/pkg/ctxutil"
 	"github.com/neekrasov/kvdb/pkg/logger"
+	"github.com/neekrasov/kvdb/pkg/sizeutil"
 	"go.uber.org/zap"
 )
 
+// defaultScanCount - number of keys returned by the scan command when the count is not set.
+const defaultScanCount = 10
+
 var (
 	ErrInvalidOperation       = errors.New("invalid operation")
 	ErrAuthenticationRequired = errors.New("authentication required")
 	ErrPermissionDenied       = errors.New("permission denied")
+	ErrNamespaceNotAccessible = errors.New("namespace not accessible")
+	ErrSystemNamespace        = errors.New("system namespace cannot be modified")
+	ErrInvalidDefaultTTL      = errors.New("default ttl must be positive")
 	ErrEmptyResult            = errors.New("empty result")
+	ErrSessionNotFound        = errors.New("session not found")
+	ErrKillOwnSession         = errors.New("cannot kill own session")
+	ErrRoleNotAssigned        = errors.New("role not assigned")
 )
 
 // HandleQuery -  processes a user query by parsing and executing the corresponding command.
@@ -31,6 +44,15 @@ func (db *Database) HandleQuery(ctx context.Context, sessionID string, query str
 		return WrapError(ctx.Err())
 	}
 
+	if db.limiter != nil && !db.limiter.allow(sessionID, time.Now()) {
+		logger.Debug("rate limit exceeded", zap.String("session", sessionID))
+		return WrapError(ErrRateLimitExceeded)
+	}
+
+	if isLoginQuery(query) {
+		return db.relogin(ctx, sessionID, query)
+	}
+
 	session, err := db.sessions.Get(sessionID)
 	if err != nil {
 		logger.Debug("get current session failed", zap.Error(err),
@@ -38,6 +60,10 @@ func (db *Database) HandleQuery(ctx context.Context, sessionID string, query str
 		return WrapError(fmt.Errorf("get current session failed: %w", err))
 	}
 
+	if explained, ok := cutExplainQuery(query); ok {
+		return db.explain(explained)
+	}
+
 	cmd, err := db.parser.Parse(query)
 	if err != nil {
 		logger.Debug(
@@ -63,7 +89,20 @@ func (db *Database) HandleQuery(ctx context.Context, sessionID string, query str
 	}
 
 	ctx = ctxutil.InjectSessionID(ctx, sessionID)
+	start := time.Now()
 	result := handler.Func(ctx, session.User, cmd.Args)
+	if db.slowQueryThreshold > 0 {
+		if elapsed := time.Since(start); elapsed >= db.slowQueryThreshold {
+			db.slowCommands.record(elapsed)
+			logger.Warn("slow query",
+				zap.Duration("duration", elapsed),
+				zap.Stringer("cmd_type", cmd.Type),
+				zap.Int("request_size", len(query)),
+				zap.Int("response_size", len(result)),
+				zap.String("session", sessionID))
+		}
+	}
+
 	logger.Info("operation executed",
 		zap.Stringer("cmd_type", cmd.Type),
 		zap.Any("args", cmd.Args),
@@ -83,8 +122,26 @@ func (db *Database) Login(ctx context.Context, sessionID string, query string) (
 		return nil, fmt.Errorf("parse input failed: %w", err)
 	}
 
-	var user *models.User
-	if cmd.Type == compute.CommandAUTH {
+	user, err := db.authenticate(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = db.sessions.Create(sessionID, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// authenticate - returns the user authenticated by the login command.
+func (db *Database) authenticate(ctx context.Context, cmd *compute.Command) (*models.User, error) {
+	var (
+		user *models.User
+		err  error
+	)
+	switch cmd.Type {
+	case compute.CommandAUTH:
 		username := cmd.Args[compute.UsernameArg]
 		password := cmd.Args[compute.PasswordArg]
 
@@ -92,26 +149,127 @@ func (db *Database) Login(ctx context.Context, sessionID string, query string) (
 		if err != nil {
 			return nil, err
 		}
+	case compute.CommandLOGINTOKEN:
+		username, err := db.tokens.verify(cmd.Args[compute.TokenArg], time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		// The user is loaded again, so a deleted user can't log in with a previously issued token.
+		user, err = db.userStorage.Get(ctx, username)
+		if err != nil {
+			return nil, identity.ErrAuthenticationFailed
+		}
 	}
 
 	if user == nil {
 		return nil, ErrAuthenticationRequired
 	}
 
-	if err = db.sessions.Create(sessionID, user); err != nil {
-		return nil, err
+	return user, nil
+}
+
+// relogin - authenticates the already connected client, the current session is replaced
+// only if the authentication succeeds.
+func (db *Database) relogin(ctx context.Context, sessionID string, query string) string {
+	cmd, err := db.parser.Parse(query)
+	if err != nil {
+		logger.Debug("parse query failed", zap.Error(err), zap.String("session", sessionID))
+		return WrapError(fmt.Errorf("parse input failed: %w", err))
 	}
 
-	return user, nil
+	user, err := db.authenticate(ctx, cmd)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	db.sessions.Delete(sessionID)
+	db.broker.Unsubscribe(sessionID)
+	if err := db.sessions.Create(sessionID, user); err != nil {
+		return WrapError(err)
+	}
+
+	logger.Info("session user changed",
+		zap.String("username", user.Username), zap.String("session", sessionID))
+
+	return WrapOK("authentication successful")
+}
+
+// explain - parses the query without executing it and returns the parsed command in JSON.
+func (db *Database) explain(query string) string {
+	cmd, err := db.parser.Parse(query)
+	if err != nil {
+		return WrapError(fmt.Errorf("parse input failed: %w", err))
+	}
+
+	args := cmd.Args
+	if args == nil {
+		args = make(map[string]string)
+	}
+
+	res, err := json.Marshal(ExplainResult{Type: cmd.Type, Args: args})
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOKType(ContentJSON, string(res))
+}
+
+// cutExplainQuery - returns the query following the explain command.
+func cutExplainQuery(query string) (string, bool) {
+	name, rest, _ := strings.Cut(strings.TrimSpace(query), " ")
+	if !strings.EqualFold(name, compute.CommandEXPLAIN.String()) {
+		return "", false
+	}
+
+	return rest, true
+}
+
+// isLoginQuery - checks whether the query is a login command.
+func isLoginQuery(query string) bool {
+	name, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	return strings.EqualFold(name, compute.CommandAUTH.String()) ||
+		strings.EqualFold(name, compute.CommandLOGINTOKEN.String())
 }
 
 // Logout - logs out the user by deleting their session token.
 func (db *Database) Logout(ctx context.Context, sessionID string) string {
 	db.sessions.Delete(sessionID)
+	db.broker.Unsubscribe(sessionID)
 
 	return okPrefix
 }
 
+// Disconnect - releases the session of the closed connection, including its rate limit state.
+// The rate limit state outlives the logout command, so the logout does not refill the limit.
+func (db *Database) Disconnect(ctx context.Context, sessionID string) {
+	db.Logout(ctx, sessionID)
+	if db.limiter != nil {
+		db.limiter.forget(sessionID)
+	}
+}
+
+// Health - reports the readiness of the database, it does not require authentication,
+// so it is also used by the health probe of the server.
+func (db *Database) Health() string {
+	readiness := db.storage.Readiness()
+	res, err := json.Marshal(Health{
+		Ready:         readiness.WALRecovered && readiness.ReplicaSynced,
+		WALRecovered:  readiness.WALRecovered,
+		ReplicaSynced: readiness.ReplicaSynced,
+	})
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOK(HealthyStatus + " " + string(res))
+}
+
+// token - executes the token command to issue a session token of the current user.
+func (db *Database) token(_ context.Context, user *models.User, _ Args) string {
+	return WrapOK(db.tokens.issue(user.Username, time.Now()))
+}
+
 // help - executes the help command to print information about commands.
 func (db *Database) help(ctx context.Context, usr *models.User, _ Args) string {
 	if usr.IsAdmin(db.cfg) {
@@ -121,7 +279,7 @@ func (db *Database) help(ctx context.Context, usr *models.User, _ Args) string {
 	return WrapOK(compute.UserHelpText)
 }
 
-// del - executes the del command to remove a key from the storage
+// del - executes the del command to remove one or several keys from the storage.
 func (db *Database) del(ctx context.Context, user *models.User, args Args) string {
 	namespace, err := db.parseNS(ctx, user, args)
 	if err != nil {
@@ -130,323 +288,1118 @@ func (db *Database) del(ctx context.Context, user *models.User, args Args) strin
 
 	role := db.checkPermissions(ctx, user, namespace)
 	if role == nil || !role.Del {
-		return WrapError(ErrPermissionDenied)
+		return WrapError(accessError(role))
+	}
+
+	if keys := compute.ArgValues(args, compute.KeyArg); len(keys) > 1 {
+		for i, key := range keys {
+			keys[i] = db.makeKey(namespace, key)
+		}
+
+		deleted, err := db.storage.DelMany(ctx, keys)
+		if err != nil {
+			return WrapError(err)
+		}
+
+		return WrapOKType(ContentInt, strconv.Itoa(deleted))
 	}
 
-	key := storage.MakeKey(namespace, args["key"])
-	if err := db.storage.Del(ctx, key); err != nil {
+	key := db.makeKey(namespace, args["key"])
+	existed, err := db.storage.Del(ctx, key)
+	if err != nil {
 		return WrapError(err)
 	}
 
+	// The count is returned only on request, the clients expecting the plain response are not broken.
+	if _, ok := args[compute.CountArg]; ok {
+		if existed {
+			return WrapOKType(ContentInt, "1")
+		}
+		return WrapOKType(ContentInt, "0")
+	}
+
 	return okPrefix
 }
 
-// get - executes the get command to retrieve the value of a key from the storage.
-func (db *Database) get(ctx context.Context, user *models.User, args Args) string {
+// delPrefix - executes the delprefix command to delete the keys of the namespace starting with the prefix.
+func (db *Database) delPrefix(ctx context.Context, user *models.User, args Args) string {
 	namespace, err := db.parseNS(ctx, user, args)
 	if err != nil {
 		return WrapError(err)
 	}
 
 	role := db.checkPermissions(ctx, user, namespace)
-	if role == nil || !role.Get {
-		return WrapError(ErrPermissionDenied)
+	if role == nil || !role.Del {
+		return WrapError(accessError(role))
 	}
 
-	key := storage.MakeKey(namespace, args["key"])
-	val, err := db.storage.Get(ctx, key)
+	// The empty prefix matches all keys of the namespace, the flush ns command is meant for it.
+	prefix := args[compute.PrefixArg]
+	if prefix == "" {
+		return WrapError(fmt.Errorf("%w: empty prefix", compute.ErrInvalidSyntax))
+	}
+
+	deleted, err := db.storage.DelByPrefix(ctx, db.makeKey(namespace, prefix))
 	if err != nil {
 		return WrapError(err)
 	}
 
-	return WrapOK(val)
+	return WrapOKType(ContentInt, strconv.Itoa(deleted))
 }
 
-// set - executes the SET command to store a key-value pair in the storage.
-func (db *Database) set(ctx context.Context, user *models.User, args Args) string {
+// get - executes the get command to retrieve the value of a key from the storage.
+func (db *Database) get(ctx context.Context, user *models.User, args Args) string {
 	namespace, err := db.parseNS(ctx, user, args)
 	if err != nil {
 		return WrapError(err)
 	}
 
 	role := db.checkPermissions(ctx, user, namespace)
-	if role == nil || !role.Set {
-		return WrapError(ErrPermissionDenied)
+	if role == nil || !role.Get {
+		return WrapError(accessError(role))
 	}
 
-	if val, ok := args[compute.TTLArg]; ok {
-		ctx = ctxutil.InjectTTL(ctx, val)
+	key := db.makeKey(namespace, args["key"])
+	val, err := db.storage.Get(ctx, key)
+	if errors.Is(err, storage.ErrKeyNotFound) && db.missHandler != nil {
+		val, err = db.loadMissing(ctx, namespace, args["key"], err)
 	}
 
-	key := storage.MakeKey(namespace, args[compute.KeyArg])
-	if err := db.storage.Set(ctx, key, args[compute.ValueArg]); err != nil {
+	_, binary := args[compute.Base64FlagArg]
+	if def, ok := args[compute.DefaultArg]; ok && errors.Is(err, storage.ErrKeyNotFound) {
+		_, store := args[compute.SetDefaultFlagArg]
+		if store && !role.Set {
+			return WrapError(ErrPermissionDenied)
+		}
+
+		val, err = db.defaultValue(ctx, namespace, key, def, binary, store)
+	}
+	if err != nil {
 		return WrapError(err)
 	}
 
-	return okPrefix
+	if binary {
+		val = base64.StdEncoding.EncodeToString([]byte(val))
+	}
+
+	return WrapOK(val)
 }
 
-// help - executes the help command to print information about commands.
-func (db *Database) listSessions(ctx context.Context, _ *models.User, _ Args) string {
-	sessions := db.sessions.List()
-	if len(sessions) == 0 {
-		return WrapError(ErrEmptyResult)
+// defaultValue - returns the default of the missing key, the base64-encoded default is decoded.
+// The stored default is set only if the key is still missing, otherwise the value stored meanwhile is returned.
+func (db *Database) defaultValue(ctx context.Context, namespace, key, def string, binary, store bool) (string, error) {
+	if binary {
+		decoded, err := base64.StdEncoding.DecodeString(def)
+		if err != nil {
+			return "", fmt.Errorf("%w: default is not base64-encoded", compute.ErrInvalidSyntax)
+		}
+		def = string(decoded)
 	}
 
-	// TODO: need to optimize
-	res, err := json.Marshal(sessions)
+	if !store {
+		return def, nil
+	}
+
+	if err := db.validateValue(namespace, def); err != nil {
+		return "", err
+	}
+
+	if ttl := db.namespaceTTL(ctx, namespace); ttl > 0 {
+		ctx = ctxutil.InjectTTL(ctx, ttl.String())
+	}
+
+	stored, err := db.storage.MSetNX(ctx, []string{key}, []string{def})
 	if err != nil {
-		return WrapError(err)
+		return "", err
+	}
+	if !stored {
+		return db.storage.Get(ctx, key)
 	}
 
-	return WrapOK(string(res))
+	return def, nil
 }
 
-// ns - executes the ns command to list namespaces.
-func (db *Database) ns(ctx context.Context, user *models.User, _ Args) string {
-	var nsList []string
-	if user.IsAdmin(db.cfg) {
-		namepaces, err := db.namespaceStorage.List(ctx)
-		if err != nil {
-			return WrapError(err)
-		}
+// loadMissing - loads the missing key with the miss handler and stores it with the default TTL of the namespace.
+// The miss error is returned if the handler did not find the key. A value rejected by the namespace validator
+// is neither stored nor returned. A failed store is only logged, the loaded value is returned anyway.
+func (db *Database) loadMissing(ctx context.Context, namespace, name string, miss error) (string, error) {
+	val, found, err := db.missHandler.Load(ctx, namespace, name)
+	if err != nil {
+		return "", fmt.Errorf("load missing key failed: %w", err)
+	}
+	if !found {
+		return "", miss
+	}
 
-		nsList = namepaces
-	} else {
-		namepaces := make([]string, 0, len(user.Roles))
-		for _, roleName := range user.Roles {
-			role, err := db.rolesStorage.Get(ctx, roleName)
-			if err != nil {
-				continue
-			}
+	if err := db.validateValue(namespace, val); err != nil {
+		return "", fmt.Errorf("load missing key failed: %w", err)
+	}
 
-			namepaces = append(namepaces, role.Namespace)
-		}
+	if ttl := db.namespaceTTL(ctx, namespace); ttl > 0 {
+		ctx = ctxutil.InjectTTL(ctx, ttl.String())
+	}
 
-		nsList = namepaces
+	if err := db.storage.Set(ctx, db.makeKey(namespace, name), val); err != nil {
+		logger.Warn("store loaded key failed", zap.String("namespace", namespace),
+			zap.String("key", name), zap.Error(err))
 	}
 
-	// TODO: need to optimize
-	res, err := json.Marshal(nsList)
+	return val, nil
+}
+
+// valueType - executes the type command to report how the value of a key is interpreted.
+// The engine stores strings, so the values parsed as integers are reported as int.
+func (db *Database) valueType(ctx context.Context, user *models.User, args Args) string {
+	namespace, err := db.parseNS(ctx, user, args)
 	if err != nil {
 		return WrapError(err)
 	}
 
-	return WrapOK(string(res))
-}
+	role := db.checkPermissions(ctx, user, namespace)
+	if role == nil || !role.Get {
+		return WrapError(accessError(role))
+	}
 
-// createUser - executes the create user command to create a new user.
-func (db *Database) createUser(ctx context.Context, _ *models.User, args Args) string {
-	username := args[compute.UsernameArg]
-	password := args[compute.PasswordArg]
+	key := db.makeKey(namespace, args[compute.KeyArg])
+	val, err := db.storage.Get(ctx, key)
+	switch {
+	case errors.Is(err, storage.ErrKeyNotFound):
+		return WrapOK(TypeNone)
+	case err != nil:
+		return WrapError(err)
+	}
+
+	if _, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return WrapOK(TypeInt)
+	}
 
-	usr, err := db.userStorage.Create(ctx, username, password)
+	return WrapOK(TypeString)
+}
+
+// lastAccess - executes the LASTACCESS command to display the time the key was last read or written.
+func (db *Database) lastAccess(ctx context.Context, user *models.User, args Args) string {
+	namespace, err := db.parseNS(ctx, user, args)
 	if err != nil {
 		return WrapError(err)
 	}
 
-	if _, err := db.userStorage.Append(ctx, usr.Username); err != nil {
+	role := db.checkPermissions(ctx, user, namespace)
+	if role == nil || !role.Get {
+		return WrapError(accessError(role))
+	}
+
+	accessed, err := db.storage.LastAccess(ctx, db.makeKey(namespace, args[compute.KeyArg]))
+	if err != nil {
 		return WrapError(err)
 	}
 
-	return okPrefix
+	return WrapOK(accessed.UTC().Format(time.RFC3339))
 }
 
-// createUser - executes the create user command to create a new user.
-func (db *Database) deleteUser(ctx context.Context, usr *models.User, args Args) string {
-	username := args[compute.UsernameArg]
-
-	if err := db.userStorage.Delete(ctx, username); err != nil {
+// set - executes the SET command to store a key-value pair in the storage.
+func (db *Database) set(ctx context.Context, user *models.User, args Args) string {
+	namespace, err := db.parseNS(ctx, user, args)
+	if err != nil {
 		return WrapError(err)
 	}
 
-	if _, err := db.userStorage.Remove(ctx, usr.Username); err != nil {
+	role := db.checkPermissions(ctx, user, namespace)
+	if role == nil || !role.Set {
+		return WrapError(accessError(role))
+	}
+
+	if val, ok := args[compute.TTLArg]; ok {
+		ctx = ctxutil.InjectTTL(ctx, val)
+	} else if ttl := db.namespaceTTL(ctx, namespace); ttl > 0 {
+		ctx = ctxutil.InjectTTL(ctx, ttl.String())
+	}
+
+	value := args[compute.ValueArg]
+	_, binary := args[compute.Base64FlagArg]
+	if binary {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return WrapError(fmt.Errorf("%w: value is not base64-encoded", compute.ErrInvalidSyntax))
+		}
+		value = string(decoded)
+	}
+
+	if err := db.validateValue(namespace, value); err != nil {
 		return WrapError(err)
 	}
 
-	return okPrefix
-}
+	key := db.makeKey(namespace, args[compute.KeyArg])
+	if _, ok := args[compute.GetFlagArg]; ok {
+		if !role.Get {
+			return WrapError(ErrPermissionDenied)
+		}
 
-// assignRole - executes the assign role command to assign a role to a user.
-func (db *Database) assignRole(ctx context.Context, _ *models.User, args Args) string {
-	username := args[compute.UsernameArg]
-	role := args[compute.RoleArg]
+		old, exists, err := db.storage.GetSet(ctx, key, value)
+		if err != nil {
+			return WrapError(err)
+		}
+		if binary && exists {
+			old = base64.StdEncoding.EncodeToString([]byte(old))
+		}
 
-	if err := db.userStorage.AssignRole(ctx, username, role); err != nil {
+		res, err := json.Marshal(PrevValue{Value: old, Exists: exists})
+		if err != nil {
+			return WrapError(err)
+		}
+
+		return WrapOKType(ContentJSON, string(res))
+	}
+
+	if err := db.storage.Set(ctx, key, value); err != nil {
 		return WrapError(err)
 	}
 
 	return okPrefix
 }
 
-// assignRole - executes the assign role command to assign a role to a user.
-func (db *Database) divestRole(ctx context.Context, _ *models.User, args Args) string {
-	username := args[compute.UsernameArg]
-	role := args[compute.RoleArg]
+// setEX - executes the SETEX command as the SET command with the ttl in seconds.
+func (db *Database) setEX(ctx context.Context, user *models.User, args Args) string {
+	seconds, err := strconv.ParseInt(args[compute.SecondsArg], 10, 64)
+	if err != nil || seconds <= 0 || seconds > math.MaxInt64/int64(time.Second) {
+		return WrapError(fmt.Errorf("%w: invalid expire time", compute.ErrInvalidSyntax))
+	}
 
-	if err := db.userStorage.AssignRole(ctx, username, role); err != nil {
-		return WrapError(err)
+	setArgs := Args{
+		compute.KeyArg:   args[compute.KeyArg],
+		compute.ValueArg: args[compute.ValueArg],
+		compute.TTLArg:   (time.Duration(seconds) * time.Second).String(),
+	}
+	if namespace, ok := args[compute.NSArg]; ok {
+		setArgs[compute.NSArg] = namespace
 	}
 
-	return okPrefix
+	return db.set(ctx, user, setArgs)
 }
 
-// users - executes the users command to list all usernames.
-func (db *Database) users(ctx context.Context, _ *models.User, _ Args) string {
-	users, err := db.userStorage.ListUsernames(ctx)
+// msetNX - executes the MSETNX command to store the key-value pairs only if none of the keys exist.
+func (db *Database) msetNX(ctx context.Context, user *models.User, args Args) string {
+	namespace, err := db.parseNS(ctx, user, args)
 	if err != nil {
 		return WrapError(err)
 	}
 
-	if len(users) == 0 {
-		return WrapError(ErrEmptyResult)
+	role := db.checkPermissions(ctx, user, namespace)
+	if role == nil || !role.Set {
+		return WrapError(accessError(role))
 	}
 
-	// TODO: need to optimize
-	res, err := json.Marshal(users)
+	pairs := compute.ArgValues(args, compute.PairsArg)
+	if len(pairs)%2 != 0 {
+		return WrapError(fmt.Errorf("%w: the key '%s' has no value", compute.ErrInvalidSyntax, pairs[len(pairs)-1]))
+	}
+
+	if ttl := db.namespaceTTL(ctx, namespace); ttl > 0 {
+		ctx = ctxutil.InjectTTL(ctx, ttl.String())
+	}
+
+	keys := make([]string, 0, len(pairs)/2)
+	values := make([]string, 0, len(pairs)/2)
+	for pair := range slices.Chunk(pairs, 2) {
+		if err := db.validateValue(namespace, pair[1]); err != nil {
+			return WrapError(err)
+		}
+		keys = append(keys, db.makeKey(namespace, pair[0]))
+		values = append(values, pair[1])
+	}
+
+	stored, err := db.storage.MSetNX(ctx, keys, values)
 	if err != nil {
 		return WrapError(err)
 	}
 
-	return WrapOK(string(res))
+	return WrapOK(strconv.FormatBool(stored))
 }
 
-func (db *Database) getUser(ctx context.Context, _ *models.User, args Args) string {
-	username := args[compute.UsernameArg]
+// validateValue - checks the value with the validator of the namespace, if it has one.
+func (db *Database) validateValue(namespace, value string) error {
+	validator, ok := db.validators[namespace]
+	if !ok {
+		return nil
+	}
 
-	user, err := db.userStorage.Get(ctx, username)
-	if err != nil {
+	if err := validator.Validate(value); err != nil {
+		logger.Debug("value validation failed",
+			zap.String("namespace", namespace), zap.Error(err))
+		return ErrValueValidation
+	}
+
+	return nil
+}
+
+// namespaceTTL - returns the default TTL of the namespace, caching it after the first lookup.
+func (db *Database) namespaceTTL(ctx context.Context, namespace string) time.Duration {
+	if ttl, ok := db.namespaceTTLs.Load(namespace); ok {
+		return ttl.(time.Duration)
+	}
+
+	var ttl time.Duration
+	ns, err := db.namespaceStorage.Get(ctx, namespace)
+	switch {
+	case err == nil:
+		ttl = ns.DefaultTTL
+	case !errors.Is(err, identity.ErrNamespaceNotFound):
+		return 0
+	}
+
+	db.namespaceTTLs.Store(namespace, ttl)
+
+	return ttl
+}
+
+// getDel - executes the getdel command to retrieve the value of a key and delete the key.
+func (db *Database) getDel(ctx context.Context, user *models.User, args Args) string {
+	namespace, err := db.parseNS(ctx, user, args)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	role := db.checkPermissions(ctx, user, namespace)
+	if role == nil || !role.Get || !role.Del {
+		return WrapError(accessError(role))
+	}
+
+	key := db.makeKey(namespace, args[compute.KeyArg])
+	val, err := db.storage.GetDel(ctx, key)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	if _, ok := args[compute.Base64FlagArg]; ok {
+		val = base64.StdEncoding.EncodeToString([]byte(val))
+	}
+
+	return WrapOK(val)
+}
+
+// renameNX - executes the renamenx command to rename a key only if the new key does not exist.
+func (db *Database) renameNX(ctx context.Context, user *models.User, args Args) string {
+	namespace, err := db.parseNS(ctx, user, args)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	role := db.checkPermissions(ctx, user, namespace)
+	if role == nil || !role.Set || !role.Del {
+		return WrapError(accessError(role))
+	}
+
+	oldKey := db.makeKey(namespace, args[compute.KeyArg])
+	newKey := db.makeKey(namespace, args[compute.NewKeyArg])
+	renamed, err := db.storage.RenameNX(ctx, oldKey, newKey)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOK(strconv.FormatBool(renamed))
+}
+
+// scan - executes the scan command to return a batch of keys of the namespace starting from the cursor.
+func (db *Database) scan(ctx context.Context, user *models.User, args Args) string {
+	namespace, err := db.parseNS(ctx, user, args)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	role := db.checkPermissions(ctx, user, namespace)
+	if role == nil || !role.Get {
+		return WrapError(accessError(role))
+	}
+
+	cursor, err := strconv.ParseUint(args[compute.CursorArg], 10, 64)
+	if err != nil {
+		return WrapError(fmt.Errorf("%w: invalid cursor", compute.ErrInvalidSyntax))
+	}
+
+	count := defaultScanCount
+	if val, ok := args[compute.CountArg]; ok {
+		count, err = strconv.Atoi(val)
+		if err != nil || count <= 0 {
+			return WrapError(fmt.Errorf("%w: invalid count", compute.ErrInvalidSyntax))
+		}
+	}
+
+	prefix := db.makeKey(namespace, "")
+	keys, next, err := db.storage.Scan(prefix, cursor, args[compute.MatchArg], count)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	res, err := json.Marshal(ScanResult{Keys: keys, Cursor: next})
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOKType(ContentJSON, string(res))
+}
+
+// expiring - executes the expiring command to list the keys of the namespace expiring within the duration.
+func (db *Database) expiring(ctx context.Context, user *models.User, args Args) string {
+	namespace, err := db.parseNS(ctx, user, args)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	role := db.checkPermissions(ctx, user, namespace)
+	if role == nil || !role.Get {
+		return WrapError(accessError(role))
+	}
+
+	within, err := time.ParseDuration(args[compute.WithinArg])
+	if err != nil || within <= 0 {
+		return WrapError(fmt.Errorf("%w: invalid duration", compute.ErrInvalidSyntax))
+	}
+
+	res, err := json.Marshal(db.storage.Expiring(db.makeKey(namespace, ""), within))
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOKType(ContentList, string(res))
+}
+
+// help - executes the help command to print information about commands.
+func (db *Database) listSessions(ctx context.Context, _ *models.User, _ Args) string {
+	sessions := db.sessions.List()
+	if len(sessions) == 0 {
+		return WrapError(ErrEmptyResult)
+	}
+
+	// TODO: need to optimize
+	res, err := json.Marshal(sessions)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOKType(ContentList, string(res))
+}
+
+// killSession - executes the kill session command to terminate a session and close its connection.
+func (db *Database) killSession(ctx context.Context, _ *models.User, args Args) string {
+	sessionID := args[compute.SessionIDArg]
+	if sessionID == ctxutil.ExtractSessionID(ctx) {
+		return WrapError(ErrKillOwnSession)
+	}
+
+	if _, err := db.sessions.Get(sessionID); err != nil {
+		return WrapError(ErrSessionNotFound)
+	}
+
+	db.sessions.Delete(sessionID)
+	if db.sessionCloser != nil {
+		db.sessionCloser(sessionID)
+	}
+
+	return okPrefix
+}
+
+// listConnections - executes the connections command to list client connections with the session users.
+func (db *Database) listConnections(ctx context.Context, _ *models.User, _ Args) string {
+	var conns []ConnInfo
+	if db.connections != nil {
+		conns = db.connections()
+	}
+	if len(conns) == 0 {
+		return WrapError(ErrEmptyResult)
+	}
+
+	usernames := make(map[string]string)
+	for _, session := range db.sessions.List() {
+		if session.User != nil {
+			usernames[session.ID] = session.User.Username
+		}
+	}
+	for i := range conns {
+		conns[i].Username = usernames[conns[i].SessionID]
+	}
+
+	res, err := json.Marshal(conns)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOKType(ContentList, string(res))
+}
+
+// ns - executes the ns command to list namespaces.
+func (db *Database) ns(ctx context.Context, user *models.User, _ Args) string {
+	var nsList []string
+	if user.IsAdmin(db.cfg) {
+		namepaces, err := db.namespaceStorage.List(ctx)
+		if err != nil {
+			return WrapError(err)
+		}
+
+		nsList = namepaces
+	} else {
+		namepaces := make([]string, 0, len(user.Roles))
+		for _, roleName := range user.Roles {
+			role, err := db.rolesStorage.Get(ctx, roleName)
+			if err != nil {
+				continue
+			}
+
+			namepaces = append(namepaces, role.Namespace)
+		}
+
+		nsList = namepaces
+	}
+
+	// TODO: need to optimize
+	res, err := json.Marshal(nsList)
+	if err != nil {
 		return WrapError(err)
 	}
 
-	// TODO: need to optimize
-	res, err := json.Marshal(user)
+	return WrapOKType(ContentList, string(res))
+}
+
+// createUser - executes the create user command to create a new user.
+func (db *Database) createUser(ctx context.Context, _ *models.User, args Args) string {
+	username := args[compute.UsernameArg]
+	password := args[compute.PasswordArg]
+	if err := models.ValidateName(username, db.keySeparator); err != nil {
+		return WrapError(err)
+	}
+
+	namespace := args[compute.NSArg]
+	if namespace != "" && !db.namespaceStorage.Exists(ctx, namespace) {
+		return WrapError(identity.ErrNamespaceNotFound)
+	}
+
+	usr, err := db.userStorage.Create(ctx, username, password, namespace)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	if _, err := db.userStorage.Append(ctx, usr.Username); err != nil {
+		return WrapError(err)
+	}
+
+	return okPrefix
+}
+
+// createUser - executes the create user command to create a new user.
+func (db *Database) deleteUser(ctx context.Context, _ *models.User, args Args) string {
+	username := args[compute.UsernameArg]
+
+	if err := db.userStorage.Delete(ctx, username); err != nil {
+		return WrapError(err)
+	}
+
+	if _, err := db.userStorage.Remove(ctx, username); err != nil {
+		return WrapError(err)
+	}
+
+	return okPrefix
+}
+
+// assignRole - executes the assign role command to assign a role to a user.
+func (db *Database) assignRole(ctx context.Context, _ *models.User, args Args) string {
+	username := args[compute.UsernameArg]
+	role := args[compute.RoleArg]
+
+	if err := db.userStorage.AssignRole(ctx, username, role); err != nil {
+		return WrapError(err)
+	}
+
+	return okPrefix
+}
+
+// divestRole - executes the divest role command to divest a role from a user.
+func (db *Database) divestRole(ctx context.Context, _ *models.User, args Args) string {
+	username := args[compute.UsernameArg]
+	role := args[compute.RoleArg]
+
+	if err := db.userStorage.DivestRole(ctx, username, role); err != nil {
+		return WrapError(err)
+	}
+
+	return okPrefix
+}
+
+// dedupRoles - executes the dedup roles command to remove duplicate roles of one or all users.
+// Returns the number of repaired users.
+func (db *Database) dedupRoles(ctx context.Context, _ *models.User, args Args) string {
+	var usernames []string
+	if username, ok := args[compute.UsernameArg]; ok {
+		usernames = []string{username}
+	} else {
+		list, err := db.userStorage.ListUsernames(ctx)
+		if err != nil && !errors.Is(err, identity.ErrEmptyUsers) {
+			return WrapError(err)
+		}
+
+		usernames = list
+	}
+
+	var repaired int
+	for _, username := range usernames {
+		changed, err := db.userStorage.DedupRoles(ctx, username)
+		if err != nil {
+			return WrapError(err)
+		}
+
+		if changed {
+			repaired++
+		}
+	}
+
+	return WrapOKType(ContentInt, strconv.Itoa(repaired))
+}
+
+// users - executes the users command to list all usernames.
+func (db *Database) users(ctx context.Context, _ *models.User, args Args) string {
+	users, err := db.userStorage.ListUsernames(ctx)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	if len(users) == 0 {
+		return WrapError(ErrEmptyResult)
+	}
+
+	users, err = paginate(users, args)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	// TODO: need to optimize
+	res, err := json.Marshal(users)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOKType(ContentList, string(res))
+}
+
+// paginate - returns the page of the list selected by the optional offset and limit args,
+// the offset past the end of the list returns an empty page.
+func paginate(list []string, args Args) ([]string, error) {
+	offset := 0
+	if val, ok := args[compute.OffsetArg]; ok {
+		var err error
+		offset, err = strconv.Atoi(val)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("%w: invalid offset", compute.ErrInvalidSyntax)
+		}
+	}
+
+	limit := len(list)
+	if val, ok := args[compute.LimitArg]; ok {
+		var err error
+		limit, err = strconv.Atoi(val)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("%w: invalid limit", compute.ErrInvalidSyntax)
+		}
+	}
+
+	if offset >= len(list) {
+		return []string{}, nil
+	}
+
+	return list[offset:min(offset+limit, len(list))], nil
+}
+
+func (db *Database) getUser(ctx context.Context, _ *models.User, args Args) string {
+	username := args[compute.UsernameArg]
+
+	user, err := db.userStorage.Get(ctx, username)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	// TODO: need to optimize
+	res, err := json.Marshal(user)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOKType(ContentJSON, string(res))
+}
+
+func (db *Database) getRole(ctx context.Context, _ *models.User, args Args) string {
+	roleName := args[compute.RoleNameArg]
+
+	role, err := db.rolesStorage.Get(ctx, roleName)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	// TODO: need to optimize
+	res, err := json.Marshal(role)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOKType(ContentJSON, string(res))
+}
+
+// createRole - executes the create role command to create a new role.
+func (db *Database) createRole(ctx context.Context, _ *models.User, args Args) string {
+	namespace := args[compute.NamespaceArg]
+	roleName := args[compute.RoleNameArg]
+	permissions := args[compute.PermissionsArg]
+	if err := models.ValidateName(roleName, db.keySeparator); err != nil {
+		return WrapError(err)
+	}
+
+	if namespace != models.AllNamespaces && !db.namespaceStorage.Exists(ctx, namespace) {
+		return WrapError(identity.ErrNamespaceNotFound)
+	}
+
+	_, err := db.rolesStorage.Get(ctx, roleName)
+	if err != nil && !errors.Is(err, identity.ErrRoleNotFound) {
+		return WrapError(err)
+	}
+
+	role, err := models.NewRole(roleName, permissions, namespace)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	if err := db.rolesStorage.Save(ctx, &role); err != nil {
+		return WrapError(err)
+	}
+
+	if _, err := db.rolesStorage.Append(ctx, role.Name); err != nil {
+		return WrapError(err)
+	}
+
+	return okPrefix
+}
+
+// updateRole - executes the update role command to change the permissions and the namespace of a role.
+// The namespace is kept if it's not set, the sessions with the active role get the updated role.
+func (db *Database) updateRole(ctx context.Context, _ *models.User, args Args) string {
+	roleName := args[compute.RoleNameArg]
+
+	current, err := db.rolesStorage.Get(ctx, roleName)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	namespace := current.Namespace
+	if val, ok := args[compute.NamespaceArg]; ok {
+		if val != models.AllNamespaces && !db.namespaceStorage.Exists(ctx, val) {
+			return WrapError(identity.ErrNamespaceNotFound)
+		}
+
+		namespace = val
+	}
+
+	role, err := models.NewRole(roleName, args[compute.PermissionsArg], namespace)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	if err := db.rolesStorage.Update(ctx, &role); err != nil {
+		return WrapError(err)
+	}
+
+	for _, session := range db.sessions.List() {
+		if session.User != nil && session.User.ActiveRole.Name == roleName {
+			session.User.ActiveRole = role
+		}
+	}
+
+	return okPrefix
+}
+
+// delRole - executes command to delete a role.
+func (db *Database) delRole(ctx context.Context, _ *models.User, args Args) string {
+	roleName := args[compute.RoleNameArg]
+
+	users, err := db.userStorage.RoleUsers(ctx, roleName)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	if len(users) > 0 {
+		return WrapError(fmt.Errorf("cannot delete role '%s': still assigned to user '%s'", roleName, users[0]))
+	}
+
+	if err := db.rolesStorage.Delete(ctx, roleName); err != nil {
+		return WrapError(err)
+	}
+
+	return okPrefix
+}
+
+// roleUsers - executes the roleusers command to list the usernames assigned the role.
+func (db *Database) roleUsers(ctx context.Context, _ *models.User, args Args) string {
+	users, err := db.userStorage.RoleUsers(ctx, args[compute.RoleNameArg])
+	if err != nil {
+		return WrapError(err)
+	}
+
+	res, err := json.Marshal(users)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOKType(ContentList, string(res))
+}
+
+// listRoles - executes the listRoles command to list all listRoles.
+func (db *Database) listRoles(ctx context.Context, _ *models.User, args Args) string {
+	roles, err := db.rolesStorage.List(ctx)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	if len(roles) == 0 {
+		return WrapError(ErrEmptyResult)
+	}
+
+	roles, err = paginate(roles, args)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	// TODO: need to optimize
+	res, err := json.Marshal(roles)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOKType(ContentList, string(res))
+}
+
+// me - executes the me command to display information about the current user,
+// including their username, roles, namespace, and permissions.
+func (db *Database) me(ctx context.Context, user *models.User, _ Args) string {
+	return WrapOK(fmt.Sprintf(
+		"user: '%s', roles: '%s', ns: '%s', perms: '%s'",
+		user.Username, strings.Join(user.Roles, " "),
+		user.ActiveRole.Namespace, user.ActiveRole.Perms(),
+	))
+}
+
+// logout - executes the logout command to close the current session, the connection stays open
+// and can log in again.
+func (db *Database) logout(ctx context.Context, _ *models.User, _ Args) string {
+	return db.Logout(ctx, ctxutil.ExtractSessionID(ctx))
+}
+
+// sessionInfo - executes the session info command to display the current session in JSON.
+func (db *Database) sessionInfo(ctx context.Context, user *models.User, _ Args) string {
+	session, err := db.sessions.Get(ctxutil.ExtractSessionID(ctx))
+	if err != nil {
+		return WrapError(err)
+	}
+
+	info := SessionInfo{
+		Username:  user.Username,
+		Roles:     user.Roles,
+		Namespace: user.ActiveRole.Namespace,
+		Get:       user.ActiveRole.Get,
+		Set:       user.ActiveRole.Set,
+		Del:       user.ActiveRole.Del,
+		CreatedAt: session.CreatedAt,
+	}
+	if !session.ExpiresAt.IsZero() {
+		info.ExpiresAt = &session.ExpiresAt
+	}
+
+	res, err := json.Marshal(info)
 	if err != nil {
 		return WrapError(err)
 	}
 
-	return WrapOK(string(res))
+	return WrapOKType(ContentJSON, string(res))
 }
 
-func (db *Database) getRole(ctx context.Context, _ *models.User, args Args) string {
-	roleName := args[compute.RoleNameArg]
+// caps - executes the caps command to list the commands the current session may execute in JSON.
+// The admin check matches HandleQuery, the login commands are always allowed and are not listed.
+// The explain command is handled before the registry lookup, so it's listed explicitly.
+func (db *Database) caps(_ context.Context, user *models.User, _ Args) string {
+	admin := user.Username == db.cfg.Username
+	commands := make([]string, 0, len(db.registry)+1)
+	commands = append(commands, compute.CommandEXPLAIN.String())
+	for cmdType, handler := range db.registry {
+		if handler.AdminOnly && !admin {
+			continue
+		}
 
-	role, err := db.rolesStorage.Get(ctx, roleName)
-	if err != nil {
-		return WrapError(err)
+		commands = append(commands, cmdType.String())
 	}
+	slices.Sort(commands)
 
-	// TODO: need to optimize
-	res, err := json.Marshal(role)
+	res, err := json.Marshal(commands)
 	if err != nil {
 		return WrapError(err)
 	}
 
-	return WrapOK(string(res))
+	return WrapOKType(ContentList, string(res))
 }
 
-// createRole - executes the create role command to create a new role.
-func (db *Database) createRole(ctx context.Context, _ *models.User, args Args) string {
+// createNS - executes the create ns command to create a new namespace.
+func (db *Database) createNS(ctx context.Context, _ *models.User, args Args) string {
 	namespace := args[compute.NamespaceArg]
-	roleName := args[compute.RoleNameArg]
-	permissions := args[compute.PermissionsArg]
-
-	if !db.namespaceStorage.Exists(ctx, namespace) {
-		return WrapError(identity.ErrNamespaceNotFound)
+	if models.IsSystemNamespace(namespace) {
+		return WrapError(ErrSystemNamespace)
 	}
 
-	_, err := db.rolesStorage.Get(ctx, roleName)
-	if err != nil && !errors.Is(err, identity.ErrRoleNotFound) {
+	if err := models.ValidateName(namespace, db.keySeparator); err != nil {
 		return WrapError(err)
 	}
 
-	role, err := models.NewRole(roleName, permissions, namespace)
-	if err != nil {
-		return WrapError(err)
+	var defaultTTL time.Duration
+	if val, ok := args[compute.DefaultTTLArg]; ok {
+		ttl, err := time.ParseDuration(val)
+		if err != nil {
+			return WrapError(fmt.Errorf("invalid format to default ttl: %w", err))
+		}
+
+		if ttl <= 0 {
+			return WrapError(ErrInvalidDefaultTTL)
+		}
+
+		defaultTTL = ttl
 	}
 
-	if err := db.rolesStorage.Save(ctx, &role); err != nil {
+	err := db.namespaceStorage.Save(ctx, &models.Namespace{
+		Name: namespace, DefaultTTL: defaultTTL,
+	})
+	if err != nil {
 		return WrapError(err)
 	}
+	db.namespaceTTLs.Delete(namespace)
 
-	if _, err := db.rolesStorage.Append(ctx, role.Name); err != nil {
+	if _, err = db.namespaceStorage.Append(ctx, namespace); err != nil {
 		return WrapError(err)
 	}
 
 	return okPrefix
 }
 
-// delRole - executes command to delete a role.
-func (db *Database) delRole(ctx context.Context, _ *models.User, args Args) string {
-	roleName := args[compute.RoleNameArg]
+// getNS - executes the get ns command to display the namespace metadata,
+// it's available to the admin and to the users with a role in the namespace.
+func (db *Database) getNS(ctx context.Context, user *models.User, args Args) string {
+	namespace := args[compute.NamespaceArg]
+	if models.IsSystemNamespace(namespace) {
+		return WrapError(ErrSystemNamespace)
+	}
 
-	users, err := db.userStorage.ListUsernames(ctx)
-	if err != nil && !errors.Is(err, identity.ErrEmptyUsers) {
-		return WrapError(err)
+	if db.checkPermissions(ctx, user, namespace) == nil {
+		return WrapError(ErrNamespaceNotAccessible)
 	}
 
-	for _, username := range users {
-		user, err := db.userStorage.Get(ctx, username)
+	info := NamespaceInfo{Name: namespace}
+	if namespace != models.DefaultNameSpace {
+		ns, err := db.namespaceStorage.Get(ctx, namespace)
 		if err != nil {
 			return WrapError(err)
 		}
 
-		if slices.Contains(user.Roles, roleName) {
-			return WrapError(fmt.Errorf("cannot delete assigned to user '%s' role", username))
+		if ns.DefaultTTL > 0 {
+			info.DefaultTTL = ns.DefaultTTL.String()
 		}
 	}
+	info.Keys = db.storage.CountByPrefix(db.makeKey(namespace, ""))
 
-	if err := db.rolesStorage.Delete(ctx, roleName); err != nil {
+	res, err := json.Marshal(info)
+	if err != nil {
 		return WrapError(err)
 	}
 
-	return okPrefix
+	return WrapOKType(ContentJSON, string(res))
 }
 
-// listRoles - executes the listRoles command to list all listRoles.
-func (db *Database) listRoles(ctx context.Context, _ *models.User, _ Args) string {
-	roles, err := db.rolesStorage.List(ctx)
-	if err != nil {
-		return WrapError(err)
+// flushNS - executes the flush ns command to delete all keys in a namespace.
+func (db *Database) flushNS(ctx context.Context, _ *models.User, args Args) string {
+	namespace := args[compute.NamespaceArg]
+	if models.IsSystemNamespace(namespace) {
+		return WrapError(ErrSystemNamespace)
 	}
 
-	if len(roles) == 0 {
-		return WrapError(ErrEmptyResult)
+	if namespace != models.DefaultNameSpace && !db.namespaceStorage.Exists(ctx, namespace) {
+		return WrapError(identity.ErrNamespaceNotFound)
 	}
 
-	// TODO: need to optimize
-	res, err := json.Marshal(roles)
+	deleted, err := db.storage.DelByPrefix(ctx, db.makeKey(namespace, ""))
 	if err != nil {
 		return WrapError(err)
 	}
 
-	return WrapOK(string(res))
+	return WrapOKType(ContentInt, strconv.Itoa(deleted))
 }
 
-// me - executes the me command to display information about the current user,
-// including their username, roles, namespace, and permissions.
-func (db *Database) me(ctx context.Context, user *models.User, _ Args) string {
-	return WrapOK(fmt.Sprintf(
-		"user: '%s', roles: '%s', ns: '%s', perms: '%s'",
-		user.Username, strings.Join(user.Roles, " "),
-		user.ActiveRole.Namespace, user.ActiveRole.Perms(),
-	))
+// export - executes the export command to dump all keys of a namespace.
+func (db *Database) export(ctx context.Context, _ *models.User, args Args) string {
+	namespace := args[compute.NamespaceArg]
+	if err := db.checkDumpNamespace(ctx, namespace); err != nil {
+		return WrapError(err)
+	}
+
+	dump, err := encodeDump(db.storage.Export(db.makeKey(namespace, "")))
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOK(dump)
 }
 
-// createNS - executes the create ns command to create a new namespace.
-func (db *Database) createNS(ctx context.Context, _ *models.User, args Args) string {
+// importNS - executes the import command to load the dump created by the export command into a namespace.
+// The keys are written through the WAL, the expired keys of the dump are skipped.
+func (db *Database) importNS(ctx context.Context, _ *models.User, args Args) string {
 	namespace := args[compute.NamespaceArg]
+	if err := db.checkDumpNamespace(ctx, namespace); err != nil {
+		return WrapError(err)
+	}
 
-	err := db.namespaceStorage.Save(ctx, namespace)
+	entries, err := decodeDump(args[compute.DataArg])
 	if err != nil {
 		return WrapError(err)
 	}
 
-	if _, err = db.namespaceStorage.Append(ctx, namespace); err != nil {
-		return WrapError(err)
+	// The values are checked as by SET before any of them is written.
+	for _, entry := range entries {
+		if err := db.validateValue(namespace, entry.Value); err != nil {
+			return WrapError(fmt.Errorf("key '%s': %w", entry.Key, err))
+		}
 	}
 
-	return okPrefix
+	defaultTTL := db.namespaceTTL(ctx, namespace)
+
+	imported := 0
+	for _, entry := range entries {
+		setCtx := ctx
+		if entry.TTL > 0 {
+			remaining := time.Until(time.Unix(entry.TTL, 0))
+			if remaining <= 0 {
+				continue
+			}
+			setCtx = ctxutil.InjectTTL(ctx, remaining.String())
+		} else if defaultTTL > 0 {
+			setCtx = ctxutil.InjectTTL(ctx, defaultTTL.String())
+		}
+
+		if err := db.storage.Set(setCtx, db.makeKey(namespace, entry.Key), entry.Value); err != nil {
+			return WrapError(fmt.Errorf("import stopped after %d keys: %w", imported, err))
+		}
+		imported++
+	}
+
+	return WrapOKType(ContentInt, strconv.Itoa(imported))
+}
+
+// checkDumpNamespace - checks that the namespace can be exported and imported.
+func (db *Database) checkDumpNamespace(ctx context.Context, namespace string) error {
+	if models.IsSystemNamespace(namespace) {
+		return ErrSystemNamespace
+	}
+
+	if namespace != models.DefaultNameSpace && !db.namespaceStorage.Exists(ctx, namespace) {
+		return identity.ErrNamespaceNotFound
+	}
+
+	return nil
 }
 
 // deleteNS - executes the delete ns command to delete a namespace
@@ -477,6 +1430,7 @@ func (db *Database) deleteNS(ctx context.Context, _ *models.User, args Args) str
 	if err != nil {
 		return WrapError(err)
 	}
+	db.namespaceTTLs.Delete(namespace)
 
 	return okPrefix
 }
@@ -491,7 +1445,23 @@ func (db *Database) setNamespace(ctx context.Context, user *models.User, args Ar
 
 	role := db.checkPermissions(ctx, user, namespace)
 	if role == nil {
-		return WrapError(ErrPermissionDenied)
+		return WrapError(ErrNamespaceNotAccessible)
+	}
+
+	user.ActiveRole = *role
+	return okPrefix
+}
+
+// setRole - executes the set role command to activate one of the roles assigned to the user.
+func (db *Database) setRole(ctx context.Context, user *models.User, args Args) string {
+	name := args[compute.RoleNameArg]
+	if !slices.Contains(user.Roles, name) {
+		return WrapError(ErrRoleNotAssigned)
+	}
+
+	role, err := db.rolesStorage.Get(ctx, name)
+	if err != nil {
+		return WrapError(err)
 	}
 
 	user.ActiveRole = *role
@@ -507,10 +1477,10 @@ func (db *Database) watch(ctx context.Context, user *models.User, args Args) str
 
 	role := db.checkPermissions(ctx, user, namespace)
 	if role == nil || !role.Get {
-		return WrapError(ErrPermissionDenied)
+		return WrapError(accessError(role))
 	}
 
-	key := storage.MakeKey(namespace, args[compute.KeyArg])
+	key := db.makeKey(namespace, args[compute.KeyArg])
 	future := db.storage.Watch(ctx, key)
 
 	ch := make(chan string)
@@ -528,10 +1498,92 @@ func (db *Database) watch(ctx context.Context, user *models.User, args Args) str
 	}
 }
 
-// stat - displays database statistics.
+// publish - executes the publish command to send the message to the current subscribers of the channel.
+func (db *Database) publish(ctx context.Context, user *models.User, args Args) string {
+	namespace, err := db.parseNS(ctx, user, args)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	role := db.checkPermissions(ctx, user, namespace)
+	if role == nil || !role.Set {
+		return WrapError(accessError(role))
+	}
+
+	channel := db.makeKey(namespace, args[compute.ChannelArg])
+	subscribers := db.broker.Publish(channel, args[compute.MessageArg])
+
+	return WrapOKType(ContentInt, strconv.Itoa(subscribers))
+}
+
+// subscribe - executes the subscribe command to subscribe the session to the channel.
+func (db *Database) subscribe(ctx context.Context, user *models.User, args Args) string {
+	namespace, err := db.parseNS(ctx, user, args)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	role := db.checkPermissions(ctx, user, namespace)
+	if role == nil || !role.Get {
+		return WrapError(accessError(role))
+	}
+
+	channel := db.makeKey(namespace, args[compute.ChannelArg])
+	db.broker.Subscribe(channel, ctxutil.ExtractSessionID(ctx))
+
+	return okPrefix
+}
+
+// listen - executes the listen command to wait for the messages of the subscribed channel.
+// An empty list is returned when the command is canceled or times out before a message is published.
+func (db *Database) listen(ctx context.Context, user *models.User, args Args) string {
+	namespace, err := db.parseNS(ctx, user, args)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	role := db.checkPermissions(ctx, user, namespace)
+	if role == nil || !role.Get {
+		return WrapError(accessError(role))
+	}
+
+	channel := db.makeKey(namespace, args[compute.ChannelArg])
+	messages, err := db.broker.Listen(ctx, channel, ctxutil.ExtractSessionID(ctx))
+	if err != nil {
+		return WrapError(err)
+	}
+	if messages == nil {
+		messages = []string{}
+	}
+
+	res, err := json.Marshal(messages)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOKType(ContentList, string(res))
+}
+
+// health - executes the health command to report the readiness of the database.
+func (db *Database) health(_ context.Context, _ *models.User, _ Args) string {
+	return db.Health()
+}
+
+// version - executes the version command to report the server and protocol versions.
+func (db *Database) version(_ context.Context, _ *models.User, _ Args) string {
+	res, err := json.Marshal(VersionInfo{Version: db.serverVersion, Protocol: ProtocolVersion})
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOKType(ContentJSON, string(res))
+}
+
+// stat - displays database statistics. When storage statistics are disabled,
+// only identity counters are returned and storage fields are marked as unavailable.
 func (db *Database) stat(ctx context.Context, _ *models.User, _ Args) string {
 	storageStats, err := db.storage.Stats()
-	if err != nil {
+	if err != nil && !errors.Is(err, storage.ErrStatsDisabled) {
 		return WrapError(err)
 	}
 
@@ -555,13 +1607,34 @@ func (db *Database) stat(ctx context.Context, _ *models.User, _ Args) string {
 		TotalNamespaces: int64(len(namespaces)),
 		TotalRoles:      int64(len(roles)),
 		TotalUsers:      int64(len(users)),
-		Uptime:          time.Since(storageStats.StartTime).Seconds(),
-		TotalCommands:   storageStats.TotalCommands.Load(),
-		GetCommands:     storageStats.GetCommands.Load(),
-		SetCommands:     storageStats.SetCommands.Load(),
-		DelCommands:     storageStats.DelCommands.Load(),
-		TotalKeys:       storageStats.TotalKeys.Load(),
-		ExpiredKeys:     storageStats.ExpiredKeys.Load(),
+		WALWriteErrors:  db.storage.WALWriteErrors(),
+	}
+
+	compactions := db.storage.WALCompactions()
+	stats.CompactionsTotal = compactions.Total
+	if !compactions.Last.IsZero() {
+		stats.LastCompaction = &compactions.Last
+	}
+
+	if storageStats != nil {
+		stats.Uptime = time.Since(storageStats.StartTime).Seconds()
+		stats.TotalCommands = storageStats.TotalCommands.Load()
+		stats.GetCommands = storageStats.GetCommands.Load()
+		stats.SetCommands = storageStats.SetCommands.Load()
+		stats.DelCommands = storageStats.DelCommands.Load()
+		stats.TotalKeys = storageStats.TotalKeys.Load()
+		stats.ExpiredKeys = storageStats.ExpiredKeys.Load()
+		stats.EvictedKeys = storageStats.EvictedKeys.Load()
+		stats.Hits = storageStats.Hits.Load()
+		stats.Misses = storageStats.Misses.Load()
+	} else {
+		stats.Unavailable = append(stats.Unavailable, storageStatsFields...)
+	}
+
+	if db.slowQueryThreshold > 0 {
+		stats.SlowCommands = db.slowCommands.summary()
+	} else {
+		stats.Unavailable = append(stats.Unavailable, "slow_commands")
 	}
 
 	res, err := json.Marshal(stats)
@@ -569,7 +1642,136 @@ func (db *Database) stat(ctx context.Context, _ *models.User, _ Args) string {
 		return WrapError(err)
 	}
 
-	return WrapOK(string(res))
+	return WrapOKType(ContentJSON, string(res))
+}
+
+// dbSize - displays the number of keys per namespace.
+func (db *Database) dbSize(ctx context.Context, _ *models.User, _ Args) string {
+	namespaces, err := db.namespaceStorage.List(ctx)
+	if err != nil && !errors.Is(err, identity.ErrEmptyNamespaces) {
+		return WrapError(err)
+	}
+
+	if !slices.Contains(namespaces, models.DefaultNameSpace) {
+		namespaces = append(namespaces, models.DefaultNameSpace)
+	}
+
+	sizes := make(map[string]int, len(namespaces))
+	for _, namespace := range namespaces {
+		sizes[namespace] = db.storage.CountByPrefix(db.makeKey(namespace, ""))
+	}
+
+	res, err := json.Marshal(sizes)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOKType(ContentJSON, string(res))
+}
+
+// setMaxSize - executes the setmaxsize command to override the maximum value size for keys matching the pattern.
+func (db *Database) setMaxSize(ctx context.Context, user *models.User, args Args) string {
+	namespace, err := db.parseNS(ctx, user, args)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	size, err := sizeutil.ParseSize(args[compute.SizeArg])
+	if err != nil {
+		return WrapError(err)
+	}
+
+	pattern := db.makeKey(namespace, args[compute.PatternArg])
+	if err := db.storage.SetMaxSize(ctx, pattern, size); err != nil {
+		return WrapError(err)
+	}
+
+	return okPrefix
+}
+
+// getMaxSize - executes the getmaxsize command to display the maximum value size for a key.
+func (db *Database) getMaxSize(ctx context.Context, user *models.User, args Args) string {
+	namespace, err := db.parseNS(ctx, user, args)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	role := db.checkPermissions(ctx, user, namespace)
+	if role == nil || !role.Get {
+		return WrapError(accessError(role))
+	}
+
+	key := db.makeKey(namespace, args[compute.KeyArg])
+
+	return WrapOKType(ContentInt, strconv.Itoa(db.storage.MaxSize(key)))
+}
+
+// dump - executes the dump command to display the stored value of the key with its metadata.
+// The access time of the key is not updated, so the dump does not affect the idle eviction.
+func (db *Database) dump(ctx context.Context, user *models.User, args Args) string {
+	namespace, err := db.parseNS(ctx, user, args)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	key := db.makeKey(namespace, args[compute.KeyArg])
+	entry, err := db.storage.Entry(ctx, key)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	dump := EntryDump{
+		Key:       key,
+		Value:     entry.Value,
+		Raw:       []byte(entry.Value),
+		Expiry:    entry.TTL,
+		Partition: entry.Partition,
+	}
+	if !entry.Accessed.IsZero() {
+		accessed := entry.Accessed.UTC()
+		dump.LastAccess = &accessed
+	}
+
+	res, err := json.Marshal(dump)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOKType(ContentJSON, string(res))
+}
+
+// walLatency - executes the wal latency command to display the WAL write latency percentiles.
+func (db *Database) walLatency(_ context.Context, _ *models.User, _ Args) string {
+	res, err := json.Marshal(db.storage.WALLatency())
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOKType(ContentJSON, string(res))
+}
+
+// compact - executes the compact command to run a WAL compaction pass.
+func (db *Database) compact(_ context.Context, _ *models.User, _ Args) string {
+	result, err := db.storage.CompactWAL()
+	if err != nil {
+		return WrapError(err)
+	}
+
+	res, err := json.Marshal(result)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return WrapOKType(ContentJSON, string(res))
+}
+
+// fsync - executes the fsync command to write the pending WAL batch and commit the log to the disk.
+func (db *Database) fsync(_ context.Context, _ *models.User, _ Args) string {
+	if err := db.storage.SyncWAL(); err != nil {
+		return WrapError(err)
+	}
+
+	return okPrefix
 }
 
 func (db *Database) parseNS(ctx context.Context, user *models.User, args Args) (string, error) {
@@ -582,6 +1784,9 @@ func (db *Database) parseNS(ctx context.Context, user *models.User, args Args) (
 		namespace = val
 	} else {
 		namespace = user.ActiveRole.Namespace
+		if namespace == models.AllNamespaces {
+			namespace = models.DefaultNameSpace
+		}
 	}
 
 	return namespace, nil
@@ -593,26 +1798,30 @@ func (db *Database) checkPermissions(
 	if user.ActiveRole.Namespace == namespace {
 		return &user.ActiveRole
 	}
+	if user.ActiveRole.Matches(namespace) {
+		return scopeRole(user.ActiveRole, namespace)
+	}
 
-	var (
-		role      *models.Role
-		hasAccess bool
-	)
+	var role *models.Role
 	if !user.IsAdmin(db.cfg) {
+		// The role of the namespace takes precedence over the role of all namespaces.
+		var wildcard *models.Role
 		for _, roleName := range user.Roles {
-			var err error
-			role, err = db.rolesStorage.Get(ctx, roleName)
+			candidate, err := db.rolesStorage.Get(ctx, roleName)
 			if err != nil {
 				continue
 			}
-			if role.Namespace == namespace {
-				hasAccess = true
+			if candidate.Namespace == namespace {
+				role = candidate
 				break
 			}
+			if wildcard == nil && candidate.Matches(namespace) {
+				wildcard = scopeRole(*candidate, namespace)
+			}
 		}
 
-		if !hasAccess {
-			return nil
+		if role == nil {
+			role = wildcard
 		}
 	} else {
 		role = &models.Role{
@@ -623,3 +1832,20 @@ func (db *Database) checkPermissions(
 
 	return role
 }
+
+// accessError - returns the error of the denied operation: the namespace without a role of the user
+// is not accessible, the role lacking the permission of the operation is denied.
+func accessError(role *models.Role) error {
+	if role == nil {
+		return ErrNamespaceNotAccessible
+	}
+
+	return ErrPermissionDenied
+}
+
+// scopeRole - returns the copy of the role bound to the namespace, so the role of all namespaces
+// activated by the set ns command keeps the chosen namespace.
+func scopeRole(role models.Role, namespace string) *models.Role {
+	role.Namespace = namespace
+	return &role
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidName - is returned when the name of a namespace, user or role has forbidden characters.
+var ErrInvalidName = errors.New("invalid name")
+
+// ValidateName - checks that the name is not empty and has no whitespaces, control characters
+// or the key separator, such names make the storage keys ambiguous and can't be typed unquoted.
+func ValidateName(name, separator string) error {
+	if name == "" || strings.Contains(name, separator) {
+		return ErrInvalidName
+	}
+
+	if strings.IndexFunc(name, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	}) >= 0 {
+		return ErrInvalidName
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/neekrasov/kvdb/internal/database/identity/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateName(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"alice", "ns_1", "reader-2", "пользователь", "名前", "naïve"} {
+		assert.NoError(t, models.ValidateName(name, ":"), name)
+	}
+
+	for _, name := range []string{"", "two words", "tab\tname", "line\nbreak", "bell\a", "ns:1", " nbsp"} {
+		assert.ErrorIs(t, models.ValidateName(name, ":"), models.ErrInvalidName, name)
+	}
+
+	assert.NoError(t, models.ValidateName("ns:1", "|"))
+	assert.ErrorIs(t, models.ValidateName("ns|1", "|"), models.ErrInvalidName)
+}
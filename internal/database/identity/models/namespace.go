@@ -1,12 +1,32 @@
 package models
 
+import "time"
+
 const (
 	DefaultNameSpace         = "default"
 	SystemRoleNameSpace      = "role"
 	SystemUserNameSpace      = "user"
 	SystemNamespaceNameSpace = "namespace"
+	SystemMaxSizeNameSpace   = "maxsize"
 
 	SystemRolesKey      = "roles"
 	SystemUsersKey      = "users"
 	SystemNamespacesKey = "namespaces"
 )
+
+// Namespace - struct representing a namespace metadata.
+type Namespace struct {
+	Name       string        `json:"name"`
+	DefaultTTL time.Duration `json:"default_ttl"` // TTL applied to keys written without an explicit TTL.
+}
+
+// IsSystemNamespace - checks whether the namespace is reserved for internal metadata.
+func IsSystemNamespace(namespace string) bool {
+	switch namespace {
+	case SystemRoleNameSpace, SystemUserNameSpace,
+		SystemNamespaceNameSpace, SystemMaxSizeNameSpace:
+		return true
+	default:
+		return false
+	}
+}
@@ -11,13 +11,19 @@ const (
 	DefaultRoleName = "default"
 )
 
+// AllNamespaces - namespace of the role granting its permissions in every non-system namespace.
+const AllNamespaces = "*"
+
 var DefaultRole = Role{
 	Name: DefaultRoleName,
 	Get:  true, Set: true, Del: true,
 	Namespace: DefaultNameSpace,
 }
 
-var ErrInvalidPerms = errors.New("invalid perms: perms must contain only 'r', 'w', 'd'")
+var (
+	ErrInvalidPerms         = errors.New("invalid perms: perms must contain only 'r', 'w', 'd'")
+	ErrInvalidRoleNamespace = errors.New("invalid namespace: '*' must be the whole namespace")
+)
 
 // Role - struct representing a role in the system.
 type Role struct {
@@ -47,6 +53,16 @@ func (r *Role) Perms() string {
 	return res
 }
 
+// Matches - checks whether the role grants its permissions in the namespace,
+// the role of all namespaces matches every namespace except the system ones.
+func (r *Role) Matches(namespace string) bool {
+	if r.Namespace == AllNamespaces {
+		return !IsSystemNamespace(namespace)
+	}
+
+	return r.Namespace == namespace
+}
+
 // String - returns a formatted string representation of the role, including its name, permissions, and namespace.
 func (r *Role) String() string {
 	return fmt.Sprintf(
@@ -71,6 +87,10 @@ func NewRole(name, perms, namespace string) (Role, error) {
 		namespace = DefaultNameSpace
 	}
 
+	if namespace != AllNamespaces && strings.Contains(namespace, AllNamespaces) {
+		return Role{}, ErrInvalidRoleNamespace
+	}
+
 	return Role{
 		Name:      name,
 		Namespace: namespace,
@@ -29,6 +29,22 @@ func TestModels(t *testing.T) {
 		_, err := models.NewRole("admin", "rwwww", "default")
 		assert.Error(t, err)
 	})
+	t.Run("NewRole - all namespaces", func(t *testing.T) {
+		role, err := models.NewRole("reader", "r", models.AllNamespaces)
+		assert.NoError(t, err)
+		assert.True(t, role.Matches("ns1"))
+		assert.True(t, role.Matches("ns2"))
+		assert.False(t, role.Matches(models.SystemUserNameSpace))
+	})
+	t.Run("NewRole - partial wildcard namespace", func(t *testing.T) {
+		_, err := models.NewRole("reader", "r", "ns*")
+		assert.ErrorIs(t, err, models.ErrInvalidRoleNamespace)
+	})
+	t.Run("Role Matches", func(t *testing.T) {
+		role := models.Role{Name: "user", Get: true, Namespace: "ns1"}
+		assert.True(t, role.Matches("ns1"))
+		assert.False(t, role.Matches("ns2"))
+	})
 	t.Run("Role Perms", func(t *testing.T) {
 		role := models.Role{Name: "user", Get: true, Set: true}
 		assert.Equal(t, "rw", role.Perms())
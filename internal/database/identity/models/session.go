@@ -11,11 +11,17 @@ type SessionID = string
 
 // Session - struct that represents a user session, including the username, and expiration time.
 type Session struct {
+	ID        SessionID `json:"id"`
 	User      *User     `json:"user"`
 	ExpiresAt time.Time `json:"expires_at"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// Expired - checks whether the session is expired at the given time.
+func (s Session) Expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && now.After(s.ExpiresAt)
+}
+
 func GenSessionID(length int) SessionID {
 	b := make([]byte, length)
 	for i := range b {
@@ -23,24 +23,30 @@ type Storage interface {
 	Set(ctx context.Context, key, value string) error
 	// Get - retrieves the value associated with a given key.
 	Get(ctx context.Context, key string) (string, error)
-	// Del - removes a key and its value from the storage.
-	Del(ctx context.Context, key string) error
+	// Del - removes a key and its value from the storage, returns whether the key existed.
+	Del(ctx context.Context, key string) (bool, error)
+}
+
+// keySeparator - returns the key separator of the storage.
+func keySeparator(s Storage) string {
+	return storage.KeySeparatorOf(s)
 }
 
 // NamespaceStorage - struct that manages namespace-related operations,
 // such as creating, deleting, and listing namespaces.
 type NamespaceStorage struct {
-	storage Storage
+	storage   Storage
+	separator string
 }
 
 // NewNamespaceStorage - initializes and returns a new NamespaceStorage instance with the provided storage engine.
 func NewNamespaceStorage(storage Storage) *NamespaceStorage {
-	return &NamespaceStorage{storage: storage}
+	return &NamespaceStorage{storage: storage, separator: keySeparator(storage)}
 }
 
 // Exists - checks if a namespace exists in the storage. Returns true if it exists, otherwise false.
 func (s *NamespaceStorage) Exists(ctx context.Context, namespace string) bool {
-	key := storage.MakeKey(models.SystemNamespaceNameSpace, namespace)
+	key := storage.JoinKey(s.separator, models.SystemNamespaceNameSpace, namespace)
 	if _, err := s.storage.Get(ctx, key); err != nil {
 		return false
 	}
@@ -49,27 +55,57 @@ func (s *NamespaceStorage) Exists(ctx context.Context, namespace string) bool {
 }
 
 // Save - saves a new namespace to the storage.
-func (s *NamespaceStorage) Save(ctx context.Context, namespace string) error {
-	key := storage.MakeKey(models.SystemNamespaceNameSpace, namespace)
+func (s *NamespaceStorage) Save(ctx context.Context, namespace *models.Namespace) error {
+	key := storage.JoinKey(s.separator, models.SystemNamespaceNameSpace, namespace.Name)
 	if _, err := s.storage.Get(ctx, key); err == nil {
 		return ErrNamespaceAlreadyExists
 	}
 
-	if err := s.storage.Set(ctx, key, ""); err != nil {
+	nsBytes, err := gob.Encode(namespace)
+	if err != nil {
+		return err
+	}
+
+	if err := s.storage.Set(ctx, key, string(nsBytes)); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// Get - retrieves a namespace metadata by its name.
+func (s *NamespaceStorage) Get(ctx context.Context, name string) (*models.Namespace, error) {
+	key := storage.JoinKey(s.separator, models.SystemNamespaceNameSpace, name)
+	nsBytes, err := s.storage.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return nil, ErrNamespaceNotFound
+		}
+
+		return nil, err
+	}
+
+	namespace := models.Namespace{Name: name}
+	if nsBytes == "" {
+		return &namespace, nil
+	}
+
+	if err := gob.Decode([]byte(nsBytes), &namespace); err != nil {
+		return nil, err
+	}
+
+	return &namespace, nil
+}
+
 // Delete - deletes a namespace from the storage.
 func (s *NamespaceStorage) Delete(ctx context.Context, namespace string) error {
-	key := storage.MakeKey(models.SystemNamespaceNameSpace, namespace)
+	key := storage.JoinKey(s.separator, models.SystemNamespaceNameSpace, namespace)
 	if _, err := s.storage.Get(ctx, key); err != nil {
 		return ErrNamespaceNotFound
 	}
 
-	return s.storage.Del(ctx, key)
+	_, err := s.storage.Del(ctx, key)
+	return err
 }
 
 // Append - adds a new namespace to the list of all namespaces in the system.
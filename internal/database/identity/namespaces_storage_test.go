@@ -3,6 +3,7 @@ package identity_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/neekrasov/kvdb/internal/database/identity"
 	"github.com/neekrasov/kvdb/internal/database/identity/models"
@@ -43,10 +44,13 @@ func TestNamespaceStorage(t *testing.T) {
 		namespace := "newNamespace"
 		key := storage.MakeKey(models.SystemNamespaceNameSpace, namespace)
 
+		nsBytes, err := gob.Encode(&models.Namespace{Name: namespace, DefaultTTL: time.Minute})
+		assert.NoError(t, err)
+
 		mockStorage.On("Get", mock.Anything, key).Return("", storage.ErrKeyNotFound).Once()
-		mockStorage.On("Set", mock.Anything, key, "").Return(nil).Once()
+		mockStorage.On("Set", mock.Anything, key, string(nsBytes)).Return(nil).Once()
 
-		err := nsStorage.Save(ctx, namespace)
+		err = nsStorage.Save(ctx, &models.Namespace{Name: namespace, DefaultTTL: time.Minute})
 		assert.NoError(t, err)
 		mockStorage.AssertExpectations(t)
 	})
@@ -57,17 +61,54 @@ func TestNamespaceStorage(t *testing.T) {
 
 		mockStorage.On("Get", mock.Anything, key).Return("{}", nil).Once()
 
-		err := nsStorage.Save(ctx, namespace)
+		err := nsStorage.Save(ctx, &models.Namespace{Name: namespace})
 		assert.Equal(t, identity.ErrNamespaceAlreadyExists, err)
 		mockStorage.AssertExpectations(t)
 	})
 
+	t.Run("Test Get - success", func(t *testing.T) {
+		namespace := "ttlNamespace"
+		key := storage.MakeKey(models.SystemNamespaceNameSpace, namespace)
+		nsBytes, err := gob.Encode(&models.Namespace{Name: namespace, DefaultTTL: time.Minute})
+		assert.NoError(t, err)
+
+		mockStorage.On("Get", mock.Anything, key).Return(string(nsBytes), nil).Once()
+
+		ns, err := nsStorage.Get(ctx, namespace)
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Namespace{Name: namespace, DefaultTTL: time.Minute}, ns)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Test Get - without metadata", func(t *testing.T) {
+		namespace := "legacyNamespace"
+		key := storage.MakeKey(models.SystemNamespaceNameSpace, namespace)
+
+		mockStorage.On("Get", mock.Anything, key).Return("", nil).Once()
+
+		ns, err := nsStorage.Get(ctx, namespace)
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Namespace{Name: namespace}, ns)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Test Get - not found", func(t *testing.T) {
+		namespace := "missingNamespace"
+		key := storage.MakeKey(models.SystemNamespaceNameSpace, namespace)
+
+		mockStorage.On("Get", mock.Anything, key).Return("", storage.ErrKeyNotFound).Once()
+
+		_, err := nsStorage.Get(ctx, namespace)
+		assert.ErrorIs(t, err, identity.ErrNamespaceNotFound)
+		mockStorage.AssertExpectations(t)
+	})
+
 	t.Run("Test Delete - success", func(t *testing.T) {
 		namespace := "deleteNamespace"
 		key := storage.MakeKey(models.SystemNamespaceNameSpace, namespace)
 
 		mockStorage.On("Get", mock.Anything, key).Return("{}", nil).Once()
-		mockStorage.On("Del", mock.Anything, key).Return(nil).Once()
+		mockStorage.On("Del", mock.Anything, key).Return(true, nil).Once()
 
 		err := nsStorage.Delete(ctx, namespace)
 		assert.NoError(t, err)
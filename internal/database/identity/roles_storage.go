@@ -17,17 +17,18 @@ var (
 
 // RolesStorage - struct that manages role-related operations, such as creating, deleting, and listing roles.
 type RolesStorage struct {
-	storage Storage
+	storage   Storage
+	separator string
 }
 
 // NewRolesStorage - initializes and returns a new RolesStorage instance with the provided storage engine.
 func NewRolesStorage(storage Storage) *RolesStorage {
-	return &RolesStorage{storage: storage}
+	return &RolesStorage{storage: storage, separator: keySeparator(storage)}
 }
 
 // Get - retrieves a role by its name.
 func (s *RolesStorage) Get(ctx context.Context, name string) (*models.Role, error) {
-	key := storage.MakeKey(models.SystemRoleNameSpace, name)
+	key := storage.JoinKey(s.separator, models.SystemRoleNameSpace, name)
 	roleBytes, err := s.storage.Get(ctx, key)
 	if err != nil {
 		if errors.Is(err, storage.ErrKeyNotFound) {
@@ -47,7 +48,7 @@ func (s *RolesStorage) Get(ctx context.Context, name string) (*models.Role, erro
 
 // Delete - deletes a role by its name.
 func (s *RolesStorage) Delete(ctx context.Context, name string) error {
-	key := storage.MakeKey(models.SystemRoleNameSpace, name)
+	key := storage.JoinKey(s.separator, models.SystemRoleNameSpace, name)
 	if _, err := s.storage.Get(ctx, key); err != nil {
 		if errors.Is(err, storage.ErrKeyNotFound) {
 			return ErrRoleNotFound
@@ -56,7 +57,8 @@ func (s *RolesStorage) Delete(ctx context.Context, name string) error {
 		return err
 	}
 
-	return s.storage.Del(ctx, key)
+	_, err := s.storage.Del(ctx, key)
+	return err
 }
 
 // Append - adds a new role to the list of all roles in the system.
@@ -101,7 +103,7 @@ func (s *RolesStorage) List(ctx context.Context) ([]string, error) {
 
 // Save - saves a role to the storage.
 func (s *RolesStorage) Save(ctx context.Context, role *models.Role) error {
-	key := storage.MakeKey(models.SystemRoleNameSpace, role.Name)
+	key := storage.JoinKey(s.separator, models.SystemRoleNameSpace, role.Name)
 	roleString, err := s.storage.Get(ctx, key)
 	if err != nil && !errors.Is(err, storage.ErrKeyNotFound) {
 		return err
@@ -118,3 +120,22 @@ func (s *RolesStorage) Save(ctx context.Context, role *models.Role) error {
 
 	return s.storage.Set(ctx, key, string(roleBytes))
 }
+
+// Update - overwrites an existing role in the storage.
+func (s *RolesStorage) Update(ctx context.Context, role *models.Role) error {
+	key := storage.JoinKey(s.separator, models.SystemRoleNameSpace, role.Name)
+	if _, err := s.storage.Get(ctx, key); err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return ErrRoleNotFound
+		}
+
+		return err
+	}
+
+	roleBytes, err := gob.Encode(role)
+	if err != nil {
+		return err
+	}
+
+	return s.storage.Set(ctx, key, string(roleBytes))
+}
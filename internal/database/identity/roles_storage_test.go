@@ -43,6 +43,30 @@ func TestRolesStorage(t *testing.T) {
 		mockStorage.AssertExpectations(t)
 	})
 
+	t.Run("Test Update - success", func(t *testing.T) {
+		role := models.Role{Name: "admin", Get: true, Set: true}
+		roleBytes, _ := gob.Encode(role)
+		key := storage.MakeKey(models.SystemRoleNameSpace, role.Name)
+
+		mockStorage.On("Get", mock.Anything, key).Return("{}", nil).Once()
+		mockStorage.On("Set", mock.Anything, key, string(roleBytes)).Return(nil).Once()
+
+		err := rolesStorage.Update(ctx, &role)
+		assert.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Test Update - not found", func(t *testing.T) {
+		role := models.Role{Name: "nonexistent"}
+		key := storage.MakeKey(models.SystemRoleNameSpace, role.Name)
+
+		mockStorage.On("Get", mock.Anything, key).Return("", storage.ErrKeyNotFound).Once()
+
+		err := rolesStorage.Update(ctx, &role)
+		assert.Equal(t, identity.ErrRoleNotFound, err)
+		mockStorage.AssertExpectations(t)
+	})
+
 	t.Run("Test Get - success", func(t *testing.T) {
 		role := models.Role{Name: "user"}
 		roleBytes, _ := gob.Encode(role)
@@ -72,7 +96,7 @@ func TestRolesStorage(t *testing.T) {
 		key := storage.MakeKey(models.SystemRoleNameSpace, roleName)
 
 		mockStorage.On("Get", mock.Anything, key).Return("{}", nil).Once()
-		mockStorage.On("Del", mock.Anything, key).Return(nil).Once()
+		mockStorage.On("Del", mock.Anything, key).Return(true, nil).Once()
 
 		err := rolesStorage.Delete(ctx, roleName)
 		assert.NoError(t, err)
@@ -1,11 +1,14 @@
 package identity
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"time"
 
 	"github.com/neekrasov/kvdb/internal/database/identity/models"
+	"github.com/neekrasov/kvdb/pkg/logger"
+	"go.uber.org/zap"
 )
 
 var (
@@ -13,19 +16,37 @@ var (
 	ErrExpiresSession       = errors.New("session expired")
 )
 
+// SessionStorageOpt - options for configuring SessionStorage.
+type SessionStorageOpt func(*SessionStorage)
+
+// WithSessionTTL - configures SessionStorage with an idle session TTL,
+// the expiration time of a session slides on each access.
+func WithSessionTTL(ttl time.Duration) SessionStorageOpt {
+	return func(s *SessionStorage) {
+		s.sessionTTL = ttl
+	}
+}
+
 // SessionStorage - a struct that manages user sessions, including creation, retrieval, and deletion.
 type SessionStorage struct {
 	mu              sync.RWMutex
 	sessions        map[string]models.Session
 	sessionLifeTime time.Duration
+	sessionTTL      time.Duration
 }
 
 // NewSessionStorage - initializes and returns a new SessionStorage instance.
-func NewSessionStorage(defaultExpiration time.Duration) *SessionStorage {
-	return &SessionStorage{
+func NewSessionStorage(defaultExpiration time.Duration, opts ...SessionStorageOpt) *SessionStorage {
+	s := &SessionStorage{
 		sessions:        make(map[models.SessionID]models.Session),
 		sessionLifeTime: defaultExpiration,
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 // Create - creates a new session for a user and stores it in the session storage.
@@ -39,32 +60,38 @@ func (s *SessionStorage) Create(id models.SessionID, user *models.User) error {
 
 	now := time.Now()
 	session := models.Session{
+		ID:        id,
 		User:      user,
 		CreatedAt: now,
 	}
-
-	if s.sessionLifeTime > 0 {
-		session.ExpiresAt = now.Add(s.sessionLifeTime)
-	}
+	session.ExpiresAt = s.expiresAt(session, now)
 
 	s.sessions[id] = session
 	return nil
 }
 
-// Get - retrieves a session by its token.
+// Get - retrieves a session by its token. Expired sessions are removed,
+// the expiration time of active sessions slides when the session TTL is set.
 func (s *SessionStorage) Get(id string) (*models.Session, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	session, exists := s.sessions[id]
 	if !exists {
 		return nil, ErrExpiresSession
 	}
 
-	if !session.ExpiresAt.IsZero() && time.Now().After(session.ExpiresAt) {
+	now := time.Now()
+	if session.Expired(now) {
+		delete(s.sessions, id)
 		return nil, ErrExpiresSession
 	}
 
+	if s.sessionTTL > 0 {
+		session.ExpiresAt = s.expiresAt(session, now)
+		s.sessions[id] = session
+	}
+
 	return &session, nil
 }
 
@@ -76,11 +103,72 @@ func (s *SessionStorage) Delete(id string) {
 	delete(s.sessions, id)
 }
 
+// List - retrieves a list of all active sessions.
 func (s *SessionStorage) List() []models.Session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
 	sessions := make([]models.Session, 0, len(s.sessions))
-	for _, s := range s.sessions {
-		sessions = append(sessions, s)
+	for _, session := range s.sessions {
+		if session.Expired(now) {
+			continue
+		}
+
+		sessions = append(sessions, session)
 	}
 
 	return sessions
 }
+
+// StartCleanup - periodically removes expired sessions until the context is done.
+func (s *SessionStorage) StartCleanup(ctx context.Context, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if removed := s.removeExpired(time.Now()); removed > 0 {
+				logger.Debug("removed expired sessions", zap.Int("count", removed))
+			}
+		case <-ctx.Done():
+			logger.Debug("sessions cleanup stopped")
+			return
+		}
+	}
+}
+
+// removeExpired - removes sessions expired at the given time.
+func (s *SessionStorage) removeExpired(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var removed int
+	for id, session := range s.sessions {
+		if session.Expired(now) {
+			delete(s.sessions, id)
+			removed++
+		}
+	}
+
+	return removed
+}
+
+// expiresAt - calculates the session expiration time, the idle TTL
+// cannot extend the session beyond its lifetime.
+func (s *SessionStorage) expiresAt(session models.Session, now time.Time) time.Time {
+	var expiresAt time.Time
+	if s.sessionLifeTime > 0 {
+		expiresAt = session.CreatedAt.Add(s.sessionLifeTime)
+	}
+
+	if s.sessionTTL > 0 {
+		idleExpiresAt := now.Add(s.sessionTTL)
+		if expiresAt.IsZero() || idleExpiresAt.Before(expiresAt) {
+			expiresAt = idleExpiresAt
+		}
+	}
+
+	return expiresAt
+}
@@ -1,10 +1,12 @@
 package identity
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/neekrasov/kvdb/internal/database/identity/models"
+	"github.com/neekrasov/kvdb/pkg/logger"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -45,6 +47,7 @@ func TestSessionStorage(t *testing.T) {
 		assert.ErrorIs(t, err, ErrExpiresSession)
 
 		assert.Nil(t, sess)
+		assert.NotContains(t, sessStorage.sessions, username)
 	})
 
 	t.Run("Delete session", func(t *testing.T) {
@@ -67,3 +70,62 @@ func TestSessionStorage(t *testing.T) {
 		assert.NotEmpty(t, sessions)
 	})
 }
+
+func TestSessionStorage_SessionTTL(t *testing.T) {
+	t.Parallel()
+
+	sessStorage := NewSessionStorage(0, WithSessionTTL(50*time.Millisecond))
+
+	t.Run("Get slides expiration", func(t *testing.T) {
+		err := sessStorage.Create("1", &models.User{})
+		assert.NoError(t, err)
+
+		created := sessStorage.sessions["1"].ExpiresAt
+		time.Sleep(10 * time.Millisecond)
+
+		sess, err := sessStorage.Get("1")
+		assert.NoError(t, err)
+		assert.True(t, sess.ExpiresAt.After(created))
+	})
+
+	t.Run("Expired session is rejected and pruned", func(t *testing.T) {
+		err := sessStorage.Create("2", &models.User{})
+		assert.NoError(t, err)
+
+		time.Sleep(60 * time.Millisecond)
+
+		sess, err := sessStorage.Get("2")
+		assert.ErrorIs(t, err, ErrExpiresSession)
+		assert.Nil(t, sess)
+		assert.NotContains(t, sessStorage.sessions, "2")
+	})
+
+	t.Run("Lifetime limits sliding expiration", func(t *testing.T) {
+		storage := NewSessionStorage(time.Second, WithSessionTTL(time.Hour))
+		err := storage.Create("1", &models.User{})
+		assert.NoError(t, err)
+
+		sess, err := storage.Get("1")
+		assert.NoError(t, err)
+		assert.Equal(t, sess.CreatedAt.Add(time.Second), sess.ExpiresAt)
+	})
+}
+
+func TestSessionStorage_StartCleanup(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	sessStorage := NewSessionStorage(0, WithSessionTTL(10*time.Millisecond))
+	assert.NoError(t, sessStorage.Create("1", &models.User{}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sessStorage.StartCleanup(ctx, 5*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		sessStorage.mu.RLock()
+		defer sessStorage.mu.RUnlock()
+
+		return len(sessStorage.sessions) == 0
+	}, time.Second, 5*time.Millisecond)
+}
@@ -3,6 +3,8 @@ package identity
 import (
 	"context"
 	"errors"
+	"slices"
+	"sync"
 
 	"github.com/neekrasov/kvdb/internal/database/identity/models"
 	"github.com/neekrasov/kvdb/internal/database/storage"
@@ -20,17 +22,40 @@ var (
 // UsersStorage - a struct that manages user-related operations,
 // such as authentication, user creation, and role assignment.
 type UsersStorage struct {
-	storage Storage
+	storage    Storage
+	separator  string
+	bcryptCost int
+
+	mu sync.Mutex
+	// roleUsers - reverse index of the usernames by the assigned role, it's built on the first lookup
+	// and updated on every user write of this storage.
+	roleUsers map[string]map[string]struct{}
+}
+
+// UsersStorageOpt - options for configuring UsersStorage.
+type UsersStorageOpt func(*UsersStorage)
+
+// WithBcryptCost - configures UsersStorage with a bcrypt cost of the password hashes.
+// The existing hashes keep their cost, it's encoded in the hash.
+func WithBcryptCost(cost int) UsersStorageOpt {
+	return func(s *UsersStorage) {
+		s.bcryptCost = cost
+	}
 }
 
 // NewUsersStorage - initializes and returns a new UsersStorage instance with the provided storage engine.
-func NewUsersStorage(storage Storage) *UsersStorage {
-	return &UsersStorage{storage: storage}
+func NewUsersStorage(storage Storage, opts ...UsersStorageOpt) *UsersStorage {
+	s := &UsersStorage{storage: storage, separator: keySeparator(storage), bcryptCost: bcrypt.DefaultCost}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 // Authenticate - authenticates a user by verifying their username and password.
 func (s *UsersStorage) Authenticate(ctx context.Context, username, password string) (*models.User, error) {
-	userKey := storage.MakeKey(models.SystemUserNameSpace, username)
+	userKey := storage.JoinKey(s.separator, models.SystemUserNameSpace, username)
 	userString, err := s.storage.Get(ctx, userKey)
 	if err != nil {
 		return nil, ErrAuthenticationFailed
@@ -49,108 +74,90 @@ func (s *UsersStorage) Authenticate(ctx context.Context, username, password stri
 	return &user, nil
 }
 
-// AssignRole - assigns a role to a user.
+// AssignRole - assigns a role to a user, an already assigned role is not duplicated.
 func (s *UsersStorage) AssignRole(ctx context.Context, username string, role string) error {
-	userKey := storage.MakeKey(models.SystemUserNameSpace, username)
-	userString, err := s.storage.Get(ctx, userKey)
+	user, err := s.Get(ctx, username)
 	if err != nil {
-		if errors.Is(err, storage.ErrKeyNotFound) {
-			return ErrUserNotFound
-		}
-
 		return err
 	}
 
-	roleKey := storage.MakeKey(models.SystemRoleNameSpace, role)
-	if _, err = s.storage.Get(ctx, roleKey); err != nil {
-		if errors.Is(err, storage.ErrKeyNotFound) {
-			return ErrRoleNotFound
-		}
-
+	if err := s.checkRole(ctx, role); err != nil {
 		return err
 	}
 
-	var user models.User
-	if err := gob.Decode([]byte(userString), &user); err != nil {
-		return err
+	if slices.Contains(user.Roles, role) {
+		return nil
 	}
 
 	user.Roles = append(user.Roles, role)
-	userBytesUpdated, err := gob.Encode(user)
-	if err != nil {
-		return err
-	}
 
-	err = s.storage.Set(ctx, userKey, string(userBytesUpdated))
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return s.save(ctx, user)
 }
 
-// AssignRole - assigns a role to a user.
+// DivestRole - divests a role from a user, removing all its occurrences.
 func (s *UsersStorage) DivestRole(ctx context.Context, username string, role string) error {
-	userKey := storage.MakeKey(models.SystemUserNameSpace, username)
-	userString, err := s.storage.Get(ctx, userKey)
+	user, err := s.Get(ctx, username)
 	if err != nil {
-		if errors.Is(err, storage.ErrKeyNotFound) {
-			return ErrUserNotFound
-		}
-
 		return err
 	}
 
-	roleKey := storage.MakeKey(models.SystemRoleNameSpace, role)
-	if _, err = s.storage.Get(ctx, roleKey); err != nil {
-		if errors.Is(err, storage.ErrKeyNotFound) {
-			return ErrRoleNotFound
-		}
-
+	if err := s.checkRole(ctx, role); err != nil {
 		return err
 	}
 
-	var user models.User
-	if err := gob.Decode([]byte(userString), &user); err != nil {
-		return err
+	roles := slices.DeleteFunc(slices.Clone(user.Roles), func(name string) bool {
+		return name == role
+	})
+	if len(roles) == len(user.Roles) {
+		return nil
 	}
 
-	index := -1
-	for i, roleName := range user.Roles {
-		if roleName == role {
-			index = i
-			break
-		}
+	user.Roles = roles
+
+	return s.save(ctx, user)
+}
+
+// DedupRoles - removes duplicate roles assigned to a user, keeping the first occurrence.
+// Returns whether the user roles have been changed.
+func (s *UsersStorage) DedupRoles(ctx context.Context, username string) (bool, error) {
+	user, err := s.Get(ctx, username)
+	if err != nil {
+		return false, err
 	}
 
-	if index == -1 {
-		return nil
+	seen := make(map[string]struct{}, len(user.Roles))
+	roles := make([]string, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		if _, ok := seen[role]; ok {
+			continue
+		}
+
+		seen[role] = struct{}{}
+		roles = append(roles, role)
 	}
 
-	user.Roles[index] = user.Roles[len(user.Roles)-1]
-	user.Roles = user.Roles[:len(user.Roles)-1]
-	userBytesUpdated, err := gob.Encode(user)
-	if err != nil {
-		return err
+	if len(roles) == len(user.Roles) {
+		return false, nil
 	}
 
-	err = s.storage.Set(ctx, userKey, string(userBytesUpdated))
-	if err != nil {
-		return err
+	user.Roles = roles
+	if err := s.save(ctx, user); err != nil {
+		return false, err
 	}
 
-	return nil
+	return true, nil
 }
 
-// Create - creates a new user with the specified username and password.
-func (s *UsersStorage) Create(ctx context.Context, username, password string) (*models.User, error) {
-	key := storage.MakeKey(models.SystemUserNameSpace, username)
+// Create - creates a new user with the specified username and password. The user starts in the namespace
+// with the permissions of the default role, an empty namespace means the default namespace.
+func (s *UsersStorage) Create(ctx context.Context, username, password, namespace string) (*models.User, error) {
+	key := storage.JoinKey(s.separator, models.SystemUserNameSpace, username)
 	if _, err := s.storage.Get(ctx, key); err == nil {
 		return nil, ErrUserAlreadyExists
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword(
-		[]byte(password), bcrypt.DefaultCost)
+		[]byte(password), s.bcryptCost)
 	if err != nil {
 		return nil, err
 	}
@@ -161,6 +168,9 @@ func (s *UsersStorage) Create(ctx context.Context, username, password string) (*
 		Roles:      []string{models.DefaultRoleName},
 		ActiveRole: models.DefaultRole,
 	}
+	if namespace != "" {
+		user.ActiveRole.Namespace = namespace
+	}
 
 	userBytes, err := gob.Encode(user)
 	if err != nil {
@@ -171,36 +181,140 @@ func (s *UsersStorage) Create(ctx context.Context, username, password string) (*
 	if err != nil {
 		return nil, err
 	}
+	s.indexUser(user.Username, user.Roles)
 
 	return &user, nil
 }
 
 // SaveRaw - saves a user object directly to the storage.
 func (s *UsersStorage) SaveRaw(ctx context.Context, user *models.User) error {
-	key := storage.MakeKey(models.SystemUserNameSpace, user.Username)
+	key := storage.JoinKey(s.separator, models.SystemUserNameSpace, user.Username)
 	if _, err := s.storage.Get(ctx, key); err == nil {
 		return ErrUserAlreadyExists
 	}
 
 	if user.Password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), s.bcryptCost)
 		if err != nil {
 			return err
 		}
 		user.Password = string(hashedPassword)
 	}
 
+	return s.save(ctx, user)
+}
+
+// save - stores the user object as is, overwriting the existing one.
+func (s *UsersStorage) save(ctx context.Context, user *models.User) error {
 	userBytes, err := gob.Encode(user)
 	if err != nil {
 		return err
 	}
 
-	return s.storage.Set(ctx, key, string(userBytes))
+	key := storage.JoinKey(s.separator, models.SystemUserNameSpace, user.Username)
+	if err := s.storage.Set(ctx, key, string(userBytes)); err != nil {
+		return err
+	}
+	s.indexUser(user.Username, user.Roles)
+
+	return nil
+}
+
+// RoleUsers - retrieves the sorted usernames assigned the role.
+func (s *UsersStorage) RoleUsers(ctx context.Context, role string) ([]string, error) {
+	if err := s.checkRole(ctx, role); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.roleUsers == nil {
+		index, err := s.buildRoleUsers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.roleUsers = index
+	}
+
+	usernames := make([]string, 0, len(s.roleUsers[role]))
+	for username := range s.roleUsers[role] {
+		usernames = append(usernames, username)
+	}
+	slices.Sort(usernames)
+
+	return usernames, nil
+}
+
+// buildRoleUsers - builds the reverse index of the usernames by role from the stored users.
+func (s *UsersStorage) buildRoleUsers(ctx context.Context) (map[string]map[string]struct{}, error) {
+	usernames, err := s.ListUsernames(ctx)
+	if err != nil && !errors.Is(err, ErrEmptyUsers) {
+		return nil, err
+	}
+
+	index := make(map[string]map[string]struct{})
+	for _, username := range usernames {
+		user, err := s.Get(ctx, username)
+		if err != nil {
+			if errors.Is(err, ErrUserNotFound) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		for _, role := range user.Roles {
+			if index[role] == nil {
+				index[role] = make(map[string]struct{})
+			}
+			index[role][username] = struct{}{}
+		}
+	}
+
+	return index, nil
+}
+
+// indexUser - replaces the roles of the user in the reverse index, nil roles remove the user from the index.
+func (s *UsersStorage) indexUser(username string, roles []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.roleUsers == nil {
+		return
+	}
+
+	for role, users := range s.roleUsers {
+		if !slices.Contains(roles, role) {
+			delete(users, username)
+		}
+	}
+
+	for _, role := range roles {
+		if s.roleUsers[role] == nil {
+			s.roleUsers[role] = make(map[string]struct{})
+		}
+		s.roleUsers[role][username] = struct{}{}
+	}
+}
+
+// checkRole - checks that the role exists.
+func (s *UsersStorage) checkRole(ctx context.Context, role string) error {
+	roleKey := storage.JoinKey(s.separator, models.SystemRoleNameSpace, role)
+	if _, err := s.storage.Get(ctx, roleKey); err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return ErrRoleNotFound
+		}
+
+		return err
+	}
+
+	return nil
 }
 
 // Get - retrieves a user by their username.
 func (s *UsersStorage) Get(ctx context.Context, username string) (*models.User, error) {
-	key := storage.MakeKey(models.SystemUserNameSpace, username)
+	key := storage.JoinKey(s.separator, models.SystemUserNameSpace, username)
 	userString, err := s.storage.Get(ctx, key)
 	if err != nil {
 		if errors.Is(err, storage.ErrKeyNotFound) {
@@ -220,7 +334,7 @@ func (s *UsersStorage) Get(ctx context.Context, username string) (*models.User,
 
 // Delete - deletes a user by their username.
 func (s *UsersStorage) Delete(ctx context.Context, username string) error {
-	key := storage.MakeKey(models.SystemUserNameSpace, username)
+	key := storage.JoinKey(s.separator, models.SystemUserNameSpace, username)
 	if _, err := s.storage.Get(ctx, key); err != nil {
 		if errors.Is(err, storage.ErrKeyNotFound) {
 			return ErrUserNotFound
@@ -229,7 +343,12 @@ func (s *UsersStorage) Delete(ctx context.Context, username string) error {
 		return err
 	}
 
-	return s.storage.Del(ctx, key)
+	if _, err := s.storage.Del(ctx, key); err != nil {
+		return err
+	}
+	s.indexUser(username, nil)
+
+	return nil
 }
 
 // Append - adds a new username to the list of all users in the system.
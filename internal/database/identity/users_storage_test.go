@@ -8,8 +8,11 @@ import (
 	"github.com/neekrasov/kvdb/internal/database/identity"
 	"github.com/neekrasov/kvdb/internal/database/identity/models"
 	"github.com/neekrasov/kvdb/internal/database/storage"
+	"github.com/neekrasov/kvdb/internal/database/storage/engine"
+	"github.com/neekrasov/kvdb/internal/database/storage/wal"
 	mocks "github.com/neekrasov/kvdb/internal/mocks/database"
 	"github.com/neekrasov/kvdb/pkg/gob"
+	"github.com/neekrasov/kvdb/pkg/logger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -31,7 +34,7 @@ func TestUsersStorage(t *testing.T) {
 		mockStorage.On("Get", mock.Anything, key).Return("", storage.ErrKeyNotFound).Once()
 		mockStorage.On("Set", mock.Anything, key, mock.Anything).Return(nil).Once()
 
-		_, err := usersStorage.Create(ctx, username, password)
+		_, err := usersStorage.Create(ctx, username, password, "")
 		assert.NoError(t, err)
 		mockStorage.AssertExpectations(t)
 	})
@@ -43,7 +46,7 @@ func TestUsersStorage(t *testing.T) {
 
 		mockStorage.On("Get", mock.Anything, key).Return("{}", nil).Once()
 
-		_, err := usersStorage.Create(ctx, username, password)
+		_, err := usersStorage.Create(ctx, username, password, "")
 		assert.Equal(t, identity.ErrUserAlreadyExists, err)
 		mockStorage.AssertExpectations(t)
 	})
@@ -113,6 +116,24 @@ func TestUsersStorage(t *testing.T) {
 		mockStorage.AssertExpectations(t)
 	})
 
+	t.Run("Test AssignRole - already assigned", func(t *testing.T) {
+		username := "assignedUser"
+		role := "admin"
+		userKey := storage.MakeKey(models.SystemUserNameSpace, username)
+		roleKey := storage.MakeKey(models.SystemRoleNameSpace, role)
+
+		user := models.User{Username: username, Roles: []string{role}}
+		userBytes, _ := gob.Encode(user)
+
+		mockStorage.On("Get", mock.Anything, userKey).Return(string(userBytes), nil).Once()
+		mockStorage.On("Get", mock.Anything, roleKey).Return("{}", nil).Once()
+
+		err := usersStorage.AssignRole(ctx, username, role)
+		assert.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+		mockStorage.AssertNotCalled(t, "Set", mock.Anything, userKey, mock.Anything)
+	})
+
 	t.Run("Test AssignRole - user not found", func(t *testing.T) {
 		username := "nonexistentUser"
 		role := "admin"
@@ -147,7 +168,7 @@ func TestUsersStorage(t *testing.T) {
 		key := storage.MakeKey(models.SystemUserNameSpace, username)
 
 		mockStorage.On("Get", mock.Anything, key).Return("{}", nil).Once()
-		mockStorage.On("Del", mock.Anything, key).Return(nil).Once()
+		mockStorage.On("Del", mock.Anything, key).Return(true, nil).Once()
 
 		err := usersStorage.Delete(ctx, username)
 		assert.NoError(t, err)
@@ -297,6 +318,33 @@ func TestDivestRole(t *testing.T) {
 		mockStorage.AssertExpectations(t)
 	})
 
+	t.Run("duplicated role divest", func(t *testing.T) {
+		username := "dupUser"
+		role := "testRole"
+		userKey := storage.MakeKey(models.SystemUserNameSpace, username)
+		roleKey := storage.MakeKey(models.SystemRoleNameSpace, role)
+
+		user := models.User{
+			Username: username,
+			Roles:    []string{role, "otherRole", role},
+		}
+		userBytes, _ := gob.Encode(user)
+
+		expectedUser := models.User{
+			Username: username,
+			Roles:    []string{"otherRole"},
+		}
+		expectedUserBytes, _ := gob.Encode(expectedUser)
+
+		mockStorage.On("Get", mock.Anything, userKey).Return(string(userBytes), nil).Once()
+		mockStorage.On("Get", mock.Anything, roleKey).Return("{}", nil).Once()
+		mockStorage.On("Set", mock.Anything, userKey, string(expectedUserBytes)).Return(nil).Once()
+
+		err := usersStorage.DivestRole(ctx, username, role)
+		assert.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+	})
+
 	t.Run("user not found", func(t *testing.T) {
 		username := "nonexistentUser"
 		role := "testRole"
@@ -350,10 +398,8 @@ func TestDivestRole(t *testing.T) {
 		username := "testUser"
 		role := "testRole"
 		userKey := storage.MakeKey(models.SystemUserNameSpace, username)
-		roleKey := storage.MakeKey(models.SystemRoleNameSpace, role)
 
 		mockStorage.On("Get", mock.Anything, userKey).Return("invalid data", nil).Once()
-		mockStorage.On("Get", mock.Anything, roleKey).Return("{}", nil).Once()
 
 		err := usersStorage.DivestRole(ctx, username, role)
 		assert.Error(t, err)
@@ -388,6 +434,60 @@ func TestDivestRole(t *testing.T) {
 	})
 }
 
+func TestDedupRoles(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	mockStorage := mocks.NewStorage(t)
+	usersStorage := identity.NewUsersStorage(mockStorage)
+
+	username := "testUser"
+	userKey := storage.MakeKey(models.SystemUserNameSpace, username)
+
+	tests := []struct {
+		name     string
+		roles    []string
+		expected []string
+	}{
+		{name: "duplicates at the end", roles: []string{"a", "b", "c", "a", "b"}, expected: []string{"a", "b", "c"}},
+		{name: "duplicates at the start", roles: []string{"a", "a", "b", "b", "c"}, expected: []string{"a", "b", "c"}},
+		{name: "duplicates interleaved", roles: []string{"c", "a", "c", "b", "a"}, expected: []string{"c", "a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userBytes, _ := gob.Encode(models.User{Username: username, Roles: tt.roles})
+			expectedBytes, _ := gob.Encode(models.User{Username: username, Roles: tt.expected})
+
+			mockStorage.On("Get", mock.Anything, userKey).Return(string(userBytes), nil).Once()
+			mockStorage.On("Set", mock.Anything, userKey, string(expectedBytes)).Return(nil).Once()
+
+			changed, err := usersStorage.DedupRoles(ctx, username)
+			require.NoError(t, err)
+			assert.True(t, changed)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+
+	t.Run("no duplicates", func(t *testing.T) {
+		userBytes, _ := gob.Encode(models.User{Username: username, Roles: []string{"a", "b"}})
+		mockStorage.On("Get", mock.Anything, userKey).Return(string(userBytes), nil).Once()
+
+		changed, err := usersStorage.DedupRoles(ctx, username)
+		require.NoError(t, err)
+		assert.False(t, changed)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		mockStorage.On("Get", mock.Anything, userKey).Return("", storage.ErrKeyNotFound).Once()
+
+		_, err := usersStorage.DedupRoles(ctx, username)
+		assert.ErrorIs(t, err, identity.ErrUserNotFound)
+		mockStorage.AssertExpectations(t)
+	})
+}
+
 func TestRemove(t *testing.T) {
 	t.Parallel()
 
@@ -489,3 +589,89 @@ func TestRemove(t *testing.T) {
 		mockStorage.AssertExpectations(t)
 	})
 }
+
+func TestRoleUsers(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	rolesStorage := identity.NewRolesStorage(dstorage)
+	for _, role := range []string{"reader", "writer"} {
+		require.NoError(t, rolesStorage.Save(ctx, &models.Role{Name: role, Namespace: "ns"}))
+	}
+
+	usersStorage := identity.NewUsersStorage(dstorage)
+	for _, username := range []string{"alice", "bob"} {
+		_, err := usersStorage.Create(ctx, username, "password", "")
+		require.NoError(t, err)
+		_, err = usersStorage.Append(ctx, username)
+		require.NoError(t, err)
+	}
+	require.NoError(t, usersStorage.AssignRole(ctx, "alice", "reader"))
+
+	// The first lookup builds the index from the stored users.
+	users, err := usersStorage.RoleUsers(ctx, "reader")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, users)
+
+	require.NoError(t, usersStorage.AssignRole(ctx, "bob", "reader"))
+	require.NoError(t, usersStorage.AssignRole(ctx, "bob", "writer"))
+
+	users, err = usersStorage.RoleUsers(ctx, "reader")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, users)
+
+	require.NoError(t, usersStorage.DivestRole(ctx, "alice", "reader"))
+
+	users, err = usersStorage.RoleUsers(ctx, "reader")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bob"}, users)
+
+	users, err = usersStorage.RoleUsers(ctx, "writer")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bob"}, users)
+
+	require.NoError(t, usersStorage.Delete(ctx, "bob"))
+
+	users, err = usersStorage.RoleUsers(ctx, "reader")
+	require.NoError(t, err)
+	assert.Empty(t, users)
+
+	_, err = usersStorage.RoleUsers(ctx, "missing")
+	assert.ErrorIs(t, err, identity.ErrRoleNotFound)
+}
+
+func TestUsersStorage_BcryptCost(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	dstorage, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	usersStorage := identity.NewUsersStorage(dstorage, identity.WithBcryptCost(bcrypt.MinCost))
+
+	user, err := usersStorage.Create(ctx, "alice", "password", "")
+	require.NoError(t, err)
+	cost, err := bcrypt.Cost([]byte(user.Password))
+	require.NoError(t, err)
+	assert.Equal(t, bcrypt.MinCost, cost)
+
+	raw := &models.User{Username: "bob", Password: "password"}
+	require.NoError(t, usersStorage.SaveRaw(ctx, raw))
+	cost, err = bcrypt.Cost([]byte(raw.Password))
+	require.NoError(t, err)
+	assert.Equal(t, bcrypt.MinCost, cost)
+
+	_, err = usersStorage.Authenticate(ctx, "bob", "password")
+	require.NoError(t, err)
+
+	user, err = identity.NewUsersStorage(dstorage).Create(ctx, "carol", "password", "")
+	require.NoError(t, err)
+	cost, err = bcrypt.Cost([]byte(user.Password))
+	require.NoError(t, err)
+	assert.Equal(t, bcrypt.DefaultCost, cost)
+}
@@ -0,0 +1,76 @@
+package database
+
+import "time"
+
+// Option - is a functional option type for configuring a Database instance.
+type Option func(*Database)
+
+// SessionCloser - closes the client connection bound to the session.
+type SessionCloser = func(sessionID string)
+
+// WithSessionCloser - sets the callback used to close connections of killed sessions.
+func WithSessionCloser(closer SessionCloser) Option {
+	return func(db *Database) {
+		db.sessionCloser = closer
+	}
+}
+
+// ConnectionsLister - returns the active client connections.
+type ConnectionsLister = func() []ConnInfo
+
+// WithConnectionsLister - sets the callback used to list connections by the connections command.
+func WithConnectionsLister(lister ConnectionsLister) Option {
+	return func(db *Database) {
+		db.connections = lister
+	}
+}
+
+// WithMissHandler - sets the loader called by the get command for the missing keys,
+// the loaded values are stored with the default TTL of the namespace.
+func WithMissHandler(handler MissHandler) Option {
+	return func(db *Database) {
+		db.missHandler = handler
+	}
+}
+
+// WithTokenTTL - sets the lifetime of the issued session tokens.
+func WithTokenTTL(ttl time.Duration) Option {
+	return func(db *Database) {
+		if ttl > 0 {
+			db.tokens.ttl = ttl
+		}
+	}
+}
+
+// WithSlowQueryThreshold - sets the execution time after which the command is logged as slow.
+func WithSlowQueryThreshold(threshold time.Duration) Option {
+	return func(db *Database) {
+		db.slowQueryThreshold = threshold
+	}
+}
+
+// WithServerVersion - sets the server version reported by the version command.
+func WithServerVersion(version string) Option {
+	return func(db *Database) {
+		if version != "" {
+			db.serverVersion = version
+		}
+	}
+}
+
+// WithValueValidators - sets the validators checking the values stored in the namespaces.
+func WithValueValidators(validators map[string]ValueValidator) Option {
+	return func(db *Database) {
+		db.validators = validators
+	}
+}
+
+// WithRateLimit - limits every session to rate commands per second with bursts of up to burst commands,
+// the burst defaults to the rate rounded up. The limit is disabled if the rate is not positive.
+func WithRateLimit(rate float64, burst int) Option {
+	return func(db *Database) {
+		if rate > 0 {
+			db.limiter = newRateLimiter(rate, burst)
+		}
+	}
+}
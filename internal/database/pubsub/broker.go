@@ -0,0 +1,167 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/neekrasov/kvdb/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// ErrNotSubscribed - is returned when the session listens to a channel it's not subscribed to.
+var ErrNotSubscribed = errors.New("not subscribed")
+
+// DefaultBufferSize - number of the messages kept for a subscriber until it listens.
+const DefaultBufferSize = 1024
+
+// Broker - delivers the messages published to the named channels to all current subscribers.
+// The messages are not persisted, a subscription lives until the session unsubscribes or ends.
+type Broker struct {
+	bufferSize int
+
+	mu       sync.Mutex
+	channels map[string]map[string]*subscription // channel -> session -> subscription
+}
+
+// subscription - messages of a channel published since the session subscribed or listened last.
+type subscription struct {
+	mu       sync.Mutex
+	messages []string
+
+	notify chan struct{} // Signaled after a message is buffered.
+	done   chan struct{} // Closed when the subscription is removed.
+}
+
+// NewBroker - creates a broker keeping up to bufferSize messages for every subscriber,
+// the size defaults to DefaultBufferSize if it's not positive.
+func NewBroker(bufferSize int) *Broker {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+
+	return &Broker{
+		bufferSize: bufferSize,
+		channels:   make(map[string]map[string]*subscription),
+	}
+}
+
+// Subscribe - subscribes the session to the channel, subscribing again keeps the buffered messages.
+func (b *Broker) Subscribe(channel, sessionID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs, ok := b.channels[channel]
+	if !ok {
+		subs = make(map[string]*subscription)
+		b.channels[channel] = subs
+	}
+
+	if _, ok := subs[sessionID]; !ok {
+		subs[sessionID] = &subscription{
+			notify: make(chan struct{}, 1),
+			done:   make(chan struct{}),
+		}
+	}
+}
+
+// Unsubscribe - removes all subscriptions of the session, the pending listens return ErrNotSubscribed.
+func (b *Broker) Unsubscribe(sessionID string) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for channel, subs := range b.channels {
+		sub, ok := subs[sessionID]
+		if !ok {
+			continue
+		}
+
+		close(sub.done)
+		delete(subs, sessionID)
+		if len(subs) == 0 {
+			delete(b.channels, channel)
+		}
+	}
+}
+
+// Publish - buffers the message for every subscriber of the channel and returns the number of subscribers.
+// The message is dropped for the subscribers with the full buffer.
+func (b *Broker) Publish(channel, message string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs := b.channels[channel]
+	for sessionID, sub := range subs {
+		if !sub.push(message, b.bufferSize) {
+			logger.Warn("subscriber buffer is full, message dropped",
+				zap.String("channel", channel), zap.String("session", sessionID))
+		}
+	}
+
+	return len(subs)
+}
+
+// Listen - returns the messages buffered for the session, waiting for the next message if there are none.
+// It returns no messages when the context is done.
+func (b *Broker) Listen(ctx context.Context, channel, sessionID string) ([]string, error) {
+	b.mu.Lock()
+	sub, ok := b.channels[channel][sessionID]
+	b.mu.Unlock()
+	if !ok {
+		return nil, ErrNotSubscribed
+	}
+
+	for {
+		if messages := sub.take(); len(messages) > 0 {
+			return messages, nil
+		}
+
+		select {
+		case <-sub.notify:
+		case <-sub.done:
+			return nil, ErrNotSubscribed
+		case <-ctx.Done():
+			return nil, nil
+		}
+	}
+}
+
+// Subscribers - returns the number of subscribers of the channel.
+func (b *Broker) Subscribers(channel string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.channels[channel])
+}
+
+// push - buffers the message and wakes up the listener, returns false if the buffer is full.
+func (s *subscription) push(message string, limit int) bool {
+	s.mu.Lock()
+	full := len(s.messages) >= limit
+	if !full {
+		s.messages = append(s.messages, message)
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+
+	return !full
+}
+
+// take - returns the buffered messages and empties the buffer.
+func (s *subscription) take() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := s.messages
+	s.messages = nil
+
+	return messages
+}
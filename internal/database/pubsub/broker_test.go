@@ -0,0 +1,105 @@
+package pubsub_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neekrasov/kvdb/internal/database/pubsub"
+	"github.com/neekrasov/kvdb/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker_Publish(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	broker := pubsub.NewBroker(2)
+
+	// Nobody is subscribed, so the message is not kept.
+	assert.Equal(t, 0, broker.Publish("events", "lost"))
+
+	broker.Subscribe("events", "first")
+	broker.Subscribe("events", "second")
+	broker.Subscribe("other", "second")
+	assert.Equal(t, 2, broker.Subscribers("events"))
+
+	assert.Equal(t, 2, broker.Publish("events", "hello"))
+	for _, session := range []string{"first", "second"} {
+		messages, err := broker.Listen(ctx, "events", session)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"hello"}, messages)
+	}
+
+	// The messages are buffered until the listen, the messages over the buffer size are dropped.
+	for _, message := range []string{"a", "b", "c"} {
+		broker.Publish("events", message)
+	}
+	messages, err := broker.Listen(ctx, "events", "first")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, messages)
+
+	_, err = broker.Listen(ctx, "events", "third")
+	assert.ErrorIs(t, err, pubsub.ErrNotSubscribed)
+
+	broker.Unsubscribe("second")
+	assert.Equal(t, 1, broker.Subscribers("events"))
+	assert.Equal(t, 0, broker.Subscribers("other"))
+	_, err = broker.Listen(ctx, "events", "second")
+	assert.ErrorIs(t, err, pubsub.ErrNotSubscribed)
+}
+
+func TestBroker_Listen(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	broker := pubsub.NewBroker(0)
+	broker.Subscribe("events", "session")
+
+	t.Run("waits for the next message", func(t *testing.T) {
+		received := make(chan []string, 1)
+		go func() {
+			messages, _ := broker.Listen(context.Background(), "events", "session")
+			received <- messages
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		assert.Empty(t, received)
+
+		broker.Publish("events", "hello")
+		select {
+		case messages := <-received:
+			assert.Equal(t, []string{"hello"}, messages)
+		case <-time.After(time.Second):
+			t.Fatal("message is not delivered")
+		}
+	})
+
+	t.Run("returns no messages when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		messages, err := broker.Listen(ctx, "events", "session")
+		require.NoError(t, err)
+		assert.Empty(t, messages)
+	})
+
+	t.Run("unsubscribe stops the listen", func(t *testing.T) {
+		listened := make(chan error, 1)
+		go func() {
+			_, err := broker.Listen(context.Background(), "events", "session")
+			listened <- err
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		broker.Unsubscribe("session")
+		select {
+		case err := <-listened:
+			assert.ErrorIs(t, err, pubsub.ErrNotSubscribed)
+		case <-time.After(time.Second):
+			t.Fatal("listen is not stopped")
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrRateLimitExceeded - is returned when the session sends commands faster than the configured rate.
+var ErrRateLimitExceeded = errors.New("rate limit exceeded")
+
+// rateLimiter - token bucket rate limiter of the sessions, every session has its own bucket.
+// A bucket holds up to burst tokens and refills with rate tokens per second, each command takes a token.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// tokenBucket - tokens left in the bucket of the session and the time they were counted at.
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// newRateLimiter - creates a rate limiter of rate commands per second,
+// the burst is rounded up from the rate if it's not positive.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst <= 0 {
+		burst = int(math.Ceil(rate))
+	}
+
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow - takes a token from the bucket of the session, returns false if the bucket is empty.
+func (l *rateLimiter) allow(sessionID string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[sessionID]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[sessionID] = bucket
+	}
+
+	if elapsed := now.Sub(bucket.updated); elapsed > 0 {
+		bucket.tokens = min(l.burst, bucket.tokens+elapsed.Seconds()*l.rate)
+		bucket.updated = now
+	}
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+
+	return true
+}
+
+// forget - removes the bucket of the closed session.
+func (l *rateLimiter) forget(sessionID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.buckets, sessionID)
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neekrasov/kvdb/internal/config"
+	"github.com/neekrasov/kvdb/internal/database/compute"
+	"github.com/neekrasov/kvdb/internal/database/identity"
+	"github.com/neekrasov/kvdb/internal/database/identity/models"
+	"github.com/neekrasov/kvdb/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	limiter := newRateLimiter(10, 3)
+
+	// The burst is allowed at once, the next command is rejected until the bucket refills.
+	for range 3 {
+		assert.True(t, limiter.allow("session", now))
+	}
+	assert.False(t, limiter.allow("session", now))
+	assert.False(t, limiter.allow("session", now.Add(50*time.Millisecond)))
+
+	// Other sessions have their own buckets.
+	assert.True(t, limiter.allow("other", now))
+
+	// A token is refilled every 100ms at 10 commands per second.
+	now = now.Add(100 * time.Millisecond)
+	assert.True(t, limiter.allow("session", now))
+	assert.False(t, limiter.allow("session", now))
+
+	// The refill is capped by the burst.
+	now = now.Add(time.Minute)
+	for range 3 {
+		assert.True(t, limiter.allow("session", now))
+	}
+	assert.False(t, limiter.allow("session", now))
+
+	// The forgotten session starts with the full bucket.
+	limiter.forget("session")
+	assert.True(t, limiter.allow("session", now))
+}
+
+func TestRateLimiter_DefaultBurst(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	limiter := newRateLimiter(1.5, 0)
+
+	assert.True(t, limiter.allow("session", now))
+	assert.True(t, limiter.allow("session", now))
+	assert.False(t, limiter.allow("session", now))
+}
+
+func TestDatabase_RateLimit(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "user", ActiveRole: models.DefaultRole}))
+
+	trie := compute.NewTrieNode()
+	trie.Insert(compute.CommandHELP, nil)
+
+	db := New(compute.NewParser(trie), nil, nil, nil, nil, sessions,
+		&config.RootConfig{Username: "admin", Password: "password"}, WithRateLimit(5, 2))
+
+	ctx := context.Background()
+	assert.False(t, IsError(db.HandleQuery(ctx, "session", compute.CommandHELP.String())))
+	assert.False(t, IsError(db.HandleQuery(ctx, "session", compute.CommandHELP.String())))
+	assert.Equal(t, WrapError(ErrRateLimitExceeded), db.HandleQuery(ctx, "session", compute.CommandHELP.String()))
+
+	// The bucket refills a token every 200ms.
+	assert.Eventually(t, func() bool {
+		return !IsError(db.HandleQuery(ctx, "session", compute.CommandHELP.String()))
+	}, 2*time.Second, 20*time.Millisecond)
+
+	db.Disconnect(ctx, "session")
+	_, err := sessions.Get("session")
+	assert.Error(t, err)
+
+	db.limiter.mu.Lock()
+	assert.Empty(t, db.limiter.buckets)
+	db.limiter.mu.Unlock()
+}
@@ -8,6 +8,19 @@ import (
 const (
 	errPrefix = "[error]"
 	okPrefix  = "[ok]"
+
+	// okTypePrefix - starts the prefix of the typed response, e.g. '[ok:json]'.
+	okTypePrefix = "[ok:"
+)
+
+// ContentType - type of the payload of a successful response, so clients don't guess how to read it.
+type ContentType string
+
+const (
+	ContentText ContentType = "text" // Plain text, the responses with the '[ok]' prefix are text.
+	ContentJSON ContentType = "json" // JSON object.
+	ContentInt  ContentType = "int"  // Integer in decimal.
+	ContentList ContentType = "list" // JSON array.
 )
 
 // WrapError - wrapping error with prefix '[error]'.
@@ -24,6 +37,15 @@ func WrapOK(msg string) string {
 	return fmt.Sprintf("%s %s", okPrefix, msg)
 }
 
+// WrapOKType - wrapping message with prefix '[ok:<type>]', the text keeps the prefix '[ok]'.
+func WrapOKType(contentType ContentType, msg string) string {
+	if contentType == ContentText {
+		return WrapOK(msg)
+	}
+
+	return fmt.Sprintf("%s%s] %s", okTypePrefix, contentType, msg)
+}
+
 // IsError - check the prefix 'error' exists.
 func IsError(val string) bool {
 	return strings.Contains(val, errPrefix)
@@ -34,7 +56,27 @@ func CutError(val string) (string, bool) {
 	return strings.CutPrefix(val, errPrefix)
 }
 
-// CutOK - cat prefix 'ok'.
+// CutOK - cat prefix 'ok' with or without the content type.
 func CutOK(val string) (string, bool) {
-	return strings.CutPrefix(val, okPrefix)
+	payload, _, ok := CutOKType(val)
+	return payload, ok
+}
+
+// CutOKType - cat prefix 'ok' and returns the content type of the payload, text if the type is not set.
+func CutOKType(val string) (string, ContentType, bool) {
+	if payload, ok := strings.CutPrefix(val, okPrefix); ok {
+		return payload, ContentText, true
+	}
+
+	rest, ok := strings.CutPrefix(val, okTypePrefix)
+	if !ok {
+		return val, "", false
+	}
+
+	contentType, payload, ok := strings.Cut(rest, "]")
+	if !ok || contentType == "" || strings.ContainsAny(contentType, " \n") {
+		return val, "", false
+	}
+
+	return payload, ContentType(contentType), true
 }
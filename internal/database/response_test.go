@@ -0,0 +1,59 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapOKType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		contentType ContentType
+		payload     string
+		expected    string
+	}{
+		{name: "text", contentType: ContentText, payload: "hello world", expected: "[ok] hello world"},
+		{name: "json", contentType: ContentJSON, payload: `{"a":1}`, expected: `[ok:json] {"a":1}`},
+		{name: "int", contentType: ContentInt, payload: "42", expected: "[ok:int] 42"},
+		{name: "list", contentType: ContentList, payload: `["a","b"]`, expected: `[ok:list] ["a","b"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := WrapOKType(tt.contentType, tt.payload)
+			assert.Equal(t, tt.expected, res)
+
+			payload, contentType, ok := CutOKType(res)
+			assert.True(t, ok)
+			assert.Equal(t, tt.contentType, contentType)
+			assert.Equal(t, " "+tt.payload, payload)
+
+			payload, ok = CutOK(res)
+			assert.True(t, ok)
+			assert.Equal(t, " "+tt.payload, payload)
+		})
+	}
+}
+
+func TestCutOKType(t *testing.T) {
+	t.Parallel()
+
+	payload, contentType, ok := CutOKType(okPrefix)
+	assert.True(t, ok)
+	assert.Equal(t, ContentText, contentType)
+	assert.Empty(t, payload)
+
+	// The payload of the text may look like a typed prefix.
+	payload, contentType, ok = CutOKType("[ok] [ok:json] {}")
+	assert.True(t, ok)
+	assert.Equal(t, ContentText, contentType)
+	assert.Equal(t, " [ok:json] {}", payload)
+
+	for _, res := range []string{"[error] key not found", "[ok:] 1", "[ok:json", "[ok:a b] 1", "value"} {
+		_, _, ok := CutOKType(res)
+		assert.False(t, ok, res)
+	}
+}
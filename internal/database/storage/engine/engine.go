@@ -3,6 +3,7 @@ package engine
 import (
 	"context"
 	"hash/fnv"
+	"strings"
 	"time"
 
 	"github.com/neekrasov/kvdb/pkg/ctxutil"
@@ -13,7 +14,8 @@ import (
 
 // Engine - abstract data storage engine.
 type Engine struct {
-	partitions []*partitionMap
+	partitions  []*partitionMap
+	trackAccess bool
 }
 
 // New - creates a new instance of Engine.
@@ -29,6 +31,10 @@ func New(options ...Option) *Engine {
 		e.partitions[0] = newPartMap()
 	}
 
+	for _, p := range e.partitions {
+		p.trackAccess = e.trackAccess
+	}
+
 	return e
 }
 
@@ -64,6 +70,62 @@ func (e *Engine) Get(ctx context.Context, key string) (string, bool) {
 	return val, found
 }
 
+// LastAccess - returns the time the key was last read or written. The time is zero
+// for an existing key if the engine does not track the access.
+func (e *Engine) LastAccess(ctx context.Context, key string) (time.Time, bool) {
+	txID := ctxutil.ExtractTxID(ctx)
+	sessionID := ctxutil.ExtractSessionID(ctx)
+
+	n, part := e.part(txID, sessionID, key)
+	accessed, found := part.lastAccess(key)
+
+	logger.Debug(
+		"successfull lastaccess query",
+		zap.Int64("tx", txID), zap.Int("part", n),
+		zap.String("session", sessionID),
+	)
+
+	if accessed == 0 {
+		return time.Time{}, found
+	}
+
+	return time.Unix(0, accessed), found
+}
+
+// Entry - stored value of a key with its metadata.
+type Entry struct {
+	Value     string
+	TTL       int64     // Unix expiration time, zero if the key does not expire.
+	Accessed  time.Time // Time of the last read or write, zero unless the access is tracked.
+	Partition int       // Partition holding the key.
+}
+
+// Entry - returns the stored value of the key with its metadata, reading it does not count as an access.
+func (e *Engine) Entry(ctx context.Context, key string) (Entry, bool) {
+	txID := ctxutil.ExtractTxID(ctx)
+	sessionID := ctxutil.ExtractSessionID(ctx)
+
+	n, part := e.part(txID, sessionID, key)
+	val, found := part.entry(key)
+
+	logger.Debug(
+		"successfull entry query",
+		zap.Int64("tx", txID), zap.Int("part", n),
+		zap.String("session", sessionID),
+	)
+
+	if !found {
+		return Entry{}, false
+	}
+
+	entry := Entry{Value: val.Value, TTL: val.TTL, Partition: n}
+	if val.accessed != nil {
+		entry.Accessed = time.Unix(0, val.accessed.Load())
+	}
+
+	return entry, true
+}
+
 // Watch - watches the key and returns the value if it has changed.
 func (e *Engine) Watch(ctx context.Context, key string) pkgsync.FutureString {
 	txID := ctxutil.ExtractTxID(ctx)
@@ -79,26 +141,94 @@ func (e *Engine) Watch(ctx context.Context, key string) pkgsync.FutureString {
 	return part.watch(ctx, key)
 }
 
-// Del - removes a key-value pair from memory.
-func (e *Engine) Del(ctx context.Context, key string) error {
+// Del - removes a key-value pair from memory. Returns whether the key existed, expired keys are not counted.
+func (e *Engine) Del(ctx context.Context, key string) bool {
 	txID := ctxutil.ExtractTxID(ctx)
 	sessionID := ctxutil.ExtractSessionID(ctx)
 
 	n, part := e.part(txID, sessionID, key)
-	err := part.del(key)
-	if err != nil {
-		logger.Debug("del query failed",
-			zap.Int64("tx", txID), zap.Int("part", n),
-			zap.String("session", sessionID), zap.Error(err),
-		)
-	} else {
-		logger.Debug("successfull del query",
-			zap.Int64("tx", txID), zap.Int("part", n),
-			zap.String("session", sessionID),
-		)
+	existed := part.del(key)
+	logger.Debug("successfull del query",
+		zap.Int64("tx", txID), zap.Int("part", n),
+		zap.String("session", sessionID), zap.Bool("existed", existed),
+	)
+
+	return existed
+}
+
+// Expired - checks the key is stored with the expired value, the key is not removed.
+func (e *Engine) Expired(ctx context.Context, key string) bool {
+	txID := ctxutil.ExtractTxID(ctx)
+	sessionID := ctxutil.ExtractSessionID(ctx)
+
+	_, part := e.part(txID, sessionID, key)
+	return part.expired(key)
+}
+
+// DelExpired - removes the key if it's still expired. Returns whether the key was removed.
+func (e *Engine) DelExpired(ctx context.Context, key string) bool {
+	txID := ctxutil.ExtractTxID(ctx)
+	sessionID := ctxutil.ExtractSessionID(ctx)
+
+	n, part := e.part(txID, sessionID, key)
+	deleted := part.delExpired(key)
+	logger.Debug("successfull del expired query",
+		zap.Int64("tx", txID), zap.Int("part", n),
+		zap.String("session", sessionID), zap.Bool("deleted", deleted),
+	)
+
+	return deleted
+}
+
+// DelIdle - removes the key if it's still not accessed since the deadline. Returns whether the key was removed.
+func (e *Engine) DelIdle(ctx context.Context, key string, deadline time.Time) bool {
+	txID := ctxutil.ExtractTxID(ctx)
+	sessionID := ctxutil.ExtractSessionID(ctx)
+
+	n, part := e.part(txID, sessionID, key)
+	deleted := part.delIdle(key, deadline.UnixNano())
+	logger.Debug("successfull del idle query",
+		zap.Int64("tx", txID), zap.Int("part", n),
+		zap.String("session", sessionID), zap.Bool("deleted", deleted),
+	)
+
+	return deleted
+}
+
+// RenameNX - atomically renames the key only if the new key does not exist.
+// Returns whether the rename happened and whether the old key exists.
+func (e *Engine) RenameNX(ctx context.Context, oldKey, newKey string) (bool, bool) {
+	txID := ctxutil.ExtractTxID(ctx)
+	sessionID := ctxutil.ExtractSessionID(ctx)
+
+	srcNum, src := e.part(txID, sessionID, oldKey)
+	dstNum, dst := e.part(txID, sessionID, newKey)
+
+	switch {
+	case srcNum == dstNum:
+		src.mu.Lock()
+		defer src.mu.Unlock()
+	case srcNum < dstNum:
+		src.mu.Lock()
+		defer src.mu.Unlock()
+		dst.mu.Lock()
+		defer dst.mu.Unlock()
+	default:
+		dst.mu.Lock()
+		defer dst.mu.Unlock()
+		src.mu.Lock()
+		defer src.mu.Unlock()
 	}
 
-	return err
+	renamed, exists := renameNX(src, dst, oldKey, newKey)
+	logger.Debug(
+		"successfull renamenx query",
+		zap.Int64("tx", txID), zap.Int("src_part", srcNum),
+		zap.Int("dst_part", dstNum), zap.Bool("renamed", renamed),
+		zap.String("session", sessionID),
+	)
+
+	return renamed, exists
 }
 
 // part - returns the partition for a given key based on hashing.
@@ -117,6 +247,84 @@ func (e *Engine) part(txID int64, sessionID string, key string) (int, *partition
 	return num, e.partitions[num]
 }
 
+// CountByPrefix - returns the number of not expired keys starting with the prefix.
+func (e *Engine) CountByPrefix(prefix string) int {
+	var count int
+	for _, p := range e.partitions {
+		p.mu.RLock()
+		for key, val := range p.data {
+			if strings.HasPrefix(key, prefix) && !val.expired() {
+				count++
+			}
+		}
+		p.mu.RUnlock()
+	}
+
+	return count
+}
+
+// KeysByPrefix - returns the not expired keys starting with the prefix.
+func (e *Engine) KeysByPrefix(prefix string) []string {
+	var keys []string
+	for _, p := range e.partitions {
+		p.mu.RLock()
+		for key, val := range p.data {
+			if strings.HasPrefix(key, prefix) && !val.expired() {
+				keys = append(keys, key)
+			}
+		}
+		p.mu.RUnlock()
+	}
+
+	return keys
+}
+
+// ForEach - calls the action for each not expired key with its value and expiration time.
+func (e *Engine) ForEach(action func(key, value string, ttl int64)) {
+	for _, p := range e.partitions {
+		p.mu.RLock()
+		for key, val := range p.data {
+			if !val.expired() {
+				action(key, val.Value, val.TTL)
+			}
+		}
+		p.mu.RUnlock()
+	}
+}
+
+// ForEachExpiring - calls the action for each not expired key starting with the prefix
+// and expiring not later than the deadline, the action gets the key with its expiration time.
+func (e *Engine) ForEachExpiring(prefix string, deadline int64, action func(key string, ttl int64)) {
+	for _, p := range e.partitions {
+		p.mu.RLock()
+		for key, val := range p.data {
+			if val.TTL > 0 && val.TTL <= deadline && !val.expired() && strings.HasPrefix(key, prefix) {
+				action(key, val.TTL)
+			}
+		}
+		p.mu.RUnlock()
+	}
+}
+
+// ForEachIdle - calls the action for each not expired key accessed not later than the deadline.
+// The keys are found only if the engine tracks the access.
+func (e *Engine) ForEachIdle(deadline time.Time, action func(key string)) {
+	if action == nil || !e.trackAccess {
+		return
+	}
+
+	nanos := deadline.UnixNano()
+	for _, p := range e.partitions {
+		p.mu.RLock()
+		for key, val := range p.data {
+			if val.idle(nanos) {
+				action(key)
+			}
+		}
+		p.mu.RUnlock()
+	}
+}
+
 // ForEachExpired - scans engine partitions for retrieve expired keys.
 func (e *Engine) ForEachExpired(action func(key string)) {
 	if action == nil {
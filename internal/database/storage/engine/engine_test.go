@@ -2,6 +2,7 @@ package engine_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -35,8 +36,7 @@ func TestInMemoryEngine(t *testing.T) {
 	t.Run("Delete existing key", func(t *testing.T) {
 		e := engine.New(engine.WithPartitionNum(1))
 		e.Set(ctx, "foo", "bar", 0)
-		err := e.Del(ctx, "foo")
-		require.NoError(t, err)
+		assert.True(t, e.Del(ctx, "foo"))
 		value, exists := e.Get(ctx, "foo")
 		assert.False(t, exists)
 		assert.Empty(t, value)
@@ -44,8 +44,7 @@ func TestInMemoryEngine(t *testing.T) {
 
 	t.Run("Delete non-existent key", func(t *testing.T) {
 		e := engine.New(engine.WithPartitionNum(1))
-		err := e.Del(ctx, "missing")
-		assert.NoError(t, err)
+		assert.False(t, e.Del(ctx, "missing"))
 	})
 
 	t.Run("Watch", func(t *testing.T) {
@@ -92,4 +91,279 @@ func TestInMemoryEngine(t *testing.T) {
 
 		assert.Equal(t, value, actual)
 	})
+
+	t.Run("RenameNX to not existing key", func(t *testing.T) {
+		e := engine.New(engine.WithPartitionNum(4))
+		e.Set(ctx, "old", "value", 0)
+
+		renamed, exists := e.RenameNX(ctx, "old", "new")
+		assert.True(t, renamed)
+		assert.True(t, exists)
+
+		_, found := e.Get(ctx, "old")
+		assert.False(t, found)
+		value, found := e.Get(ctx, "new")
+		require.True(t, found)
+		assert.Equal(t, "value", value)
+	})
+
+	t.Run("RenameNX to existing key", func(t *testing.T) {
+		e := engine.New(engine.WithPartitionNum(4))
+		e.Set(ctx, "old", "old_value", 0)
+		e.Set(ctx, "new", "new_value", 0)
+
+		renamed, exists := e.RenameNX(ctx, "old", "new")
+		assert.False(t, renamed)
+		assert.True(t, exists)
+
+		value, found := e.Get(ctx, "old")
+		require.True(t, found)
+		assert.Equal(t, "old_value", value)
+		value, found = e.Get(ctx, "new")
+		require.True(t, found)
+		assert.Equal(t, "new_value", value)
+	})
+
+	t.Run("RenameNX missing key", func(t *testing.T) {
+		e := engine.New()
+
+		renamed, exists := e.RenameNX(ctx, "missing", "new")
+		assert.False(t, renamed)
+		assert.False(t, exists)
+	})
+
+	t.Run("CountByPrefix", func(t *testing.T) {
+		e := engine.New(engine.WithPartitionNum(4))
+		e.Set(ctx, "ns1:a", "1", 0)
+		e.Set(ctx, "ns1:b", "2", 0)
+		e.Set(ctx, "ns1:expired", "3", time.Now().Add(-time.Hour).Unix())
+		e.Set(ctx, "ns2:a", "1", 0)
+
+		assert.Equal(t, 2, e.CountByPrefix("ns1:"))
+		assert.Equal(t, 1, e.CountByPrefix("ns2:"))
+		assert.Equal(t, 0, e.CountByPrefix("ns3:"))
+	})
+
+	t.Run("KeysByPrefix", func(t *testing.T) {
+		e := engine.New(engine.WithPartitionNum(4))
+		e.Set(ctx, "ns1:a", "1", 0)
+		e.Set(ctx, "ns1:b", "2", 0)
+		e.Set(ctx, "ns1:expired", "3", time.Now().Add(-time.Hour).Unix())
+		e.Set(ctx, "ns2:a", "1", 0)
+
+		assert.ElementsMatch(t, []string{"ns1:a", "ns1:b"}, e.KeysByPrefix("ns1:"))
+		assert.Empty(t, e.KeysByPrefix("ns3:"))
+	})
+
+	t.Run("Scan", func(t *testing.T) {
+		e := engine.New(engine.WithPartitionNum(4))
+		expected := make([]string, 0, 25)
+		for i := range 25 {
+			key := fmt.Sprintf("ns1:key%d", i)
+			e.Set(ctx, key, "1", 0)
+			expected = append(expected, key)
+		}
+		e.Set(ctx, "ns1:expired", "1", time.Now().Add(-time.Hour).Unix())
+		e.Set(ctx, "ns2:a", "1", 0)
+
+		var (
+			keys   []string
+			cursor uint64
+			pages  int
+		)
+		for {
+			page, next := e.Scan("ns1:", cursor, 10, nil)
+			keys = append(keys, page...)
+			pages++
+
+			if next == 0 {
+				break
+			}
+			cursor = next
+		}
+
+		assert.Equal(t, 3, pages)
+		assert.ElementsMatch(t, expected, keys)
+
+		page, next := e.Scan("ns1:", 0, 0, func(key string) bool { return key == "ns1:key7" })
+		assert.Equal(t, []string{"ns1:key7"}, page)
+		assert.Zero(t, next)
+	})
+
+	t.Run("Scan keeps position after changes", func(t *testing.T) {
+		e := engine.New()
+		for i := range 20 {
+			e.Set(ctx, fmt.Sprintf("key%d", i), "1", 0)
+		}
+
+		first, cursor := e.Scan("", 0, 10, nil)
+		require.NotZero(t, cursor)
+
+		// The deleted key was already returned, so the rest of the keys must not shift.
+		assert.True(t, e.Del(ctx, first[0]))
+
+		rest, next := e.Scan("", cursor, 0, nil)
+		assert.Zero(t, next)
+		assert.Len(t, append(first, rest...), 20)
+		for _, key := range rest {
+			assert.NotContains(t, first, key)
+		}
+	})
+
+	t.Run("ForEach", func(t *testing.T) {
+		ttl := time.Now().Add(time.Hour).Unix()
+
+		e := engine.New(engine.WithPartitionNum(4))
+		e.Set(ctx, "a", "1", 0)
+		e.Set(ctx, "b", "2", ttl)
+		e.Set(ctx, "expired", "3", time.Now().Add(-time.Hour).Unix())
+
+		type item struct {
+			value string
+			ttl   int64
+		}
+
+		items := make(map[string]item)
+		e.ForEach(func(key, value string, ttl int64) {
+			items[key] = item{value: value, ttl: ttl}
+		})
+
+		assert.Equal(t, map[string]item{
+			"a": {value: "1"},
+			"b": {value: "2", ttl: ttl},
+		}, items)
+	})
+}
+
+// BenchmarkEngine_Parallel - compares the throughput of the single map and the sharded engine under concurrent load.
+func BenchmarkEngine_Parallel(b *testing.B) {
+	logger.MockLogger()
+
+	const keysNum = 1024
+	keys := make([]string, keysNum)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+	}
+
+	for _, partitionNum := range []int{1, 16} {
+		b.Run(fmt.Sprintf("partitions=%d", partitionNum), func(b *testing.B) {
+			ctx := context.Background()
+			e := engine.New(engine.WithPartitionNum(partitionNum))
+			for _, key := range keys {
+				e.Set(ctx, key, "value", 0)
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				var i int
+				for pb.Next() {
+					key := keys[i%keysNum]
+					// One write per four operations.
+					if i%4 == 0 {
+						e.Set(ctx, key, "value", 0)
+					} else {
+						e.Get(ctx, key)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
+
+func TestEngine_LastAccess(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger.MockLogger()
+
+	t.Run("Get updates the last access", func(t *testing.T) {
+		// The access tracking is applied to the partitions created by the options before it.
+		e := engine.New(engine.WithPartitionNum(4), engine.WithAccessTracking())
+
+		before := time.Now()
+		e.Set(ctx, "foo", "bar", 0)
+		written, exists := e.LastAccess(ctx, "foo")
+		require.True(t, exists)
+		assert.False(t, written.Before(before))
+
+		time.Sleep(10 * time.Millisecond)
+		_, exists = e.Get(ctx, "foo")
+		require.True(t, exists)
+
+		read, exists := e.LastAccess(ctx, "foo")
+		require.True(t, exists)
+		assert.True(t, read.After(written))
+
+		// A missing key does not record the access.
+		_, exists = e.Get(ctx, "missing")
+		require.False(t, exists)
+		_, exists = e.LastAccess(ctx, "missing")
+		assert.False(t, exists)
+	})
+
+	t.Run("Expired key", func(t *testing.T) {
+		e := engine.New(engine.WithAccessTracking())
+		e.Set(ctx, "foo", "bar", time.Now().Add(-time.Second).Unix())
+
+		_, exists := e.LastAccess(ctx, "foo")
+		assert.False(t, exists)
+	})
+
+	t.Run("Idle keys", func(t *testing.T) {
+		e := engine.New(engine.WithAccessTracking())
+		e.Set(ctx, "idle", "bar", 0)
+		e.Set(ctx, "read", "bar", 0)
+
+		time.Sleep(10 * time.Millisecond)
+		deadline := time.Now()
+		_, exists := e.Get(ctx, "read")
+		require.True(t, exists)
+
+		var idle []string
+		e.ForEachIdle(deadline, func(key string) { idle = append(idle, key) })
+		assert.Equal(t, []string{"idle"}, idle)
+
+		assert.False(t, e.DelIdle(ctx, "read", deadline))
+		assert.True(t, e.DelIdle(ctx, "idle", deadline))
+		_, exists = e.Get(ctx, "idle")
+		assert.False(t, exists)
+	})
+
+	t.Run("Access is not tracked", func(t *testing.T) {
+		e := engine.New()
+		e.Set(ctx, "foo", "bar", 0)
+
+		accessed, exists := e.LastAccess(ctx, "foo")
+		require.True(t, exists)
+		assert.True(t, accessed.IsZero())
+	})
+}
+
+func TestEngine_Entry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger.MockLogger()
+
+	e := engine.New(engine.WithAccessTracking())
+	ttl := time.Now().Add(time.Hour).Unix()
+	e.Set(ctx, "foo", "bar", ttl)
+	written, exists := e.LastAccess(ctx, "foo")
+	require.True(t, exists)
+
+	time.Sleep(10 * time.Millisecond)
+	entry, exists := e.Entry(ctx, "foo")
+	require.True(t, exists)
+	assert.Equal(t, engine.Entry{Value: "bar", TTL: ttl, Accessed: written}, entry)
+
+	// Reading the entry does not count as an access.
+	accessed, _ := e.LastAccess(ctx, "foo")
+	assert.Equal(t, written, accessed)
+
+	e.Set(ctx, "expired", "value", time.Now().Add(-time.Second).Unix())
+	_, exists = e.Entry(ctx, "expired")
+	assert.False(t, exists)
+	_, exists = e.Entry(ctx, "missing")
+	assert.False(t, exists)
 }
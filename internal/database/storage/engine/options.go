@@ -3,6 +3,13 @@ package engine
 // Option - options for configuring Engine.
 type Option func(*Engine)
 
+// WithAccessTracking - configures Engine to track the time of the last read or write of every key.
+func WithAccessTracking() Option {
+	return func(e *Engine) {
+		e.trackAccess = true
+	}
+}
+
 // WithPartitionNum - configures Engine with a partition number.
 func WithPartitionNum(partnum int) Option {
 	return func(e *Engine) {
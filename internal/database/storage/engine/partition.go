@@ -3,6 +3,7 @@ package engine
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	pkgsync "github.com/neekrasov/kvdb/pkg/sync"
@@ -12,13 +13,17 @@ import (
 type value struct {
 	Value string
 	TTL   int64
+	// accessed - unix time in nanoseconds of the last read or write, nil unless the access is tracked.
+	// It's a pointer, so the reads update it under the read lock.
+	accessed *atomic.Int64
 }
 
 // partitionMap - represents one data partition.
 type partitionMap struct {
-	mu       sync.RWMutex
-	data     map[string]value
-	watchers map[string]*watcher
+	mu          sync.RWMutex
+	data        map[string]value
+	watchers    map[string]*watcher
+	trackAccess bool
 }
 
 // newPartMap - returns a new partition instance
@@ -39,7 +44,12 @@ func (p *partitionMap) set(key, val string, ttl int64) {
 		watcher.set(val)
 	}
 
-	p.data[key] = value{Value: val, TTL: ttl}
+	v := value{Value: val, TTL: ttl}
+	if p.trackAccess {
+		v.accessed = new(atomic.Int64)
+		v.accessed.Store(time.Now().UnixNano())
+	}
+	p.data[key] = v
 }
 
 // get - retrieves the value associated with a key.
@@ -54,16 +64,121 @@ func (p *partitionMap) get(key string) (string, bool) {
 		return "", false
 	}
 
+	if exists && val.accessed != nil {
+		val.accessed.Store(time.Now().UnixNano())
+	}
+
 	return val.Value, exists
 }
 
+// lastAccess - returns the unix time in nanoseconds of the last access to the key, zero if it's not tracked.
+func (p *partitionMap) lastAccess(key string) (int64, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	val, exists := p.data[key]
+	if !exists || val.expired() {
+		return 0, false
+	}
+
+	if val.accessed == nil {
+		return 0, true
+	}
+
+	return val.accessed.Load(), true
+}
+
+// entry - returns the not expired value of the key without updating its access time.
+func (p *partitionMap) entry(key string) (value, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	val, exists := p.data[key]
+	if !exists || val.expired() {
+		return value{}, false
+	}
+
+	return val, true
+}
+
 // del - removes a key-value pair from memory.
-func (p *partitionMap) del(key string) error {
+func (p *partitionMap) del(key string) bool {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	val, ok := p.data[key]
 	delete(p.data, key)
-	return nil
+
+	return ok && !val.expired()
+}
+
+// expired - checks the key exists with the expired value.
+func (p *partitionMap) expired(key string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	val, ok := p.data[key]
+	return ok && val.expired()
+}
+
+// delExpired - removes the key only if its value is expired, so a key set again with a new TTL is kept.
+func (p *partitionMap) delExpired(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	val, ok := p.data[key]
+	if !ok || !val.expired() {
+		return false
+	}
+	delete(p.data, key)
+
+	return true
+}
+
+// delIdle - removes the key only if it's not accessed since the deadline, so a key read after the sweep is kept.
+func (p *partitionMap) delIdle(key string, deadline int64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	val, ok := p.data[key]
+	if !ok || !val.idle(deadline) {
+		return false
+	}
+	delete(p.data, key)
+
+	return true
+}
+
+// idle - checks whether the tracked access of the not expired value is not later than the deadline.
+func (v value) idle(deadline int64) bool {
+	return v.accessed != nil && v.accessed.Load() <= deadline && !v.expired()
+}
+
+// expired - checks whether the value lifetime is over.
+func (v value) expired() bool {
+	return v.TTL > 0 && time.Now().Unix() > v.TTL
+}
+
+// renameNX - moves the key from src to dst partition if the new key does not exist.
+// The caller must hold the locks of both partitions.
+func renameNX(src, dst *partitionMap, oldKey, newKey string) (renamed bool, exists bool) {
+	val, ok := src.data[oldKey]
+	if !ok || val.expired() {
+		delete(src.data, oldKey)
+		return false, false
+	}
+
+	if current, ok := dst.data[newKey]; ok && !current.expired() {
+		return false, true
+	}
+
+	delete(src.data, oldKey)
+	dst.data[newKey] = val
+	if watcher, ok := dst.watchers[newKey]; ok {
+		watcher.set(val.Value)
+	}
+
+	return true, true
 }
 
 // watch - watches the key and returns the value if it has changed.
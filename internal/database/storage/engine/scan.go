@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"cmp"
+	"hash/fnv"
+	"math"
+	"slices"
+	"strings"
+)
+
+// scanItem - key with its position in the scan order.
+type scanItem struct {
+	hash uint64
+	key  string
+}
+
+// Scan - returns up to count not expired keys starting with the prefix and accepted by the match,
+// along with the cursor of the next call, zero cursor starts the iteration and is returned when it is done.
+//
+// Keys are ordered by the 64-bit FNV-1a hash of the key and the cursor is the hash to resume from,
+// so the position does not depend on the keys set or deleted between the calls: every key present
+// during the whole iteration is returned exactly once. Keys with the same hash are never split
+// between pages, so a page may contain more than count keys.
+func (e *Engine) Scan(prefix string, cursor uint64, count int, match func(key string) bool) ([]string, uint64) {
+	var items []scanItem
+	for _, p := range e.partitions {
+		p.mu.RLock()
+		for key, val := range p.data {
+			if !strings.HasPrefix(key, prefix) || val.expired() {
+				continue
+			}
+
+			hash := scanHash(key)
+			if hash < cursor || (match != nil && !match(key)) {
+				continue
+			}
+
+			items = append(items, scanItem{hash: hash, key: key})
+		}
+		p.mu.RUnlock()
+	}
+
+	slices.SortFunc(items, func(a, b scanItem) int {
+		if c := cmp.Compare(a.hash, b.hash); c != 0 {
+			return c
+		}
+		return strings.Compare(a.key, b.key)
+	})
+
+	end := len(items)
+	if count > 0 && count < end {
+		end = count
+		for end < len(items) && items[end].hash == items[end-1].hash {
+			end++
+		}
+	}
+
+	keys := make([]string, 0, end)
+	for _, item := range items[:end] {
+		keys = append(keys, item.key)
+	}
+
+	// The last hash can not be the maximum one while keys with greater hashes remain.
+	var next uint64
+	if end < len(items) && items[end-1].hash < math.MaxUint64 {
+		next = items[end-1].hash + 1
+	}
+
+	return keys, next
+}
+
+// scanHash - returns the position of the key in the scan order.
+func scanHash(key string) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(key))
+
+	return hash.Sum64()
+}
@@ -37,6 +37,14 @@ func WithCleanupPeriod(cleanupPeriod time.Duration) StorageOpt {
 	}
 }
 
+// WithCleanupJitter - configures Storage with a jitter of the cleanup period,
+// every cleanup is delayed by the period and a random duration up to the jitter.
+func WithCleanupJitter(jitter time.Duration) StorageOpt {
+	return func(s *Storage) {
+		s.cleanupJitter = jitter
+	}
+}
+
 // WithPartitionNum - configures Engine with a cleanup period.
 func WithCleanupBatchSize(batchSize int) StorageOpt {
 	return func(s *Storage) {
@@ -44,9 +52,43 @@ func WithCleanupBatchSize(batchSize int) StorageOpt {
 	}
 }
 
+// WithIdleEviction - configures Storage to evict the keys not accessed within the duration
+// on every cleanup, the engine must track the access of the keys.
+func WithIdleEviction(idle time.Duration) StorageOpt {
+	return func(s *Storage) {
+		s.idleEviction = idle
+	}
+}
+
 // WithPartitionNum - configures Engine with a cleanup period.
 func WithStatistics() StorageOpt {
 	return func(s *Storage) {
 		s.stats = &Stats{StartTime: time.Now()}
 	}
 }
+
+// WithMaxValueSize - configures Storage with a global maximum value size.
+func WithMaxValueSize(size int) StorageOpt {
+	return func(s *Storage) {
+		s.maxValueSize = size
+	}
+}
+
+// WithSnapshotter - configures Storage with a snapshotter and a period of the snapshots.
+// The latest snapshot is loaded on recovery and only the newer WAL entries are replayed.
+func WithSnapshotter(snapshotter Snapshotter, period time.Duration) StorageOpt {
+	return func(s *Storage) {
+		s.snapshotter = snapshotter
+		s.snapshotPeriod = period
+	}
+}
+
+// WithKeySeparator - configures Storage with the separator of the namespace and the key name, empty keeps the default.
+// The keys stored with another separator are not found.
+func WithKeySeparator(separator string) StorageOpt {
+	return func(s *Storage) {
+		if separator != "" {
+			s.keySeparator = separator
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -44,6 +45,9 @@ type Slave struct {
 	syncInterval      time.Duration
 	syncRetryDuration time.Duration
 	lastLSN           int64
+
+	// synced - set after the first successful exchange with the master.
+	synced atomic.Bool
 }
 
 // NewSlave - constructor function that creates a new Slave instance.
@@ -160,10 +164,16 @@ func (s *Slave) sync(ctx context.Context) error {
 			s.lastSegmentNum += 1
 		}
 	}
+	s.synced.Store(true)
 
 	return nil
 }
 
+// Synced - returns true if the slave has synced with the master at least once.
+func (s *Slave) Synced() bool {
+	return s.synced.Load()
+}
+
 // applySegment - applies the received segment data to the slave's write-ahead log (WAL) and stream.
 func (s *Slave) applySegment(payload []byte) error {
 	if len(payload) == 0 {
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/neekrasov/kvdb/internal/database/compute"
+	"github.com/neekrasov/kvdb/internal/database/identity/models"
+	"github.com/neekrasov/kvdb/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// sizeOverrides - in-memory index of the maximum value size overrides
+// persisted in the system namespace.
+type sizeOverrides struct {
+	mu       sync.RWMutex
+	prefix   string // Prefix of the override keys.
+	patterns map[string]int
+}
+
+// newSizeOverrides - creates an empty overrides index of the keys with the separator.
+func newSizeOverrides(separator string) *sizeOverrides {
+	return &sizeOverrides{
+		prefix:   JoinKey(separator, models.SystemMaxSizeNameSpace, ""),
+		patterns: make(map[string]int),
+	}
+}
+
+// isOverrideKey - checks whether the key stores a maximum value size override.
+func (o *sizeOverrides) isOverrideKey(key string) bool {
+	return strings.HasPrefix(key, o.prefix)
+}
+
+// apply - updates the index if the operation touches an override key.
+func (o *sizeOverrides) apply(op compute.CommandID, key, value string) {
+	pattern, ok := strings.CutPrefix(key, o.prefix)
+	if !ok {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	switch op {
+	case compute.SetCommandID:
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			logger.Warn("invalid max size override",
+				zap.String("pattern", pattern), zap.Error(err))
+			return
+		}
+
+		o.patterns[pattern] = size
+	case compute.DelCommandID:
+		delete(o.patterns, pattern)
+	}
+}
+
+// match - returns the largest override among patterns matching the key.
+func (o *sizeOverrides) match(key string) (int, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	var (
+		size    int
+		matched bool
+	)
+	for pattern, limit := range o.patterns {
+		if ok, _ := path.Match(pattern, key); ok && limit > size {
+			size, matched = limit, true
+		}
+	}
+
+	return size, matched
+}
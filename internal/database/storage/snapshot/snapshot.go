@@ -0,0 +1,157 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/neekrasov/kvdb/pkg/gob"
+	"github.com/neekrasov/kvdb/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	filePrefix = "snapshot_"
+	tmpSuffix  = ".tmp"
+)
+
+// Entry - a single key of the engine state stored in the snapshot.
+type Entry struct {
+	Key   string
+	Value string
+	TTL   int64
+}
+
+// FileSnapshotter - stores engine state snapshots as snapshot_<lsn> files in the directory.
+type FileSnapshotter struct {
+	dir string
+}
+
+// NewFileSnapshotter - initializes and returns a new FileSnapshotter, creating the directory if needed.
+func NewFileSnapshotter(dir string) (*FileSnapshotter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	return &FileSnapshotter{dir: dir}, nil
+}
+
+// Save - writes the entries into the snapshot of the LSN and removes the older snapshots.
+// The snapshot is written into a temporary file first, so a crash never leaves a partial snapshot.
+func (fs *FileSnapshotter) Save(lsn int64, entries []Entry) error {
+	data, err := gob.Encode(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	path := fs.path(lsn)
+	tmpPath := path + tmpSuffix
+	if err := writeFile(tmpPath, data); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to rename snapshot: %w", err)
+	}
+
+	lsns, err := fs.list()
+	if err != nil {
+		return err
+	}
+
+	for _, old := range lsns {
+		if old >= lsn {
+			continue
+		}
+
+		if err := os.Remove(fs.path(old)); err != nil {
+			logger.Warn("failed to remove old snapshot", zap.Int64("lsn", old), zap.Error(err))
+		}
+	}
+
+	logger.Debug("saved snapshot", zap.Int64("lsn", lsn), zap.Int("entries", len(entries)))
+
+	return nil
+}
+
+// Load - returns the LSN and the entries of the latest snapshot.
+// Returns zero LSN if there are no snapshots.
+func (fs *FileSnapshotter) Load() (int64, []Entry, error) {
+	lsns, err := fs.list()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if len(lsns) == 0 {
+		return 0, nil, nil
+	}
+
+	lsn := lsns[len(lsns)-1]
+	data, err := os.ReadFile(fs.path(lsn))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read snapshot %d: %w", lsn, err)
+	}
+
+	var entries []Entry
+	if err := gob.Decode(data, &entries); err != nil {
+		return 0, nil, fmt.Errorf("failed to decode snapshot %d: %w", lsn, err)
+	}
+
+	return lsn, entries, nil
+}
+
+// list - returns LSNs of the stored snapshots in ascending order.
+func (fs *FileSnapshotter) list() ([]int64, error) {
+	files, err := os.ReadDir(fs.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var lsns []int64
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasPrefix(name, filePrefix) || strings.HasSuffix(name, tmpSuffix) {
+			continue
+		}
+
+		var lsn int64
+		if _, err := fmt.Sscanf(name, filePrefix+"%d", &lsn); err != nil {
+			continue
+		}
+
+		lsns = append(lsns, lsn)
+	}
+
+	sort.Slice(lsns, func(i, j int) bool { return lsns[i] < lsns[j] })
+
+	return lsns, nil
+}
+
+// path - returns the path of the snapshot file of the LSN.
+func (fs *FileSnapshotter) path(lsn int64) string {
+	return filepath.Join(fs.dir, fmt.Sprintf("%s%d", filePrefix, lsn))
+}
+
+// writeFile - writes the data into the file and syncs it to the disk.
+func writeFile(path string, data []byte) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
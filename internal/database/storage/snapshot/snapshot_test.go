@@ -0,0 +1,75 @@
+package snapshot_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neekrasov/kvdb/internal/database/storage/snapshot"
+	"github.com/neekrasov/kvdb/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSnapshotter(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	t.Run("Load without snapshots", func(t *testing.T) {
+		snapshotter, err := snapshot.NewFileSnapshotter(filepath.Join(t.TempDir(), "snapshots"))
+		require.NoError(t, err)
+
+		lsn, entries, err := snapshotter.Load()
+		require.NoError(t, err)
+		assert.Zero(t, lsn)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("Save and load the latest snapshot", func(t *testing.T) {
+		dir := t.TempDir()
+		snapshotter, err := snapshot.NewFileSnapshotter(dir)
+		require.NoError(t, err)
+
+		require.NoError(t, snapshotter.Save(10, []snapshot.Entry{{Key: "old", Value: "1"}}))
+
+		expected := []snapshot.Entry{
+			{Key: "a", Value: "1"},
+			{Key: "b", Value: "2", TTL: 1700000000},
+		}
+		require.NoError(t, snapshotter.Save(20, expected))
+
+		lsn, entries, err := snapshotter.Load()
+		require.NoError(t, err)
+		assert.Equal(t, int64(20), lsn)
+		assert.Equal(t, expected, entries)
+
+		_, err = os.Stat(filepath.Join(dir, "snapshot_10"))
+		assert.True(t, os.IsNotExist(err), "older snapshot must be removed")
+	})
+
+	t.Run("Incomplete snapshot is ignored", func(t *testing.T) {
+		dir := t.TempDir()
+		snapshotter, err := snapshot.NewFileSnapshotter(dir)
+		require.NoError(t, err)
+
+		require.NoError(t, snapshotter.Save(5, []snapshot.Entry{{Key: "a", Value: "1"}}))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "snapshot_7.tmp"), []byte("partial"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "segment_1.wal"), []byte("segment"), 0644))
+
+		lsn, entries, err := snapshotter.Load()
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), lsn)
+		assert.Equal(t, []snapshot.Entry{{Key: "a", Value: "1"}}, entries)
+	})
+
+	t.Run("Corrupted snapshot", func(t *testing.T) {
+		dir := t.TempDir()
+		snapshotter, err := snapshot.NewFileSnapshotter(dir)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "snapshot_3"), []byte("garbage"), 0644))
+
+		_, _, err = snapshotter.Load()
+		assert.ErrorContains(t, err, "failed to decode snapshot 3")
+	})
+}
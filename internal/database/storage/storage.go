@@ -1,14 +1,24 @@
 package storage
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"math/rand/v2"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/neekrasov/kvdb/internal/database/compute"
+	"github.com/neekrasov/kvdb/internal/database/identity/models"
+	"github.com/neekrasov/kvdb/internal/database/storage/engine"
 	"github.com/neekrasov/kvdb/internal/database/storage/replication"
+	"github.com/neekrasov/kvdb/internal/database/storage/snapshot"
 	"github.com/neekrasov/kvdb/internal/database/storage/wal"
 	"github.com/neekrasov/kvdb/pkg/ctxutil"
 	"github.com/neekrasov/kvdb/pkg/logger"
@@ -19,40 +29,97 @@ import (
 var (
 	ErrorMutableOp = errors.New("mutable operation on slave")
 	ErrKeyNotFound = errors.New("key not found")
+
+	// ErrStatsDisabled - is returned when statistics collection is disabled.
+	ErrStatsDisabled = errors.New("statistics disabled")
+
+	// ErrSnapshotsDisabled - is returned when the storage is configured without a snapshotter.
+	ErrSnapshotsDisabled = errors.New("snapshots disabled")
+
+	// ErrValueTooLarge - is returned when the value exceeds the maximum size allowed for the key.
+	ErrValueTooLarge = errors.New("value too large")
+
+	// ErrCleanupDisabled - is returned when the background cleanup of expired keys is not running.
+	ErrCleanupDisabled = errors.New("background cleanup disabled")
+
+	// ErrAccessNotTracked - is returned when the engine does not track the last access of the keys.
+	ErrAccessNotTracked = errors.New("access tracking disabled")
 )
 
+// delBatchSize - number of deletes flushed to the WAL in a single batch.
+const delBatchSize = 100
+
 type (
 	// Stats - structure for storing 'storage' statistics.
 	Stats struct {
 		StartTime     time.Time    `json:"start_time"`     // Server startup time.
 		TotalCommands atomic.Int64 `json:"total_commands"` // Total number of commands executed.
-		GetCommands   atomic.Int64 `json:"get_commands"`   // Number of GET commands.
+		GetCommands   atomic.Int64 `json:"get_commands"`   // Number of GET, GETSET and GETDEL commands.
 		SetCommands   atomic.Int64 `json:"set_commands"`   // Number of SET commands.
 		DelCommands   atomic.Int64 `json:"del_commands"`   // Number of DEL commands.
 		TotalKeys     atomic.Int64 `json:"total_keys"`     // Total number of keys in the storage (approximate).
 		ExpiredKeys   atomic.Int64 `json:"expired_keys"`   // Number of expired keys (deleted).
+		EvictedKeys   atomic.Int64 `json:"evicted_keys"`   // Number of keys evicted as not accessed within the idle eviction.
+		Hits          atomic.Int64 `json:"hits"`           // Number of GET, GETSET and GETDEL commands that found the key.
+		Misses        atomic.Int64 `json:"misses"`         // Number of GET, GETSET and GETDEL commands that did not find the key.
 	}
 
 	// Engine - key-value storage operations.
 	Engine interface {
 		Set(ctx context.Context, key, value string, ttl int64)
 		Get(ctx context.Context, key string) (string, bool)
-		Del(ctx context.Context, key string) error
+		LastAccess(ctx context.Context, key string) (time.Time, bool)
+		Entry(ctx context.Context, key string) (engine.Entry, bool)
+		Del(ctx context.Context, key string) bool
+		Expired(ctx context.Context, key string) bool
+		DelExpired(ctx context.Context, key string) bool
+		DelIdle(ctx context.Context, key string, deadline time.Time) bool
+		RenameNX(ctx context.Context, oldKey, newKey string) (bool, bool)
 		Watch(ctx context.Context, key string) pkgsync.FutureString
 		ForEachExpired(action func(key string))
+		ForEachIdle(deadline time.Time, action func(key string))
+		CountByPrefix(prefix string) int
+		KeysByPrefix(prefix string) []string
+		Scan(prefix string, cursor uint64, count int, match func(key string) bool) ([]string, uint64)
+		ForEach(action func(key, value string, ttl int64))
+		ForEachExpiring(prefix string, deadline int64, action func(key string, ttl int64))
 	}
 
 	// WAL - Write-Ahead Log interface for data persistence.
 	WAL interface {
 		Set(ctx context.Context, key, value string) error
 		Del(ctx context.Context, key string) error
-		Recover(applyFunc func(ctx context.Context, entry []wal.LogEntry) error) (int64, error)
+		RenameNX(ctx context.Context, oldKey, newKey string) error
+		Recover(ctx context.Context, applyFunc func(ctx context.Context, entry []wal.LogEntry) error) (int64, error)
 		Flush(batch []wal.WriteEntry) error
+		Latency() wal.LatencyStats
+		WriteErrors() int64
+		Compact() (wal.CompactionResult, error)
+		Compactions() wal.CompactionStats
+		Sync() error
+		Truncate(lsn int64) (int, error)
 	}
 
 	Replica interface {
 		IsMaster() bool
 	}
+
+	// SyncedReplica - replica reporting whether it has synced with the master.
+	SyncedReplica interface {
+		Synced() bool
+	}
+
+	// Readiness - readiness of the storage to serve queries.
+	Readiness struct {
+		WALRecovered  bool `json:"wal_recovered"`  // The state is restored from the snapshot and the WAL.
+		ReplicaSynced bool `json:"replica_synced"` // The slave has synced with the master at least once, always true on a master.
+	}
+
+	// Snapshotter - persists snapshots of the engine state.
+	Snapshotter interface {
+		Save(lsn int64, entries []snapshot.Entry) error
+		Load() (int64, []snapshot.Entry, error)
+	}
 )
 
 // Storage - struct that provides a higher-level abstraction
@@ -64,10 +131,27 @@ type Storage struct {
 	wal     WAL
 	gen     *pkgsync.IDGenerator
 
+	// writeMu - serializes renames against other writes, so the rename
+	// decision and its LSN follow the same order as the replay.
+	writeMu sync.RWMutex
+
 	stats *Stats
 
+	keySeparator  string
+	maxValueSize  int
+	sizeOverrides *sizeOverrides
+
 	cleanupPeriod    time.Duration
+	cleanupJitter    time.Duration
 	cleanupBatchSize int
+	cleanupReset     chan time.Duration
+	idleEviction     time.Duration
+
+	snapshotter    Snapshotter
+	snapshotPeriod time.Duration
+	snapshotLSN    int64
+
+	recovered atomic.Bool
 }
 
 // NewStorage - initializes and returns a new Storage instance with the provided storage engine.
@@ -76,19 +160,31 @@ func NewStorage(
 	engine Engine,
 	opts ...StorageOpt,
 ) (*Storage, error) {
-	s := &Storage{engine: engine}
+	s := &Storage{engine: engine, keySeparator: DefaultKeySeparator}
 	for _, option := range opts {
 		option(s)
 	}
+	s.sizeOverrides = newSizeOverrides(s.keySeparator)
 
 	var lastLSN int64
+	if s.snapshotter != nil {
+		lsn, entries, err := s.snapshotter.Load()
+		if err != nil {
+			return nil, fmt.Errorf("snapshot loading failed: %w", err)
+		}
+
+		s.restore(ctx, entries)
+		s.snapshotLSN, lastLSN = lsn, lsn
+	}
+
 	if s.wal != nil {
-		var err error
-		lastLSN, err = s.wal.Recover(s.applyFunc)
+		walLSN, err := s.wal.Recover(ctx, s.applyFunc)
 		if err != nil {
 			return nil, fmt.Errorf("wal recovering failed: %w", err)
 		}
+		lastLSN = max(lastLSN, walLSN)
 	}
+	s.recovered.Store(true)
 
 	if s.stream != nil {
 		go func() {
@@ -105,30 +201,30 @@ func NewStorage(
 
 	if s.cleanupPeriod != 0 &&
 		(s.replica == nil || s.replica.IsMaster()) {
+		s.cleanupReset = make(chan time.Duration, 1)
 		go s.startCleanupExpiresKeys(ctx)
 	}
 
+	if s.snapshotter != nil && s.snapshotPeriod != 0 &&
+		(s.replica == nil || s.replica.IsMaster()) {
+		go s.startSnapshots(ctx)
+	}
+
 	return s, nil
 }
 
 // Set - stores a key-value pair in the storage
 func (s *Storage) Set(ctx context.Context, key, value string) error {
-	if s.replica != nil && !s.replica.IsMaster() {
-		return ErrorMutableOp
+	ttl, err := s.prepareSet(ctx, key, value)
+	if err != nil {
+		return err
 	}
 
-	var ttl int64
-	if ttlStr := ctxutil.ExtractTTL(ctx); ttlStr != "" {
-		duration, err := time.ParseDuration(ttlStr)
-		if err != nil {
-			return fmt.Errorf("invalid format to ttl: %w", err)
-		}
-
-		ttl = time.Now().Unix() + (duration.Nanoseconds() / 1e9)
-	}
+	s.writeMu.RLock()
+	defer s.writeMu.RUnlock()
 
 	ctx = ctxutil.InjectTxID(ctx, s.gen.Generate())
-	err := s.wal.Set(ctx, key, value)
+	err = s.wal.Set(ctx, key, value)
 	if err != nil {
 		return err
 	}
@@ -143,54 +239,281 @@ func (s *Storage) Set(ctx context.Context, key, value string) error {
 	}
 
 	s.engine.Set(ctx, key, value, ttl)
+	s.sizeOverrides.apply(compute.SetCommandID, key, value)
 
 	return nil
 }
 
+// GetSet - stores a key-value pair and returns the previous value of the key and whether the key existed.
+// The writes are excluded until the value is stored, so the previous value is returned to a single writer.
+func (s *Storage) GetSet(ctx context.Context, key, value string) (string, bool, error) {
+	ttl, err := s.prepareSet(ctx, key, value)
+	if err != nil {
+		return "", false, err
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	ctx = ctxutil.InjectTxID(ctx, s.gen.Generate())
+	old, exists := s.engine.Get(ctx, key)
+	if err := s.wal.Set(ctx, key, value); err != nil {
+		return "", false, err
+	}
+
+	s.engine.Set(ctx, key, value, ttl)
+	s.sizeOverrides.apply(compute.SetCommandID, key, value)
+
+	if s.stats != nil {
+		s.stats.GetCommands.Add(1)
+		s.stats.SetCommands.Add(1)
+		s.stats.TotalCommands.Add(1)
+		if exists {
+			s.stats.Hits.Add(1)
+		} else {
+			s.stats.Misses.Add(1)
+			s.stats.TotalKeys.Add(1)
+		}
+	}
+
+	return old, exists, nil
+}
+
+// MSetNX - stores all the key-value pairs only if none of the keys exist. Returns whether the pairs are stored.
+// The writes are excluded until the pairs are stored, and the pairs are written to the WAL in a single batch.
+func (s *Storage) MSetNX(ctx context.Context, keys, values []string) (bool, error) {
+	if len(keys) != len(values) {
+		return false, fmt.Errorf("got %d keys and %d values", len(keys), len(values))
+	}
+
+	ttls := make([]int64, len(keys))
+	for i, key := range keys {
+		ttl, err := s.prepareSet(ctx, key, values[i])
+		if err != nil {
+			return false, err
+		}
+		ttls[i] = ttl
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	ctx = ctxutil.InjectTxID(ctx, s.gen.Generate())
+	unique := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		if _, exists := s.engine.Get(ctx, key); exists {
+			return false, nil
+		}
+		unique[key] = struct{}{}
+	}
+
+	entries := make([]wal.WriteEntry, 0, len(keys))
+	for i, key := range keys {
+		entries = append(entries, wal.NewWriteEntry(
+			s.gen.Generate(), compute.SetCommandID, []string{key, values[i]},
+		))
+	}
+
+	if err := s.wal.Flush(entries); err != nil {
+		return false, err
+	}
+
+	for i, key := range keys {
+		s.engine.Set(ctx, key, values[i], ttls[i])
+		s.sizeOverrides.apply(compute.SetCommandID, key, values[i])
+	}
+
+	if s.stats != nil {
+		s.stats.SetCommands.Add(int64(len(keys)))
+		s.stats.TotalCommands.Add(int64(len(keys)))
+		s.stats.TotalKeys.Add(int64(len(unique)))
+	}
+
+	return true, nil
+}
+
+// prepareSet - checks that the value may be stored and returns the expiration time of the ttl from the context.
+func (s *Storage) prepareSet(ctx context.Context, key, value string) (int64, error) {
+	if s.replica != nil && !s.replica.IsMaster() {
+		return 0, ErrorMutableOp
+	}
+
+	var ttl int64
+	if ttlStr := ctxutil.ExtractTTL(ctx); ttlStr != "" {
+		duration, err := time.ParseDuration(ttlStr)
+		if err != nil {
+			return 0, fmt.Errorf("invalid format to ttl: %w", err)
+		}
+
+		ttl = time.Now().Unix() + (duration.Nanoseconds() / 1e9)
+	}
+
+	if limit := s.MaxSize(key); limit > 0 && len(value) > limit && !s.sizeOverrides.isOverrideKey(key) {
+		return 0, ErrValueTooLarge
+	}
+
+	return ttl, nil
+}
+
 // Get - retrieves the value associated with a key from the storage
 func (s *Storage) Get(ctx context.Context, key string) (string, error) {
 	txID := s.gen.Generate()
 	ctx = ctxutil.InjectTxID(ctx, txID)
 
 	val, exists := s.engine.Get(ctx, key)
-	if !exists {
-		return "", ErrKeyNotFound
-	}
-
 	if s.stats != nil {
 		s.stats.GetCommands.Add(1)
 		s.stats.TotalCommands.Add(1)
+		if exists {
+			s.stats.Hits.Add(1)
+		} else {
+			s.stats.Misses.Add(1)
+		}
+	}
+
+	if !exists {
+		return "", ErrKeyNotFound
 	}
 
 	return val, nil
 }
 
-// Del - deletes a key-value pair from the storage.
-func (s *Storage) Del(ctx context.Context, key string) error {
+// LastAccess - returns the time the key was last read or written.
+func (s *Storage) LastAccess(ctx context.Context, key string) (time.Time, error) {
+	ctx = ctxutil.InjectTxID(ctx, s.gen.Generate())
+
+	accessed, exists := s.engine.LastAccess(ctx, key)
+	if !exists {
+		return time.Time{}, ErrKeyNotFound
+	}
+
+	if accessed.IsZero() {
+		return time.Time{}, ErrAccessNotTracked
+	}
+
+	return accessed, nil
+}
+
+// Entry - returns the stored value of the key with its metadata, the access time of the key is not updated.
+func (s *Storage) Entry(ctx context.Context, key string) (engine.Entry, error) {
+	ctx = ctxutil.InjectTxID(ctx, s.gen.Generate())
+
+	entry, exists := s.engine.Entry(ctx, key)
+	if !exists {
+		return engine.Entry{}, ErrKeyNotFound
+	}
+
+	return entry, nil
+}
+
+// Del - deletes a key-value pair from the storage. Returns whether the key existed.
+func (s *Storage) Del(ctx context.Context, key string) (bool, error) {
 	if s.replica != nil && !s.replica.IsMaster() {
-		return ErrorMutableOp
+		return false, ErrorMutableOp
 	}
 
+	s.writeMu.RLock()
+	defer s.writeMu.RUnlock()
+
 	txID := s.gen.Generate()
 	ctx = ctxutil.InjectTxID(ctx, txID)
 
 	err := s.wal.Del(ctx, key)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	err = s.engine.Del(ctx, key)
-	if err != nil {
-		return err
+	existed := s.engine.Del(ctx, key)
+	s.sizeOverrides.apply(compute.DelCommandID, key, "")
+
+	if s.stats != nil {
+		s.stats.DelCommands.Add(1)
+		s.stats.TotalCommands.Add(1)
+		if existed {
+			s.stats.TotalKeys.Add(-1)
+		}
 	}
 
+	return existed, nil
+}
+
+// GetDel - retrieves the value of the key and deletes the key, the delete is written to the WAL.
+// The writes are excluded until the key is deleted, so the value is returned only once.
+func (s *Storage) GetDel(ctx context.Context, key string) (string, error) {
+	if s.replica != nil && !s.replica.IsMaster() {
+		return "", ErrorMutableOp
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	txID := s.gen.Generate()
+	ctx = ctxutil.InjectTxID(ctx, txID)
+
+	val, exists := s.engine.Get(ctx, key)
+	if !exists {
+		if s.stats != nil {
+			s.stats.GetCommands.Add(1)
+			s.stats.TotalCommands.Add(1)
+			s.stats.Misses.Add(1)
+		}
+
+		return "", ErrKeyNotFound
+	}
+
+	if err := s.wal.Del(ctx, key); err != nil {
+		return "", err
+	}
+
+	s.engine.Del(ctx, key)
+	s.sizeOverrides.apply(compute.DelCommandID, key, "")
+
 	if s.stats != nil {
+		s.stats.GetCommands.Add(1)
 		s.stats.DelCommands.Add(1)
 		s.stats.TotalCommands.Add(1)
 		s.stats.TotalKeys.Add(-1)
+		s.stats.Hits.Add(1)
 	}
 
-	return nil
+	return val, nil
+}
+
+// RenameNX - renames the key only if the new key does not exist.
+// Returns whether the rename happened. Only performed renames are written to the WAL.
+func (s *Storage) RenameNX(ctx context.Context, oldKey, newKey string) (bool, error) {
+	if s.replica != nil && !s.replica.IsMaster() {
+		return false, ErrorMutableOp
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if _, exists := s.engine.Get(ctx, oldKey); !exists {
+		return false, ErrKeyNotFound
+	}
+
+	if _, exists := s.engine.Get(ctx, newKey); exists {
+		return false, nil
+	}
+
+	txID := s.gen.Generate()
+	ctx = ctxutil.InjectTxID(ctx, txID)
+
+	if err := s.wal.RenameNX(ctx, oldKey, newKey); err != nil {
+		return false, err
+	}
+
+	renamed, exists := s.engine.RenameNX(ctx, oldKey, newKey)
+	if !exists {
+		return false, ErrKeyNotFound
+	}
+
+	if s.stats != nil {
+		s.stats.TotalCommands.Add(1)
+	}
+
+	return renamed, nil
 }
 
 // Watch - watches the key and returns the value if it has changed.
@@ -201,32 +524,268 @@ func (s *Storage) Watch(ctx context.Context, key string) pkgsync.FutureString {
 	return s.engine.Watch(ctx, key)
 }
 
-// MakeKey - constructs a key by combining a namespace and a key name using a colon (:).
+// DelByPrefix - deletes all keys starting with the prefix, batching the WAL writes.
+// Returns the number of deleted keys.
+func (s *Storage) DelByPrefix(ctx context.Context, prefix string) (int, error) {
+	if s.replica != nil && !s.replica.IsMaster() {
+		return 0, ErrorMutableOp
+	}
+
+	s.writeMu.RLock()
+	defer s.writeMu.RUnlock()
+
+	return s.delKeys(ctx, s.engine.KeysByPrefix(prefix))
+}
+
+// DelMany - deletes the keys, batching the WAL writes. Returns the number of deleted existing keys.
+func (s *Storage) DelMany(ctx context.Context, keys []string) (int, error) {
+	if s.replica != nil && !s.replica.IsMaster() {
+		return 0, ErrorMutableOp
+	}
+
+	s.writeMu.RLock()
+	defer s.writeMu.RUnlock()
+
+	return s.delKeys(ctx, keys)
+}
+
+// delKeys - writes the deletes of the keys to the WAL in batches and removes the keys from the engine.
+// The caller must hold the write lock.
+func (s *Storage) delKeys(ctx context.Context, keys []string) (int, error) {
+	var deleted int
+	entries := make([]wal.WriteEntry, 0, delBatchSize)
+	for batch := range slices.Chunk(keys, delBatchSize) {
+		entries = entries[:0]
+		for _, key := range batch {
+			entries = append(entries, wal.NewWriteEntry(
+				s.gen.Generate(), compute.DelCommandID, []string{key},
+			))
+		}
+
+		if err := s.wal.Flush(entries); err != nil {
+			return deleted, err
+		}
+
+		for _, key := range batch {
+			if !s.engine.Del(ctx, key) {
+				continue
+			}
+			s.sizeOverrides.apply(compute.DelCommandID, key, "")
+			deleted++
+
+			if s.stats != nil {
+				s.stats.DelCommands.Add(1)
+				s.stats.TotalCommands.Add(1)
+				s.stats.TotalKeys.Add(-1)
+			}
+		}
+	}
+
+	return deleted, nil
+}
+
+// SetMaxSize - stores the maximum value size override for keys matching the pattern.
+// A zero size removes the override.
+func (s *Storage) SetMaxSize(ctx context.Context, pattern string, size int) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	key := s.MakeKey(models.SystemMaxSizeNameSpace, pattern)
+	if size <= 0 {
+		_, err := s.Del(ctx, key)
+		return err
+	}
+
+	return s.Set(ctx, key, strconv.Itoa(size))
+}
+
+// MaxSize - returns the maximum value size for the key, zero means unlimited.
+// Overrides matching the key take precedence over the global limit,
+// so an override also limits keys when no global limit is configured.
+func (s *Storage) MaxSize(key string) int {
+	if size, ok := s.sizeOverrides.match(key); ok {
+		return size
+	}
+
+	return s.maxValueSize
+}
+
+// WALLatency - returns the WAL write latency percentiles.
+func (s *Storage) WALLatency() wal.LatencyStats {
+	return s.wal.Latency()
+}
+
+// WALWriteErrors - returns the number of WAL batches that failed to be written.
+func (s *Storage) WALWriteErrors() int64 {
+	return s.wal.WriteErrors()
+}
+
+// CompactWAL - synchronously runs a WAL compaction pass.
+func (s *Storage) CompactWAL() (wal.CompactionResult, error) {
+	if s.wal == nil {
+		return wal.CompactionResult{}, wal.ErrCompactionDisabled
+	}
+
+	return s.wal.Compact()
+}
+
+// SyncWAL - writes the pending WAL batch and commits the log to the disk.
+func (s *Storage) SyncWAL() error {
+	if s.wal == nil {
+		return wal.ErrSyncUnavailable
+	}
+
+	return s.wal.Sync()
+}
+
+// WALCompactions - returns the summary of the completed WAL compaction passes.
+func (s *Storage) WALCompactions() wal.CompactionStats {
+	if s.wal == nil {
+		return wal.CompactionStats{}
+	}
+
+	return s.wal.Compactions()
+}
+
+// Readiness - returns whether the state is recovered and the slave has synced with the master.
+func (s *Storage) Readiness() Readiness {
+	readiness := Readiness{WALRecovered: s.recovered.Load(), ReplicaSynced: true}
+	if replica, ok := s.replica.(SyncedReplica); ok && !s.replica.IsMaster() {
+		readiness.ReplicaSynced = replica.Synced()
+	}
+
+	return readiness
+}
+
+// CountByPrefix - returns the number of keys starting with the prefix.
+func (s *Storage) CountByPrefix(prefix string) int {
+	return s.engine.CountByPrefix(prefix)
+}
+
+// Scan - returns up to count keys starting with the prefix and the next cursor, zero when the iteration is done.
+// Keys are returned without the prefix, the pattern is matched against them the same way as in the size overrides.
+func (s *Storage) Scan(prefix string, cursor uint64, pattern string, count int) ([]string, uint64, error) {
+	var match func(key string) bool
+	if pattern != "" {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, 0, fmt.Errorf("invalid pattern: %w", err)
+		}
+
+		match = func(key string) bool {
+			ok, _ := path.Match(pattern, strings.TrimPrefix(key, prefix))
+			return ok
+		}
+	}
+
+	keys, next := s.engine.Scan(prefix, cursor, count, match)
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, prefix)
+	}
+
+	return keys, next, nil
+}
+
+// Expiring - returns the keys starting with the prefix and expiring within the duration, the soonest first.
+// Keys are returned without the prefix, the keys without TTL never expire and are not returned.
+func (s *Storage) Expiring(prefix string, within time.Duration) []string {
+	type expiring struct {
+		key string
+		ttl int64
+	}
+
+	var items []expiring
+	deadline := time.Now().Add(within).Unix()
+	s.engine.ForEachExpiring(prefix, deadline, func(key string, ttl int64) {
+		items = append(items, expiring{key: strings.TrimPrefix(key, prefix), ttl: ttl})
+	})
+
+	slices.SortFunc(items, func(a, b expiring) int {
+		return cmp.Or(cmp.Compare(a.ttl, b.ttl), cmp.Compare(a.key, b.key))
+	})
+
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, item.key)
+	}
+
+	return keys
+}
+
+// Export - returns the entries of the keys starting with the prefix, keys are returned without the prefix.
+func (s *Storage) Export(prefix string) []snapshot.Entry {
+	var entries []snapshot.Entry
+	s.engine.ForEach(func(key, value string, ttl int64) {
+		if name, ok := strings.CutPrefix(key, prefix); ok {
+			entries = append(entries, snapshot.Entry{Key: name, Value: value, TTL: ttl})
+		}
+	})
+
+	return entries
+}
+
+// DefaultKeySeparator - default separator of the namespace and the key name in the storage keys.
+const DefaultKeySeparator = ":"
+
+// KeySeparator - returns the separator of the namespace and the key name in the keys of the storage.
+func (s *Storage) KeySeparator() string {
+	return s.keySeparator
+}
+
+// MakeKey - constructs a key of the storage by combining a namespace and a key name.
+func (s *Storage) MakeKey(namespace, key string) string {
+	return JoinKey(s.keySeparator, namespace, key)
+}
+
+// KeySeparatorOf - returns the key separator of the storage, the default one
+// if the storage does not report it, e.g. a mock.
+func KeySeparatorOf(storage any) string {
+	if separated, ok := storage.(interface{ KeySeparator() string }); ok {
+		return separated.KeySeparator()
+	}
+
+	return DefaultKeySeparator
+}
+
+// JoinKey - constructs a key by combining a namespace and a key name using the separator.
+// The key name may contain the separator, the namespace ends at its first occurrence.
+func JoinKey(separator, namespace, key string) string {
+	return namespace + separator + key
+}
+
+// MakeKey - constructs a key with the default separator, see JoinKey.
 func MakeKey(namespace, key string) string {
-	return namespace + ":" + key
+	return JoinKey(DefaultKeySeparator, namespace, key)
 }
 
 func (s *Storage) applyFunc(ctx context.Context, entries []wal.LogEntry) error {
 	var lastLSN int64
 	for _, entry := range entries {
+		// Entries up to the snapshot LSN are already restored from the snapshot.
+		if entry.LSN <= s.snapshotLSN {
+			continue
+		}
+
 		lastLSN = max(lastLSN, entry.LSN)
 		ctx := ctxutil.InjectTxID(ctx, entry.LSN)
 
 		switch entry.Operation {
 		case compute.SetCommandID:
 			s.engine.Set(ctx, entry.Args[0], entry.Args[1], 0)
+			s.sizeOverrides.apply(entry.Operation, entry.Args[0], entry.Args[1])
 
 			if s.stats != nil {
 				s.stats.SetCommands.Add(1)
 			}
 		case compute.DelCommandID:
-			if err := s.engine.Del(ctx, entry.Args[0]); err != nil {
-				return fmt.Errorf("apply del (%s) failed: %w", entry.Args[0], err)
-			}
+			s.engine.Del(ctx, entry.Args[0])
+			s.sizeOverrides.apply(entry.Operation, entry.Args[0], "")
 
 			if s.stats != nil {
 				s.stats.DelCommands.Add(1)
 			}
+		case compute.RenameNXCommandID:
+			s.engine.RenameNX(ctx, entry.Args[0], entry.Args[1])
 		case compute.UnknownCommandID:
 			return nil
 		default:
@@ -247,30 +806,110 @@ func (s *Storage) applyFunc(ctx context.Context, entries []wal.LogEntry) error {
 	return nil
 }
 
-func (s *Storage) startCleanupExpiresKeys(ctx context.Context) {
-	ticker := time.NewTicker(s.cleanupPeriod)
+// Snapshot - saves the snapshot of the engine state. Writes are paused while the state is copied,
+// so the snapshot contains exactly the entries with LSN up to the snapshot LSN. The WAL segments
+// holding only such entries are removed afterwards.
+func (s *Storage) Snapshot(ctx context.Context) error {
+	if s.snapshotter == nil {
+		return ErrSnapshotsDisabled
+	}
+
+	var (
+		lsn     int64
+		entries []snapshot.Entry
+	)
+	pkgsync.WithLock(&s.writeMu, func() {
+		lsn = s.gen.Generate()
+		s.engine.ForEach(func(key, value string, ttl int64) {
+			entries = append(entries, snapshot.Entry{Key: key, Value: value, TTL: ttl})
+		})
+	})
+
+	if err := s.snapshotter.Save(lsn, entries); err != nil {
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+
+	logger.Info("snapshot saved", zap.Int64("lsn", lsn), zap.Int("keys", len(entries)))
+
+	// The segments covered by the snapshot are no longer replayed. Replicas fetch
+	// segments by number, so with replication the log is kept whole.
+	if s.wal != nil && s.replica == nil {
+		if _, err := s.wal.Truncate(lsn); err != nil {
+			logger.Warn("failed to truncate wal after snapshot", zap.Int64("lsn", lsn), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// restore - loads the snapshot entries into the engine.
+func (s *Storage) restore(ctx context.Context, entries []snapshot.Entry) {
+	for _, entry := range entries {
+		s.engine.Set(ctx, entry.Key, entry.Value, entry.TTL)
+		s.sizeOverrides.apply(compute.SetCommandID, entry.Key, entry.Value)
+	}
+
+	logger.Info("snapshot restored", zap.Int("keys", len(entries)))
+}
+
+func (s *Storage) startSnapshots(ctx context.Context) {
+	ticker := time.NewTicker(s.snapshotPeriod)
 	defer ticker.Stop()
 
-	entries := make([]wal.WriteEntry, 0, s.cleanupBatchSize)
 	for {
 		select {
 		case <-ticker.C:
+			if err := s.Snapshot(ctx); err != nil {
+				logger.Warn("snapshot failed", zap.Error(err))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Storage) startCleanupExpiresKeys(ctx context.Context) {
+	period := s.cleanupPeriod
+	timer := time.NewTimer(s.cleanupInterval(period))
+	defer timer.Stop()
+
+	var keys []string
+	for {
+		select {
+		case <-timer.C:
 			logger.Debug("start removing expires keys")
 
+			// The keys are removed after the scan, the engine partitions are locked while it runs.
 			s.engine.ForEachExpired(func(key string) {
-				entries = append(entries, wal.NewWriteEntry(
-					s.gen.Generate(), compute.DelCommandID, []string{key},
-				))
-				if len(entries) == s.cleanupBatchSize {
-					s.cleanupKeys(ctx, entries)
-					entries = entries[:0]
-				}
+				keys = append(keys, key)
 			})
 
-			if len(entries) > 0 {
-				s.cleanupKeys(ctx, entries)
-				entries = entries[:0]
+			for batch := range slices.Chunk(keys, max(s.cleanupBatchSize, 1)) {
+				s.cleanupKeys(ctx, batch)
 			}
+			keys = keys[:0]
+
+			if s.idleEviction > 0 {
+				deadline := time.Now().Add(-s.idleEviction)
+				s.engine.ForEachIdle(deadline, func(key string) {
+					// The users, roles and namespaces are kept however long they are not accessed.
+					namespace, _, _ := strings.Cut(key, s.keySeparator)
+					if !models.IsSystemNamespace(namespace) {
+						keys = append(keys, key)
+					}
+				})
+
+				for batch := range slices.Chunk(keys, max(s.cleanupBatchSize, 1)) {
+					s.evictKeys(ctx, batch, deadline)
+				}
+				keys = keys[:0]
+			}
+
+			// The delay is counted from the end of the sweep, so a long sweep is not followed by the next one at once.
+			timer.Reset(s.cleanupInterval(period))
+		case period = <-s.cleanupReset:
+			timer.Reset(s.cleanupInterval(period))
+			logger.Debug("cleanup period changed", zap.Stringer("period", period))
 		case <-ctx.Done():
 			logger.Debug("cleanup expired key stopped", zap.Stringer("time", time.Now().UTC()))
 			return
@@ -278,22 +917,120 @@ func (s *Storage) startCleanupExpiresKeys(ctx context.Context) {
 	}
 }
 
-func (s *Storage) cleanupKeys(ctx context.Context, entries []wal.WriteEntry) {
-	s.wal.Flush(entries)
-	for _, entry := range entries {
-		key := entry.Log().Args[0]
-		s.engine.Del(ctx, key)
+// cleanupInterval - returns the delay before the next cleanup, the period extended with a random part
+// of the jitter, so the nodes started together do not sweep the expired keys at the same time.
+func (s *Storage) cleanupInterval(period time.Duration) time.Duration {
+	if s.cleanupJitter <= 0 {
+		return period
+	}
+
+	return period + time.Duration(rand.Int64N(int64(s.cleanupJitter)+1))
+}
+
+// SetCleanupPeriod - changes the period of the running background cleanup of expired keys.
+// Returns ErrCleanupDisabled if the cleanup was not started.
+func (s *Storage) SetCleanupPeriod(period time.Duration) error {
+	if s.cleanupReset == nil {
+		return ErrCleanupDisabled
+	}
+
+	if period <= 0 {
+		return fmt.Errorf("invalid cleanup period %s", period)
+	}
+
+	for {
+		select {
+		case s.cleanupReset <- period:
+			return nil
+		default:
+			// The period not applied yet is replaced with the new one.
+			select {
+			case <-s.cleanupReset:
+			default:
+			}
+		}
+	}
+}
+
+// cleanupKeys - removes the keys found expired by the sweep after their deletes are written to the WAL.
+// The WAL keeps no TTL, so a key removed before its delete is durable would be restored without the expiry,
+// after a failed write the keys are kept expired until the next sweep. The writes are excluded for the batch,
+// so a key set again after the sweep keeps its new value and its delete is not logged after the set.
+func (s *Storage) cleanupKeys(ctx context.Context, keys []string) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	expired := make([]string, 0, len(keys))
+	entries := make([]wal.WriteEntry, 0, len(keys))
+	for _, key := range keys {
+		if !s.engine.Expired(ctx, key) {
+			logger.Debug("expired key was set again, skip removing", zap.String("key", key))
+			continue
+		}
+
+		expired = append(expired, key)
+		entries = append(entries, wal.NewWriteEntry(
+			s.gen.Generate(), compute.DelCommandID, []string{key},
+		))
+	}
+
+	if len(entries) == 0 {
+		return
+	}
+
+	if err := s.wal.Flush(entries); err != nil {
+		logger.Warn("failed to write expired keys deletes, the keys are kept until the next sweep", zap.Error(err))
+		return
+	}
+
+	for _, key := range expired {
+		// The key may be already removed by a read, its delete is logged anyway.
+		s.engine.DelExpired(ctx, key)
 		if s.stats != nil {
 			s.stats.ExpiredKeys.Add(1)
+			s.stats.TotalKeys.Add(-1)
 		}
 		logger.Debug("removed expired key (background)", zap.String("key", key))
 	}
 }
 
+// evictKeys - removes the keys found idle by the sweep and writes their deletes to the WAL.
+// A key accessed after the sweep is kept, the writes are excluded for the batch as in cleanupKeys.
+func (s *Storage) evictKeys(ctx context.Context, keys []string, deadline time.Time) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	entries := make([]wal.WriteEntry, 0, len(keys))
+	for _, key := range keys {
+		if !s.engine.DelIdle(ctx, key, deadline) {
+			logger.Debug("idle key was accessed again, skip evicting", zap.String("key", key))
+			continue
+		}
+
+		entries = append(entries, wal.NewWriteEntry(
+			s.gen.Generate(), compute.DelCommandID, []string{key},
+		))
+		if s.stats != nil {
+			s.stats.EvictedKeys.Add(1)
+			s.stats.TotalKeys.Add(-1)
+		}
+		logger.Debug("evicted idle key (background)", zap.String("key", key))
+	}
+
+	if len(entries) == 0 {
+		return
+	}
+
+	// Unlike the expired keys the evicted ones would be restored alive, so the failure is reported louder.
+	if err := s.wal.Flush(entries); err != nil {
+		logger.Error("failed to write evicted keys deletes, they are restored after the restart", zap.Error(err))
+	}
+}
+
 // Stats - returns the collected database statistics.
 func (s *Storage) Stats() (*Stats, error) {
 	if s.stats == nil {
-		return nil, errors.New("statistics disabled")
+		return nil, ErrStatsDisabled
 	}
 
 	return s.stats, nil
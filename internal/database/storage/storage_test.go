@@ -1,12 +1,28 @@
 package storage_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/neekrasov/kvdb/internal/database/compute"
+	"github.com/neekrasov/kvdb/internal/database/identity/models"
 	"github.com/neekrasov/kvdb/internal/database/storage"
+	"github.com/neekrasov/kvdb/internal/database/storage/engine"
+	"github.com/neekrasov/kvdb/internal/database/storage/replication"
+	"github.com/neekrasov/kvdb/internal/database/storage/snapshot"
+	"github.com/neekrasov/kvdb/internal/database/storage/wal"
+	"github.com/neekrasov/kvdb/internal/database/storage/wal/filesystem"
+	"github.com/neekrasov/kvdb/internal/database/storage/wal/segment"
+	replMocks "github.com/neekrasov/kvdb/internal/mocks/replication"
 	mocks "github.com/neekrasov/kvdb/internal/mocks/storage"
+	walMocks "github.com/neekrasov/kvdb/internal/mocks/wal"
 	"github.com/neekrasov/kvdb/pkg/ctxutil"
 	"github.com/neekrasov/kvdb/pkg/logger"
 	pkgsync "github.com/neekrasov/kvdb/pkg/sync"
@@ -22,7 +38,7 @@ func TestStorage(t *testing.T) {
 	mockWAL := mocks.NewWAL(t)
 
 	ctx := context.Background()
-	mockWAL.On("Recover", mock.Anything).Return(int64(0), nil)
+	mockWAL.On("Recover", mock.Anything, mock.Anything).Return(int64(0), nil)
 	store, err := storage.NewStorage(ctx, mockEngine, storage.WithWALOpt(mockWAL))
 	require.NoError(t, err)
 
@@ -60,26 +76,38 @@ func TestStorage(t *testing.T) {
 
 	t.Run("Del - Success", func(t *testing.T) {
 		key := "testKey"
-		mockEngine.On("Del", mock.Anything, key).Return(nil).Once()
+		mockEngine.On("Del", mock.Anything, key).Return(true).Once()
 		mockWAL.On("Del", mock.Anything, key).Return(nil).Once()
 
-		err := store.Del(ctx, key)
+		existed, err := store.Del(ctx, key)
 
 		assert.NoError(t, err)
+		assert.True(t, existed)
 		mockEngine.AssertExpectations(t)
 	})
 
-	t.Run("Del - Error", func(t *testing.T) {
+	t.Run("Del - Missing Key", func(t *testing.T) {
 		key := "testKey"
-		mockEngine.On("Del", mock.Anything, key).Return(storage.ErrKeyNotFound).Once()
+		mockEngine.On("Del", mock.Anything, key).Return(false).Once()
 		mockWAL.On("Del", mock.Anything, key).Return(nil).Once()
 
-		err := store.Del(ctx, key)
+		existed, err := store.Del(ctx, key)
 
-		assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+		assert.NoError(t, err)
+		assert.False(t, existed)
 		mockEngine.AssertExpectations(t)
 	})
 
+	t.Run("Del - WAL Error", func(t *testing.T) {
+		key := "testKey"
+		mockWAL.On("Del", mock.Anything, key).Return(errors.New("disk failure")).Once()
+
+		_, err := store.Del(ctx, key)
+
+		assert.ErrorContains(t, err, "disk failure")
+		mockWAL.AssertExpectations(t)
+	})
+
 	t.Run("Watch", func(t *testing.T) {
 		key := "testKey"
 		mockEngine.On("Watch", mock.Anything, key).Return(pkgsync.NewFuture[string]()).Once()
@@ -110,6 +138,94 @@ func TestStorage(t *testing.T) {
 		mockEngine.AssertExpectations(t)
 	})
 
+	t.Run("GetDel - Found", func(t *testing.T) {
+		mockEngine.On("Get", mock.Anything, "queued").Return("job", true).Once()
+		mockWAL.On("Del", mock.Anything, "queued").Return(nil).Once()
+		mockEngine.On("Del", mock.Anything, "queued").Return(true).Once()
+
+		val, err := store.GetDel(ctx, "queued")
+		require.NoError(t, err)
+		assert.Equal(t, "job", val)
+		mockEngine.AssertExpectations(t)
+		mockWAL.AssertExpectations(t)
+	})
+
+	t.Run("GetDel - Not Found", func(t *testing.T) {
+		mockEngine.On("Get", mock.Anything, "missing").Return("", false).Once()
+
+		_, err := store.GetDel(ctx, "missing")
+		assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+		mockWAL.AssertNotCalled(t, "Del", mock.Anything, "missing")
+	})
+
+	t.Run("GetSet - Existing Key", func(t *testing.T) {
+		mockEngine.On("Get", mock.Anything, "counter").Return("1", true).Once()
+		mockWAL.On("Set", mock.Anything, "counter", "2").Return(nil).Once()
+		mockEngine.On("Set", mock.Anything, "counter", "2", int64(0)).Return().Once()
+
+		old, exists, err := store.GetSet(ctx, "counter", "2")
+		require.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, "1", old)
+		mockEngine.AssertExpectations(t)
+		mockWAL.AssertExpectations(t)
+	})
+
+	t.Run("GetSet - New Key", func(t *testing.T) {
+		mockEngine.On("Get", mock.Anything, "fresh").Return("", false).Once()
+		mockWAL.On("Set", mock.Anything, "fresh", "1").Return(nil).Once()
+		mockEngine.On("Set", mock.Anything, "fresh", "1", int64(0)).Return().Once()
+
+		old, exists, err := store.GetSet(ctx, "fresh", "1")
+		require.NoError(t, err)
+		assert.False(t, exists)
+		assert.Empty(t, old)
+		mockEngine.AssertExpectations(t)
+		mockWAL.AssertExpectations(t)
+	})
+
+	t.Run("RenameNX - Renamed", func(t *testing.T) {
+		mockEngine.On("Get", mock.Anything, "old").Return("value", true).Once()
+		mockEngine.On("Get", mock.Anything, "new").Return("", false).Once()
+		mockWAL.On("RenameNX", mock.Anything, "old", "new").Return(nil).Once()
+		mockEngine.On("RenameNX", mock.Anything, "old", "new").Return(true, true).Once()
+
+		renamed, err := store.RenameNX(ctx, "old", "new")
+		require.NoError(t, err)
+		assert.True(t, renamed)
+		mockEngine.AssertExpectations(t)
+		mockWAL.AssertExpectations(t)
+	})
+
+	t.Run("RenameNX - New key exists", func(t *testing.T) {
+		mockEngine.On("Get", mock.Anything, "old").Return("value", true).Once()
+		mockEngine.On("Get", mock.Anything, "exists").Return("value", true).Once()
+
+		renamed, err := store.RenameNX(ctx, "old", "exists")
+		require.NoError(t, err)
+		assert.False(t, renamed)
+		mockEngine.AssertExpectations(t)
+		mockWAL.AssertNotCalled(t, "RenameNX", mock.Anything, "old", "exists")
+	})
+
+	t.Run("RenameNX - Not Found", func(t *testing.T) {
+		mockEngine.On("Get", mock.Anything, "missing").Return("", false).Once()
+
+		_, err := store.RenameNX(ctx, "missing", "new")
+		assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+		mockEngine.AssertExpectations(t)
+		mockWAL.AssertNotCalled(t, "RenameNX", mock.Anything, "missing", "new")
+	})
+
+	t.Run("RenameNX - WAL error", func(t *testing.T) {
+		mockEngine.On("Get", mock.Anything, "src").Return("value", true).Once()
+		mockEngine.On("Get", mock.Anything, "dst").Return("", false).Once()
+		mockWAL.On("RenameNX", mock.Anything, "src", "dst").Return(errors.New("wal error")).Once()
+
+		_, err := store.RenameNX(ctx, "src", "dst")
+		assert.ErrorContains(t, err, "wal error")
+		mockEngine.AssertNotCalled(t, "RenameNX", mock.Anything, "src", "dst")
+	})
 }
 
 func TestStorageCleanupBackground(t *testing.T) {
@@ -118,7 +234,7 @@ func TestStorageCleanupBackground(t *testing.T) {
 	mockEngine := mocks.NewEngine(t)
 	mockWAL := mocks.NewWAL(t)
 
-	mockWAL.On("Recover", mock.Anything).Return(int64(0), nil)
+	mockWAL.On("Recover", mock.Anything, mock.Anything).Return(int64(0), nil)
 	mockWAL.On("Flush", mock.Anything).Return(nil)
 
 	expiredKeys := []string{"expiredKey1", "expiredKey2", "expiredKey3"}
@@ -131,7 +247,8 @@ func TestStorageCleanupBackground(t *testing.T) {
 	mockEngine.On("ForEachExpired", mock.Anything).Return()
 
 	for _, key := range expiredKeys {
-		mockEngine.On("Del", mock.Anything, key).Return(nil).Once()
+		mockEngine.On("Expired", mock.Anything, key).Return(true).Once()
+		mockEngine.On("DelExpired", mock.Anything, key).Return(true).Once()
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -154,5 +271,588 @@ func TestStorageCleanupBackground(t *testing.T) {
 	mockEngine.AssertExpectations(t)
 	mockWAL.AssertExpectations(t)
 
-	mockEngine.AssertNumberOfCalls(t, "Del", 3)
+	mockEngine.AssertNumberOfCalls(t, "DelExpired", 3)
+}
+
+func TestStorageCleanupKeepsKeysOnFailedWrite(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	e := engine.New()
+	e.Set(ctx, "expired", "value", time.Now().Add(-time.Minute).Unix())
+
+	var (
+		mu       sync.Mutex
+		attempts int
+	)
+	mockWAL := mocks.NewWAL(t)
+	mockWAL.On("Recover", mock.Anything, mock.Anything).Return(int64(0), nil)
+	mockWAL.On("Flush", mock.Anything).Return(func([]wal.WriteEntry) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		attempts++
+		if attempts == 1 {
+			return errors.New("wal error")
+		}
+		return nil
+	})
+
+	store, err := storage.NewStorage(ctx, e,
+		storage.WithWALOpt(mockWAL),
+		storage.WithStatistics(),
+		storage.WithCleanupPeriod(20*time.Millisecond),
+		storage.WithCleanupBatchSize(10),
+	)
+	require.NoError(t, err)
+	stats, err := store.Stats()
+	require.NoError(t, err)
+
+	// The key of the failed write is kept and removed by the next sweep once its delete is durable.
+	require.Eventually(t, func() bool {
+		return stats.ExpiredKeys.Load() == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Empty(t, e.KeysByPrefix(""))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.GreaterOrEqual(t, attempts, 2)
+}
+
+// sweepHookEngine - engine running the hook after the expired keys are found by the sweep.
+type sweepHookEngine struct {
+	*engine.Engine
+	once    sync.Once
+	onSweep func()
+}
+
+func (e *sweepHookEngine) ForEachExpired(action func(key string)) {
+	e.Engine.ForEachExpired(action)
+	e.once.Do(e.onSweep)
+}
+
+func TestStorageCleanupKeepsResetKey(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	expired := time.Now().Add(-time.Minute).Unix()
+	e := &sweepHookEngine{Engine: engine.New()}
+	e.Set(ctx, "reset", "old", expired)
+	e.Set(ctx, "expired", "old", expired)
+
+	stores, reset := make(chan *storage.Storage, 1), make(chan error, 1)
+	e.onSweep = func() {
+		store := <-stores
+		reset <- store.Set(ctxutil.InjectTTL(ctx, "1h"), "reset", "new")
+	}
+
+	store, err := storage.NewStorage(ctx, e,
+		storage.WithWALOpt((*wal.WAL)(nil)),
+		storage.WithCleanupPeriod(20*time.Millisecond),
+		storage.WithCleanupBatchSize(10),
+	)
+	require.NoError(t, err)
+	stores <- store
+
+	select {
+	case err := <-reset:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		require.FailNow(t, "cleanup did not run")
+	}
+
+	require.Eventually(t, func() bool {
+		var expired int
+		e.Engine.ForEachExpired(func(string) { expired++ })
+		return expired == 0
+	}, time.Second, 10*time.Millisecond)
+
+	// The key set again during the sweep survives it and the following sweeps.
+	time.Sleep(50 * time.Millisecond)
+	value, err := store.Get(ctx, "reset")
+	require.NoError(t, err)
+	assert.Equal(t, "new", value)
+	assert.Equal(t, []string{"reset"}, e.KeysByPrefix(""))
+}
+
+func TestStorageIdleEviction(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	e := engine.New(engine.WithAccessTracking())
+	untouched := storage.MakeKey(models.DefaultNameSpace, "untouched")
+	read := storage.MakeKey(models.DefaultNameSpace, "read")
+	user := storage.MakeKey(models.SystemUserNameSpace, "alice")
+	for _, key := range []string{untouched, read, user} {
+		e.Set(ctx, key, "value", 0)
+	}
+
+	var (
+		mu      sync.Mutex
+		deletes []string
+	)
+	mockWAL := mocks.NewWAL(t)
+	mockWAL.On("Recover", mock.Anything, mock.Anything).Return(int64(0), nil)
+	mockWAL.On("Flush", mock.Anything).Run(func(args mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, entry := range args.Get(0).([]wal.WriteEntry) {
+			deletes = append(deletes, entry.Log().Args...)
+		}
+	}).Return(nil)
+
+	const idle = 100 * time.Millisecond
+	store, err := storage.NewStorage(ctx, e,
+		storage.WithWALOpt(mockWAL),
+		storage.WithStatistics(),
+		storage.WithCleanupPeriod(20*time.Millisecond),
+		storage.WithCleanupBatchSize(10),
+		storage.WithIdleEviction(idle),
+	)
+	require.NoError(t, err)
+
+	// The read key is accessed more often than the idle eviction.
+	deadline := time.Now().Add(3 * idle)
+	for time.Now().Before(deadline) {
+		_, err := store.Get(ctx, read)
+		require.NoError(t, err)
+		time.Sleep(idle / 5)
+	}
+
+	_, err = store.Get(ctx, untouched)
+	require.ErrorIs(t, err, storage.ErrKeyNotFound)
+	_, err = store.Get(ctx, read)
+	require.NoError(t, err)
+	_, err = store.Get(ctx, user)
+	require.NoError(t, err, "system keys are not evicted")
+
+	stats, err := store.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.EvictedKeys.Load())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{untouched}, deletes)
+}
+
+func TestStorageCleanupJitter(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	const (
+		period = 50 * time.Millisecond
+		jitter = 50 * time.Millisecond
+		ticks  = 6
+	)
+
+	sweeps := make(chan time.Time, ticks)
+	mockEngine := mocks.NewEngine(t)
+	mockEngine.On("ForEachExpired", mock.Anything).Run(func(mock.Arguments) {
+		select {
+		case sweeps <- time.Now():
+		default:
+		}
+	}).Return()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Now()
+	_, err := storage.NewStorage(ctx, mockEngine,
+		storage.WithWALOpt((*wal.WAL)(nil)),
+		storage.WithCleanupPeriod(period),
+		storage.WithCleanupJitter(jitter),
+		storage.WithCleanupBatchSize(10),
+	)
+	require.NoError(t, err)
+
+	prev := start
+	for range ticks {
+		select {
+		case sweep := <-sweeps:
+			interval := sweep.Sub(prev)
+			assert.GreaterOrEqual(t, interval, period)
+			// The scheduler may delay the sweep a bit after the jittered interval.
+			assert.Less(t, interval, period+jitter+25*time.Millisecond)
+			prev = sweep
+		case <-time.After(time.Second):
+			require.FailNow(t, "cleanup did not run")
+		}
+	}
+}
+
+func TestStorageMaxSize(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	store, err := storage.NewStorage(ctx, engine.New(),
+		storage.WithWALOpt((*wal.WAL)(nil)), storage.WithMaxValueSize(4))
+	require.NoError(t, err)
+
+	largeKey := storage.MakeKey("default", "large:1")
+	smallKey := storage.MakeKey("default", "small:1")
+
+	t.Run("Global limit", func(t *testing.T) {
+		assert.Equal(t, 4, store.MaxSize(largeKey))
+		require.NoError(t, store.Set(ctx, smallKey, "1234"))
+		assert.ErrorIs(t, store.Set(ctx, largeKey, "12345"), storage.ErrValueTooLarge)
+	})
+
+	t.Run("Override exceeds global limit", func(t *testing.T) {
+		require.NoError(t, store.SetMaxSize(ctx, storage.MakeKey("default", "large:*"), 1<<20))
+
+		assert.Equal(t, 1<<20, store.MaxSize(largeKey))
+		require.NoError(t, store.Set(ctx, largeKey, strings.Repeat("a", 1<<20)))
+		assert.ErrorIs(t, store.Set(ctx, largeKey, strings.Repeat("a", 1<<20+1)), storage.ErrValueTooLarge)
+		assert.ErrorIs(t, store.Set(ctx, smallKey, "12345"), storage.ErrValueTooLarge)
+	})
+
+	t.Run("Remove override", func(t *testing.T) {
+		require.NoError(t, store.SetMaxSize(ctx, storage.MakeKey("default", "large:*"), 0))
+
+		assert.Equal(t, 4, store.MaxSize(largeKey))
+		assert.ErrorIs(t, store.Set(ctx, largeKey, "12345"), storage.ErrValueTooLarge)
+	})
+
+	t.Run("Invalid pattern", func(t *testing.T) {
+		assert.Error(t, store.SetMaxSize(ctx, "[", 8))
+	})
+}
+
+func TestStorageDelByPrefix(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	store, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	for i := range 150 {
+		require.NoError(t, store.Set(ctx, storage.MakeKey("ns1", strconv.Itoa(i)), "value"))
+	}
+	require.NoError(t, store.Set(ctx, storage.MakeKey("ns2", "a"), "value"))
+
+	deleted, err := store.DelByPrefix(ctx, storage.MakeKey("ns1", ""))
+	require.NoError(t, err)
+	assert.Equal(t, 150, deleted)
+	assert.Equal(t, 0, store.CountByPrefix(storage.MakeKey("ns1", "")))
+	assert.Equal(t, 1, store.CountByPrefix(storage.MakeKey("ns2", "")))
+}
+
+func TestStorageHitsMisses(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	store, err := storage.NewStorage(ctx, engine.New(),
+		storage.WithWALOpt((*wal.WAL)(nil)), storage.WithStatistics())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Set(ctx, "key", "value"))
+	for range 3 {
+		_, err := store.Get(ctx, "key")
+		require.NoError(t, err)
+	}
+	_, err = store.Get(ctx, "missing")
+	require.ErrorIs(t, err, storage.ErrKeyNotFound)
+
+	// GETSET and GETDEL count as the get commands along with the hits and misses.
+	_, _, err = store.GetSet(ctx, "key", "updated")
+	require.NoError(t, err)
+	_, _, err = store.GetSet(ctx, "fresh", "value")
+	require.NoError(t, err)
+	_, err = store.GetDel(ctx, "fresh")
+	require.NoError(t, err)
+	_, err = store.GetDel(ctx, "fresh")
+	require.ErrorIs(t, err, storage.ErrKeyNotFound)
+
+	stats, err := store.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), stats.GetCommands.Load())
+	assert.Equal(t, int64(5), stats.Hits.Load())
+	assert.Equal(t, int64(3), stats.Misses.Load())
+}
+
+func TestStorageExpiring(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx := context.Background()
+	e := engine.New()
+	now := time.Now()
+	e.Set(ctx, storage.MakeKey("ns1", "persistent"), "value", 0)
+	e.Set(ctx, storage.MakeKey("ns1", "expired"), "value", now.Add(-time.Minute).Unix())
+	e.Set(ctx, storage.MakeKey("ns1", "minute"), "value", now.Add(time.Minute).Unix())
+	e.Set(ctx, storage.MakeKey("ns1", "seconds"), "value", now.Add(10*time.Second).Unix())
+	e.Set(ctx, storage.MakeKey("ns1", "hour"), "value", now.Add(time.Hour).Unix())
+	e.Set(ctx, storage.MakeKey("ns2", "seconds"), "value", now.Add(10*time.Second).Unix())
+
+	store, err := storage.NewStorage(ctx, e, storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	prefix := storage.MakeKey("ns1", "")
+	assert.Empty(t, store.Expiring(prefix, time.Second))
+	assert.Equal(t, []string{"seconds"}, store.Expiring(prefix, 30*time.Second))
+	assert.Equal(t, []string{"seconds", "minute"}, store.Expiring(prefix, 5*time.Minute))
+	assert.Equal(t, []string{"seconds", "minute", "hour"}, store.Expiring(prefix, 24*time.Hour))
+}
+
+func TestStorageKeySeparator(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	assert.Equal(t, "ns:key", storage.MakeKey("ns", "key"))
+
+	ctx := context.Background()
+	store, err := storage.NewStorage(ctx, engine.New(),
+		storage.WithWALOpt((*wal.WAL)(nil)), storage.WithKeySeparator("|"))
+	require.NoError(t, err)
+	assert.Equal(t, "|", store.KeySeparator())
+	assert.Equal(t, "|", storage.KeySeparatorOf(store))
+	assert.Equal(t, "a|b", store.MakeKey("a", "b"))
+
+	// With the colon no longer separating, "a:b" is a namespace name of its own.
+	require.NoError(t, store.Set(ctx, store.MakeKey("a:b", "c"), "namespaced"))
+	require.NoError(t, store.Set(ctx, store.MakeKey("a", "b:c"), "plain"))
+	require.NoError(t, store.Set(ctx, store.MakeKey("a", "x|y"), "separated"))
+
+	val, err := store.Get(ctx, store.MakeKey("a:b", "c"))
+	require.NoError(t, err)
+	assert.Equal(t, "namespaced", val)
+
+	val, err = store.Get(ctx, store.MakeKey("a", "b:c"))
+	require.NoError(t, err)
+	assert.Equal(t, "plain", val)
+
+	val, err = store.Get(ctx, store.MakeKey("a", "x|y"))
+	require.NoError(t, err)
+	assert.Equal(t, "separated", val)
+
+	assert.Equal(t, 1, store.CountByPrefix(store.MakeKey("a:b", "")))
+	assert.Equal(t, 2, store.CountByPrefix(store.MakeKey("a", "")))
+
+	keys, cursor, err := store.Scan(store.MakeKey("a", ""), 0, "", 10)
+	require.NoError(t, err)
+	assert.Zero(t, cursor)
+	assert.ElementsMatch(t, []string{"b:c", "x|y"}, keys)
+
+	// The size overrides are stored with the separator of the storage too.
+	require.NoError(t, store.SetMaxSize(ctx, "a|*", 4))
+	assert.Equal(t, 4, store.MaxSize(store.MakeKey("a", "key")))
+
+	// Another storage keeps the default separator.
+	other, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+	assert.Equal(t, storage.DefaultKeySeparator, other.KeySeparator())
+	assert.Equal(t, storage.DefaultKeySeparator, storage.KeySeparatorOf(nil))
+}
+
+func TestStorageSnapshotRecovery(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	dir := t.TempDir()
+	segmentStorage, err := segment.NewFileSegmentStorage(new(filesystem.LocalFileSystem), dir)
+	require.NoError(t, err)
+
+	openWAL := func() *wal.WAL {
+		manager, err := wal.NewFileSegmentManager(segmentStorage, wal.WithMaxSegmentSize(256))
+		require.NoError(t, err)
+
+		return wal.NewWAL(manager, 1, time.Millisecond)
+	}
+
+	snapshotter, err := snapshot.NewFileSnapshotter(dir)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := openWAL()
+	w.Start(ctx)
+
+	store, err := storage.NewStorage(ctx, engine.New(),
+		storage.WithWALOpt(w), storage.WithSnapshotter(snapshotter, 0))
+	require.NoError(t, err)
+
+	for i := range 20 {
+		require.NoError(t, store.Set(ctx, "key_"+strconv.Itoa(i), "value_"+strconv.Itoa(i)))
+	}
+	for i := range 5 {
+		_, err := store.Del(ctx, "key_"+strconv.Itoa(i))
+		require.NoError(t, err)
+	}
+	_, err = store.RenameNX(ctx, "key_5", "renamed_5")
+	require.NoError(t, err)
+
+	before, err := segmentStorage.List()
+	require.NoError(t, err)
+	require.NoError(t, store.Snapshot(ctx))
+
+	// The segments covered by the snapshot are removed.
+	after, err := segmentStorage.List()
+	require.NoError(t, err)
+	assert.Less(t, len(after), len(before))
+
+	require.NoError(t, store.Set(ctx, "key_1", "restored"))
+	require.NoError(t, store.Set(ctx, "key_10", "updated"))
+	_, err = store.Del(ctx, "key_11")
+	require.NoError(t, err)
+	_, err = store.Del(ctx, "renamed_5")
+	require.NoError(t, err)
+	_, err = store.RenameNX(ctx, "key_12", "renamed_12")
+	require.NoError(t, err)
+
+	cancel()
+	require.NoError(t, w.Close())
+
+	recoverState := func(opts ...storage.StorageOpt) map[string]string {
+		e := engine.New()
+		_, err := storage.NewStorage(context.Background(), e, append(opts, storage.WithWALOpt(openWAL()))...)
+		require.NoError(t, err)
+
+		state := make(map[string]string)
+		e.ForEach(func(key, value string, _ int64) {
+			state[key] = value
+		})
+
+		return state
+	}
+
+	// The WAL alone no longer holds the entries saved in the snapshot.
+	walOnly := recoverState()
+	assert.NotContains(t, walOnly, "key_19")
+
+	fromSnapshot := recoverState(storage.WithSnapshotter(snapshotter, 0))
+	assert.Len(t, fromSnapshot, 14)
+	assert.Equal(t, "restored", fromSnapshot["key_1"])
+	assert.Equal(t, "updated", fromSnapshot["key_10"])
+	assert.Equal(t, "value_12", fromSnapshot["renamed_12"])
+	assert.NotContains(t, fromSnapshot, "key_0")
+	assert.NotContains(t, fromSnapshot, "key_11")
+	assert.NotContains(t, fromSnapshot, "renamed_5")
+}
+
+func TestStorageSnapshotSkipsReplayedEntries(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	snapshotter, err := snapshot.NewFileSnapshotter(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, snapshotter.Save(2, []snapshot.Entry{{Key: "a", Value: "snapshot"}}))
+
+	mockWAL := mocks.NewWAL(t)
+	mockWAL.On("Recover", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		apply := args.Get(1).(func(context.Context, []wal.LogEntry) error)
+		require.NoError(t, apply(context.Background(), []wal.LogEntry{
+			{LSN: 1, Operation: compute.SetCommandID, Args: []string{"a", "stale"}},
+			{LSN: 2, Operation: compute.DelCommandID, Args: []string{"a"}},
+			{LSN: 3, Operation: compute.SetCommandID, Args: []string{"b", "tail"}},
+		}))
+	}).Return(int64(3), nil).Once()
+
+	ctx := context.Background()
+	store, err := storage.NewStorage(ctx, engine.New(),
+		storage.WithWALOpt(mockWAL), storage.WithSnapshotter(snapshotter, 0))
+	require.NoError(t, err)
+
+	val, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "snapshot", val)
+
+	val, err = store.Get(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, "tail", val)
+}
+
+func TestStorageReadiness(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("master", func(t *testing.T) {
+		store, err := storage.NewStorage(ctx, engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+		require.NoError(t, err)
+
+		assert.Equal(t, storage.Readiness{WALRecovered: true, ReplicaSynced: true}, store.Readiness())
+	})
+
+	t.Run("slave is not ready before the first sync", func(t *testing.T) {
+		var response bytes.Buffer
+		require.NoError(t, replication.NewMasterResponse(false, false, nil).Encode(&response))
+
+		synced := make(chan struct{})
+		mockNetClient := replMocks.NewNetClient(t)
+		mockNetClient.On("Send", mock.Anything, mock.Anything).
+			Run(func(mock.Arguments) { <-synced }).Return(response.Bytes(), nil)
+
+		mockSegmentStorage := walMocks.NewSegmentStorage(t)
+		mockSegmentStorage.On("List").Return([]int{}, nil).Once()
+
+		slave, err := replication.NewSlave(mockNetClient, mockSegmentStorage, replMocks.NewWAL(t), time.Millisecond, 1)
+		require.NoError(t, err)
+
+		store, err := storage.NewStorage(ctx, engine.New(),
+			storage.WithWALOpt((*wal.WAL)(nil)),
+			storage.WithReplicaOpt(slave),
+			storage.WithReplicaStreamOpt(slave.Stream()),
+		)
+		require.NoError(t, err)
+
+		go slave.Start(ctx)
+		assert.Equal(t, storage.Readiness{WALRecovered: true, ReplicaSynced: false}, store.Readiness())
+
+		close(synced)
+		assert.Eventually(t, func() bool {
+			return store.Readiness().ReplicaSynced
+		}, time.Second, time.Millisecond)
+	})
+}
+
+// BenchmarkStorage_Statistics - shows the overhead of the statistics counters on the parallel sets and gets.
+func BenchmarkStorage_Statistics(b *testing.B) {
+	logger.MockLogger()
+
+	const keysNum = 1024
+	keys := make([]string, keysNum)
+	for i := range keys {
+		keys[i] = storage.MakeKey("default", strconv.Itoa(i))
+	}
+
+	for _, enabled := range []bool{false, true} {
+		b.Run(fmt.Sprintf("stats=%t", enabled), func(b *testing.B) {
+			ctx := context.Background()
+			opts := []storage.StorageOpt{storage.WithWALOpt((*wal.WAL)(nil))}
+			if enabled {
+				opts = append(opts, storage.WithStatistics())
+			}
+
+			store, err := storage.NewStorage(ctx, engine.New(engine.WithPartitionNum(16)), opts...)
+			require.NoError(b, err)
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				var i int
+				for pb.Next() {
+					key := keys[i%keysNum]
+					if i%4 == 0 {
+						_ = store.Set(ctx, key, "value")
+					} else {
+						_, _ = store.Get(ctx, key)
+					}
+					i++
+				}
+			})
+		})
+	}
 }
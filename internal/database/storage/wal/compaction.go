@@ -0,0 +1,327 @@
+package wal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+
+	"github.com/neekrasov/kvdb/internal/database/compute"
+	"github.com/neekrasov/kvdb/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// CompactionResult - outcome of a log compaction pass.
+type CompactionResult struct {
+	BytesReclaimed  int64 `json:"bytes_reclaimed"`  // Difference between the sizes of the removed and the written segments.
+	SegmentsRemoved int   `json:"segments_removed"` // Number of the removed segments.
+}
+
+// Compact - rewrites the log keeping only the latest entry per key and dropping entries of deleted keys.
+// Compacted entries are written into fresh segments, then the old segments are removed.
+//
+// The state is rebuilt by replaying the log rather than taken from the engine snapshot:
+// the engine does not keep LSNs and the TTLs are not logged, so replay keeps the
+// compacted log equal to what the recovery sees. Compaction must not be used
+// with replication, since replicas fetch segments by number.
+func (fsm *FileSegmentManager) Compact() (CompactionResult, error) {
+	fsm.mu.Lock()
+	defer fsm.mu.Unlock()
+
+	if len(fsm.segments) == 0 {
+		return CompactionResult{}, nil
+	}
+
+	// The last segment of the previous run may end with a torn write, it's reopened
+	// first to drop the broken entries the recovery stopped at.
+	if fsm.current == nil {
+		if err := fsm.reopen(); err != nil {
+			return CompactionResult{}, fmt.Errorf("failed to reopen last segment: %w", err)
+		}
+	}
+
+	entries, total, err := fsm.replay(fsm.segments)
+	if err != nil {
+		return CompactionResult{}, fmt.Errorf("failed to replay segments: %w", err)
+	}
+
+	if len(entries) == total {
+		logger.Debug("nothing to compact", zap.Int("entries", total))
+		return CompactionResult{}, nil
+	}
+
+	nextID := fsm.segments[len(fsm.segments)-1] + 1
+	compacted, written, err := fsm.writeCompacted(nextID, entries)
+	if err != nil {
+		return CompactionResult{}, fmt.Errorf("failed to write compacted segments: %w", err)
+	}
+
+	// The new current segment is created before the old one is closed,
+	// so on failure the manager keeps writing to the old segment.
+	currentID := nextID + len(compacted)
+	current, err := fsm.storage.Create(currentID, false)
+	if err != nil {
+		fsm.removeSegments(compacted)
+		return CompactionResult{}, fmt.Errorf("failed to create new segment: %w", err)
+	}
+
+	old := fsm.segments
+	reclaimed := max(fsm.segmentsSize(old)-written, 0)
+
+	if fsm.current != nil {
+		if err := fsm.current.Close(); err != nil {
+			logger.Warn("failed to close compacted segment", zap.Int("id", fsm.current.ID()), zap.Error(err))
+		}
+	}
+
+	fsm.current = current
+	fsm.segments = append(compacted, currentID)
+	fsm.size, fsm.measured = written, true
+
+	fsm.removeSegments(old)
+
+	logger.Info("wal compaction completed",
+		zap.Int("segments_removed", len(old)),
+		zap.Int("segments_written", len(compacted)),
+		zap.Int("entries_total", total),
+		zap.Int("entries_kept", len(entries)),
+		zap.Int64("bytes_reclaimed", reclaimed),
+	)
+
+	return CompactionResult{BytesReclaimed: reclaimed, SegmentsRemoved: len(old)}, nil
+}
+
+// Truncate - removes the closed segments holding only the entries with LSN up to the lsn, which
+// are saved elsewhere, e.g. in a snapshot. The segments are removed in order up to the first one
+// holding a later entry, the last segment is always kept. Returns the number of the removed segments.
+func (fsm *FileSegmentManager) Truncate(lsn int64) (int, error) {
+	fsm.mu.Lock()
+	defer fsm.mu.Unlock()
+
+	var covered []int
+	iterator := NewSegmentIterator(fsm.storage, fsm.compression)
+	for _, id := range fsm.segments[:max(len(fsm.segments)-1, 0)] {
+		data, err := iterator.Next(id)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+
+		entries, err := decodeEntries(data)
+		if err != nil {
+			return 0, fmt.Errorf("failed to decode segment %d: %w", id, err)
+		}
+
+		if slices.ContainsFunc(entries, func(entry LogEntry) bool { return entry.LSN > lsn }) {
+			break
+		}
+		covered = append(covered, id)
+	}
+
+	if len(covered) == 0 {
+		return 0, nil
+	}
+
+	if fsm.measured {
+		fsm.size = max(fsm.size-fsm.segmentsSize(covered), 0)
+	}
+	fsm.segments = fsm.segments[len(covered):]
+	fsm.removeSegments(covered)
+
+	logger.Info("wal segments truncated", zap.Int("segments_removed", len(covered)), zap.Int64("lsn", lsn))
+
+	return len(covered), nil
+}
+
+// segmentsSize - returns the total size of the segments, segments failed to be opened are skipped.
+func (fsm *FileSegmentManager) segmentsSize(ids []int) int64 {
+	var size int64
+	for _, id := range ids {
+		if fsm.current != nil && fsm.current.ID() == id {
+			size += int64(fsm.current.Size())
+			continue
+		}
+
+		segment, err := fsm.storage.Open(id)
+		if err != nil {
+			logger.Warn("failed to open segment to measure size", zap.Int("id", id), zap.Error(err))
+			continue
+		}
+
+		size += int64(segment.Size())
+		if err := segment.Close(); err != nil {
+			logger.Warn("failed to close measured segment", zap.Int("id", id), zap.Error(err))
+		}
+	}
+
+	return size
+}
+
+// replay - applies entries of the segments in the recovery order and returns
+// the latest set entry per alive key ordered by LSN along with the number of read entries.
+func (fsm *FileSegmentManager) replay(segments []int) ([]LogEntry, int, error) {
+	var total int
+	state := make(map[string]LogEntry)
+
+	iterator := NewSegmentIterator(fsm.storage, fsm.compression)
+	for _, id := range segments {
+		data, err := iterator.Next(id)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, 0, err
+		}
+
+		entries, err := decodeEntries(data)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode segment %d: %w", id, err)
+		}
+		total += len(entries)
+
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].LSN < entries[j].LSN
+		})
+
+		for _, entry := range entries {
+			switch entry.Operation {
+			case compute.SetCommandID:
+				state[entry.Args[0]] = entry
+			case compute.DelCommandID:
+				delete(state, entry.Args[0])
+			case compute.RenameNXCommandID:
+				oldKey, newKey := entry.Args[0], entry.Args[1]
+				src, exists := state[oldKey]
+				if _, taken := state[newKey]; !exists || taken {
+					continue
+				}
+
+				delete(state, oldKey)
+				state[newKey] = LogEntry{
+					LSN:       entry.LSN,
+					Operation: compute.SetCommandID,
+					Args:      []string{newKey, src.Args[1]},
+				}
+			}
+		}
+	}
+
+	entries := make([]LogEntry, 0, len(state))
+	for _, entry := range state {
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LSN < entries[j].LSN
+	})
+
+	return entries, total, nil
+}
+
+// writeCompacted - writes entries into new segments starting from the id,
+// respecting the maximum segment size. Returns ids and the total size of the written segments.
+func (fsm *FileSegmentManager) writeCompacted(id int, entries []LogEntry) ([]int, int64, error) {
+	var (
+		ids     []int
+		written int64
+		buf     bytes.Buffer
+		entry   bytes.Buffer
+	)
+
+	flush := func() error {
+		if buf.Len() == 0 {
+			return nil
+		}
+
+		n, err := fsm.writeSegment(id, buf.Bytes())
+		if err != nil {
+			fsm.removeSegments(ids)
+			return err
+		}
+
+		ids = append(ids, id)
+		written += int64(n)
+		id++
+		buf.Reset()
+
+		return nil
+	}
+
+	for _, log := range entries {
+		entry.Reset()
+		if err := log.Encode(&entry); err != nil {
+			return nil, 0, err
+		}
+
+		if fsm.maxSegmentSize > 0 && buf.Len()+entry.Len() > fsm.maxSegmentSize {
+			if err := flush(); err != nil {
+				return nil, 0, err
+			}
+		}
+
+		buf.Write(entry.Bytes())
+	}
+
+	if err := flush(); err != nil {
+		return nil, 0, err
+	}
+
+	return ids, written, nil
+}
+
+// writeSegment - writes the data into a new sealed segment, compressing it if compression is configured.
+// Returns the number of bytes written to the segment.
+func (fsm *FileSegmentManager) writeSegment(id int, data []byte) (int, error) {
+	compressed := fsm.compression != nil
+	if compressed {
+		var err error
+		data, err = fsm.compression.Compress(data)
+		if err != nil {
+			return 0, fmt.Errorf("failed to compress segment %d: %w", id, err)
+		}
+	}
+
+	segment, err := fsm.storage.Create(id, compressed)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create segment %d: %w", id, err)
+	}
+	defer segment.Close()
+
+	n, err := segment.Write(data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write segment %d: %w", id, err)
+	}
+
+	// The old segments are removed after the compaction, so the new ones are synced unless
+	// the durability is left to the OS.
+	if fsm.syncMode != SyncNone {
+		if err := segment.Sync(); err != nil {
+			return 0, fmt.Errorf("failed to sync segment %d: %w", id, err)
+		}
+	}
+
+	return n, nil
+}
+
+// removeSegments - removes the segments, failures are only logged.
+func (fsm *FileSegmentManager) removeSegments(ids []int) {
+	for _, id := range ids {
+		if err := fsm.storage.Remove(id); err != nil {
+			logger.Warn("failed to remove segment", zap.Int("id", id), zap.Error(err))
+		}
+	}
+}
+
+// decodeEntries - decodes all log entries stored in the segment data.
+func decodeEntries(data []byte) ([]LogEntry, error) {
+	var entries []LogEntry
+
+	buffer := bytes.NewBuffer(data)
+	for buffer.Len() > 0 {
+		var entry LogEntry
+		if err := entry.Decode(buffer); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -1,14 +1,24 @@
 package wal
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/gob"
+	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
 
 	"github.com/neekrasov/kvdb/internal/database/compute"
 	"github.com/neekrasov/kvdb/pkg/sync"
 )
 
+// entryHeaderSize - size of the encoded entry header: payload length and CRC32 checksum of the payload.
+const entryHeaderSize = 8
+
+// ErrChecksumMismatch - is returned when the decoded entry does not match its checksum.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
 // LogEntry - represents a single log entry in the Write-Ahead Log (WAL).
 // It is the minimal unit that is written to the WAL.
 type LogEntry struct {
@@ -21,18 +31,65 @@ type LogEntry struct {
 	Args []string
 }
 
-// Encode - encodes a LogEntry.
+// Encode - encodes a LogEntry prefixed with its length and CRC32 checksum.
 func (e LogEntry) Encode(w io.Writer) error {
-	if err := gob.NewEncoder(w).Encode(e); err != nil {
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(e); err != nil {
+		return fmt.Errorf("encode failed: %w", err)
+	}
+
+	var header [entryHeaderSize]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(payload.Len()))
+	binary.BigEndian.PutUint32(header[4:], crc32.ChecksumIEEE(payload.Bytes()))
+
+	if _, err := w.Write(header[:]); err != nil {
+		return fmt.Errorf("encode failed: %w", err)
+	}
+
+	if _, err := w.Write(payload.Bytes()); err != nil {
 		return fmt.Errorf("encode failed: %w", err)
 	}
 
 	return nil
 }
 
-// Decode - decodes a LogEntry.
+// Size - returns the size of the encoded LogEntry including its header.
+func (e LogEntry) Size() (int, error) {
+	var buf bytes.Buffer
+	if err := e.Encode(&buf); err != nil {
+		return 0, err
+	}
+
+	return buf.Len(), nil
+}
+
+// Decode - decodes a LogEntry verifying its checksum.
+// Returns io.ErrUnexpectedEOF for a truncated entry and ErrChecksumMismatch for a corrupted one.
 func (e *LogEntry) Decode(r io.Reader) error {
-	if err := gob.NewDecoder(r).Decode(e); err != nil {
+	var header [entryHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return fmt.Errorf("decode failed: %w", err)
+	}
+
+	size := int(binary.BigEndian.Uint32(header[:4]))
+	if remaining, ok := r.(interface{ Len() int }); ok && size > remaining.Len() {
+		return fmt.Errorf("decode failed: %w", io.ErrUnexpectedEOF)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return fmt.Errorf("decode failed: %w", err)
+	}
+
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+		return fmt.Errorf("decode failed: %w", ErrChecksumMismatch)
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(e); err != nil {
 		return fmt.Errorf("decode failed: %w", err)
 	}
 
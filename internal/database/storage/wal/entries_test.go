@@ -3,6 +3,7 @@ package wal_test
 import (
 	"bytes"
 	"errors"
+	"io"
 	"testing"
 
 	"github.com/neekrasov/kvdb/internal/database/compute"
@@ -31,6 +32,44 @@ func TestLogEntryEncodeDecode(t *testing.T) {
 	assert.Equal(t, entry.Args, decodedEntry.Args, "Args should match")
 }
 
+func TestLogEntryChecksum(t *testing.T) {
+	t.Parallel()
+
+	entry := wal.LogEntry{
+		LSN:       1,
+		Operation: compute.SetCommandID,
+		Args:      []string{"key", "value"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, entry.Encode(&buf))
+	encoded := buf.Bytes()
+
+	t.Run("Flipped payload byte", func(t *testing.T) {
+		corrupted := bytes.Clone(encoded)
+		corrupted[len(corrupted)-2] ^= 0xFF
+
+		var decoded wal.LogEntry
+		err := decoded.Decode(bytes.NewBuffer(corrupted))
+		assert.ErrorIs(t, err, wal.ErrChecksumMismatch)
+	})
+
+	t.Run("Flipped checksum byte", func(t *testing.T) {
+		corrupted := bytes.Clone(encoded)
+		corrupted[5] ^= 0x01
+
+		var decoded wal.LogEntry
+		err := decoded.Decode(bytes.NewBuffer(corrupted))
+		assert.ErrorIs(t, err, wal.ErrChecksumMismatch)
+	})
+
+	t.Run("Truncated entry", func(t *testing.T) {
+		var decoded wal.LogEntry
+		err := decoded.Decode(bytes.NewBuffer(encoded[:len(encoded)-3]))
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+}
+
 func TestWriteEntry(t *testing.T) {
 	t.Parallel()
 
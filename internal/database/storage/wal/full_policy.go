@@ -0,0 +1,26 @@
+package wal
+
+import "fmt"
+
+// FullPolicy - controls the writes to the log that reached its maximum total size.
+type FullPolicy string
+
+const (
+	// FullPolicyReject - the writes fail with ErrWALFull until the compaction frees the space.
+	FullPolicyReject FullPolicy = "reject"
+	// FullPolicyBackpressure - the writes wait for the compaction to free the space
+	// or until their context is done.
+	FullPolicyBackpressure FullPolicy = "backpressure"
+)
+
+// ParseFullPolicy - parses the full policy name, an empty name means FullPolicyReject.
+func ParseFullPolicy(name string) (FullPolicy, error) {
+	switch policy := FullPolicy(name); policy {
+	case "":
+		return FullPolicyReject, nil
+	case FullPolicyReject, FullPolicyBackpressure:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown full policy '%s'", name)
+	}
+}
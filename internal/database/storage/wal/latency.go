@@ -0,0 +1,84 @@
+package wal
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets - number of histogram buckets, bucket i holds durations below 2^i microseconds.
+const latencyBuckets = 32
+
+// LatencyStats - percentiles of the segment write latency.
+type LatencyStats struct {
+	Count int64         `json:"count"`
+	P50   time.Duration `json:"p50"`
+	P95   time.Duration `json:"p95"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+// latencyHistogram - lock-free histogram of durations with exponential buckets.
+type latencyHistogram struct {
+	buckets [latencyBuckets]atomic.Int64
+	max     atomic.Int64
+}
+
+// observe - records the duration into the histogram.
+func (h *latencyHistogram) observe(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+
+	idx := bits.Len64(uint64(d / time.Microsecond))
+	if idx >= latencyBuckets {
+		idx = latencyBuckets - 1
+	}
+
+	h.buckets[idx].Add(1)
+
+	for {
+		current := h.max.Load()
+		if int64(d) <= current || h.max.CompareAndSwap(current, int64(d)) {
+			break
+		}
+	}
+}
+
+// stats - returns the estimated percentiles, each is the upper bound of the bucket capped by the maximum.
+func (h *latencyHistogram) stats() LatencyStats {
+	var counts [latencyBuckets]int64
+	var total int64
+	for i := range h.buckets {
+		counts[i] = h.buckets[i].Load()
+		total += counts[i]
+	}
+
+	stats := LatencyStats{Count: total, Max: time.Duration(h.max.Load())}
+	if total == 0 {
+		return stats
+	}
+
+	percentile := func(q float64) time.Duration {
+		rank := int64(q * float64(total))
+		if rank < 1 {
+			rank = 1
+		}
+
+		var cumulative int64
+		for i, n := range counts {
+			cumulative += n
+			if cumulative >= rank {
+				return min(time.Duration(1<<i)*time.Microsecond, stats.Max)
+			}
+		}
+
+		return stats.Max
+	}
+
+	stats.P50 = percentile(0.50)
+	stats.P95 = percentile(0.95)
+	stats.P99 = percentile(0.99)
+
+	return stats
+}
@@ -1,6 +1,10 @@
 package wal
 
-import "github.com/neekrasov/kvdb/internal/database/compression"
+import (
+	"time"
+
+	"github.com/neekrasov/kvdb/internal/database/compression"
+)
 
 // FileSegmentManagerOpt - options for configuring FileSegmentManager.
 type FileSegmentManagerOpt func(*FileSegmentManager)
@@ -18,3 +22,86 @@ func WithMaxSegmentSize(maxSegmentSize int) FileSegmentManagerOpt {
 		fsm.maxSegmentSize = maxSegmentSize
 	}
 }
+
+// WithSyncMode - configures FileSegmentManager with a segment fsync mode.
+func WithSyncMode(mode SyncMode) FileSegmentManagerOpt {
+	return func(fsm *FileSegmentManager) {
+		fsm.syncMode = mode
+	}
+}
+
+// WALOpt - options for configuring WAL.
+type WALOpt func(*WAL)
+
+// WithRecoveryProgressInterval - configures WAL with an interval of recovery progress reporting.
+func WithRecoveryProgressInterval(interval time.Duration) WALOpt {
+	return func(w *WAL) {
+		w.recoveryProgressInterval = interval
+	}
+}
+
+// WithRecoveryProgressHandler - configures WAL with a handler called with the recovery progress
+// on every recovery progress interval.
+func WithRecoveryProgressHandler(handler func(RecoveryProgress)) WALOpt {
+	return func(w *WAL) {
+		w.progressHandler = handler
+	}
+}
+
+// WithCompaction - configures WAL with a log compaction period and a segments count threshold.
+// If the threshold is set, the log is compacted only when the number of segments reaches it.
+func WithCompaction(period time.Duration, segmentsThreshold int) WALOpt {
+	return func(w *WAL) {
+		w.compactionPeriod = period
+		w.compactionSegmentsThreshold = segmentsThreshold
+	}
+}
+
+// WithFlushWorkers - configures WAL with a number of the goroutines flushing the batches.
+// The batches are written one by one in their order, the workers acknowledge the written batch
+// while the next one is written.
+func WithFlushWorkers(workers int) WALOpt {
+	return func(w *WAL) {
+		if workers > 0 {
+			w.flushWorkers = workers
+		}
+	}
+}
+
+// WithMaxBatchBytes - configures WAL with a maximum encoded size of the batch,
+// the batch is flushed early when its size reaches the limit.
+func WithMaxBatchBytes(size int) WALOpt {
+	return func(w *WAL) {
+		w.maxBatchBytes = size
+	}
+}
+
+// WithWriteRetries - configures WAL with a number of retries of a failed batch write,
+// the batch is retried after the delay before the error is surfaced.
+func WithWriteRetries(retries int, delay time.Duration) WALOpt {
+	return func(w *WAL) {
+		w.writeRetries = retries
+		if delay > 0 {
+			w.writeRetryDelay = delay
+		}
+	}
+}
+
+// WithWALErrorHandler - configures WAL with a handler called when a batch failed to be written.
+// The handler is called from the flushing goroutine, so it must not block.
+func WithWALErrorHandler(handler func(error)) WALOpt {
+	return func(w *WAL) {
+		w.errorHandler = handler
+	}
+}
+
+// WithMaxTotalSize - configures WAL with a maximum total size of the segments and the policy
+// of the writes to the full log. The space is freed only by the log compaction.
+func WithMaxTotalSize(size int64, policy FullPolicy) WALOpt {
+	return func(w *WAL) {
+		w.maxTotalSize = size
+		if policy != "" {
+			w.fullPolicy = policy
+		}
+	}
+}
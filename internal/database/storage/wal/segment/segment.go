@@ -38,6 +38,16 @@ func (s *Segment) Read(p []byte) (n int, err error) {
 	return s.file.Read(p)
 }
 
+// Sync - commits the written data of the segment file to the disk.
+// Files that can not be synced are skipped.
+func (s *Segment) Sync() error {
+	if syncer, ok := s.file.(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+
+	return nil
+}
+
 // Close - closes the segment file.
 func (s *Segment) Close() error {
 	return s.file.Close()
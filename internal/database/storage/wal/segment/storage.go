@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 
 	"github.com/neekrasov/kvdb/internal/database/storage/wal"
@@ -100,6 +101,10 @@ func (fss *FileSegmentStorage) Open(id int) (wal.Segment, error) {
 // Remove - removes a segment file.
 func (fss *FileSegmentStorage) Remove(id int) error {
 	path := filepath.Join(fss.dataDir, fmt.Sprintf("segment_%d.wal", id))
+	if _, err := fss.fs.Stat(path); os.IsNotExist(err) {
+		path += ".gzip"
+	}
+
 	logger.Debug("remove segment",
 		zap.String("filename", path),
 		zap.Int("id", id))
@@ -128,7 +133,10 @@ func (fss *FileSegmentStorage) List() ([]int, error) {
 		segments = append(segments, segmentID)
 	}
 
+	// A segment may be listed twice if the process stopped after
+	// its compressed copy was written but before the original was removed.
 	sort.Ints(segments)
+	segments = slices.Compact(segments)
 	logger.Debug("stat segments", zap.Ints("segments", segments))
 
 	return segments, nil
@@ -197,16 +197,29 @@ func TestFileSegmentStorage_Remove(t *testing.T) {
 			prepareMocks: func(mockFS *mocks.FileSystem, dataDir string, id int) {
 				filePath := filepath.Join(dataDir, fmt.Sprintf("segment_%d.wal", id))
 				mockFS.EXPECT().Stat(dataDir).Return(nil, nil).Once()
+				mockFS.EXPECT().Stat(filePath).Return(nil, nil).Once()
 				mockFS.EXPECT().Remove(filePath).Return(nil)
 			},
 			expectError: false,
 		},
+		{
+			name: "Success - Remove compressed segment",
+			id:   1,
+			prepareMocks: func(mockFS *mocks.FileSystem, dataDir string, id int) {
+				filePath := filepath.Join(dataDir, fmt.Sprintf("segment_%d.wal", id))
+				mockFS.EXPECT().Stat(dataDir).Return(nil, nil).Once()
+				mockFS.EXPECT().Stat(filePath).Return(nil, os.ErrNotExist).Once()
+				mockFS.EXPECT().Remove(filePath + ".gzip").Return(nil)
+			},
+			expectError: false,
+		},
 		{
 			name: "Error - Failed to remove segment",
 			id:   1,
 			prepareMocks: func(mockFS *mocks.FileSystem, dataDir string, id int) {
 				filePath := filepath.Join(dataDir, fmt.Sprintf("segment_%d.wal", id))
 				mockFS.EXPECT().Stat(dataDir).Return(nil, nil).Once()
+				mockFS.EXPECT().Stat(filePath).Return(nil, nil).Once()
 				mockFS.EXPECT().Remove(filePath).Return(errors.New("remove error"))
 			},
 			expectError: true,
@@ -255,6 +268,24 @@ func TestFileSegmentStorage_List(t *testing.T) {
 			expectError: false,
 			expectedIDs: []int{2},
 		},
+		{
+			name: "Success - Segment with both compressed and uncompressed files",
+			prepareMocks: func(mockFS *mocks.FileSystem, dataDir string) {
+				mockFS.EXPECT().Stat(dataDir).Return(nil, nil).Once()
+				mockEntry1 := mocks.NewDirEntry(t)
+				mockEntry1.EXPECT().IsDir().Return(false)
+				mockEntry1.EXPECT().Name().Return("segment_1.wal")
+				mockEntry2 := mocks.NewDirEntry(t)
+				mockEntry2.EXPECT().IsDir().Return(false)
+				mockEntry2.EXPECT().Name().Return("segment_1.wal.gzip")
+				mockEntry3 := mocks.NewDirEntry(t)
+				mockEntry3.EXPECT().IsDir().Return(false)
+				mockEntry3.EXPECT().Name().Return("segment_2.wal")
+				mockFS.EXPECT().ReadDir(dataDir).Return([]os.DirEntry{mockEntry1, mockEntry2, mockEntry3}, nil)
+			},
+			expectError: false,
+			expectedIDs: []int{1, 2},
+		},
 		{
 			name: "Error - Failed to read directory",
 			prepareMocks: func(mockFS *mocks.FileSystem, dataDir string) {
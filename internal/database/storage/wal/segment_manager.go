@@ -3,6 +3,7 @@ package wal
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"sync"
@@ -39,6 +40,8 @@ type (
 		Size() int
 		// Write - writes data to the segment file.
 		Write(data []byte) (int, error)
+		// Sync - commits the written data to the disk.
+		Sync() error
 	}
 )
 
@@ -47,10 +50,14 @@ type FileSegmentManager struct {
 	storage        SegmentStorage
 	compression    compression.Compressor
 	maxSegmentSize int
+	syncMode       SyncMode
 
 	mu       sync.Mutex
 	current  Segment
 	segments []int
+	unsynced []int // Segments closed without the fsync, they are committed by Sync.
+	size     int64 // Total size of the stored segments in bytes, valid once measured.
+	measured bool
 }
 
 // NewFileSegmentManager - initializes and returns a new FileSegmentManager.
@@ -63,6 +70,7 @@ func NewFileSegmentManager(storage SegmentStorage, opts ...FileSegmentManagerOpt
 	fsm := &FileSegmentManager{
 		storage:  storage,
 		segments: segments,
+		syncMode: SyncNone,
 	}
 
 	for _, option := range opts {
@@ -106,17 +114,23 @@ func (fsm *FileSegmentManager) Write(entries []WriteEntry, nolock bool) error {
 			zap.Int("id", fsm.current.ID()))
 
 		if err := fsm.rotate(); err != nil {
-			return fmt.Errorf("failed to rotate segment: %w", err)
+			err = fmt.Errorf("failed to rotate segment: %w", err)
+			if !nolock {
+				fsm.ackEntries(entries, err)
+			}
+
+			return err
 		}
 	}
 
 	if fsm.current == nil {
-		segment, err := fsm.storage.Create(fsm.segments[len(fsm.segments)-1], false)
-		if err != nil {
+		if err := fsm.reopen(); err != nil {
+			if !nolock {
+				fsm.ackEntries(entries, err)
+			}
+
 			return err
 		}
-
-		fsm.current = segment
 	}
 
 	logger.Debug("write data to segment",
@@ -124,7 +138,24 @@ func (fsm *FileSegmentManager) Write(entries []WriteEntry, nolock bool) error {
 		zap.Int("id", fsm.current.ID()))
 
 	if _, err := fsm.current.Write(buf.Bytes()); err != nil {
-		return fmt.Errorf("failed to write to segment: %w", err)
+		err = fmt.Errorf("failed to write to segment: %w", err)
+		if !nolock {
+			fsm.ackEntries(entries, err)
+		}
+
+		return err
+	}
+	fsm.size += int64(buf.Len())
+
+	if fsm.syncMode == SyncAlways {
+		if err := fsm.current.Sync(); err != nil {
+			err = fmt.Errorf("failed to sync segment: %w", err)
+			if !nolock {
+				fsm.ackEntries(entries, err)
+			}
+
+			return err
+		}
 	}
 
 	if !nolock {
@@ -134,10 +165,53 @@ func (fsm *FileSegmentManager) Write(entries []WriteEntry, nolock bool) error {
 	return nil
 }
 
+// Sync - commits the written data to the disk regardless of the sync mode, the segments
+// rotated without the fsync since the previous call are committed along with the current one.
+func (fsm *FileSegmentManager) Sync() error {
+	fsm.mu.Lock()
+	defer fsm.mu.Unlock()
+
+	for len(fsm.unsynced) > 0 {
+		id := fsm.unsynced[0]
+		if err := fsm.syncSegment(id); err != nil {
+			return fmt.Errorf("failed to sync segment %d: %w", id, err)
+		}
+		fsm.unsynced = fsm.unsynced[1:]
+	}
+
+	if fsm.current == nil {
+		return nil
+	}
+
+	return fsm.current.Sync()
+}
+
+// syncSegment - commits the closed segment to the disk, the segments removed by the compaction are skipped.
+func (fsm *FileSegmentManager) syncSegment(id int) error {
+	segment, err := fsm.storage.Open(id)
+	if errors.Is(err, ErrSegmentNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer segment.Close()
+
+	return segment.Sync()
+}
+
 // rotate - rotates to a new segment.
 func (fsm *FileSegmentManager) rotate() error {
 	var sID int
 	if fsm.current != nil {
+		if fsm.syncMode != SyncNone {
+			if err := fsm.current.Sync(); err != nil {
+				return fmt.Errorf("failed to sync current segment: %w", err)
+			}
+		} else {
+			fsm.unsynced = append(fsm.unsynced, fsm.current.ID())
+		}
+
 		if err := fsm.current.Close(); err != nil {
 			return fmt.Errorf("failed to close current segment: %w", err)
 		}
@@ -162,34 +236,91 @@ func (fsm *FileSegmentManager) rotate() error {
 	return nil
 }
 
-// compress - compresses a segment.
-func (fsm *FileSegmentManager) compress(id int) error {
-	logger.Debug("compress segment",
-		zap.Int("size", fsm.current.Size()),
-		zap.Int("id", fsm.current.ID()))
+// reopen - makes the last stored segment current to continue writing to it after a restart.
+// The segment is rewritten without the entries following a torn write, so the appended
+// entries stay reachable on recovery. A compressed segment is sealed, the writes go to a new one.
+func (fsm *FileSegmentManager) reopen() error {
+	id := fsm.segments[len(fsm.segments)-1]
 
 	reader, err := fsm.storage.Open(id)
 	if err != nil {
 		return fmt.Errorf("failed to open segment %d: %w", id, err)
 	}
-	defer reader.Close()
 
+	compressed := reader.Compressed()
 	data, err := io.ReadAll(reader)
+	if closeErr := reader.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
 		return fmt.Errorf("failed to read segment %d: %w", id, err)
 	}
 
-	logger.Debug("remove old no compressed segment",
+	if compressed {
+		id++
+		data = nil
+	}
+
+	valid := validLength(data)
+	if valid < len(data) {
+		logger.Warn("drop torn entries of the last segment",
+			zap.Int("id", id), zap.Int("bytes_dropped", len(data)-valid))
+	}
+
+	segment, err := fsm.storage.Create(id, false)
+	if err != nil {
+		return fmt.Errorf("failed to create segment %d: %w", id, err)
+	}
+
+	if valid > 0 {
+		if _, err := segment.Write(data[:valid]); err != nil {
+			_ = segment.Close()
+			return fmt.Errorf("failed to rewrite segment %d: %w", id, err)
+		}
+	}
+
+	fsm.current = segment
+	if compressed {
+		fsm.segments = append(fsm.segments, id)
+	}
+	// The last segment is rewritten, so the size is measured again.
+	fsm.measured = false
+
+	return nil
+}
+
+// validLength - returns the length of the segment data up to the first broken entry.
+func validLength(data []byte) int {
+	var valid int
+
+	buffer := bytes.NewBuffer(data)
+	for buffer.Len() > 0 {
+		var entry LogEntry
+		if err := entry.Decode(buffer); err != nil {
+			break
+		}
+		valid = len(data) - buffer.Len()
+	}
+
+	return valid
+}
+
+// compress - compresses a segment. The compressed copy is written before the uncompressed
+// segment is removed, so a failure in between leaves the uncompressed segment readable.
+func (fsm *FileSegmentManager) compress(id int) error {
+	logger.Debug("compress segment",
 		zap.Int("size", fsm.current.Size()),
 		zap.Int("id", fsm.current.ID()))
 
-	if err := fsm.storage.Remove(id); err != nil {
-		return fmt.Errorf("failed to delete uncompressed segment %d: %w", id, err)
+	reader, err := fsm.storage.Open(id)
+	if err != nil {
+		return fmt.Errorf("failed to open segment %d: %w", id, err)
 	}
+	defer reader.Close()
 
-	segment, err := fsm.storage.Create(id, true)
+	data, err := io.ReadAll(reader)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to read segment %d: %w", id, err)
 	}
 
 	compressed, err := fsm.compression.Compress(data)
@@ -197,10 +328,31 @@ func (fsm *FileSegmentManager) compress(id int) error {
 		return fmt.Errorf("failed to compress segment %d: %w", id, err)
 	}
 
+	segment, err := fsm.storage.Create(id, true)
+	if err != nil {
+		return err
+	}
+	defer segment.Close()
+
 	if _, err := segment.Write(compressed); err != nil {
 		return err
 	}
 
+	if fsm.syncMode != SyncNone {
+		if err := segment.Sync(); err != nil {
+			return fmt.Errorf("failed to sync compressed segment %d: %w", id, err)
+		}
+	}
+
+	logger.Debug("remove old no compressed segment",
+		zap.Int("size", fsm.current.Size()),
+		zap.Int("id", fsm.current.ID()))
+
+	if err := fsm.storage.Remove(id); err != nil {
+		return fmt.Errorf("failed to delete uncompressed segment %d: %w", id, err)
+	}
+	fsm.size += int64(len(compressed) - len(data))
+
 	logger.Debug("writed new compressed segment",
 		zap.Int("size", fsm.current.Size()),
 		zap.Int("id", fsm.current.ID()))
@@ -215,31 +367,59 @@ func (fsm *FileSegmentManager) ackEntries(entries []WriteEntry, err error) {
 	}
 }
 
-// ForEach - iterates through all segments.
-func (fsm *FileSegmentManager) ForEach(action func(context.Context, []byte) error) error {
+// ForEach - iterates through all segments up to the last stored one, the iteration stops when the context is done.
+func (fsm *FileSegmentManager) ForEach(ctx context.Context, action func(context.Context, []byte) error) error {
 	if action == nil {
 		return nil
 	}
 
 	iterator := NewSegmentIterator(fsm.storage, fsm.compression)
 	for _, n := range fsm.segments {
-		data, err := iterator.Next(n)
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("iteration interrupted (s.num %d): %w", n, err)
+		}
 
+		// The last stored segment is returned along with io.EOF.
+		data, err := iterator.Next(n)
+		last := errors.Is(err, io.EOF)
+		if err != nil && !last {
 			return fmt.Errorf("iteration failed: %w", err)
 		}
 
-		if err := action(context.TODO(), data); err != nil {
+		if err := action(ctx, data); err != nil {
 			return fmt.Errorf("action failed (s.num %d): %w", n, err)
 		}
+
+		if last {
+			break
+		}
 	}
 
 	return nil
 }
 
+// SegmentsCount - returns the number of stored segments.
+func (fsm *FileSegmentManager) SegmentsCount() int {
+	fsm.mu.Lock()
+	defer fsm.mu.Unlock()
+
+	return len(fsm.segments)
+}
+
+// TotalSize - returns the total size of the stored segments in bytes. The segments are measured
+// on the first call, then the size is tracked by the writes, the rotations and the compactions.
+func (fsm *FileSegmentManager) TotalSize() int64 {
+	fsm.mu.Lock()
+	defer fsm.mu.Unlock()
+
+	if !fsm.measured {
+		fsm.size = fsm.segmentsSize(fsm.segments)
+		fsm.measured = true
+	}
+
+	return fsm.size
+}
+
 // Close - closes the current segment.
 func (fsm *FileSegmentManager) Close() error {
 	fsm.mu.Lock()
@@ -3,12 +3,20 @@ package wal_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/neekrasov/kvdb/internal/database/compression"
 	"github.com/neekrasov/kvdb/internal/database/compute"
 	"github.com/neekrasov/kvdb/internal/database/storage/wal"
+	"github.com/neekrasov/kvdb/internal/database/storage/wal/filesystem"
 	"github.com/neekrasov/kvdb/internal/database/storage/wal/segment"
 	mocks "github.com/neekrasov/kvdb/internal/mocks/wal"
 	"github.com/neekrasov/kvdb/pkg/logger"
@@ -92,6 +100,7 @@ func TestFileSegmentManager_Write(t *testing.T) {
 			},
 			prepareMocks: func(mockStorage *mocks.SegmentStorage, mockSegment *mocks.Segment) {
 				mockStorage.EXPECT().List().Return([]int{1}, nil)
+				expectReopen(t, mockStorage, 1)
 				mockStorage.EXPECT().Create(1, false).Return(mockSegment, nil)
 				mockSegment.EXPECT().ID().Return(1)
 				mockSegment.EXPECT().Write(mock.Anything).Return(0, nil)
@@ -105,12 +114,25 @@ func TestFileSegmentManager_Write(t *testing.T) {
 			},
 			prepareMocks: func(mockStorage *mocks.SegmentStorage, mockSegment *mocks.Segment) {
 				mockStorage.EXPECT().List().Return([]int{1}, nil)
+				expectReopen(t, mockStorage, 1)
 				mockStorage.EXPECT().Create(1, false).Return(mockSegment, nil)
 				mockSegment.EXPECT().ID().Return(1)
 				mockSegment.EXPECT().Write(mock.Anything).Return(0, errors.New("write error"))
 			},
 			expectError: true,
 		},
+		{
+			name: "Error - Failed to open the last segment",
+			entries: []wal.WriteEntry{
+				wal.NewWriteEntry(0, compute.SetCommandID, []string{}),
+				wal.NewWriteEntry(1, compute.SetCommandID, []string{}),
+			},
+			prepareMocks: func(mockStorage *mocks.SegmentStorage, _ *mocks.Segment) {
+				mockStorage.EXPECT().List().Return([]int{1}, nil)
+				mockStorage.EXPECT().Open(1).Return(nil, errors.New("open error")).Once()
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -127,8 +149,11 @@ func TestFileSegmentManager_Write(t *testing.T) {
 			for _, entry := range tt.entries {
 				go func() {
 					w.Done()
-					err = entry.Get()
-					require.NoError(t, err)
+					if err := entry.Get(); tt.expectError {
+						assert.Error(t, err)
+					} else {
+						assert.NoError(t, err)
+					}
 				}()
 			}
 
@@ -147,6 +172,15 @@ func TestFileSegmentManager_Write(t *testing.T) {
 	}
 }
 
+// expectReopen - expects the empty stored segment to be read when the manager reopens it on the first write.
+func expectReopen(t *testing.T, mockStorage *mocks.SegmentStorage, id int) {
+	stored := mocks.NewSegment(t)
+	stored.EXPECT().Compressed().Return(false)
+	stored.EXPECT().Read(mock.Anything).Return(0, io.EOF)
+	stored.EXPECT().Close().Return(nil)
+	mockStorage.EXPECT().Open(id).Return(stored, nil).Once()
+}
+
 func TestFileSegmentManager_Write_WithCompression(t *testing.T) {
 	t.Parallel()
 	logger.MockLogger()
@@ -218,6 +252,283 @@ func TestFileSegmentManager_Write_WithCompression(t *testing.T) {
 	}
 }
 
+func TestFileSegmentManager_Write_SyncMode(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	tests := []struct {
+		name         string
+		mode         wal.SyncMode
+		prepareMocks func(mockSegment *mocks.Segment)
+		expectError  bool
+	}{
+		{
+			name: "Success - None mode does not sync",
+			mode: wal.SyncNone,
+		},
+		{
+			name: "Success - Batch mode does not sync the write",
+			mode: wal.SyncBatch,
+		},
+		{
+			name: "Success - Always mode syncs the write",
+			mode: wal.SyncAlways,
+			prepareMocks: func(mockSegment *mocks.Segment) {
+				mockSegment.EXPECT().Sync().Return(nil)
+			},
+		},
+		{
+			name: "Error - Failed to sync segment",
+			mode: wal.SyncAlways,
+			prepareMocks: func(mockSegment *mocks.Segment) {
+				mockSegment.EXPECT().Sync().Return(errors.New("sync error"))
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := mocks.NewSegmentStorage(t)
+			mockSegment := mocks.NewSegment(t)
+			mockStorage.EXPECT().List().Return([]int{1}, nil)
+			expectReopen(t, mockStorage, 1)
+			mockStorage.EXPECT().Create(1, false).Return(mockSegment, nil)
+			mockSegment.EXPECT().ID().Return(1)
+			mockSegment.EXPECT().Write(mock.Anything).Return(0, nil)
+			if tt.prepareMocks != nil {
+				tt.prepareMocks(mockSegment)
+			}
+
+			manager, err := wal.NewFileSegmentManager(mockStorage, wal.WithSyncMode(tt.mode))
+			require.NoError(t, err)
+
+			entry := wal.NewWriteEntry(0, compute.SetCommandID, []string{})
+			err = manager.Write([]wal.WriteEntry{entry}, true)
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			manager.SetCurrent(nil)
+			require.NoError(t, manager.Close())
+		})
+	}
+}
+
+func TestFileSegmentManager_Sync(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	mockStorage := mocks.NewSegmentStorage(t)
+	rotated, current, reopened := mocks.NewSegment(t), mocks.NewSegment(t), mocks.NewSegment(t)
+	mockStorage.EXPECT().List().Return([]int{}, nil)
+	mockStorage.EXPECT().Create(1, false).Return(rotated, nil)
+	mockStorage.EXPECT().Create(2, false).Return(current, nil)
+	rotated.EXPECT().ID().Return(1)
+	rotated.EXPECT().Size().Return(0)
+	rotated.EXPECT().Close().Return(nil)
+	current.EXPECT().ID().Return(2)
+	current.EXPECT().Write(mock.Anything).Return(1, nil)
+	current.EXPECT().Sync().Return(nil).Twice()
+
+	// The rotated segment is not synced in the none mode, so the sync reopens it.
+	mockStorage.EXPECT().Open(1).Return(reopened, nil).Once()
+	reopened.EXPECT().Sync().Return(nil).Once()
+	reopened.EXPECT().Close().Return(nil).Once()
+
+	manager, err := wal.NewFileSegmentManager(mockStorage, wal.WithSyncMode(wal.SyncNone))
+	require.NoError(t, err)
+
+	entry := wal.NewWriteEntry(1, compute.SetCommandID, []string{"key", "value"})
+	require.NoError(t, manager.Write([]wal.WriteEntry{entry}, true))
+
+	require.NoError(t, manager.Sync())
+	require.NoError(t, manager.Sync())
+}
+
+func TestParseSyncMode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		input       string
+		expected    wal.SyncMode
+		expectError bool
+	}{
+		{name: "empty", input: "", expected: wal.SyncNone},
+		{name: "none", input: "none", expected: wal.SyncNone},
+		{name: "batch", input: "batch", expected: wal.SyncBatch},
+		{name: "always", input: "always", expected: wal.SyncAlways},
+		{name: "unknown", input: "sometimes", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, err := wal.ParseSyncMode(tt.input)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, mode)
+		})
+	}
+}
+
+func TestParseFullPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		input       string
+		expected    wal.FullPolicy
+		expectError bool
+	}{
+		{name: "empty", input: "", expected: wal.FullPolicyReject},
+		{name: "reject", input: "reject", expected: wal.FullPolicyReject},
+		{name: "backpressure", input: "backpressure", expected: wal.FullPolicyBackpressure},
+		{name: "unknown", input: "drop", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := wal.ParseFullPolicy(tt.input)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, policy)
+		})
+	}
+}
+
+func TestFileSegmentManager_TotalSize(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	// diskSize - returns the total size of the segment files in the directory.
+	diskSize := func(t *testing.T, dir string) int64 {
+		t.Helper()
+
+		files, err := os.ReadDir(dir)
+		require.NoError(t, err)
+
+		var size int64
+		for _, file := range files {
+			info, err := file.Info()
+			require.NoError(t, err)
+			size += info.Size()
+		}
+
+		return size
+	}
+
+	tests := []struct {
+		name       string
+		compressor string
+	}{
+		{name: "Without compression"},
+		{name: "With compression", compressor: "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			storage, err := segment.NewFileSegmentStorage(new(filesystem.LocalFileSystem), dir)
+			require.NoError(t, err)
+
+			opts := []wal.FileSegmentManagerOpt{wal.WithMaxSegmentSize(256)}
+			if tt.compressor != "" {
+				compressor, err := compression.New(tt.compressor)
+				require.NoError(t, err)
+				opts = append(opts, wal.WithCompressor(compressor))
+			}
+
+			manager, err := wal.NewFileSegmentManager(storage, opts...)
+			require.NoError(t, err)
+			assert.Zero(t, manager.TotalSize())
+
+			writeCompactionLog(t, manager)
+			require.Greater(t, manager.SegmentsCount(), 2)
+			assert.Equal(t, diskSize(t, dir), manager.TotalSize())
+
+			result, err := manager.Compact()
+			require.NoError(t, err)
+			assert.Positive(t, result.BytesReclaimed)
+			assert.Equal(t, diskSize(t, dir), manager.TotalSize())
+
+			// The reopened manager measures the stored segments.
+			require.NoError(t, manager.Close())
+			reopened, err := wal.NewFileSegmentManager(storage, opts...)
+			require.NoError(t, err)
+			assert.Equal(t, diskSize(t, dir), reopened.TotalSize())
+		})
+	}
+}
+
+// BenchmarkFileSegmentManager_Write - compares the write throughput of the sync modes on a real disk.
+func BenchmarkFileSegmentManager_Write(b *testing.B) {
+	logger.MockLogger()
+
+	for _, mode := range []wal.SyncMode{wal.SyncNone, wal.SyncBatch, wal.SyncAlways} {
+		b.Run(string(mode), func(b *testing.B) {
+			storage, err := segment.NewFileSegmentStorage(new(filesystem.LocalFileSystem), b.TempDir())
+			require.NoError(b, err)
+
+			manager, err := wal.NewFileSegmentManager(storage,
+				wal.WithSyncMode(mode),
+				wal.WithMaxSegmentSize(1<<20),
+			)
+			require.NoError(b, err)
+			defer manager.Close()
+
+			args := []string{"key", "value"}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				entry := wal.NewWriteEntry(int64(i), compute.SetCommandID, args)
+				if err := manager.Write([]wal.WriteEntry{entry}, true); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestFileSegmentManager_Write_MaxSegmentSize(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	const maxSegmentSize = 1 << 10
+
+	dir := t.TempDir()
+	storage, err := segment.NewFileSegmentStorage(new(filesystem.LocalFileSystem), dir)
+	require.NoError(t, err)
+
+	manager, err := wal.NewFileSegmentManager(storage, wal.WithMaxSegmentSize(maxSegmentSize))
+	require.NoError(t, err)
+
+	for i := 0; i < 64; i++ {
+		entry := wal.NewWriteEntry(int64(i), compute.SetCommandID, []string{fmt.Sprintf("key_%d", i), "value"})
+		require.NoError(t, manager.Write([]wal.WriteEntry{entry}, true))
+	}
+	require.NoError(t, manager.Close())
+
+	ids, err := storage.List()
+	require.NoError(t, err)
+	require.Greater(t, len(ids), 1, "segment must be rotated at the configured size")
+
+	for _, id := range ids {
+		info, err := os.Stat(filepath.Join(dir, fmt.Sprintf("segment_%d.wal", id)))
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(maxSegmentSize))
+	}
+}
+
 func TestFileSegmentManager_ForEach(t *testing.T) {
 	t.Parallel()
 	logger.MockLogger()
@@ -314,7 +625,7 @@ func TestFileSegmentManager_ForEach(t *testing.T) {
 			manager, err := wal.NewFileSegmentManager(mockStorage, wal.WithCompressor(mockCompressor))
 			require.NoError(t, err)
 
-			err = manager.ForEach(tt.action)
+			err = manager.ForEach(context.Background(), tt.action)
 			if tt.expectError {
 				assert.Error(t, err)
 			} else {
@@ -323,3 +634,318 @@ func TestFileSegmentManager_ForEach(t *testing.T) {
 		})
 	}
 }
+
+func TestFileSegmentManager_Compact(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	tests := []struct {
+		name       string
+		compressor string
+	}{
+		{name: "Without compression"},
+		{name: "With compression", compressor: "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, err := segment.NewFileSegmentStorage(new(filesystem.LocalFileSystem), t.TempDir())
+			require.NoError(t, err)
+
+			opts := []wal.FileSegmentManagerOpt{wal.WithMaxSegmentSize(256)}
+			if tt.compressor != "" {
+				compressor, err := compression.New(tt.compressor)
+				require.NoError(t, err)
+				opts = append(opts, wal.WithCompressor(compressor))
+			}
+
+			manager, err := wal.NewFileSegmentManager(storage, opts...)
+			require.NoError(t, err)
+			expected := writeCompactionLog(t, manager)
+
+			before := manager.SegmentsCount()
+			require.Greater(t, before, 2)
+			result, err := manager.Compact()
+			require.NoError(t, err)
+			assert.Less(t, manager.SegmentsCount(), before)
+			assert.Equal(t, before, result.SegmentsRemoved)
+			assert.Positive(t, result.BytesReclaimed)
+
+			// Compacting an already compacted log is a no-op.
+			after := manager.SegmentsCount()
+			result, err = manager.Compact()
+			require.NoError(t, err)
+			assert.Equal(t, after, manager.SegmentsCount())
+			assert.Equal(t, wal.CompactionResult{}, result)
+			require.NoError(t, manager.Close())
+
+			assert.Equal(t, expected, recoverCompactionState(t, storage, opts...))
+		})
+	}
+
+	t.Run("After restart", func(t *testing.T) {
+		storage, err := segment.NewFileSegmentStorage(new(filesystem.LocalFileSystem), t.TempDir())
+		require.NoError(t, err)
+
+		manager, err := wal.NewFileSegmentManager(storage, wal.WithMaxSegmentSize(256))
+		require.NoError(t, err)
+		writeCompactionLog(t, manager)
+		require.NoError(t, manager.Close())
+
+		// The last segment of the previous run is recovered, so compaction must keep its entries.
+		expected := recoverCompactionState(t, storage, wal.WithMaxSegmentSize(256))
+
+		restarted, err := wal.NewFileSegmentManager(storage, wal.WithMaxSegmentSize(256))
+		require.NoError(t, err)
+		_, err = restarted.Compact()
+		require.NoError(t, err)
+		require.NoError(t, restarted.Close())
+
+		assert.Equal(t, expected, recoverCompactionState(t, storage, wal.WithMaxSegmentSize(256)))
+	})
+
+	t.Run("Error - Failed to create current segment", func(t *testing.T) {
+		fileStorage, err := segment.NewFileSegmentStorage(new(filesystem.LocalFileSystem), t.TempDir())
+		require.NoError(t, err)
+		storage := &failingCreateStorage{SegmentStorage: fileStorage, failID: -1}
+
+		manager, err := wal.NewFileSegmentManager(storage, wal.WithMaxSegmentSize(1<<20))
+		require.NoError(t, err)
+		expected := writeCompactionLog(t, manager)
+
+		ids, err := storage.List()
+		require.NoError(t, err)
+		before := manager.SegmentsCount()
+
+		// Compacted entries fit into a single segment, the next id is the new current segment.
+		require.Len(t, ids, 1)
+		storage.failID = ids[len(ids)-1] + 2
+		_, err = manager.Compact()
+		require.Error(t, err)
+		assert.Equal(t, before, manager.SegmentsCount())
+
+		listed, err := storage.List()
+		require.NoError(t, err)
+		assert.Equal(t, ids, listed)
+
+		// The manager keeps writing to the old current segment.
+		require.NoError(t, manager.Write([]wal.WriteEntry{
+			wal.NewWriteEntry(1000, compute.SetCommandID, []string{"after", "failure"}),
+		}, true))
+		expected["after"] = "failure"
+
+		storage.failID = -1
+		_, err = manager.Compact()
+		require.NoError(t, err)
+		require.NoError(t, manager.Close())
+
+		assert.Equal(t, expected, recoverCompactionState(t, storage, wal.WithMaxSegmentSize(1<<20)))
+	})
+}
+
+func TestFileSegmentManager_Truncate(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	tests := []struct {
+		name       string
+		compressor string
+	}{
+		{name: "Without compression"},
+		{name: "With compression", compressor: "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, err := segment.NewFileSegmentStorage(new(filesystem.LocalFileSystem), t.TempDir())
+			require.NoError(t, err)
+
+			opts := []wal.FileSegmentManagerOpt{wal.WithMaxSegmentSize(256)}
+			if tt.compressor != "" {
+				compressor, err := compression.New(tt.compressor)
+				require.NoError(t, err)
+				opts = append(opts, wal.WithCompressor(compressor))
+			}
+
+			manager, err := wal.NewFileSegmentManager(storage, opts...)
+			require.NoError(t, err)
+			writeCompactionLog(t, manager)
+
+			before := manager.SegmentsCount()
+			require.Greater(t, before, 2)
+			manager.TotalSize()
+
+			// The first segment holds later entries, nothing is removed.
+			removed, err := manager.Truncate(0)
+			require.NoError(t, err)
+			assert.Zero(t, removed)
+			assert.Equal(t, before, manager.SegmentsCount())
+
+			removed, err = manager.Truncate(10)
+			require.NoError(t, err)
+			assert.Positive(t, removed)
+			assert.Equal(t, before-removed, manager.SegmentsCount())
+
+			lsns := recoverLSNs(t, storage, opts...)
+			assert.Greater(t, lsns[0], int64(1))
+			assert.LessOrEqual(t, lsns[0], int64(11))
+			assert.Equal(t, int64(23), lsns[len(lsns)-1])
+
+			// The last segment is kept even if it's covered.
+			_, err = manager.Truncate(1000)
+			require.NoError(t, err)
+			assert.Equal(t, 1, manager.SegmentsCount())
+
+			size := manager.TotalSize()
+			require.NoError(t, manager.Close())
+
+			restarted, err := wal.NewFileSegmentManager(storage, opts...)
+			require.NoError(t, err)
+			assert.Equal(t, size, restarted.TotalSize())
+			require.NoError(t, restarted.Close())
+
+			truncated := recoverLSNs(t, storage, opts...)
+			assert.Greater(t, truncated[0], lsns[0])
+			assert.Equal(t, int64(23), truncated[len(truncated)-1])
+		})
+	}
+}
+
+func TestFileSegmentManager_CompressionRoundTrip(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	for _, algorithm := range []compression.CompressionType{
+		compression.Gzip, compression.Zstd, compression.Flate, compression.Bzip2,
+	} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			storage, err := segment.NewFileSegmentStorage(new(filesystem.LocalFileSystem), t.TempDir())
+			require.NoError(t, err)
+
+			compressor, err := compression.New(string(algorithm))
+			require.NoError(t, err)
+			opts := []wal.FileSegmentManagerOpt{wal.WithMaxSegmentSize(256), wal.WithCompressor(compressor)}
+
+			manager, err := wal.NewFileSegmentManager(storage, opts...)
+			require.NoError(t, err)
+			expected := writeCompactionLog(t, manager)
+
+			// The filler seals the logged entries, so they are recovered from the compressed segments.
+			filler := wal.NewWriteEntry(1000, compute.SetCommandID, []string{"filler", strings.Repeat("x", 256)})
+			require.NoError(t, manager.Write([]wal.WriteEntry{filler}, true))
+			expected["filler"] = strings.Repeat("x", 256)
+			require.NoError(t, manager.Close())
+
+			ids, err := storage.List()
+			require.NoError(t, err)
+			require.Greater(t, len(ids), 2)
+			for _, id := range ids[:len(ids)-1] {
+				seg, err := storage.Open(id)
+				require.NoError(t, err)
+				assert.True(t, seg.Compressed(), "sealed segment %d must be compressed", id)
+				require.NoError(t, seg.Close())
+			}
+
+			assert.Equal(t, expected, recoverCompactionState(t, storage, opts...))
+		})
+	}
+}
+
+// failingCreateStorage - segment storage failing to create the segment with the given id.
+type failingCreateStorage struct {
+	wal.SegmentStorage
+	failID int
+}
+
+func (s *failingCreateStorage) Create(id int, compressed bool) (wal.Segment, error) {
+	if id == s.failID {
+		return nil, errors.New("create error")
+	}
+
+	return s.SegmentStorage.Create(id, compressed)
+}
+
+// writeCompactionLog - writes overwritten, deleted and renamed keys
+// and returns the expected state.
+func writeCompactionLog(t *testing.T, manager *wal.FileSegmentManager) map[string]string {
+	t.Helper()
+
+	var lsn int64
+	write := func(op compute.CommandID, args ...string) {
+		lsn++
+		require.NoError(t, manager.Write([]wal.WriteEntry{wal.NewWriteEntry(lsn, op, args)}, true))
+	}
+
+	expected := make(map[string]string)
+	for i := range 20 {
+		key := fmt.Sprintf("key%d", i%5)
+		value := fmt.Sprintf("value%d", i)
+		write(compute.SetCommandID, key, value)
+		expected[key] = value
+	}
+	write(compute.DelCommandID, "key0")
+	delete(expected, "key0")
+	write(compute.RenameNXCommandID, "key1", "renamed")
+	expected["renamed"] = expected["key1"]
+	delete(expected, "key1")
+	write(compute.RenameNXCommandID, "key2", "key3")
+
+	return expected
+}
+
+// recoverLSNs - returns the ordered LSNs of the entries recovered from the stored segments.
+func recoverLSNs(t *testing.T, storage wal.SegmentStorage, opts ...wal.FileSegmentManagerOpt) []int64 {
+	t.Helper()
+
+	manager, err := wal.NewFileSegmentManager(storage, opts...)
+	require.NoError(t, err)
+
+	var lsns []int64
+	_, err = wal.NewWAL(manager, 1, time.Second).Recover(context.Background(), func(_ context.Context, entries []wal.LogEntry) error {
+		for _, entry := range entries {
+			lsns = append(lsns, entry.LSN)
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+	slices.Sort(lsns)
+
+	return lsns
+}
+
+// recoverCompactionState - recovers the key-value state from the stored segments.
+func recoverCompactionState(
+	t *testing.T, storage wal.SegmentStorage,
+	opts ...wal.FileSegmentManagerOpt,
+) map[string]string {
+	t.Helper()
+
+	manager, err := wal.NewFileSegmentManager(storage, opts...)
+	require.NoError(t, err)
+
+	state := make(map[string]string)
+	_, err = wal.NewWAL(manager, 1, time.Second).Recover(context.Background(), func(_ context.Context, entries []wal.LogEntry) error {
+		for _, entry := range entries {
+			switch entry.Operation {
+			case compute.SetCommandID:
+				state[entry.Args[0]] = entry.Args[1]
+			case compute.DelCommandID:
+				delete(state, entry.Args[0])
+			case compute.RenameNXCommandID:
+				oldKey, newKey := entry.Args[0], entry.Args[1]
+				if value, ok := state[oldKey]; ok {
+					if _, taken := state[newKey]; !taken {
+						delete(state, oldKey)
+						state[newKey] = value
+					}
+				}
+			}
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	return state
+}
@@ -0,0 +1,30 @@
+package wal
+
+import "fmt"
+
+// SyncMode - controls when the segment files are fsynced, trading durability for throughput.
+type SyncMode string
+
+const (
+	// SyncNone - segments are never fsynced explicitly, the OS decides when the data hits the disk.
+	// The fastest mode, acknowledged writes may be lost on a power failure or a kernel crash.
+	SyncNone SyncMode = "none"
+	// SyncBatch - the segment is fsynced when it is rotated. Only writes to the current
+	// segment may be lost, the throughput is close to the none mode.
+	SyncBatch SyncMode = "batch"
+	// SyncAlways - every written batch is fsynced before it is acknowledged.
+	// No acknowledged write is lost, the throughput is bound by the disk sync latency.
+	SyncAlways SyncMode = "always"
+)
+
+// ParseSyncMode - parses the sync mode name, an empty name means SyncNone.
+func ParseSyncMode(name string) (SyncMode, error) {
+	switch mode := SyncMode(name); mode {
+	case "":
+		return SyncNone, nil
+	case SyncNone, SyncBatch, SyncAlways:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown sync mode '%s'", name)
+	}
+}
@@ -3,10 +3,12 @@ package wal
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/neekrasov/kvdb/internal/database/compute"
@@ -21,69 +23,397 @@ import (
 type SegmentManager interface {
 	// Write - writes entries to the current segment.
 	Write(entries []WriteEntry, nolock bool) error
-	// ForEach - iterates through all segments.
-	ForEach(action func(ctx context.Context, b []byte) error) error
+	// ForEach - iterates through all segments, the iteration stops when the context is done.
+	ForEach(ctx context.Context, action func(ctx context.Context, b []byte) error) error
 	// Close - closes the current segment.
 	Close() error
 }
 
+// segmentCounter - optional interface of segment managers that know the number of stored segments.
+type segmentCounter interface {
+	// SegmentsCount - returns the number of stored segments.
+	SegmentsCount() int
+}
+
+// syncer - optional interface of segment managers that commit the current segment to the disk on demand.
+type syncer interface {
+	// Sync - commits the written data of the current segment to the disk.
+	Sync() error
+}
+
+// sizer - optional interface of segment managers that know the total size of the stored segments.
+type sizer interface {
+	// TotalSize - returns the total size of the stored segments in bytes.
+	TotalSize() int64
+}
+
+// compactor - optional interface of segment managers that support log compaction.
+type compactor interface {
+	// Compact - rewrites the log keeping only the latest entry per key.
+	Compact() (CompactionResult, error)
+	// SegmentsCount - returns the number of stored segments.
+	SegmentsCount() int
+}
+
+// truncater - optional interface of segment managers that remove the segments covered by a snapshot.
+type truncater interface {
+	// Truncate - removes the segments holding only the entries with LSN up to the lsn.
+	Truncate(lsn int64) (int, error)
+}
+
+// ErrSyncUnavailable - the WAL is disabled or the segment manager can't commit the segments to the disk.
+var ErrSyncUnavailable = errors.New("wal sync unavailable")
+
+// ErrWALFull - the total size of the segments reached the configured maximum.
+var ErrWALFull = errors.New("wal full")
+
+// ErrWALStopped - the WAL is stopped and does not accept the writes.
+var ErrWALStopped = errors.New("wal stopped")
+
+// ErrCompactionDisabled - the log compaction is not configured or not supported by the segment manager.
+var ErrCompactionDisabled = errors.New("wal compaction disabled")
+
+// CompactionStats - summary of the completed log compaction passes.
+type CompactionStats struct {
+	Total int64     // Number of the completed compaction passes.
+	Last  time.Time // Completion time of the last pass, zero if the log was never compacted.
+}
+
+// RecoveryProgress - progress of the WAL recovery.
+type RecoveryProgress struct {
+	SegmentsProcessed int // Number of the replayed segments.
+	SegmentsTotal     int // Number of the stored segments, zero if the segment manager does not count them.
+	EntriesApplied    int // Number of the applied entries.
+}
+
+const (
+	defaultRecoveryProgressInterval = 5 * time.Second
+	defaultCompactionPeriod         = time.Minute
+	defaultWriteRetryDelay          = 10 * time.Millisecond
+)
+
 // WAL - Write-Ahead Log implementation.
 type WAL struct {
-	segmentManager SegmentManager
-	batchSize      int
-	flushTimeout   time.Duration
-	batches        chan struct{}
-
-	mu    sync.Mutex
-	batch []WriteEntry
+	segmentManager           SegmentManager
+	batchSize                int
+	flushTimeout             time.Duration
+	batches                  chan struct{}
+	recoveryProgressInterval time.Duration
+	progressHandler          func(RecoveryProgress)
+
+	compactionPeriod            time.Duration
+	compactionSegmentsThreshold int
+	compactionsTotal            atomic.Int64
+	lastCompaction              atomic.Int64
+
+	latency latencyHistogram
+
+	writeRetries    int
+	writeRetryDelay time.Duration
+	writeErrors     atomic.Int64
+	errorHandler    func(error)
+
+	flushWorkers int
+	order        flushOrder
+	flushers     sync.WaitGroup
+	stop         chan struct{} // Closed by Stop to let the flush workers write the last batch and exit.
+	stopOnce     sync.Once
+
+	// maxBatchBytes - encoded size of the batch flushed before it's full, zero disables the limit.
+	maxBatchBytes int
+
+	// maxTotalSize - total size of the segments the writes are limited at, zero disables the limit.
+	maxTotalSize int64
+	fullPolicy   FullPolicy
+	spaceMu      sync.Mutex
+	spaceFreed   chan struct{} // Closed and replaced after every compaction pass and truncation.
+
+	mu         sync.Mutex
+	batch      []WriteEntry
+	batchBytes int
+	batchSeq   uint64 // Sequence number of the next flushed batch.
+	stopped    bool
 }
 
 // NewWAL - initializes and returns a new WAL.
-func NewWAL(segmentManager SegmentManager, batchSize int, flushTimeout time.Duration) *WAL {
-	return &WAL{
-		segmentManager: segmentManager,
-		batchSize:      batchSize,
-		flushTimeout:   flushTimeout,
-		batch:          make([]WriteEntry, 0, batchSize),
-		batches:        make(chan struct{}),
+func NewWAL(
+	segmentManager SegmentManager,
+	batchSize int, flushTimeout time.Duration,
+	opts ...WALOpt,
+) *WAL {
+	wal := &WAL{
+		segmentManager:           segmentManager,
+		batchSize:                batchSize,
+		flushTimeout:             flushTimeout,
+		batch:                    make([]WriteEntry, 0, batchSize),
+		batches:                  make(chan struct{}, 1),
+		recoveryProgressInterval: defaultRecoveryProgressInterval,
+		writeRetryDelay:          defaultWriteRetryDelay,
+		flushWorkers:             1,
+		fullPolicy:               FullPolicyReject,
+		spaceFreed:               make(chan struct{}),
+		stop:                     make(chan struct{}),
 	}
+	wal.order.cond = sync.NewCond(&wal.order.mu)
+
+	for _, opt := range opts {
+		opt(wal)
+	}
+
+	return wal
 }
 
-// Start - starts the WAL background flush process.
+// Start - starts the WAL background flush workers and compaction processes.
 func (w *WAL) Start(ctx context.Context) {
-	baseErrMsg := "failed to flush batch"
+	if w.compactionEnabled() {
+		if compactor, ok := w.segmentManager.(compactor); ok {
+			go w.startCompaction(ctx, compactor)
+		}
+	}
+
+	w.flushers.Add(w.flushWorkers)
+	for range w.flushWorkers {
+		go func() {
+			defer w.flushers.Done()
+			w.startFlushing(ctx)
+		}()
+	}
+}
+
+// Stop - stops the flush workers and writes the last batch, it returns once the batch is committed to the disk
+// if the segment manager supports it. The writes pushed after the stop are rejected with ErrWALStopped.
+// The context limits the wait for the flush workers.
+func (w *WAL) Stop(ctx context.Context) error {
+	if w == nil {
+		return nil
+	}
+
+	w.stopOnce.Do(func() {
+		pkgsync.WithLock(&w.mu, func() { w.stopped = true })
+		close(w.stop)
+	})
+
+	done := make(chan struct{})
 	go func() {
-		ticker := time.NewTicker(w.flushTimeout)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				if err := w.flush(); err != nil {
-					logger.Debug(baseErrMsg, zap.Error(err))
-				}
-				return
-			default:
+		w.flushers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("failed to wait for the flush workers: %w", ctx.Err())
+	}
+
+	// The workers exited on the context may have left the batch pushed before the stop.
+	if err := w.flush(); err != nil {
+		w.writeErrors.Add(1)
+		return err
+	}
+
+	syncer, ok := w.segmentManager.(syncer)
+	if !ok {
+		return nil
+	}
+
+	if err := syncer.Sync(); err != nil {
+		return fmt.Errorf("failed to sync segment: %w", err)
+	}
+
+	logger.Debug("wal stopped")
+	return nil
+}
+
+// startFlushing - flushes the batch when it's full or the flush timeout has passed.
+func (w *WAL) startFlushing(ctx context.Context) {
+	ticker := time.NewTicker(w.flushTimeout)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.flushAndReport()
+			return
+		case <-w.stop:
+			w.flushAndReport()
+			return
+		default:
+		}
+
+		select {
+		case <-ctx.Done():
+			w.flushAndReport()
+			return
+		case <-w.stop:
+			w.flushAndReport()
+			return
+		case <-w.batches:
+			w.flushAndReport()
+			ticker.Reset(w.flushTimeout)
+		case <-ticker.C:
+			w.flushAndReport()
+		}
+	}
+}
+
+// flushAndReport - flushes the current batch, failures are counted and passed to the error handler.
+func (w *WAL) flushAndReport() {
+	err := w.flush()
+	if err == nil {
+		return
+	}
+
+	w.writeErrors.Add(1)
+	logger.Error("failed to flush batch", zap.Error(err))
+	if w.errorHandler != nil {
+		w.errorHandler(err)
+	}
+}
+
+// startCompaction - periodically compacts the log. If the segments threshold is set,
+// the log is compacted only when the number of segments reaches it.
+func (w *WAL) startCompaction(ctx context.Context, compactor compactor) {
+	period := w.compactionPeriod
+	if period <= 0 {
+		period = defaultCompactionPeriod
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if w.compactionSegmentsThreshold > 0 &&
+				compactor.SegmentsCount() < w.compactionSegmentsThreshold {
+				continue
 			}
 
-			select {
-			case <-ctx.Done():
-				if err := w.flush(); err != nil {
-					logger.Debug(baseErrMsg, zap.Error(err))
-				}
-				return
-			case <-w.batches:
-				if err := w.flush(); err != nil {
-					logger.Debug(baseErrMsg, zap.Error(err))
-				}
-				ticker.Reset(w.flushTimeout)
-			case <-ticker.C:
-				if err := w.flush(); err != nil {
-					logger.Debug(baseErrMsg, zap.Error(err))
-				}
+			if _, err := w.compact(compactor); err != nil {
+				logger.Warn("failed to compact wal", zap.Error(err))
 			}
 		}
-	}()
+	}
+}
+
+// Compact - synchronously runs a log compaction pass. The compaction must be configured,
+// since it is disabled for the setups it is not safe for (e.g. replication and snapshots).
+func (w *WAL) Compact() (CompactionResult, error) {
+	if w == nil || !w.compactionEnabled() {
+		return CompactionResult{}, ErrCompactionDisabled
+	}
+
+	compactor, ok := w.segmentManager.(compactor)
+	if !ok {
+		return CompactionResult{}, ErrCompactionDisabled
+	}
+
+	return w.compact(compactor)
+}
+
+// Truncate - removes the segments holding only the entries with LSN up to the lsn, e.g. saved
+// in a snapshot. Returns the number of the removed segments, zero if the WAL is disabled
+// or the segment manager can't remove the segments.
+func (w *WAL) Truncate(lsn int64) (int, error) {
+	if w == nil {
+		return 0, nil
+	}
+
+	truncater, ok := w.segmentManager.(truncater)
+	if !ok {
+		return 0, nil
+	}
+
+	removed, err := truncater.Truncate(lsn)
+	if err != nil {
+		return 0, fmt.Errorf("failed to truncate segments: %w", err)
+	}
+
+	if removed > 0 {
+		w.notifySpaceFreed()
+	}
+
+	return removed, nil
+}
+
+// Compactions - returns the summary of the completed log compaction passes.
+func (w *WAL) Compactions() CompactionStats {
+	if w == nil {
+		return CompactionStats{}
+	}
+
+	stats := CompactionStats{Total: w.compactionsTotal.Load()}
+	if last := w.lastCompaction.Load(); last != 0 {
+		stats.Last = time.Unix(0, last)
+	}
+
+	return stats
+}
+
+// compactionEnabled - returns whether the log compaction is configured.
+func (w *WAL) compactionEnabled() bool {
+	return w.compactionPeriod > 0 || w.compactionSegmentsThreshold > 0
+}
+
+// compact - runs a compaction pass and counts it if it succeeded.
+func (w *WAL) compact(compactor compactor) (CompactionResult, error) {
+	result, err := compactor.Compact()
+	if err != nil {
+		return CompactionResult{}, err
+	}
+
+	w.compactionsTotal.Add(1)
+	w.lastCompaction.Store(time.Now().UnixNano())
+	w.notifySpaceFreed()
+
+	return result, nil
+}
+
+// notifySpaceFreed - wakes up the writes waiting for the space in the log.
+func (w *WAL) notifySpaceFreed() {
+	w.spaceMu.Lock()
+	defer w.spaceMu.Unlock()
+
+	close(w.spaceFreed)
+	w.spaceFreed = make(chan struct{})
+}
+
+// waitSpace - checks the total size of the segments against the limit. The write fails with ErrWALFull
+// when the log is full, with the backpressure policy it waits for a compaction pass to free the space instead.
+func (w *WAL) waitSpace(ctx context.Context) error {
+	if w.maxTotalSize <= 0 {
+		return nil
+	}
+
+	sizer, ok := w.segmentManager.(sizer)
+	if !ok {
+		return nil
+	}
+
+	for {
+		// The channel is taken before the size is checked, so a compaction in between is not missed.
+		var freed chan struct{}
+		pkgsync.WithLock(&w.spaceMu, func() { freed = w.spaceFreed })
+
+		size := sizer.TotalSize()
+		if size < w.maxTotalSize {
+			return nil
+		}
+
+		if w.fullPolicy != FullPolicyBackpressure {
+			return ErrWALFull
+		}
+
+		logger.Debug("wal is full, wait for compaction",
+			zap.Int64("total_size", size), zap.Int64("max_total_size", w.maxTotalSize))
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrWALFull, ctx.Err())
+		case <-freed:
+		}
+	}
 }
 
 // Set - push a set operation to the WAL.
@@ -104,12 +434,25 @@ func (w *WAL) Del(ctx context.Context, key string) error {
 	return w.push(ctx, compute.DelCommandID, []string{key})
 }
 
+// RenameNX - push a renamenx operation to the WAL.
+func (w *WAL) RenameNX(ctx context.Context, oldKey, newKey string) error {
+	if w == nil {
+		return nil
+	}
+
+	return w.push(ctx, compute.RenameNXCommandID, []string{oldKey, newKey})
+}
+
 // push - pushes a log entry to the batch.
 func (w *WAL) push(ctx context.Context, op compute.CommandID, args []string) error {
 	if w == nil {
 		return nil
 	}
 
+	if err := w.waitSpace(ctx); err != nil {
+		return err
+	}
+
 	txID := ctxutil.ExtractTxID(ctx)
 	logger.Debug(
 		"pushed log entry to wal",
@@ -118,19 +461,51 @@ func (w *WAL) push(ctx context.Context, op compute.CommandID, args []string) err
 		zap.Int64("tx", txID),
 	)
 
+	var (
+		full bool
+		size int
+	)
 	entry := NewWriteEntry(txID, op, args)
+	if w.maxBatchBytes > 0 {
+		// The encoding error is left to the segment manager, it fails the whole batch.
+		size, _ = entry.log.Size()
+	}
+
+	var stopped bool
 	pkgsync.WithLock(&w.mu, func() {
-		w.batch = append(w.batch, entry)
-		if len(w.batch) >= w.batchSize {
-			w.batches <- struct{}{}
+		if w.stopped {
+			stopped = true
+			return
 		}
+
+		w.batch = append(w.batch, entry)
+		w.batchBytes += size
+		full = len(w.batch) >= w.batchSize ||
+			(w.maxBatchBytes > 0 && w.batchBytes >= w.maxBatchBytes)
 	})
+	if stopped {
+		return ErrWALStopped
+	}
+
+	// The signal is sent without holding the lock, because the flusher takes it to swap the batch.
+	// A pending signal is enough to flush the batch, so the duplicate signals are dropped.
+	if full {
+		select {
+		case w.batches <- struct{}{}:
+		default:
+		}
+	}
 
 	return entry.future.Get()
 }
 
+// Flush - writes the batch to the segment bypassing the pending batch.
 func (w *WAL) Flush(batch []WriteEntry) error {
-	if err := w.segmentManager.Write(batch, true); err != nil {
+	if w == nil {
+		return nil
+	}
+
+	if err := w.write(batch, true); err != nil {
 		return fmt.Errorf("failed to write to segment: %w", err)
 	}
 
@@ -138,37 +513,193 @@ func (w *WAL) Flush(batch []WriteEntry) error {
 	return nil
 }
 
+// Sync - writes the pending batch and commits the current segment to the disk. It returns once the batch
+// and the batches taken by the flush workers before it are written, so the acknowledged writes are durable.
+func (w *WAL) Sync() error {
+	if w == nil {
+		return ErrSyncUnavailable
+	}
+
+	syncer, ok := w.segmentManager.(syncer)
+	if !ok {
+		return ErrSyncUnavailable
+	}
+
+	if err := w.flush(); err != nil {
+		w.writeErrors.Add(1)
+		return err
+	}
+
+	var taken uint64
+	pkgsync.WithLock(&w.mu, func() { taken = w.batchSeq })
+	w.order.waitWritten(taken)
+
+	if err := syncer.Sync(); err != nil {
+		return fmt.Errorf("failed to sync segment: %w", err)
+	}
+
+	logger.Debug("sync segments")
+	return nil
+}
+
 // flush - flushes the current batch to the segment.
+//
+// The batches are written in the order they were taken even with several flush workers:
+// the recovery sorts the entries by LSN only within a segment, so a batch must not be written
+// to a later segment than the batch taken after it. The waiters are acknowledged after
+// the turn is passed, so the next batch is written meanwhile.
 func (w *WAL) flush() error {
-	if len(w.batch) != 0 {
-		if err := w.segmentManager.Write(w.batch, false); err != nil {
-			return fmt.Errorf("failed to write to segment: %w", err)
+	var (
+		batch []WriteEntry
+		seq   uint64
+	)
+	pkgsync.WithLock(&w.mu, func() {
+		batch = w.batch
+		w.batch = make([]WriteEntry, 0, w.batchSize)
+		w.batchBytes = 0
+		if len(batch) > 0 {
+			seq = w.batchSeq
+			w.batchSeq++
 		}
+	})
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	w.order.wait(seq)
+	err := w.writeWithRetries(batch)
+	w.order.done()
 
-		logger.Debug("flush segments")
-		w.batch = nil
+	for i := range batch {
+		batch[i].Set(err)
 	}
 
+	if err != nil {
+		return fmt.Errorf("failed to write to segment: %w", err)
+	}
+
+	logger.Debug("flush segments")
 	return nil
 }
 
-// Recover - recovers the state from the WAL.
-func (w *WAL) Recover(applyFunc func(ctx context.Context, entry []LogEntry) error) (int64, error) {
+// writeWithRetries - writes the batch to the segment retrying the failed writes.
+// The entries are not acknowledged, the caller passes the result of the last attempt to the waiters.
+func (w *WAL) writeWithRetries(batch []WriteEntry) error {
+	for attempt := 0; ; attempt++ {
+		err := w.write(batch, true)
+		if err == nil || attempt >= w.writeRetries {
+			return err
+		}
+
+		logger.Warn("failed to write batch, retrying",
+			zap.Int("attempt", attempt+1), zap.Int("retries", w.writeRetries), zap.Error(err))
+		time.Sleep(w.writeRetryDelay)
+	}
+}
+
+// flushOrder - passes the turn to write the batches in the order of their sequence numbers.
+type flushOrder struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	next uint64
+}
+
+// wait - blocks until it's the turn of the batch with the sequence number.
+func (o *flushOrder) wait(seq uint64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for o.next != seq {
+		o.cond.Wait()
+	}
+}
+
+// waitWritten - blocks until the batches with the sequence numbers below the one are written.
+func (o *flushOrder) waitWritten(seq uint64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for o.next < seq {
+		o.cond.Wait()
+	}
+}
+
+// done - passes the turn to the next batch.
+func (o *flushOrder) done() {
+	o.mu.Lock()
+	o.next++
+	o.mu.Unlock()
+
+	o.cond.Broadcast()
+}
+
+// write - writes the batch to the segment and records the write latency.
+func (w *WAL) write(batch []WriteEntry, nolock bool) error {
+	start := time.Now()
+	defer func() { w.latency.observe(time.Since(start)) }()
+
+	return w.segmentManager.Write(batch, nolock)
+}
+
+// Latency - returns the segment write latency percentiles.
+func (w *WAL) Latency() LatencyStats {
+	if w == nil {
+		return LatencyStats{}
+	}
+
+	return w.latency.stats()
+}
+
+// WriteErrors - returns the number of batches that failed to be written.
+func (w *WAL) WriteErrors() int64 {
+	if w == nil {
+		return 0
+	}
+
+	return w.writeErrors.Load()
+}
+
+// Recover - recovers the state from the WAL, the recovery is aborted when the context is done.
+func (w *WAL) Recover(ctx context.Context, applyFunc func(ctx context.Context, entry []LogEntry) error) (int64, error) {
 	if w == nil || applyFunc == nil {
 		return 0, nil
 	}
 
-	var lastLSN int64
-	logger.Debug("start recovering segments")
-	err := w.segmentManager.ForEach(
+	var progress RecoveryProgress
+	if counter, ok := w.segmentManager.(segmentCounter); ok {
+		progress.SegmentsTotal = counter.SegmentsCount()
+	}
+
+	var (
+		lastLSN    int64
+		lastReport = time.Now()
+	)
+	logger.Debug("start recovering segments", zap.Int("segments_total", progress.SegmentsTotal))
+	// A broken last entry of a segment is a torn write, it's tolerated only in the last segment,
+	// so the segment following the torn one means the log is corrupted.
+	var truncated error
+	err := w.segmentManager.ForEach(ctx,
 		func(ctx context.Context, b []byte) error {
+			if truncated != nil {
+				return fmt.Errorf("truncated entry in a segment before the last one: %w", truncated)
+			}
+
 			var entries []LogEntry
 
 			buffer := bytes.NewBuffer(b)
 			for buffer.Len() > 0 {
 				var entry LogEntry
-				if err := entry.Decode(buffer); err != nil && err != io.EOF {
-					return fmt.Errorf("error gob decoding: %w", err)
+				if err := entry.Decode(buffer); err != nil {
+					if errors.Is(err, io.ErrUnexpectedEOF) ||
+						(errors.Is(err, ErrChecksumMismatch) && buffer.Len() == 0) {
+						logger.Warn("truncated wal entry, stop replay", zap.Error(err),
+							zap.Int("entries_decoded", len(entries)))
+						truncated = err
+						break
+					}
+
+					return fmt.Errorf("error decoding entry: %w", err)
 				}
 				entries = append(entries, entry)
 			}
@@ -177,22 +708,49 @@ func (w *WAL) Recover(applyFunc func(ctx context.Context, entry []LogEntry) erro
 				return entries[i].LSN < entries[j].LSN
 			})
 
-			if err := applyFunc(ctx, entries); err != nil {
-				return fmt.Errorf("failed to epply entries: %w", err)
-			}
-
 			if len(entries) > 0 {
+				if err := applyFunc(ctx, entries); err != nil {
+					return fmt.Errorf("failed to epply entries: %w", err)
+				}
+
 				lastLSN = entries[len(entries)-1].LSN
 			}
+
+			progress.SegmentsProcessed++
+			progress.EntriesApplied += len(entries)
+			if w.recoveryProgressInterval > 0 && time.Since(lastReport) >= w.recoveryProgressInterval {
+				w.reportProgress(progress)
+				lastReport = time.Now()
+			}
+
 			return nil
 		})
 	if err != nil {
 		return 0, fmt.Errorf("execute action for recover failed: %w", err)
 	}
 
+	logger.Info("wal recovery completed",
+		zap.Int("segments_processed", progress.SegmentsProcessed),
+		zap.Int("entries_applied", progress.EntriesApplied),
+		zap.Int64("last_lsn", lastLSN),
+	)
+
 	return lastLSN, nil
 }
 
+// reportProgress - logs the recovery progress and passes it to the progress handler if it's set.
+func (w *WAL) reportProgress(progress RecoveryProgress) {
+	logger.Info("wal recovery progress",
+		zap.Int("segments_processed", progress.SegmentsProcessed),
+		zap.Int("segments_total", progress.SegmentsTotal),
+		zap.Int("entries_applied", progress.EntriesApplied),
+	)
+
+	if w.progressHandler != nil {
+		w.progressHandler(progress)
+	}
+}
+
 // Close - closes the WAL.
 func (w *WAL) Close() error {
 	if w == nil {
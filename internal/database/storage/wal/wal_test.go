@@ -4,19 +4,32 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/neekrasov/kvdb/internal/database/compute"
 	"github.com/neekrasov/kvdb/internal/database/storage/wal"
+	"github.com/neekrasov/kvdb/internal/database/storage/wal/filesystem"
+	"github.com/neekrasov/kvdb/internal/database/storage/wal/segment"
 	mocks "github.com/neekrasov/kvdb/internal/mocks/wal"
 	"github.com/neekrasov/kvdb/pkg/logger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 	"golang.org/x/sync/errgroup"
 )
 
+// The generated mock must follow the interface, regenerate it with mockery after changing SegmentManager.
+var _ wal.SegmentManager = (*mocks.SegmentManager)(nil)
+
 func TestWAL_SetAndDel(t *testing.T) {
 	t.Parallel()
 	logger.MockLogger()
@@ -43,14 +56,7 @@ func TestWAL_SetAndDel(t *testing.T) {
 		{
 			name: "Success - Set key-value ticker flush",
 			prepareMocks: func(mockSegmentManager *mocks.SegmentManager) {
-				mockSegmentManager.On("Write", mock.Anything, false).Run(
-					func(args mock.Arguments) {
-						entries := args.Get(0).([]wal.WriteEntry)
-						for _, entry := range entries {
-							entry.Set(nil)
-						}
-					},
-				).Return(nil).Once()
+				mockSegmentManager.On("Write", mock.Anything, true).Return(nil).Once()
 			},
 			operation: func(ctx context.Context, w *wal.WAL) error {
 				return w.Set(ctx, "key1", "value1")
@@ -61,13 +67,7 @@ func TestWAL_SetAndDel(t *testing.T) {
 		{
 			name: "Success - Set key-value flush batches",
 			prepareMocks: func(mockSegmentManager *mocks.SegmentManager) {
-				mockSegmentManager.On("Write", mock.Anything, false).Run(
-					func(args mock.Arguments) {
-						entries := args.Get(0).([]wal.WriteEntry)
-						for _, entry := range entries {
-							entry.Set(nil)
-						}
-					}).Return(nil).Once()
+				mockSegmentManager.On("Write", mock.Anything, true).Return(nil).Once()
 			},
 			operation: func(ctx context.Context, w *wal.WAL) error {
 				wg := errgroup.Group{}
@@ -85,14 +85,7 @@ func TestWAL_SetAndDel(t *testing.T) {
 		{
 			name: "Success - Delete key",
 			prepareMocks: func(mockSegmentManager *mocks.SegmentManager) {
-				mockSegmentManager.On("Write", mock.Anything, false).Run(
-					func(args mock.Arguments) {
-						entries := args.Get(0).([]wal.WriteEntry)
-						for _, entry := range entries {
-							entry.Set(nil)
-						}
-					},
-				).Return(nil).Once()
+				mockSegmentManager.On("Write", mock.Anything, true).Return(nil).Once()
 			},
 			operation: func(ctx context.Context, w *wal.WAL) error {
 				return w.Del(ctx, "key1")
@@ -103,19 +96,15 @@ func TestWAL_SetAndDel(t *testing.T) {
 		{
 			name: "Error - Write failed",
 			prepareMocks: func(mockSegmentManager *mocks.SegmentManager) {
-				mockSegmentManager.On("Write", mock.Anything, false).Run(
+				mockSegmentManager.On("Write", mock.Anything, true).Run(
 					func(args mock.Arguments) {
 						time.Sleep(time.Millisecond * 5)
-						entries := args.Get(0).([]wal.WriteEntry)
-						for _, entry := range entries {
-							entry.Set(nil)
-						}
 					}).Return(errors.New("write error"))
 			},
 			operation: func(ctx context.Context, w *wal.WAL) error {
 				return w.Set(ctx, "key1", "value1")
 			},
-			expectError: false,
+			expectError: true,
 			timeout:     time.Millisecond,
 		},
 	}
@@ -142,6 +131,54 @@ func TestWAL_SetAndDel(t *testing.T) {
 	}
 }
 
+func TestWAL_WriteErrors(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	writeErr := errors.New("write error")
+
+	t.Run("Handler fires after retries are exhausted", func(t *testing.T) {
+		mockSegmentManager := mocks.NewSegmentManager(t)
+		mockSegmentManager.On("Write", mock.Anything, true).Return(writeErr).Times(3)
+
+		handled := make(chan error, 1)
+		w := wal.NewWAL(mockSegmentManager, 1, time.Hour,
+			wal.WithWriteRetries(2, time.Millisecond),
+			wal.WithWALErrorHandler(func(err error) { handled <- err }),
+		)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		w.Start(ctx)
+
+		assert.ErrorIs(t, w.Set(ctx, "key", "value"), writeErr)
+
+		select {
+		case err := <-handled:
+			assert.ErrorIs(t, err, writeErr)
+		case <-time.After(time.Second):
+			t.Fatal("error handler was not called")
+		}
+		assert.Equal(t, int64(1), w.WriteErrors())
+	})
+
+	t.Run("Retry succeeds", func(t *testing.T) {
+		mockSegmentManager := mocks.NewSegmentManager(t)
+		mockSegmentManager.On("Write", mock.Anything, true).Return(writeErr).Once()
+		mockSegmentManager.On("Write", mock.Anything, true).Return(nil).Once()
+
+		w := wal.NewWAL(mockSegmentManager, 1, time.Hour,
+			wal.WithWriteRetries(3, time.Millisecond),
+			wal.WithWALErrorHandler(func(err error) { t.Errorf("unexpected handler call: %v", err) }),
+		)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		w.Start(ctx)
+
+		assert.NoError(t, w.Set(ctx, "key", "value"))
+		assert.Equal(t, int64(0), w.WriteErrors())
+	})
+}
+
 func TestWAL_Recover(t *testing.T) {
 	t.Parallel()
 	logger.MockLogger()
@@ -155,8 +192,8 @@ func TestWAL_Recover(t *testing.T) {
 		{
 			name: "Error - error decoding log entry",
 			prepareMocks: func(mockSegmentManager *mocks.SegmentManager) {
-				mockSegmentManager.On("ForEach", mock.Anything).Run(func(args mock.Arguments) {
-					action := args.Get(0).(func(context.Context, []byte) error)
+				mockSegmentManager.On("ForEach", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+					action := args.Get(1).(func(context.Context, []byte) error)
 					_ = action(context.Background(), []byte("invalid data"))
 				}).Return(nil).Once()
 			},
@@ -170,7 +207,7 @@ func TestWAL_Recover(t *testing.T) {
 		{
 			name: "Error - ForEach failed",
 			prepareMocks: func(mockSegmentManager *mocks.SegmentManager) {
-				mockSegmentManager.On("ForEach", mock.Anything).Return(errors.New("foreach error")).Once()
+				mockSegmentManager.On("ForEach", mock.Anything, mock.Anything).Return(errors.New("foreach error")).Once()
 			},
 			applyFunc: func(ctx context.Context, entry []wal.LogEntry) error {
 				return nil
@@ -187,8 +224,8 @@ func TestWAL_Recover(t *testing.T) {
 		{
 			name: "Error - applyFunc returns error",
 			prepareMocks: func(mockSegmentManager *mocks.SegmentManager) {
-				mockSegmentManager.On("ForEach", mock.Anything).Run(func(args mock.Arguments) {
-					action := args.Get(0).(func(context.Context, []byte) error)
+				mockSegmentManager.On("ForEach", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+					action := args.Get(1).(func(context.Context, []byte) error)
 					entry := wal.LogEntry{
 						Operation: compute.SetCommandID,
 						Args:      []string{"key1", "value1"},
@@ -208,8 +245,8 @@ func TestWAL_Recover(t *testing.T) {
 		{
 			name: "Success - applyFunc returns nil",
 			prepareMocks: func(mockSegmentManager *mocks.SegmentManager) {
-				mockSegmentManager.On("ForEach", mock.Anything).Run(func(args mock.Arguments) {
-					action := args.Get(0).(func(context.Context, []byte) error)
+				mockSegmentManager.On("ForEach", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+					action := args.Get(1).(func(context.Context, []byte) error)
 					entry := wal.LogEntry{
 						Operation: compute.SetCommandID,
 						Args:      []string{"key1", "value1"},
@@ -235,7 +272,7 @@ func TestWAL_Recover(t *testing.T) {
 
 			w := wal.NewWAL(mockSegmentManager, 1, time.Second)
 
-			_, err := w.Recover(tt.applyFunc)
+			_, err := w.Recover(context.Background(), tt.applyFunc)
 			if tt.expectError {
 				assert.Error(t, err)
 			} else {
@@ -247,6 +284,530 @@ func TestWAL_Recover(t *testing.T) {
 	}
 }
 
+func TestWAL_RecoverCancel(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	storage, err := segment.NewFileSegmentStorage(new(filesystem.LocalFileSystem), t.TempDir())
+	require.NoError(t, err)
+
+	manager, err := wal.NewFileSegmentManager(storage, wal.WithMaxSegmentSize(256))
+	require.NoError(t, err)
+	writeCompactionLog(t, manager)
+	require.NoError(t, manager.Close())
+
+	manager, err = wal.NewFileSegmentManager(storage)
+	require.NoError(t, err)
+	require.Greater(t, manager.SegmentsCount(), 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var applied int
+	_, err = wal.NewWAL(manager, 1, time.Second).Recover(ctx, func(_ context.Context, _ []wal.LogEntry) error {
+		applied++
+		cancel()
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, applied)
+}
+
+func TestWAL_RecoverProgress(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger.Init(core)
+	defer logger.MockLogger()
+
+	const segmentsNum = 3
+	mockSegmentManager := mocks.NewSegmentManager(t)
+	mockSegmentManager.On("ForEach", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		action := args.Get(1).(func(context.Context, []byte) error)
+		for i := range segmentsNum {
+			entry := wal.LogEntry{
+				LSN:       int64(i + 1),
+				Operation: compute.SetCommandID,
+				Args:      []string{"key", "value"},
+			}
+
+			var buffer bytes.Buffer
+			require.NoError(t, entry.Encode(&buffer))
+			require.NoError(t, action(context.Background(), buffer.Bytes()))
+		}
+	}).Return(nil).Once()
+
+	var reported []wal.RecoveryProgress
+	w := wal.NewWAL(mockSegmentManager, 1, time.Second,
+		wal.WithRecoveryProgressInterval(time.Nanosecond),
+		wal.WithRecoveryProgressHandler(func(p wal.RecoveryProgress) {
+			reported = append(reported, p)
+		}))
+
+	lastLSN, err := w.Recover(context.Background(), func(ctx context.Context, entry []wal.LogEntry) error {
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(segmentsNum), lastLSN)
+
+	// The handler gets the same progress as the log.
+	progress := logs.FilterMessage("wal recovery progress").All()
+	require.Len(t, progress, segmentsNum)
+	require.Len(t, reported, segmentsNum)
+	for i, entry := range progress {
+		fields := entry.ContextMap()
+		assert.Equal(t, int64(i+1), fields["segments_processed"])
+		assert.Equal(t, int64(i+1), fields["entries_applied"])
+		assert.Equal(t, wal.RecoveryProgress{SegmentsProcessed: i + 1, EntriesApplied: i + 1}, reported[i])
+	}
+	assert.Equal(t, 1, logs.FilterMessage("wal recovery completed").Len())
+}
+
+func TestWAL_ConcurrentPush(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	mockSegmentManager := mocks.NewSegmentManager(t)
+	mockSegmentManager.On("Write", mock.Anything, true).Return(nil)
+
+	// The tiny flush timeout makes the ticker flush race with the full batch signals.
+	w := wal.NewWAL(mockSegmentManager, 2, time.Microsecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w.Start(ctx)
+
+	wg := errgroup.Group{}
+	for i := range 256 {
+		wg.Go(func() error {
+			return w.Set(ctx, fmt.Sprintf("key%d", i), "value")
+		})
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- wg.Wait() }()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("concurrent pushes are blocked")
+	}
+}
+
+func TestWAL_FlushWorkers(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	var (
+		mu       sync.Mutex
+		lastLSN  int64 = -1
+		written  int
+		reorders int
+	)
+	mockSegmentManager := mocks.NewSegmentManager(t)
+	mockSegmentManager.On("Write", mock.Anything, true).Run(
+		func(args mock.Arguments) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			for _, entry := range args.Get(0).([]wal.WriteEntry) {
+				if entry.Log().LSN < lastLSN {
+					reorders++
+				}
+				lastLSN = entry.Log().LSN
+				written++
+			}
+		}).Return(nil)
+
+	w := wal.NewWAL(mockSegmentManager, 4, time.Microsecond, wal.WithFlushWorkers(4))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w.Start(ctx)
+
+	wg := errgroup.Group{}
+	for i := range 256 {
+		wg.Go(func() error {
+			return w.Set(ctx, fmt.Sprintf("key%d", i), "value")
+		})
+	}
+	require.NoError(t, wg.Wait())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 256, written)
+	assert.Zero(t, reorders, "batches are written out of order")
+}
+
+func TestWAL_MaxBatchBytes(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	tests := []struct {
+		name      string
+		value     string
+		pushes    int
+		batchSize int
+	}{
+		{name: "Small entries flush by count", value: "value", pushes: 4, batchSize: 4},
+		{name: "Large entries flush by bytes", value: strings.Repeat("v", 1024), pushes: 2, batchSize: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSegmentManager := mocks.NewSegmentManager(t)
+			mockSegmentManager.On("Write", mock.Anything, true).Run(
+				func(args mock.Arguments) {
+					assert.Len(t, args.Get(0).([]wal.WriteEntry), tt.batchSize)
+				}).Return(nil)
+
+			// The flush timeout is never reached, the batches are flushed only when they are full.
+			w := wal.NewWAL(mockSegmentManager, 4, time.Hour, wal.WithMaxBatchBytes(512))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			w.Start(ctx)
+
+			wg := errgroup.Group{}
+			for i := range tt.pushes {
+				wg.Go(func() error {
+					return w.Set(ctx, fmt.Sprintf("key%d", i), tt.value)
+				})
+			}
+
+			done := make(chan error, 1)
+			go func() { done <- wg.Wait() }()
+
+			select {
+			case err := <-done:
+				require.NoError(t, err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("batch was not flushed")
+			}
+			mockSegmentManager.AssertNumberOfCalls(t, "Write", tt.pushes/tt.batchSize)
+		})
+	}
+}
+
+// BenchmarkWAL_FlushWorkers - compares the throughput of the flush workers numbers on a real disk.
+func BenchmarkWAL_FlushWorkers(b *testing.B) {
+	logger.MockLogger()
+
+	for _, workers := range []int{1, 4} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			storage, err := segment.NewFileSegmentStorage(new(filesystem.LocalFileSystem), b.TempDir())
+			require.NoError(b, err)
+
+			manager, err := wal.NewFileSegmentManager(storage,
+				wal.WithSyncMode(wal.SyncAlways),
+				wal.WithMaxSegmentSize(1<<20),
+			)
+			require.NoError(b, err)
+
+			w := wal.NewWAL(manager, 64, time.Millisecond, wal.WithFlushWorkers(workers))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			w.Start(ctx)
+			defer w.Close()
+
+			b.SetParallelism(16)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if err := w.Set(ctx, "key", "value"); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestWAL_Latency(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	mockSegmentManager := mocks.NewSegmentManager(t)
+	mockSegmentManager.On("Write", mock.Anything, true).Run(
+		func(args mock.Arguments) {
+			time.Sleep(time.Millisecond)
+		}).Return(nil)
+
+	w := wal.NewWAL(mockSegmentManager, 4, time.Millisecond)
+	assert.Equal(t, wal.LatencyStats{}, w.Latency())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w.Start(ctx)
+
+	wg := errgroup.Group{}
+	for i := range 64 {
+		wg.Go(func() error {
+			return w.Set(ctx, fmt.Sprintf("key%d", i), "value")
+		})
+	}
+	require.NoError(t, wg.Wait())
+
+	latency := w.Latency()
+	assert.Positive(t, latency.Count)
+	assert.GreaterOrEqual(t, latency.P50, time.Millisecond)
+	assert.LessOrEqual(t, latency.P50, latency.P95)
+	assert.LessOrEqual(t, latency.P95, latency.P99)
+	assert.LessOrEqual(t, latency.P99, latency.Max)
+}
+
+func TestWAL_Compact(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	t.Run("Compaction after overwrites", func(t *testing.T) {
+		storage, err := segment.NewFileSegmentStorage(new(filesystem.LocalFileSystem), t.TempDir())
+		require.NoError(t, err)
+
+		manager, err := wal.NewFileSegmentManager(storage, wal.WithMaxSegmentSize(256))
+		require.NoError(t, err)
+		writeCompactionLog(t, manager)
+
+		w := wal.NewWAL(manager, 1, time.Second, wal.WithCompaction(time.Hour, 0))
+		assert.Equal(t, wal.CompactionStats{}, w.Compactions())
+
+		before := manager.SegmentsCount()
+		result, err := w.Compact()
+		require.NoError(t, err)
+		assert.Less(t, manager.SegmentsCount(), before)
+		assert.Equal(t, before, result.SegmentsRemoved)
+		assert.Positive(t, result.BytesReclaimed)
+
+		stats := w.Compactions()
+		assert.Equal(t, int64(1), stats.Total)
+		assert.WithinDuration(t, time.Now(), stats.Last, time.Minute)
+		require.NoError(t, w.Close())
+	})
+
+	t.Run("Compaction is not configured", func(t *testing.T) {
+		mockSegmentManager := mocks.NewSegmentManager(t)
+		w := wal.NewWAL(mockSegmentManager, 1, time.Second)
+
+		_, err := w.Compact()
+		require.ErrorIs(t, err, wal.ErrCompactionDisabled)
+		assert.Equal(t, wal.CompactionStats{}, w.Compactions())
+	})
+
+	t.Run("Segment manager does not support compaction", func(t *testing.T) {
+		mockSegmentManager := mocks.NewSegmentManager(t)
+		w := wal.NewWAL(mockSegmentManager, 1, time.Second, wal.WithCompaction(time.Hour, 0))
+
+		_, err := w.Compact()
+		require.ErrorIs(t, err, wal.ErrCompactionDisabled)
+	})
+
+	t.Run("Nil WAL", func(t *testing.T) {
+		var w *wal.WAL
+
+		_, err := w.Compact()
+		require.ErrorIs(t, err, wal.ErrCompactionDisabled)
+		assert.Equal(t, wal.CompactionStats{}, w.Compactions())
+	})
+}
+
+// syncingSegmentManager - segment manager recording the fsync of the written entries.
+type syncingSegmentManager struct {
+	*mocks.SegmentManager
+
+	mu      sync.Mutex
+	written int
+	synced  int // Number of the written entries when the segment was synced.
+}
+
+func (m *syncingSegmentManager) Sync() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.synced = m.written
+	return nil
+}
+
+func TestWAL_Sync(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	manager := &syncingSegmentManager{SegmentManager: mocks.NewSegmentManager(t)}
+	manager.On("Write", mock.Anything, true).Run(func(args mock.Arguments) {
+		time.Sleep(50 * time.Millisecond)
+
+		manager.mu.Lock()
+		defer manager.mu.Unlock()
+		manager.written += len(args.Get(0).([]wal.WriteEntry))
+	}).Return(nil).Once()
+
+	// The flush workers are not started, so the batch is pending until the sync.
+	w := wal.NewWAL(manager, 100, time.Hour)
+
+	ctx := context.Background()
+	pushed := make(chan error, 2)
+	go func() { pushed <- w.Set(ctx, "key", "value") }()
+	go func() { pushed <- w.Del(ctx, "other") }()
+
+	// The entries join the pending batch and wait for the acknowledgement.
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, pushed)
+
+	require.NoError(t, w.Sync())
+
+	manager.mu.Lock()
+	assert.Equal(t, 2, manager.written)
+	assert.Equal(t, 2, manager.synced)
+	manager.mu.Unlock()
+
+	for range 2 {
+		require.NoError(t, <-pushed)
+	}
+
+	// Without the pending batch only the segment is synced.
+	require.NoError(t, w.Sync())
+
+	// The mock segment manager can't sync the segments.
+	assert.ErrorIs(t, wal.NewWAL(mocks.NewSegmentManager(t), 1, time.Hour).Sync(), wal.ErrSyncUnavailable)
+
+	var disabled *wal.WAL
+	assert.ErrorIs(t, disabled.Sync(), wal.ErrSyncUnavailable)
+}
+
+func TestWAL_Stop(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	manager := &syncingSegmentManager{SegmentManager: mocks.NewSegmentManager(t)}
+	manager.On("Write", mock.Anything, true).Run(func(args mock.Arguments) {
+		manager.mu.Lock()
+		defer manager.mu.Unlock()
+		manager.written += len(args.Get(0).([]wal.WriteEntry))
+	}).Return(nil).Once()
+
+	// Neither the batch size nor the flush timeout is reached, so only the stop flushes the batch.
+	w := wal.NewWAL(manager, 100, time.Hour, wal.WithFlushWorkers(2))
+	ctx := context.Background()
+	w.Start(ctx)
+
+	pushed := make(chan error, 2)
+	go func() { pushed <- w.Set(ctx, "key", "value") }()
+	go func() { pushed <- w.Del(ctx, "other") }()
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, pushed)
+
+	require.NoError(t, w.Stop(ctx))
+
+	manager.mu.Lock()
+	assert.Equal(t, 2, manager.written)
+	assert.Equal(t, 2, manager.synced, "the final batch is committed before the stop returns")
+	manager.mu.Unlock()
+
+	for range 2 {
+		require.NoError(t, <-pushed)
+	}
+
+	assert.ErrorIs(t, w.Set(ctx, "late", "value"), wal.ErrWALStopped)
+	require.NoError(t, w.Stop(ctx), "the stop is idempotent")
+
+	var disabled *wal.WAL
+	assert.NoError(t, disabled.Stop(ctx))
+}
+
+// sizedSegmentManager - segment manager with a fixed total size, the compaction shrinks it to the compacted size.
+type sizedSegmentManager struct {
+	*mocks.SegmentManager
+
+	size      atomic.Int64
+	compacted int64
+}
+
+func (m *sizedSegmentManager) TotalSize() int64 { return m.size.Load() }
+
+func (m *sizedSegmentManager) SegmentsCount() int { return 1 }
+
+func (m *sizedSegmentManager) Compact() (wal.CompactionResult, error) {
+	reclaimed := m.size.Swap(m.compacted) - m.compacted
+	return wal.CompactionResult{BytesReclaimed: reclaimed, SegmentsRemoved: 1}, nil
+}
+
+func TestWAL_MaxTotalSize(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	t.Run("Reject", func(t *testing.T) {
+		manager := &sizedSegmentManager{SegmentManager: mocks.NewSegmentManager(t), compacted: 10}
+		manager.size.Store(100)
+		manager.On("Write", mock.Anything, true).Return(nil).Once()
+
+		w := wal.NewWAL(manager, 1, time.Hour,
+			wal.WithCompaction(time.Hour, 0), wal.WithMaxTotalSize(100, wal.FullPolicyReject))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		w.Start(ctx)
+
+		require.ErrorIs(t, w.Set(ctx, "key", "value"), wal.ErrWALFull)
+		require.ErrorIs(t, w.Del(ctx, "key"), wal.ErrWALFull)
+
+		_, err := w.Compact()
+		require.NoError(t, err)
+		require.NoError(t, w.Set(ctx, "key", "value"))
+	})
+
+	t.Run("Backpressure", func(t *testing.T) {
+		manager := &sizedSegmentManager{SegmentManager: mocks.NewSegmentManager(t), compacted: 10}
+		manager.size.Store(100)
+		manager.On("Write", mock.Anything, true).Return(nil).Once()
+
+		w := wal.NewWAL(manager, 1, time.Hour,
+			wal.WithCompaction(time.Hour, 0), wal.WithMaxTotalSize(100, wal.FullPolicyBackpressure))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		w.Start(ctx)
+
+		pushed := make(chan error, 1)
+		go func() { pushed <- w.Set(ctx, "key", "value") }()
+
+		// The write waits for the space instead of failing.
+		time.Sleep(20 * time.Millisecond)
+		assert.Empty(t, pushed)
+
+		_, err := w.Compact()
+		require.NoError(t, err)
+		select {
+		case err := <-pushed:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			require.FailNow(t, "the write is not released after the compaction")
+		}
+	})
+
+	t.Run("Backpressure until the context is done", func(t *testing.T) {
+		manager := &sizedSegmentManager{SegmentManager: mocks.NewSegmentManager(t)}
+		manager.size.Store(100)
+
+		w := wal.NewWAL(manager, 1, time.Hour, wal.WithMaxTotalSize(100, wal.FullPolicyBackpressure))
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		err := w.Set(ctx, "key", "value")
+		require.ErrorIs(t, err, wal.ErrWALFull)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Below the limit", func(t *testing.T) {
+		manager := &sizedSegmentManager{SegmentManager: mocks.NewSegmentManager(t)}
+		manager.size.Store(99)
+		manager.On("Write", mock.Anything, true).Return(nil).Once()
+
+		w := wal.NewWAL(manager, 1, time.Hour, wal.WithMaxTotalSize(100, wal.FullPolicyReject))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		w.Start(ctx)
+
+		require.NoError(t, w.Set(ctx, "key", "value"))
+	})
+}
+
 func TestWAL_Close(t *testing.T) {
 	t.Parallel()
 	logger.MockLogger()
@@ -261,3 +822,135 @@ func TestWAL_Close(t *testing.T) {
 	err = w.Close()
 	require.NoError(t, err)
 }
+
+func TestWAL_RecoverCorruptedEntries(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	encode := func(lsn int64, key string) []byte {
+		var buffer bytes.Buffer
+		entry := wal.LogEntry{LSN: lsn, Operation: compute.SetCommandID, Args: []string{key, "value"}}
+		require.NoError(t, entry.Encode(&buffer))
+		return buffer.Bytes()
+	}
+
+	corrupt := func(data []byte) []byte {
+		data = bytes.Clone(data)
+		data[len(data)-2] ^= 0xFF
+		return data
+	}
+
+	recoverSegments := func(segments ...[]byte) ([]string, int64, error) {
+		mockSegmentManager := mocks.NewSegmentManager(t)
+		mockSegmentManager.On("ForEach", mock.Anything, mock.Anything).Return(func(_ context.Context, action func(context.Context, []byte) error) error {
+			for _, segment := range segments {
+				if err := action(context.Background(), segment); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Once()
+
+		var keys []string
+		lsn, err := wal.NewWAL(mockSegmentManager, 1, time.Second).Recover(context.Background(),
+			func(_ context.Context, entries []wal.LogEntry) error {
+				for _, entry := range entries {
+					keys = append(keys, entry.Args[0])
+				}
+				return nil
+			})
+
+		return keys, lsn, err
+	}
+
+	t.Run("Corrupted tail of the last segment stops replay", func(t *testing.T) {
+		keys, lsn, err := recoverSegments(
+			encode(1, "a"),
+			append(encode(2, "b"), corrupt(encode(3, "c"))...),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, keys)
+		assert.Equal(t, int64(2), lsn)
+	})
+
+	t.Run("Corrupted tail of an earlier segment", func(t *testing.T) {
+		_, _, err := recoverSegments(
+			append(encode(1, "a"), corrupt(encode(2, "b"))...),
+			encode(3, "c"),
+		)
+		assert.ErrorIs(t, err, wal.ErrChecksumMismatch)
+	})
+
+	t.Run("Truncated tail stops replay", func(t *testing.T) {
+		tail := encode(2, "b")
+		keys, _, err := recoverSegments(append(encode(1, "a"), tail[:len(tail)-4]...))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a"}, keys)
+	})
+
+	t.Run("Corrupted entry in the middle", func(t *testing.T) {
+		segment := append(corrupt(encode(1, "a")), encode(2, "b")...)
+		_, _, err := recoverSegments(segment)
+		assert.ErrorIs(t, err, wal.ErrChecksumMismatch)
+	})
+}
+
+func TestWAL_RecoverTruncatedSegment(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	dir := t.TempDir()
+	storage, err := segment.NewFileSegmentStorage(new(filesystem.LocalFileSystem), dir)
+	require.NoError(t, err)
+
+	manager, err := wal.NewFileSegmentManager(storage, wal.WithMaxSegmentSize(1<<20))
+	require.NoError(t, err)
+	for i := 1; i <= 3; i++ {
+		entry := wal.NewWriteEntry(int64(i), compute.SetCommandID, []string{fmt.Sprintf("key_%d", i), "value"})
+		require.NoError(t, manager.Write([]wal.WriteEntry{entry}, true))
+	}
+	require.NoError(t, manager.Close())
+
+	// A torn write leaves only a part of the next entry at the end of the segment.
+	var tail bytes.Buffer
+	entry := wal.LogEntry{LSN: 4, Operation: compute.SetCommandID, Args: []string{"key_4", "value"}}
+	require.NoError(t, entry.Encode(&tail))
+	file, err := os.OpenFile(filepath.Join(dir, "segment_1.wal"), os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = file.Write(tail.Bytes()[:tail.Len()/2])
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	recoverKeys := func() ([]string, int64) {
+		reopened, err := wal.NewFileSegmentManager(storage, wal.WithMaxSegmentSize(1<<20))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = reopened.Close() })
+
+		var keys []string
+		lsn, err := wal.NewWAL(reopened, 1, time.Second).Recover(context.Background(),
+			func(_ context.Context, entries []wal.LogEntry) error {
+				for _, entry := range entries {
+					keys = append(keys, entry.Args[0])
+				}
+				return nil
+			})
+		require.NoError(t, err)
+
+		return keys, lsn
+	}
+
+	keys, lsn := recoverKeys()
+	assert.Equal(t, []string{"key_1", "key_2", "key_3"}, keys)
+	assert.Equal(t, int64(3), lsn)
+
+	// The writes after the restart drop the torn entry, so they are recovered along with the valid prefix.
+	restarted, err := wal.NewFileSegmentManager(storage, wal.WithMaxSegmentSize(1<<20))
+	require.NoError(t, err)
+	next := wal.NewWriteEntry(4, compute.SetCommandID, []string{"key_5", "value"})
+	require.NoError(t, restarted.Write([]wal.WriteEntry{next}, true))
+	require.NoError(t, restarted.Close())
+
+	keys, lsn = recoverKeys()
+	assert.Equal(t, []string{"key_1", "key_2", "key_3", "key_5"}, keys)
+	assert.Equal(t, int64(4), lsn)
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken - is returned when the login token is malformed, forged or expired.
+var ErrInvalidToken = errors.New("invalid token")
+
+const (
+	defaultTokenTTL     = 10 * time.Minute
+	tokenSecretSize     = 32
+	tokenPartsSeparator = "."
+)
+
+var tokenEncoding = base64.RawURLEncoding
+
+// tokenSigner - issues and verifies short-lived session tokens signed with HMAC-SHA256.
+// A token is "<username>.<expires_at>.<signature>", the username and the signature are base64url encoded.
+type tokenSigner struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// newTokenSigner - creates a token signer with a random secret,
+// so the tokens are not valid after the server restart.
+func newTokenSigner() *tokenSigner {
+	secret := make([]byte, tokenSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		panic("failed to generate token secret: " + err.Error())
+	}
+
+	return &tokenSigner{secret: secret, ttl: defaultTokenTTL}
+}
+
+// issue - returns a token of the user valid until now + ttl.
+func (s *tokenSigner) issue(username string, now time.Time) string {
+	payload := tokenEncoding.EncodeToString([]byte(username)) +
+		tokenPartsSeparator + strconv.FormatInt(now.Add(s.ttl).Unix(), 10)
+
+	return payload + tokenPartsSeparator + tokenEncoding.EncodeToString(s.sign(payload))
+}
+
+// verify - checks the token signature and expiration and returns the username.
+func (s *tokenSigner) verify(token string, now time.Time) (string, error) {
+	parts := strings.Split(token, tokenPartsSeparator)
+	if len(parts) != 3 {
+		return "", ErrInvalidToken
+	}
+
+	signature, err := tokenEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+
+	payload := parts[0] + tokenPartsSeparator + parts[1]
+	if !hmac.Equal(signature, s.sign(payload)) {
+		return "", ErrInvalidToken
+	}
+
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || now.Unix() >= expiresAt {
+		return "", ErrInvalidToken
+	}
+
+	username, err := tokenEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+
+	return string(username), nil
+}
+
+// sign - returns the HMAC-SHA256 of the payload.
+func (s *tokenSigner) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+
+	return mac.Sum(nil)
+}
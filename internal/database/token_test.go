@@ -0,0 +1,76 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenSigner(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	signer := newTokenSigner()
+	token := signer.issue("user.name", now)
+
+	tests := []struct {
+		name         string
+		signer       *tokenSigner
+		token        string
+		now          time.Time
+		expectedUser string
+		expectedErr  error
+	}{
+		{
+			name:         "valid token",
+			signer:       signer,
+			token:        token,
+			now:          now,
+			expectedUser: "user.name",
+		},
+		{
+			name:        "expired token",
+			signer:      signer,
+			token:       token,
+			now:         now.Add(defaultTokenTTL),
+			expectedErr: ErrInvalidToken,
+		},
+		{
+			name:        "token signed by another server",
+			signer:      newTokenSigner(),
+			token:       token,
+			now:         now,
+			expectedErr: ErrInvalidToken,
+		},
+		{
+			name:        "forged username",
+			signer:      signer,
+			token:       tokenEncoding.EncodeToString([]byte("admin")) + token[strings.Index(token, "."):],
+			now:         now,
+			expectedErr: ErrInvalidToken,
+		},
+		{
+			name:        "malformed token",
+			signer:      signer,
+			token:       "token",
+			now:         now,
+			expectedErr: ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			username, err := tt.signer.verify(tt.token, tt.now)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedUser, username)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrValueValidation - is returned when the value does not match the schema of the namespace.
+var ErrValueValidation = errors.New("value validation failed")
+
+const (
+	// ValueSchemaJSON - schema of the namespaces accepting only valid JSON values.
+	ValueSchemaJSON = "json"
+	// ValueSchemaRegexPrefix - prefixes the pattern the whole value must match, e.g. "regex:[0-9]+".
+	ValueSchemaRegexPrefix = "regex:"
+)
+
+// ValueValidator - checks the values stored in a namespace.
+type ValueValidator interface {
+	// Validate - returns an error describing why the value is rejected.
+	Validate(value string) error
+}
+
+// JSONValidator - accepts only valid JSON values.
+type JSONValidator struct{}
+
+// Validate - checks that the value is valid JSON.
+func (JSONValidator) Validate(value string) error {
+	if !json.Valid([]byte(value)) {
+		return errors.New("value is not valid JSON")
+	}
+
+	return nil
+}
+
+// RegexValidator - accepts only the values entirely matching the pattern.
+type RegexValidator struct {
+	re *regexp.Regexp
+}
+
+// NewRegexValidator - compiles the pattern anchored to the whole value.
+func NewRegexValidator(pattern string) (*RegexValidator, error) {
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	return &RegexValidator{re: re}, nil
+}
+
+// Validate - checks that the whole value matches the pattern.
+func (v *RegexValidator) Validate(value string) error {
+	if !v.re.MatchString(value) {
+		return fmt.Errorf("value does not match '%s'", v.re.String())
+	}
+
+	return nil
+}
+
+// ParseValueSchema - returns the validator of the schema, "json" or "regex:<pattern>".
+func ParseValueSchema(schema string) (ValueValidator, error) {
+	if schema == ValueSchemaJSON {
+		return JSONValidator{}, nil
+	}
+
+	if pattern, ok := strings.CutPrefix(schema, ValueSchemaRegexPrefix); ok {
+		return NewRegexValidator(pattern)
+	}
+
+	return nil, fmt.Errorf("unknown value schema '%s', expected '%s' or '%s<pattern>'",
+		schema, ValueSchemaJSON, ValueSchemaRegexPrefix)
+}
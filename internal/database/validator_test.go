@@ -0,0 +1,31 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseValueSchema(t *testing.T) {
+	t.Parallel()
+
+	validator, err := ParseValueSchema("json")
+	require.NoError(t, err)
+	assert.NoError(t, validator.Validate(`{"name": "kvdb"}`))
+	assert.NoError(t, validator.Validate(`[1, 2]`))
+	assert.Error(t, validator.Validate(`{"name": `))
+	assert.Error(t, validator.Validate("plain"))
+
+	validator, err = ParseValueSchema("regex:[0-9]+")
+	require.NoError(t, err)
+	assert.NoError(t, validator.Validate("123"))
+	assert.Error(t, validator.Validate("12a"), "the pattern must match the whole value")
+	assert.Error(t, validator.Validate(""))
+
+	_, err = ParseValueSchema("regex:[0-9")
+	assert.ErrorContains(t, err, "invalid pattern")
+
+	_, err = ParseValueSchema("xml")
+	assert.EqualError(t, err, "unknown value schema 'xml', expected 'json' or 'regex:<pattern>'")
+}
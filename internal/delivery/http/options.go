@@ -0,0 +1,25 @@
+package http
+
+// ServerOption - is a functional option type for configuring a Server instance.
+type ServerOption func(*Server)
+
+// WithStats - exposes the storage statistics.
+func WithStats(stats StatsProvider) ServerOption {
+	return func(server *Server) {
+		server.stats = stats
+	}
+}
+
+// WithSessions - exposes the number of active sessions.
+func WithSessions(sessions SessionsProvider) ServerOption {
+	return func(server *Server) {
+		server.sessions = sessions
+	}
+}
+
+// WithConnections - exposes the number of active connections.
+func WithConnections(connections ConnectionsProvider) ServerOption {
+	return func(server *Server) {
+		server.connections = connections
+	}
+}
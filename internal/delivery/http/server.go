@@ -0,0 +1,161 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/neekrasov/kvdb/internal/database/identity/models"
+	"github.com/neekrasov/kvdb/internal/database/storage"
+	"github.com/neekrasov/kvdb/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	metricsPath            = "/metrics"
+	metricsContentType     = "text/plain; version=0.0.4; charset=utf-8"
+	defaultShutdownTimeout = 5 * time.Second
+	defaultHeaderTimeout   = 5 * time.Second
+)
+
+type (
+	// StatsProvider - source of the storage statistics.
+	StatsProvider interface {
+		Stats() (*storage.Stats, error)
+	}
+
+	// SessionsProvider - source of the active sessions.
+	SessionsProvider interface {
+		List() []models.Session
+	}
+
+	// ConnectionsProvider - source of the active connections number.
+	ConnectionsProvider interface {
+		ActiveConnections() int32
+	}
+)
+
+// Server - HTTP server exposing the metrics in the Prometheus text format.
+type Server struct {
+	listener    net.Listener
+	server      *http.Server
+	stats       StatsProvider
+	sessions    SessionsProvider
+	connections ConnectionsProvider
+}
+
+// NewServer - creates a new instance of the metrics server listening on the address.
+func NewServer(address string, opts ...ServerOption) (*Server, error) {
+	if address == "" {
+		return nil, errors.New("empty address")
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start metrics server: %w", err)
+	}
+	logger.Info("start metrics server listening", zap.String("addr", address))
+
+	server := &Server{listener: listener}
+	for _, opt := range opts {
+		opt(server)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, server.Handler())
+	server.server = &http.Server{Handler: mux, ReadHeaderTimeout: defaultHeaderTimeout}
+
+	return server, nil
+}
+
+// Start - serves the metrics until the context is canceled.
+func (s *Server) Start(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), defaultShutdownTimeout)
+		defer cancel()
+
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			logger.Warn("failed to shutdown metrics server", zap.Error(err))
+		}
+	}()
+
+	if err := s.server.Serve(s.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("metrics server failed", zap.Error(err))
+	}
+}
+
+// Handler - returns the handler writing the metrics.
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", metricsContentType)
+		s.write(w)
+	})
+}
+
+// metric - single metric sample.
+type metric struct {
+	name  string
+	kind  string
+	help  string
+	value int64
+}
+
+// write - writes the metrics of the available sources, statistics are skipped if they are disabled.
+func (s *Server) write(w io.Writer) {
+	var metrics []metric
+	if s.stats != nil {
+		if stats, err := s.stats.Stats(); err == nil {
+			metrics = append(metrics,
+				metric{"kvdb_total_keys", "gauge", "Total number of keys in the storage.", stats.TotalKeys.Load()},
+				metric{"kvdb_commands_total", "counter", "Total number of executed commands.", stats.TotalCommands.Load()},
+				metric{"kvdb_get_commands_total", "counter", "Number of executed GET commands.", stats.GetCommands.Load()},
+				metric{"kvdb_get_hits_total", "counter", "Number of GET commands that found the key.", stats.Hits.Load()},
+				metric{"kvdb_get_misses_total", "counter", "Number of GET commands that did not find the key.", stats.Misses.Load()},
+				metric{"kvdb_set_commands_total", "counter", "Number of executed SET commands.", stats.SetCommands.Load()},
+				metric{"kvdb_del_commands_total", "counter", "Number of executed DEL commands.", stats.DelCommands.Load()},
+				metric{"kvdb_expired_keys_total", "counter", "Number of deleted expired keys.", stats.ExpiredKeys.Load()},
+				metric{"kvdb_evicted_keys_total", "counter", "Number of evicted idle keys.", stats.EvictedKeys.Load()},
+			)
+		}
+	}
+
+	if s.sessions != nil {
+		metrics = append(metrics, metric{
+			"kvdb_active_sessions", "gauge", "Number of active sessions.", int64(len(s.sessions.List())),
+		})
+	}
+
+	if s.connections != nil {
+		metrics = append(metrics, metric{
+			"kvdb_active_connections", "gauge", "Number of active connections.", int64(s.connections.ActiveConnections()),
+		})
+	}
+
+	for _, m := range metrics {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", m.name, m.help, m.name, m.kind, m.name, m.value)
+	}
+}
+
+// Close - closes the metrics server and its listener.
+func (s *Server) Close() error {
+	if err := s.server.Close(); err != nil {
+		return err
+	}
+
+	if err := s.listener.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/neekrasov/kvdb/internal/database/identity"
+	"github.com/neekrasov/kvdb/internal/database/identity/models"
+	"github.com/neekrasov/kvdb/internal/database/storage"
+	"github.com/neekrasov/kvdb/internal/database/storage/engine"
+	"github.com/neekrasov/kvdb/internal/database/storage/wal"
+	"github.com/neekrasov/kvdb/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type connections int32
+
+func (c connections) ActiveConnections() int32 {
+	return int32(c)
+}
+
+func TestServer_Metrics(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dstorage, err := storage.NewStorage(ctx, engine.New(),
+		storage.WithWALOpt((*wal.WAL)(nil)), storage.WithStatistics())
+	require.NoError(t, err)
+	require.NoError(t, dstorage.Set(ctx, storage.MakeKey("default", "key"), "value"))
+	_, err = dstorage.Get(ctx, storage.MakeKey("default", "key"))
+	require.NoError(t, err)
+	_, err = dstorage.Get(ctx, storage.MakeKey("default", "missing"))
+	require.ErrorIs(t, err, storage.ErrKeyNotFound)
+
+	sessions := identity.NewSessionStorage(0)
+	require.NoError(t, sessions.Create("session", &models.User{Username: "admin"}))
+
+	server, err := NewServer("localhost:0",
+		WithStats(dstorage),
+		WithSessions(sessions),
+		WithConnections(connections(3)),
+	)
+	require.NoError(t, err)
+	defer server.Close()
+
+	go server.Start(ctx)
+
+	res, err := http.Get("http://" + server.listener.Addr().String() + metricsPath)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, metricsContentType, res.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	for _, line := range []string{
+		"# TYPE kvdb_total_keys gauge\nkvdb_total_keys 1\n",
+		"# TYPE kvdb_active_sessions gauge\nkvdb_active_sessions 1\n",
+		"# TYPE kvdb_active_connections gauge\nkvdb_active_connections 3\n",
+		"# TYPE kvdb_get_commands_total counter\nkvdb_get_commands_total 2\n",
+		"# TYPE kvdb_get_hits_total counter\nkvdb_get_hits_total 1\n",
+		"# TYPE kvdb_get_misses_total counter\nkvdb_get_misses_total 1\n",
+		"# TYPE kvdb_set_commands_total counter\nkvdb_set_commands_total 1\n",
+		"# TYPE kvdb_del_commands_total counter\nkvdb_del_commands_total 0\n",
+	} {
+		assert.Contains(t, string(body), line)
+	}
+}
+
+func TestServer_MetricsStatsDisabled(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	dstorage, err := storage.NewStorage(context.Background(), engine.New(), storage.WithWALOpt((*wal.WAL)(nil)))
+	require.NoError(t, err)
+
+	server, err := NewServer("localhost:0", WithStats(dstorage), WithConnections(connections(0)))
+	require.NoError(t, err)
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, metricsPath, nil)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, req)
+
+	assert.NotContains(t, recorder.Body.String(), "kvdb_total_keys")
+	assert.Contains(t, recorder.Body.String(), "kvdb_active_connections 0\n")
+}
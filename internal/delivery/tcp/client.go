@@ -1,12 +1,15 @@
 package tcp
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"net"
 	"sync"
 	"time"
+
+	"github.com/neekrasov/kvdb/internal/database/compression"
 )
 
 var (
@@ -26,6 +29,12 @@ type Client struct {
 	idleTimeout     time.Duration // Timeout for idle connection.
 	bufferSize      int           // The buffer size for reading data.
 	keepAlivePeriod time.Duration // Period for keep alive
+	compression     string        // Codec of the response compression, empty disables it.
+	protocol        Protocol      // Wire protocol of the connection, text by default.
+
+	serverIdleTimeout time.Duration // Idle timeout requested from the server, zero keeps the server one.
+
+	compressor compression.Compressor
 
 	mu         sync.Mutex
 	connection net.Conn // The TCP connection for the client.
@@ -46,6 +55,14 @@ func NewClient(address string, options ...ClientOption) (*Client, error) {
 		client.keepAlivePeriod = time.Second
 	}
 
+	if client.compression != "" {
+		compressor, err := compression.New(client.compression)
+		if err != nil {
+			return nil, err
+		}
+		client.compressor = compressor
+	}
+
 	if err := client.Сonnect(); err != nil {
 		return nil, fmt.Errorf("init connection failed: %w", err)
 	}
@@ -73,6 +90,38 @@ func (c *Client) Сonnect() error {
 		return fmt.Errorf("setting keep alive period failed: %w", err)
 	}
 
+	// The idle timeout is negotiated first, its acknowledgement is never compressed.
+	if c.serverIdleTimeout > 0 {
+		if err := c.negotiateIdleTimeoutLocked(); err != nil {
+			return fmt.Errorf("idle timeout negotiation failed: %w", err)
+		}
+	}
+
+	if c.compressor != nil {
+		if err := c.negotiateCompressionLocked(); err != nil {
+			return fmt.Errorf("compression negotiation failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// negotiateCompressionLocked - sends the compression handshake and waits for the server acknowledgement.
+func (c *Client) negotiateCompressionLocked() error {
+	if err := c.write([]byte(compressCommand + " " + c.compression)); err != nil {
+		return fmt.Errorf("error writing to connection: %w", err)
+	}
+
+	response := make([]byte, c.bufferSize)
+	n, err := c.read(response)
+	if err != nil {
+		return fmt.Errorf("error reading from connection: %w", err)
+	}
+
+	if !bytes.HasPrefix(response[:n], []byte("[ok]")) {
+		return fmt.Errorf("%w: %s", ErrCompressionRejected, response[:n])
+	}
+
 	return nil
 }
 
@@ -92,7 +141,7 @@ func (c *Client) Send(ctx context.Context, request []byte) ([]byte, error) {
 		}
 	}
 
-	if _, err := c.connection.Write(request); err != nil {
+	if err := c.write(request); err != nil {
 		if isTimeout(err) {
 			return nil, errors.Join(ErrTimeout, err)
 		}
@@ -105,7 +154,7 @@ func (c *Client) Send(ctx context.Context, request []byte) ([]byte, error) {
 	response := make([]byte, c.bufferSize)
 
 	go func() {
-		n, err := c.connection.Read(response)
+		n, err := c.read(response)
 		if err != nil {
 			if isTimeout(err) {
 				readErr = errors.Join(ErrTimeout, err)
@@ -114,6 +163,8 @@ func (c *Client) Send(ctx context.Context, request []byte) ([]byte, error) {
 			}
 		} else if n >= c.bufferSize {
 			readErr = ErrSmallBufferSize
+		} else if c.compressor != nil {
+			response, readErr = decodePayload(c.compressor, response[:n])
 		} else {
 			response = response[:n]
 		}
@@ -133,13 +184,43 @@ func (c *Client) Send(ctx context.Context, request []byte) ([]byte, error) {
 }
 
 func (c *Client) cancelCurrentOperationLocked() error {
-	if _, err := c.connection.Write([]byte(cancelCommand)); err != nil {
+	if err := c.write([]byte(cancelCommand)); err != nil {
 		return fmt.Errorf("failed to send cancel request: %w", err)
 	}
 
 	return nil
 }
 
+// write - writes the message as a single command of the connection protocol.
+func (c *Client) write(message []byte) error {
+	if c.protocol == ProtocolBinary {
+		_, err := c.connection.Write(EncodeFrame(message))
+		return err
+	}
+
+	// The message is copied, the caller's slice must not be modified by the terminator.
+	line := make([]byte, 0, len(message)+1)
+	_, err := c.connection.Write(append(append(line, message...), '\n'))
+	return err
+}
+
+// read - reads a single response of the connection protocol into the buffer.
+func (c *Client) read(buffer []byte) (int, error) {
+	if c.protocol != ProtocolBinary {
+		return c.connection.Read(buffer)
+	}
+
+	payload, err := ReadFrame(c.connection, len(buffer))
+	if errors.Is(err, ErrFrameTooLarge) {
+		return 0, ErrSmallBufferSize
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return copy(buffer, payload), nil
+}
+
 // Close - closes the client connection.
 func (c *Client) Close() error {
 	c.mu.Lock()
@@ -56,7 +56,7 @@ func TestClient_Send(t *testing.T) {
 			buf := make([]byte, 1024)
 			n, err := conn.Read(buf)
 			require.NoError(t, err)
-			assert.Equal(t, "hello", string(buf[:n]))
+			assert.Equal(t, "hello\n", string(buf[:n]))
 
 			_, err = conn.Write([]byte("world"))
 			require.NoError(t, err)
@@ -106,7 +106,7 @@ func TestClient_Send(t *testing.T) {
 			buf := make([]byte, 5)
 			n, err := conn.Read(buf)
 			require.NoError(t, err)
-			assert.Equal(t, "hell", string(buf[:n]))
+			assert.Equal(t, "hell\n", string(buf[:n]))
 
 			_, err = conn.Write([]byte("hello world"))
 			require.NoError(t, err)
@@ -0,0 +1,121 @@
+package tcp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/neekrasov/kvdb/internal/database/compression"
+)
+
+const (
+	// compressCommand - handshake sent by the client before the login to enable the response compression.
+	compressCommand = "COMPRESS"
+	// compressionThreshold - responses smaller than the threshold are sent uncompressed.
+	compressionThreshold = 512
+)
+
+// Flags of the responses on a connection with the negotiated compression.
+const (
+	rawPayload byte = iota
+	compressedPayload
+)
+
+var (
+	ErrCompressionRejected = errors.New("compression rejected by server")
+	ErrInvalidPayload      = errors.New("invalid payload flag")
+)
+
+// replayConn - connection returning the already read message before reading from the connection.
+type replayConn struct {
+	net.Conn
+	pending []byte
+}
+
+// Read - reads the pending message first, then from the connection.
+func (c *replayConn) Read(p []byte) (int, error) {
+	if len(c.pending) == 0 {
+		return c.Conn.Read(p)
+	}
+
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+
+	return n, nil
+}
+
+// compressedConn - connection writing every response prefixed with the payload flag,
+// responses not smaller than the threshold are compressed.
+type compressedConn struct {
+	net.Conn
+	compressor compression.Compressor
+	threshold  int
+}
+
+// Write - writes the flagged and possibly compressed response.
+func (c *compressedConn) Write(p []byte) (int, error) {
+	payload, err := encodePayload(c.compressor, c.threshold, p)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := c.Conn.Write(payload); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// negotiateCompression - acknowledges the compression handshake, the responses of the connection are compressed
+// with the requested codec. An unsupported codec is rejected and the connection stays uncompressed.
+func negotiateCompression(conn net.Conn, codec string) (net.Conn, error) {
+	compressor, err := compression.New(codec)
+	if err != nil {
+		if _, err := conn.Write([]byte("[error] " + err.Error())); err != nil {
+			return nil, err
+		}
+
+		return conn, nil
+	}
+
+	if _, err := conn.Write([]byte("[ok]")); err != nil {
+		return nil, err
+	}
+
+	return &compressedConn{Conn: conn, compressor: compressor, threshold: compressionThreshold}, nil
+}
+
+// encodePayload - prefixes the data with the payload flag compressing it if it's not smaller than the threshold.
+func encodePayload(compressor compression.Compressor, threshold int, data []byte) ([]byte, error) {
+	if len(data) < threshold {
+		return append([]byte{rawPayload}, data...), nil
+	}
+
+	compressed, err := compressor.Compress(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress response: %w", err)
+	}
+
+	return append([]byte{compressedPayload}, compressed...), nil
+}
+
+// decodePayload - returns the data of the flagged payload decompressing it if needed.
+func decodePayload(compressor compression.Compressor, payload []byte) ([]byte, error) {
+	if len(payload) == 0 {
+		return nil, ErrInvalidPayload
+	}
+
+	switch payload[0] {
+	case rawPayload:
+		return payload[1:], nil
+	case compressedPayload:
+		data, err := compressor.Decompress(payload[1:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress response: %w", err)
+		}
+
+		return data, nil
+	default:
+		return nil, ErrInvalidPayload
+	}
+}
@@ -0,0 +1,132 @@
+package tcp
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConnectionInfo - snapshot of a client connection of the server.
+type ConnectionInfo struct {
+	SessionID    ConnectionID
+	RemoteAddr   string
+	ConnectedAt  time.Time
+	BytesRead    int64
+	BytesWritten int64
+	// Command - name of the command in progress, empty if the connection is idle.
+	// The arguments are not kept, they may contain credentials.
+	Command string
+	// IdleTimeout - idle timeout negotiated by the client, zero if the server one is used.
+	IdleTimeout time.Duration
+}
+
+// connection - the registry entry of an accepted connection.
+type connection struct {
+	cancel       context.CancelFunc
+	remoteAddr   string
+	connectedAt  time.Time
+	bytesRead    atomic.Int64
+	bytesWritten atomic.Int64
+	// activeAt - unix nanoseconds of the last command read or response written.
+	activeAt atomic.Int64
+
+	mu          sync.Mutex
+	command     string
+	idleTimeout time.Duration
+}
+
+// setCommand - marks the command in progress, only the first word of the command is kept.
+func (c *connection) setCommand(command []byte) {
+	name, _, _ := bytes.Cut(bytes.TrimSpace(command), []byte(" "))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.command = string(name)
+}
+
+// clearCommand - marks the connection idle.
+func (c *connection) clearCommand() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.command = ""
+}
+
+// busy - reports whether a command of the connection is in progress.
+func (c *connection) busy() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.command != ""
+}
+
+// touch - marks the connection active now, the idle timeout is counted from the last activity.
+func (c *connection) touch() {
+	c.activeAt.Store(time.Now().UnixNano())
+}
+
+// lastActive - returns the time of the last activity of the connection.
+func (c *connection) lastActive() time.Time {
+	return time.Unix(0, c.activeAt.Load())
+}
+
+func (c *connection) info(sessionID ConnectionID) ConnectionInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return ConnectionInfo{
+		SessionID:    sessionID,
+		RemoteAddr:   c.remoteAddr,
+		ConnectedAt:  c.connectedAt,
+		BytesRead:    c.bytesRead.Load(),
+		BytesWritten: c.bytesWritten.Load(),
+		Command:      c.command,
+		IdleTimeout:  c.idleTimeout,
+	}
+}
+
+// countingConn - connection counting the bytes transferred over the network.
+type countingConn struct {
+	net.Conn
+	stats *connection
+}
+
+// Read - reads from the connection and counts the read bytes.
+func (c *countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.stats.bytesRead.Add(int64(n))
+
+	return n, err
+}
+
+// Write - writes to the connection and counts the written bytes.
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.stats.bytesWritten.Add(int64(n))
+
+	return n, err
+}
+
+// Connections - returns the snapshot of the active connections sorted by the connection time.
+func (s *Server) Connections() []ConnectionInfo {
+	s.mu.Lock()
+	infos := make([]ConnectionInfo, 0, len(s.sessions))
+	for sessionID, conn := range s.sessions {
+		infos = append(infos, conn.info(sessionID))
+	}
+	s.mu.Unlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		if !infos[i].ConnectedAt.Equal(infos[j].ConnectedAt) {
+			return infos[i].ConnectedAt.Before(infos[j].ConnectedAt)
+		}
+		return infos[i].SessionID < infos[j].SessionID
+	})
+
+	return infos
+}
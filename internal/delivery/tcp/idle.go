@@ -0,0 +1,69 @@
+package tcp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// idleCommand - handshake sent by the client before the login to request the idle timeout of the connection.
+const idleCommand = "IDLE"
+
+var ErrIdleTimeoutRejected = errors.New("idle timeout rejected by server")
+
+// negotiateIdleTimeout - sets the idle timeout of the connection to the requested one clamped to the server maximum.
+// Without the maximum the clients may only shorten the server idle timeout. The effective timeout is acknowledged.
+func (s *Server) negotiateIdleTimeout(conn net.Conn, stats *connection, requested string) error {
+	timeout, err := time.ParseDuration(strings.TrimSpace(requested))
+	if err != nil || timeout <= 0 {
+		_, err := conn.Write([]byte("[error] invalid idle timeout '" + strings.TrimSpace(requested) + "'"))
+		return err
+	}
+
+	limit := s.maxIdleTimeout
+	if limit == 0 {
+		limit = s.IdleTimeout()
+	}
+	timeout = min(timeout, limit)
+
+	stats.mu.Lock()
+	stats.idleTimeout = timeout
+	stats.mu.Unlock()
+
+	_, err = conn.Write([]byte("[ok] " + timeout.String()))
+	return err
+}
+
+// connectionIdleTimeout - returns the idle timeout negotiated by the connection or the server one.
+func (s *Server) connectionIdleTimeout(stats *connection) time.Duration {
+	stats.mu.Lock()
+	timeout := stats.idleTimeout
+	stats.mu.Unlock()
+
+	if timeout > 0 {
+		return timeout
+	}
+
+	return s.IdleTimeout()
+}
+
+// negotiateIdleTimeoutLocked - requests the idle timeout of the connection and waits for the server acknowledgement.
+func (c *Client) negotiateIdleTimeoutLocked() error {
+	if err := c.write([]byte(idleCommand + " " + c.serverIdleTimeout.String())); err != nil {
+		return fmt.Errorf("error writing to connection: %w", err)
+	}
+
+	response := make([]byte, c.bufferSize)
+	n, err := c.read(response)
+	if err != nil {
+		return fmt.Errorf("error reading from connection: %w", err)
+	}
+
+	if !strings.HasPrefix(string(response[:n]), "[ok]") {
+		return fmt.Errorf("%w: %s", ErrIdleTimeoutRejected, response[:n])
+	}
+
+	return nil
+}
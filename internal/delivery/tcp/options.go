@@ -15,6 +15,14 @@ func WithServerIdleTimeout(timeout time.Duration) ServerOption {
 	}
 }
 
+// WithServerMaxIdleTimeout - sets the maximum idle timeout the clients may request for their connections,
+// without it the clients may only shorten the idle timeout.
+func WithServerMaxIdleTimeout(timeout time.Duration) ServerOption {
+	return func(server *Server) {
+		server.maxIdleTimeout = timeout
+	}
+}
+
 // WithConnectionHandler - handler activates where client connect.
 func WithConnectionHandler(handler ConnectionHandler) ServerOption {
 	return func(server *Server) {
@@ -43,6 +51,43 @@ func WithServerMaxConnectionsNumber(count uint) ServerOption {
 	}
 }
 
+// WithServerProtocol - sets the wire protocol of the client connections.
+func WithServerProtocol(protocol Protocol) ServerOption {
+	return func(server *Server) {
+		server.protocol = protocol
+	}
+}
+
+// WithServerCommandTimeout - sets the maximum duration of a command execution, zero disables the timeout.
+func WithServerCommandTimeout(timeout time.Duration) ServerOption {
+	return func(server *Server) {
+		server.commandTimeout = timeout
+	}
+}
+
+// WithServerAuthTimeout - closes the connection if the connect handler does not succeed within the timeout
+// after the connect, zero disables the timeout.
+func WithServerAuthTimeout(timeout time.Duration) ServerOption {
+	return func(server *Server) {
+		server.authTimeout = timeout
+	}
+}
+
+// WithServerCompression - allows clients to negotiate the compression of the responses at connect time.
+func WithServerCompression(enabled bool) ServerOption {
+	return func(server *Server) {
+		server.compression = enabled
+	}
+}
+
+// WithServerHealthCheck - answers the HEALTH probe sent instead of the login with the check result,
+// the probe does not require authentication.
+func WithServerHealthCheck(check func() []byte) ServerOption {
+	return func(server *Server) {
+		server.healthCheck = check
+	}
+}
+
 // ClientOption - function type used to configure a Client.
 type ClientOption func(*Client)
 
@@ -53,6 +98,14 @@ func WithClientIdleTimeout(timeout time.Duration) ClientOption {
 	}
 }
 
+// WithClientServerIdleTimeout - requests the server to close the idle connection after the timeout,
+// the server clamps it to its maximum.
+func WithClientServerIdleTimeout(timeout time.Duration) ClientOption {
+	return func(client *Client) {
+		client.serverIdleTimeout = timeout
+	}
+}
+
 // WithClientBufferSize - sets the buffer size for the client.
 func WithClientBufferSize(size uint) ClientOption {
 	return func(client *Client) {
@@ -66,3 +119,17 @@ func WithKeepAlivePeriod(period time.Duration) ClientOption {
 		client.keepAlivePeriod = period
 	}
 }
+
+// WithClientCompression - requests the server to compress the responses with the codec.
+func WithClientCompression(codec string) ClientOption {
+	return func(client *Client) {
+		client.compression = codec
+	}
+}
+
+// WithClientProtocol - sets the wire protocol of the connection, it must match the server one.
+func WithClientProtocol(protocol Protocol) ClientOption {
+	return func(client *Client) {
+		client.protocol = protocol
+	}
+}
@@ -0,0 +1,224 @@
+package tcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+// Protocol - wire protocol of the client connections.
+type Protocol string
+
+const (
+	// ProtocolText - plain text commands, each terminated by a newline.
+	ProtocolText Protocol = "text"
+	// ProtocolBinary - commands and responses are sent as length-prefixed frames.
+	ProtocolBinary Protocol = "binary"
+	// ProtocolAuto - the protocol is detected by the first bytes of the connection.
+	ProtocolAuto Protocol = "auto"
+)
+
+// frameHeaderSize - size of the frame magic and the big-endian payload length.
+const frameHeaderSize = len(FrameMagic) + 4
+
+var (
+	// FrameMagic - prefix of every binary frame. A text command never starts with a zero byte.
+	FrameMagic = [2]byte{0x00, 'K'}
+
+	ErrInvalidFrame  = errors.New("invalid frame magic")
+	ErrFrameTooLarge = errors.New("frame too large")
+)
+
+// ParseProtocol - parses the protocol name.
+func ParseProtocol(name string) (Protocol, error) {
+	switch protocol := Protocol(name); protocol {
+	case ProtocolText, ProtocolBinary, ProtocolAuto:
+		return protocol, nil
+	default:
+		return "", fmt.Errorf("unknown protocol '%s'", name)
+	}
+}
+
+// EncodeFrame - wraps the payload into a binary frame.
+func EncodeFrame(payload []byte) []byte {
+	frame := make([]byte, frameHeaderSize+len(payload))
+	copy(frame, FrameMagic[:])
+	binary.BigEndian.PutUint32(frame[len(FrameMagic):], uint32(len(payload)))
+	copy(frame[frameHeaderSize:], payload)
+
+	return frame
+}
+
+// ReadFrame - reads a single binary frame and returns its payload.
+// Frames with a payload larger than maxSize are rejected, zero maxSize means no limit.
+func ReadFrame(r io.Reader, maxSize int) ([]byte, error) {
+	size, err := readFrameHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxSize > 0 && size > maxSize {
+		return nil, ErrFrameTooLarge
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// readFrameHeader - reads the frame header and returns the payload size.
+func readFrameHeader(r io.Reader) (int, error) {
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, err
+	}
+
+	if !bytes.Equal(header[:len(FrameMagic)], FrameMagic[:]) {
+		return 0, ErrInvalidFrame
+	}
+
+	return int(binary.BigEndian.Uint32(header[len(FrameMagic):])), nil
+}
+
+// detectProtocol - peeks the first bytes of the connection to distinguish
+// the binary frame magic from plain text, falls back to text on ambiguity.
+func detectProtocol(reader *bufio.Reader) (Protocol, error) {
+	prefix, err := reader.Peek(1)
+	if err != nil {
+		return "", err
+	}
+
+	if prefix[0] != FrameMagic[0] {
+		return ProtocolText, nil
+	}
+
+	prefix, err = reader.Peek(len(FrameMagic))
+	if err != nil {
+		return "", err
+	}
+
+	if bytes.Equal(prefix, FrameMagic[:]) {
+		return ProtocolBinary, nil
+	}
+
+	return ProtocolText, nil
+}
+
+// lineConn - text protocol connection, reads newline terminated commands without the terminator.
+type lineConn struct {
+	net.Conn
+	reader  *bufio.Reader
+	maxSize int
+	line    []byte // The part of the line read before a timeout.
+	skip    bool   // The line is larger than maxSize and is discarded up to the terminator.
+	pending []byte
+}
+
+// Read - reads the next command. A command larger than p is returned in parts, a line not fitting
+// into maxSize together with the terminator is discarded and ErrRequestTooLarge is returned.
+// Empty lines are skipped.
+func (c *lineConn) Read(p []byte) (int, error) {
+	for len(c.pending) == 0 {
+		chunk, err := c.reader.ReadSlice('\n')
+		if len(c.line)+len(chunk) > c.maxSize {
+			c.line, c.skip = c.line[:0], true
+		}
+		if !c.skip {
+			c.line = append(c.line, chunk...)
+		}
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil {
+			// The partial line is kept, so a read timeout does not drop it.
+			return 0, err
+		}
+
+		if c.skip {
+			c.line, c.skip = c.line[:0], false
+			return 0, ErrRequestTooLarge
+		}
+
+		c.pending = bytes.TrimSuffix(bytes.TrimSuffix(c.line, []byte("\n")), []byte("\r"))
+		c.line = nil
+	}
+
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+
+	return n, nil
+}
+
+// framedConn - binary protocol connection, reads frame payloads and writes responses as frames.
+type framedConn struct {
+	net.Conn
+	reader  io.Reader
+	maxSize int
+	pending []byte
+}
+
+// Read - reads the payload of the next frame. A payload larger than p is returned in parts,
+// a payload larger than maxSize is discarded and ErrRequestTooLarge is returned.
+func (c *framedConn) Read(p []byte) (int, error) {
+	if len(c.pending) == 0 {
+		size, err := readFrameHeader(c.reader)
+		if err != nil {
+			return 0, err
+		}
+
+		if size > c.maxSize {
+			if _, err := io.CopyN(io.Discard, c.reader, int64(size)); err != nil {
+				return 0, err
+			}
+
+			return 0, ErrRequestTooLarge
+		}
+
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(c.reader, payload); err != nil {
+			return 0, err
+		}
+		c.pending = payload
+	}
+
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+
+	return n, nil
+}
+
+// Write - writes the data as a single frame.
+func (c *framedConn) Write(p []byte) (int, error) {
+	if _, err := c.Conn.Write(EncodeFrame(p)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// wrapConn - wraps the connection into the codec of the protocol.
+func wrapConn(conn net.Conn, protocol Protocol, bufferSize int) (net.Conn, error) {
+	reader := bufio.NewReaderSize(conn, bufferSize)
+	if protocol == ProtocolAuto {
+		detected, err := detectProtocol(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect protocol: %w", err)
+		}
+		protocol = detected
+	}
+
+	if protocol == ProtocolBinary {
+		return &framedConn{Conn: conn, reader: reader, maxSize: bufferSize}, nil
+	}
+
+	// A command must be smaller than the buffer, the handlers treat a full read as an overflow.
+	return &lineConn{Conn: conn, reader: reader, maxSize: bufferSize}, nil
+}
@@ -0,0 +1,70 @@
+package tcp
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrame(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Encode and read", func(t *testing.T) {
+		payload, err := ReadFrame(bytes.NewReader(EncodeFrame([]byte("get key"))), 0)
+		require.NoError(t, err)
+		assert.Equal(t, "get key", string(payload))
+	})
+
+	t.Run("Invalid magic", func(t *testing.T) {
+		_, err := ReadFrame(strings.NewReader("get key"), 0)
+		assert.ErrorIs(t, err, ErrInvalidFrame)
+	})
+
+	t.Run("Too large", func(t *testing.T) {
+		_, err := ReadFrame(bytes.NewReader(EncodeFrame([]byte("get key"))), 4)
+		assert.ErrorIs(t, err, ErrFrameTooLarge)
+	})
+}
+
+func TestDetectProtocol(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected Protocol
+	}{
+		{name: "text command", data: []byte("get key"), expected: ProtocolText},
+		{name: "binary frame", data: EncodeFrame([]byte("get key")), expected: ProtocolBinary},
+		{name: "zero byte without magic", data: []byte{0x00, 'x'}, expected: ProtocolText},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := bufio.NewReader(bytes.NewReader(tt.data))
+
+			protocol, err := detectProtocol(reader)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, protocol)
+
+			buffered, err := reader.Peek(len(tt.data))
+			require.NoError(t, err)
+			assert.Equal(t, tt.data, buffered, "detection must not consume data")
+		})
+	}
+}
+
+func TestParseProtocol(t *testing.T) {
+	t.Parallel()
+
+	protocol, err := ParseProtocol("auto")
+	require.NoError(t, err)
+	assert.Equal(t, ProtocolAuto, protocol)
+
+	_, err = ParseProtocol("json")
+	assert.Error(t, err)
+}
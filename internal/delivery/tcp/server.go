@@ -1,12 +1,14 @@
 package tcp
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"runtime/debug"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -20,8 +22,19 @@ const (
 	defaultConnIDLen   = 16
 	defaultIdleTimeout = 30 * time.Second
 	cancelCommand      = "CANCEL"
+	healthCommand      = "HEALTH"
 )
 
+// ErrCommandTimeout - is returned to the client when the command is not executed within the command timeout.
+var ErrCommandTimeout = errors.New("command timed out")
+
+var commandTimeoutResponse = []byte("[error] " + ErrCommandTimeout.Error())
+
+// ErrRequestTooLarge - is returned to the client when the command does not fit into the buffer.
+var ErrRequestTooLarge = errors.New("request too large")
+
+var requestTooLargeResponse = []byte("[error] " + ErrRequestTooLarge.Error())
+
 type (
 	ConnectionID      = string
 	Handler           = func(ctx context.Context, sessionID string, request []byte) []byte
@@ -32,13 +45,22 @@ type (
 type Server struct {
 	listener       net.Listener
 	idleTimeout    time.Duration
+	maxIdleTimeout time.Duration
 	semaphore      *pkgsync.Semaphore
 	bufferSize     uint
 	maxConnections uint
+	protocol       Protocol
+	commandTimeout time.Duration
+	authTimeout    time.Duration
+	compression    bool
+	healthCheck    func() []byte
 
 	activeConnections int32
 	onconnect         ConnectionHandler
 	ondisconnect      ConnectionHandler
+
+	mu       sync.Mutex
+	sessions map[ConnectionID]*connection
 }
 
 // NewServer - creates a new instance of the TCP server.
@@ -56,6 +78,7 @@ func NewServer(address string, opts ...ServerOption) (*Server, error) {
 	server := &Server{
 		listener:   listener,
 		bufferSize: defaultBufferSize,
+		sessions:   make(map[ConnectionID]*connection),
 	}
 
 	for _, opt := range opts {
@@ -99,11 +122,13 @@ func (s *Server) Start(ctx context.Context, handler Handler) {
 				zap.String("session", sessionID),
 			)
 
-			s.semaphore.Acquire()
+			// The permit is released to the semaphore it was acquired from, the limit may change meanwhile.
+			semaphore := s.connectionsSemaphore()
+			semaphore.Acquire()
 			atomic.AddInt32(&s.activeConnections, 1)
 			go func() {
 				defer func() {
-					s.semaphore.Release()
+					semaphore.Release()
 					atomic.AddInt32(&s.activeConnections, -1)
 				}()
 
@@ -122,7 +147,13 @@ func (s *Server) handleConnection(
 	conn net.Conn,
 	handler Handler,
 ) {
+	ctx, closeSession := context.WithCancel(ctx)
+	stats := s.registerSession(sessionID, conn, closeSession)
+	defer closeSession()
+
 	defer func() {
+		s.unregisterSession(sessionID)
+
 		if v := recover(); v != nil {
 			logger.Error(
 				"captured panic", zap.Any("panic", v),
@@ -148,8 +179,64 @@ func (s *Server) handleConnection(
 			zap.String("session", sessionID))
 	}()
 
+	protocolConn, err := wrapConn(&countingConn{Conn: conn, stats: stats}, s.protocol, int(s.bufferSize))
+	if err != nil {
+		logger.Debug("failed to init connection protocol",
+			zap.String("session", sessionID), zap.Error(err))
+		return
+	}
+	conn = protocolConn
+
+	// The authentication deadline is counted from the connect, the handshake is within it too.
+	var authDeadline time.Time
+	if s.authTimeout > 0 {
+		authDeadline = time.Now().Add(s.authTimeout)
+	}
+
+	// The handshake waits for the first message, so without the features the connect handler runs at once.
+	if s.compression || s.healthCheck != nil || s.maxIdleTimeout > 0 {
+		handshakeDeadline := time.Now().Add(s.IdleTimeout())
+		if !authDeadline.IsZero() && authDeadline.Before(handshakeDeadline) {
+			handshakeDeadline = authDeadline
+		}
+
+		if err := conn.SetReadDeadline(handshakeDeadline); err != nil {
+			logger.Debug("failed to set handshake deadline",
+				zap.String("session", sessionID), zap.Error(err))
+			return
+		}
+
+		handshakeConn, err := s.handshake(conn, stats)
+		if err != nil {
+			logger.Debug("connection handshake failed",
+				zap.String("session", sessionID), zap.Error(err))
+			return
+		}
+
+		if handshakeConn == nil {
+			logger.Debug("health probe answered", zap.String("session", sessionID))
+			return
+		}
+		conn = handshakeConn
+	}
+
 	if s.onconnect != nil {
+		if !authDeadline.IsZero() {
+			if err := conn.SetReadDeadline(authDeadline); err != nil {
+				logger.Debug("failed to set authentication deadline",
+					zap.String("session", sessionID), zap.Error(err))
+				return
+			}
+		}
+
 		if err := s.onconnect(ctx, sessionID, conn); err != nil {
+			// With the authentication timeout the connection is open only for the authenticated clients.
+			if !authDeadline.IsZero() {
+				logger.Debug("connection not authenticated, closing",
+					zap.String("session", sessionID), zap.Error(err))
+				return
+			}
+
 			logger.Warn("executing connect handler failed", zap.Error(err))
 		}
 	}
@@ -160,9 +247,31 @@ func (s *Server) handleConnection(
 	go func() {
 		buffer := make([]byte, s.bufferSize)
 		for {
+			// The idle timeout is counted from the last command or response, a running command,
+			// e.g. a long WATCH, keeps the connection alive.
+			timeout := s.connectionIdleTimeout(stats)
+			deadline := stats.lastActive().Add(timeout)
+			if stats.busy() {
+				deadline = time.Now().Add(timeout)
+			}
+			if err := conn.SetReadDeadline(deadline); err != nil {
+				errorCh <- err
+				return
+			}
+
 			n, err := conn.Read(buffer)
+			if errors.Is(err, ErrRequestTooLarge) {
+				// The codec has already discarded the whole command, the nil command is sent instead.
+				stats.touch()
+				commandCh <- nil
+				continue
+			}
 			if err != nil {
-				if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+				if isTimeout(err) && (stats.busy() || time.Since(stats.lastActive()) < timeout) {
+					continue
+				}
+
+				if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) || isTimeout(err) {
 					logger.Debug("client closed connection", zap.String("session", sessionID))
 					errorCh <- err
 					return
@@ -176,31 +285,70 @@ func (s *Server) handleConnection(
 				errorCh <- err
 				return
 			}
+			stats.touch()
 
-			commandCh <- buffer[:n]
+			// The command is copied, the buffer is reused while the handler may still run.
+			commandCh <- bytes.Clone(buffer[:n])
 		}
 	}()
 
 	var (
-		opCtx  context.Context
-		cancel context.CancelFunc
+		opCtx    context.Context
+		cancel   context.CancelFunc
+		resCh    chan []byte
+		deadline <-chan struct{}
 	)
+	defer func() {
+		if cancel != nil {
+			cancel()
+		}
+	}()
+
+	finish := func() {
+		cancel()
+		stats.clearCommand()
+		opCtx, cancel, resCh, deadline = nil, nil, nil, nil
+	}
 
-	resCh := make(chan []byte)
 	for {
 		select {
 		case <-ctx.Done():
-			logger.Debug("server context canceled", zap.String("session", sessionID))
+			logger.Debug("session context canceled", zap.String("session", sessionID))
 			return
 		case err := <-errorCh:
+			if isTimeout(err) {
+				logger.Debug("idle connection closed", zap.String("session", sessionID),
+					zap.Stringer("idle_timeout", s.connectionIdleTimeout(stats)))
+				return
+			}
+
 			logger.Warn("connection error", zap.String("session", sessionID), zap.Error(err))
 			return
 		case command := <-commandCh:
+			if command == nil {
+				logger.Warn("request too large", zap.String("session", sessionID),
+					zap.Uint("buffer_size_bytes", s.bufferSize))
+				if cancel != nil {
+					continue
+				}
+
+				if _, err := conn.Write(requestTooLargeResponse); err != nil {
+					logger.Warn("failed to write data",
+						zap.Stringer("address", conn.RemoteAddr()),
+						zap.String("session", sessionID),
+						zap.Error(err),
+					)
+					return
+				}
+				continue
+			}
+
 			if string(command) == cancelCommand {
 				logger.Debug("received CANCEL command", zap.String("session", sessionID))
 				if cancel != nil {
+					// The canceled handler still writes its response, it must not look like the timeout.
 					cancel()
-					cancel = nil
+					deadline = nil
 				}
 				continue
 			}
@@ -211,13 +359,37 @@ func (s *Server) handleConnection(
 				continue
 			}
 
-			go func() {
-				opCtx, cancel = context.WithCancel(ctx)
-				defer cancel()
+			opCtx, cancel = s.commandContext(ctx)
+			stats.setCommand(command)
+			if s.commandTimeout > 0 {
+				deadline = opCtx.Done()
+			}
 
-				resCh <- handler(opCtx, sessionID, command)
-			}()
+			// The channel is buffered, so the handler of a timed out command does not block.
+			resCh = make(chan []byte, 1)
+			go func(ctx context.Context, resCh chan<- []byte) {
+				resCh <- handler(ctx, sessionID, command)
+			}(opCtx, resCh)
+		case <-deadline:
+			logger.Debug("command timed out", zap.String("session", sessionID),
+				zap.Stringer("timeout", s.commandTimeout))
+			finish()
+
+			if _, err := conn.Write(commandTimeoutResponse); err != nil {
+				logger.Warn("failed to write data",
+					zap.Stringer("address", conn.RemoteAddr()),
+					zap.String("session", sessionID),
+					zap.Error(err),
+				)
+				return
+			}
 		case resp := <-resCh:
+			// A handler respecting the context may return right at the deadline.
+			if deadline != nil && errors.Is(opCtx.Err(), context.DeadlineExceeded) {
+				resp = commandTimeoutResponse
+			}
+			finish()
+
 			if _, err := conn.Write(resp); err != nil {
 				logger.Warn("failed to write data",
 					zap.Stringer("address", conn.RemoteAddr()),
@@ -226,15 +398,142 @@ func (s *Server) handleConnection(
 				)
 				return
 			}
+			stats.touch()
+		}
+	}
+}
 
-			if cancel != nil {
-				cancel()
-				cancel = nil
+// handshake - reads the first messages of the connection to answer the health probe or to negotiate
+// the idle timeout and the compression, the first other message is kept to be read by the connection handlers.
+// Returns nil connection if the health probe was answered, the probe connection is closed.
+func (s *Server) handshake(conn net.Conn, stats *connection) (net.Conn, error) {
+	var compressed, idle bool
+	buffer := make([]byte, s.bufferSize)
+	for {
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return nil, err
+		}
+		message := buffer[:n]
+
+		if s.healthCheck != nil && string(bytes.TrimSpace(message)) == healthCommand {
+			_, err := conn.Write(s.healthCheck())
+			return nil, err
+		}
+
+		if timeout, ok := bytes.CutPrefix(message, []byte(idleCommand+" ")); ok && !idle {
+			if err := s.negotiateIdleTimeout(conn, stats, string(timeout)); err != nil {
+				return nil, err
+			}
+			idle = true
+			continue
+		}
+
+		if codec, ok := bytes.CutPrefix(message, []byte(compressCommand+" ")); ok && s.compression && !compressed {
+			if conn, err = negotiateCompression(conn, string(bytes.TrimSpace(codec))); err != nil {
+				return nil, err
 			}
+			compressed = true
+			continue
 		}
+
+		return &replayConn{Conn: conn, pending: message}, nil
+	}
+}
+
+// commandContext - returns the context of a command execution bounded by the command timeout.
+func (s *Server) commandContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.commandTimeout > 0 {
+		return context.WithTimeout(ctx, s.commandTimeout)
+	}
+
+	return context.WithCancel(ctx)
+}
+
+// CloseSession - closes the connection bound to the session. Returns false if the session is unknown.
+func (s *Server) CloseSession(sessionID ConnectionID) bool {
+	s.mu.Lock()
+	conn, ok := s.sessions[sessionID]
+	s.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	conn.cancel()
+	return true
+}
+
+func (s *Server) registerSession(sessionID ConnectionID, conn net.Conn, cancel context.CancelFunc) *connection {
+	entry := &connection{
+		cancel:      cancel,
+		remoteAddr:  conn.RemoteAddr().String(),
+		connectedAt: time.Now(),
+	}
+	entry.touch()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[sessionID] = entry
+	return entry
+}
+
+func (s *Server) unregisterSession(sessionID ConnectionID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, sessionID)
+}
+
+// SetMaxConnections - changes the maximum number of concurrent connections at runtime, zero removes the limit.
+// Already accepted connections are counted against the previous limit until they are closed.
+func (s *Server) SetMaxConnections(count uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxConnections = count
+	s.semaphore = nil
+	if count > 0 {
+		s.semaphore = pkgsync.NewSemaphore(count)
 	}
 }
 
+// MaxConnections - returns the maximum number of concurrent connections, zero means no limit.
+func (s *Server) MaxConnections() uint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.maxConnections
+}
+
+// SetIdleTimeout - changes the idle timeout for client connections at runtime.
+func (s *Server) SetIdleTimeout(timeout time.Duration) {
+	if timeout == 0 {
+		timeout = defaultIdleTimeout
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.idleTimeout = timeout
+}
+
+// IdleTimeout - returns the idle timeout for client connections.
+func (s *Server) IdleTimeout() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.idleTimeout
+}
+
+func (s *Server) connectionsSemaphore() *pkgsync.Semaphore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.semaphore
+}
+
 // ActiveConnections - returns the current number of active connections atomically.
 func (s *Server) ActiveConnections() int32 {
 	return atomic.LoadInt32(&s.activeConnections)
@@ -2,11 +2,15 @@ package tcp
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/neekrasov/kvdb/internal/database/compression"
 	"github.com/neekrasov/kvdb/pkg/logger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -62,7 +66,7 @@ func TestServer(t *testing.T) {
 		firstConn, clientErr := net.Dial("tcp", serverAddress)
 		require.NoError(t, clientErr)
 
-		_, clientErr = firstConn.Write([]byte("client-1"))
+		_, clientErr = firstConn.Write([]byte("client-1\n"))
 		require.NoError(t, clientErr)
 
 		buffer := make([]byte, 1024)
@@ -82,7 +86,7 @@ func TestServer(t *testing.T) {
 		secondConn, clientErr := net.Dial("tcp", serverAddress)
 		require.NoError(t, clientErr)
 
-		_, clientErr = secondConn.Write([]byte("client-2"))
+		_, clientErr = secondConn.Write([]byte("client-2\n"))
 		require.NoError(t, clientErr)
 
 		buffer := make([]byte, 1024)
@@ -102,3 +106,630 @@ func TestServer(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestServer_CloseSession(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverAddress := "localhost:22224"
+	sessionCh := make(chan string, 1)
+	server, err := NewServer(serverAddress, WithConnectionHandler(
+		func(ctx context.Context, sessionID string, conn net.Conn) error {
+			sessionCh <- sessionID
+			return nil
+		},
+	))
+	require.NoError(t, err)
+	defer server.Close()
+
+	go server.Start(ctx, func(ctx context.Context, _ string, data []byte) []byte {
+		return data
+	})
+
+	conn, err := net.Dial("tcp", serverAddress)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	sessionID := <-sessionCh
+	assert.False(t, server.CloseSession("unknown"))
+	assert.True(t, server.CloseSession(sessionID))
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, err = conn.Read(make([]byte, 16))
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestServer_ProtocolDetection(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverAddress := "localhost:22225"
+	server, err := NewServer(serverAddress, WithServerProtocol(ProtocolAuto))
+	require.NoError(t, err)
+	defer server.Close()
+
+	go server.Start(ctx, func(ctx context.Context, _ string, data []byte) []byte {
+		return []byte("[ok] " + string(data))
+	})
+
+	t.Run("Text command", func(t *testing.T) {
+		conn, err := net.Dial("tcp", serverAddress)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Write([]byte("get key\n"))
+		require.NoError(t, err)
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		buffer := make([]byte, 1024)
+		n, err := conn.Read(buffer)
+		require.NoError(t, err)
+		assert.Equal(t, "[ok] get key", string(buffer[:n]))
+	})
+
+	t.Run("Binary command", func(t *testing.T) {
+		conn, err := net.Dial("tcp", serverAddress)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Write(EncodeFrame([]byte("get key")))
+		require.NoError(t, err)
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		payload, err := ReadFrame(conn, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "[ok] get key", string(payload))
+	})
+}
+
+func TestServer_CommandTimeout(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverAddress := "localhost:22226"
+	server, err := NewServer(serverAddress, WithServerCommandTimeout(50*time.Millisecond))
+	require.NoError(t, err)
+	defer server.Close()
+
+	go server.Start(ctx, func(ctx context.Context, _ string, data []byte) []byte {
+		switch string(data) {
+		case "sleep":
+			// The handler ignores the context and runs past the timeout.
+			time.Sleep(200 * time.Millisecond)
+			return []byte("[ok] slept")
+		case "wait":
+			<-ctx.Done()
+			return []byte("[ok] stopped")
+		default:
+			return []byte("[ok] " + string(data))
+		}
+	})
+
+	conn, err := net.Dial("tcp", serverAddress)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	send := func(command string) string {
+		_, err := conn.Write([]byte(command + "\n"))
+		require.NoError(t, err)
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		buffer := make([]byte, 1024)
+		n, err := conn.Read(buffer)
+		require.NoError(t, err)
+
+		return string(buffer[:n])
+	}
+
+	assert.Equal(t, "[error] command timed out", send("sleep"))
+	assert.Equal(t, "[error] command timed out", send("wait"))
+
+	// The late response of the timed out handler is dropped.
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, "[ok] get key", send("get key"))
+
+	// CANCEL aborts the command before the timeout.
+	_, err = conn.Write([]byte("wait\n"))
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, "[ok] stopped", send(cancelCommand))
+}
+
+func TestServer_RequestTooLarge(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverAddress := "localhost:22230"
+	server, err := NewServer(serverAddress, WithServerBufferSize(16), WithServerProtocol(ProtocolAuto))
+	require.NoError(t, err)
+	defer server.Close()
+
+	var handled []string
+	var mu sync.Mutex
+	go server.Start(ctx, func(_ context.Context, _ string, data []byte) []byte {
+		mu.Lock()
+		handled = append(handled, string(data))
+		mu.Unlock()
+
+		return []byte("[ok] " + string(data))
+	})
+
+	t.Run("text", func(t *testing.T) {
+		conn, err := net.Dial("tcp", serverAddress)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		send := func(parts ...string) string {
+			for _, part := range parts {
+				_, err := conn.Write([]byte(part))
+				require.NoError(t, err)
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+			buffer := make([]byte, 1024)
+			n, err := conn.Read(buffer)
+			require.NoError(t, err)
+
+			return string(buffer[:n])
+		}
+
+		assert.Equal(t, "[error] request too large", send("set key "+strings.Repeat("v", 32)+"\n"))
+		// The command of the buffer size does not fit together with the terminator.
+		assert.Equal(t, "[error] request too large", send(strings.Repeat("k", 16)+"\n"))
+		assert.Equal(t, "[error] request too large", send("set key ", strings.Repeat("v", 12), "\n"))
+		assert.Equal(t, "[ok] get key", send("get", " key\n"))
+		assert.Equal(t, "[ok] "+strings.Repeat("k", 15), send(strings.Repeat("k", 15)+"\n"))
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		conn, err := net.Dial("tcp", serverAddress)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		send := func(command string) string {
+			_, err := conn.Write(EncodeFrame([]byte(command)))
+			require.NoError(t, err)
+
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+			payload, err := ReadFrame(conn, 0)
+			require.NoError(t, err)
+
+			return string(payload)
+		}
+
+		assert.Equal(t, "[error] request too large", send("set key "+strings.Repeat("v", 32)))
+		assert.Equal(t, "[ok] get key", send("get key"))
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"get key", strings.Repeat("k", 15), "get key"}, handled)
+}
+
+func TestServer_Compression(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverAddress := "localhost:22227"
+	server, err := NewServer(serverAddress,
+		WithServerCompression(true),
+		WithServerBufferSize(64<<10),
+		WithConnectionHandler(func(ctx context.Context, sessionID string, conn net.Conn) error {
+			buffer := make([]byte, 1024)
+			n, err := Read(conn, buffer, len(buffer))
+			if err != nil {
+				return err
+			}
+
+			_, err = conn.Write([]byte("[ok] hello " + string(buffer[:n])))
+			return err
+		}),
+	)
+	require.NoError(t, err)
+	defer server.Close()
+
+	large := "[ok] " + strings.Repeat("value", 4<<10)
+	go server.Start(ctx, func(ctx context.Context, _ string, data []byte) []byte {
+		if string(data) == "large" {
+			return []byte(large)
+		}
+
+		return []byte("[ok] " + string(data))
+	})
+
+	t.Run("on the wire", func(t *testing.T) {
+		conn, err := net.Dial("tcp", serverAddress)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		send := func(command string) []byte {
+			_, err := conn.Write([]byte(command + "\n"))
+			require.NoError(t, err)
+
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+			buffer := make([]byte, 64<<10)
+			n, err := conn.Read(buffer)
+			require.NoError(t, err)
+
+			return buffer[:n]
+		}
+
+		assert.Equal(t, "[ok]", string(send(compressCommand+" gzip")))
+
+		// The connection handler reads the login after the handshake.
+		assert.Equal(t, append([]byte{rawPayload}, "[ok] hello login"...), send("login"))
+		assert.Equal(t, append([]byte{rawPayload}, "[ok] get key"...), send("get key"))
+
+		payload := send("large")
+		require.Equal(t, compressedPayload, payload[0])
+		assert.Less(t, len(payload), len(large))
+
+		data, err := new(compression.GzipCompressor).Decompress(payload[1:])
+		require.NoError(t, err)
+		assert.Equal(t, large, string(data))
+	})
+
+	t.Run("client", func(t *testing.T) {
+		client, err := NewClient(serverAddress, WithClientCompression("zstd"), WithClientBufferSize(64<<10))
+		require.NoError(t, err)
+		defer client.Close()
+
+		res, err := client.Send(ctx, []byte("login"))
+		require.NoError(t, err)
+		assert.Equal(t, "[ok] hello login", string(res))
+
+		res, err = client.Send(ctx, []byte("large"))
+		require.NoError(t, err)
+		assert.Equal(t, large, string(res))
+	})
+
+	t.Run("unknown codec", func(t *testing.T) {
+		conn, err := net.Dial("tcp", serverAddress)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Write([]byte(compressCommand + " unknown\n"))
+		require.NoError(t, err)
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		buffer := make([]byte, 1024)
+		n, err := conn.Read(buffer)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(buffer[:n]), "[error]"))
+
+		// The connection stays uncompressed.
+		_, err = conn.Write([]byte("login\n"))
+		require.NoError(t, err)
+		n, err = conn.Read(buffer)
+		require.NoError(t, err)
+		assert.Equal(t, "[ok] hello login", string(buffer[:n]))
+	})
+}
+
+func TestServer_HealthCheck(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverAddress := "localhost:22228"
+	server, err := NewServer(serverAddress,
+		WithServerHealthCheck(func() []byte { return []byte("[ok] healthy") }),
+		WithConnectionHandler(func(ctx context.Context, sessionID string, conn net.Conn) error {
+			buffer := make([]byte, 1024)
+			n, err := Read(conn, buffer, len(buffer))
+			if err != nil {
+				return err
+			}
+
+			_, err = conn.Write([]byte("[ok] hello " + string(buffer[:n])))
+			return err
+		}),
+	)
+	require.NoError(t, err)
+	defer server.Close()
+
+	go server.Start(ctx, func(ctx context.Context, _ string, data []byte) []byte {
+		return data
+	})
+
+	read := func(conn net.Conn) (string, error) {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		buffer := make([]byte, 1024)
+		n, err := conn.Read(buffer)
+
+		return string(buffer[:n]), err
+	}
+
+	t.Run("probe without login", func(t *testing.T) {
+		conn, err := net.Dial("tcp", serverAddress)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Write([]byte(healthCommand + "\n"))
+		require.NoError(t, err)
+
+		res, err := read(conn)
+		require.NoError(t, err)
+		assert.Equal(t, "[ok] healthy", res)
+
+		// The probe connection is closed after the answer.
+		_, err = read(conn)
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("login", func(t *testing.T) {
+		conn, err := net.Dial("tcp", serverAddress)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Write([]byte("login\n"))
+		require.NoError(t, err)
+
+		res, err := read(conn)
+		require.NoError(t, err)
+		assert.Equal(t, "[ok] hello login", res)
+	})
+}
+
+func TestServer_Connections(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverAddress := "localhost:22229"
+	server, err := NewServer(serverAddress)
+	require.NoError(t, err)
+	defer server.Close()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	go server.Start(ctx, func(ctx context.Context, _ string, data []byte) []byte {
+		close(started)
+		<-release
+		return []byte("[ok]")
+	})
+
+	conn, err := net.Dial("tcp", serverAddress)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("get secret_key\n"))
+	require.NoError(t, err)
+	<-started
+
+	conns := server.Connections()
+	require.Len(t, conns, 1)
+	assert.NotEmpty(t, conns[0].SessionID)
+	assert.Equal(t, conn.LocalAddr().String(), conns[0].RemoteAddr)
+	assert.False(t, conns[0].ConnectedAt.IsZero())
+	assert.Equal(t, int64(len("get secret_key\n")), conns[0].BytesRead)
+	assert.Equal(t, "get", conns[0].Command)
+
+	close(release)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, err = conn.Read(make([]byte, 16))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		conns := server.Connections()
+		return len(conns) == 1 && conns[0].Command == "" && conns[0].BytesWritten == int64(len("[ok]"))
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, conn.Close())
+	require.Eventually(t, func() bool {
+		return len(server.Connections()) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestServer_IdleTimeoutNegotiation(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := func(address string, opts ...ServerOption) *Server {
+		server, err := NewServer(address, append(opts, WithServerIdleTimeout(time.Minute))...)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = server.Close() })
+
+		go server.Start(ctx, func(_ context.Context, _ string, data []byte) []byte {
+			return []byte("[ok] " + string(data))
+		})
+
+		return server
+	}
+
+	negotiated := func(server *Server, address string, opts ...ClientOption) time.Duration {
+		client, err := NewClient(address, opts...)
+		require.NoError(t, err)
+		defer client.Close()
+
+		res, err := client.Send(ctx, []byte("ping"))
+		require.NoError(t, err)
+		assert.Equal(t, "[ok] ping", string(res))
+
+		conns := server.Connections()
+		require.Len(t, conns, 1)
+		require.NoError(t, client.Close())
+		require.Eventually(t, func() bool {
+			return len(server.Connections()) == 0
+		}, time.Second, 10*time.Millisecond)
+
+		return conns[0].IdleTimeout
+	}
+
+	capped := start("localhost:22231", WithServerMaxIdleTimeout(10*time.Minute), WithServerCompression(true))
+	assert.Equal(t, 5*time.Minute,
+		negotiated(capped, "localhost:22231", WithClientServerIdleTimeout(5*time.Minute)))
+	assert.Equal(t, 10*time.Minute,
+		negotiated(capped, "localhost:22231", WithClientServerIdleTimeout(time.Hour), WithClientCompression("gzip")))
+	assert.Zero(t, negotiated(capped, "localhost:22231"))
+
+	// Without the maximum the clients may only shorten the idle timeout.
+	uncapped := start("localhost:22232", WithServerHealthCheck(func() []byte { return []byte("[ok]") }))
+	assert.Equal(t, time.Minute,
+		negotiated(uncapped, "localhost:22232", WithClientServerIdleTimeout(time.Hour)))
+	assert.Equal(t, time.Second,
+		negotiated(uncapped, "localhost:22232", WithClientServerIdleTimeout(time.Second)))
+}
+
+func TestServer_IdleTimeout(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverAddress := "localhost:22233"
+	server, err := NewServer(serverAddress, WithServerIdleTimeout(200*time.Millisecond))
+	require.NoError(t, err)
+	defer server.Close()
+
+	go server.Start(ctx, func(ctx context.Context, _ string, data []byte) []byte {
+		if string(data) == "watch" {
+			// The running command outlives the idle timeout, the connection must stay open.
+			time.Sleep(500 * time.Millisecond)
+		}
+		return []byte("[ok] " + string(data))
+	})
+
+	conn, err := net.Dial("tcp", serverAddress)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	buffer := make([]byte, 32)
+	_, err = conn.Write([]byte("watch\n"))
+	require.NoError(t, err)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, err := conn.Read(buffer)
+	require.NoError(t, err)
+	assert.Equal(t, "[ok] watch", string(buffer[:n]))
+
+	// The activity resets the idle timeout.
+	for range 3 {
+		time.Sleep(100 * time.Millisecond)
+		_, err = conn.Write([]byte("ping\n"))
+		require.NoError(t, err)
+		n, err = conn.Read(buffer)
+		require.NoError(t, err)
+		assert.Equal(t, "[ok] ping", string(buffer[:n]))
+	}
+
+	started := time.Now()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	_, err = conn.Read(buffer)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Less(t, time.Since(started), time.Second)
+
+	require.Eventually(t, func() bool {
+		return len(server.Connections()) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestServer_AuthTimeout(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverAddress := "localhost:22234"
+	server, err := NewServer(serverAddress,
+		WithServerAuthTimeout(200*time.Millisecond),
+		WithConnectionHandler(func(_ context.Context, _ string, conn net.Conn) error {
+			buffer := make([]byte, 32)
+			n, err := conn.Read(buffer)
+			if err != nil {
+				return err
+			}
+
+			if string(buffer[:n]) != "login" {
+				_, _ = conn.Write([]byte("[error] failed"))
+				return errors.New("login failed")
+			}
+
+			_, err = conn.Write([]byte("[ok] authenticated"))
+			return err
+		}),
+	)
+	require.NoError(t, err)
+	defer server.Close()
+
+	go server.Start(ctx, func(ctx context.Context, _ string, data []byte) []byte {
+		return []byte("[ok] " + string(data))
+	})
+
+	buffer := make([]byte, 32)
+	t.Run("never authenticates", func(t *testing.T) {
+		conn, err := net.Dial("tcp", serverAddress)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		started := time.Now()
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+		_, err = conn.Read(buffer)
+		assert.ErrorIs(t, err, io.EOF)
+		assert.Less(t, time.Since(started), time.Second)
+	})
+
+	t.Run("failed login", func(t *testing.T) {
+		conn, err := net.Dial("tcp", serverAddress)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Write([]byte("wrong\n"))
+		require.NoError(t, err)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+		n, err := conn.Read(buffer)
+		require.NoError(t, err)
+		assert.Equal(t, "[error] failed", string(buffer[:n]))
+
+		_, err = conn.Read(buffer)
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("authenticated", func(t *testing.T) {
+		conn, err := net.Dial("tcp", serverAddress)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Write([]byte("login\n"))
+		require.NoError(t, err)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+		n, err := conn.Read(buffer)
+		require.NoError(t, err)
+		assert.Equal(t, "[ok] authenticated", string(buffer[:n]))
+
+		// The authenticated connection outlives the auth timeout.
+		time.Sleep(300 * time.Millisecond)
+		_, err = conn.Write([]byte("ping\n"))
+		require.NoError(t, err)
+		n, err = conn.Read(buffer)
+		require.NoError(t, err)
+		assert.Equal(t, "[ok] ping", string(buffer[:n]))
+	})
+
+	require.Eventually(t, func() bool {
+		return len(server.Connections()) == 0
+	}, time.Second, 10*time.Millisecond)
+}
@@ -6,6 +6,8 @@ import (
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+
+	models "github.com/neekrasov/kvdb/internal/database/identity/models"
 )
 
 // NamespacesStorage is an autogenerated mock type for the NamespacesStorage type
@@ -174,6 +176,65 @@ func (_c *NamespacesStorage_Exists_Call) RunAndReturn(run func(context.Context,
 	return _c
 }
 
+// Get provides a mock function with given fields: ctx, namespace
+func (_m *NamespacesStorage) Get(ctx context.Context, namespace string) (*models.Namespace, error) {
+	ret := _m.Called(ctx, namespace)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 *models.Namespace
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.Namespace, error)); ok {
+		return rf(ctx, namespace)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Namespace); ok {
+		r0 = rf(ctx, namespace)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Namespace)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, namespace)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NamespacesStorage_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
+type NamespacesStorage_Get_Call struct {
+	*mock.Call
+}
+
+// Get is a helper method to define mock.On call
+//   - ctx context.Context
+//   - namespace string
+func (_e *NamespacesStorage_Expecter) Get(ctx interface{}, namespace interface{}) *NamespacesStorage_Get_Call {
+	return &NamespacesStorage_Get_Call{Call: _e.mock.On("Get", ctx, namespace)}
+}
+
+func (_c *NamespacesStorage_Get_Call) Run(run func(ctx context.Context, namespace string)) *NamespacesStorage_Get_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *NamespacesStorage_Get_Call) Return(_a0 *models.Namespace, _a1 error) *NamespacesStorage_Get_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *NamespacesStorage_Get_Call) RunAndReturn(run func(context.Context, string) (*models.Namespace, error)) *NamespacesStorage_Get_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // List provides a mock function with given fields: ctx
 func (_m *NamespacesStorage) List(ctx context.Context) ([]string, error) {
 	ret := _m.Called(ctx)
@@ -233,7 +294,7 @@ func (_c *NamespacesStorage_List_Call) RunAndReturn(run func(context.Context) ([
 }
 
 // Save provides a mock function with given fields: ctx, namespace
-func (_m *NamespacesStorage) Save(ctx context.Context, namespace string) error {
+func (_m *NamespacesStorage) Save(ctx context.Context, namespace *models.Namespace) error {
 	ret := _m.Called(ctx, namespace)
 
 	if len(ret) == 0 {
@@ -241,7 +302,7 @@ func (_m *NamespacesStorage) Save(ctx context.Context, namespace string) error {
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Namespace) error); ok {
 		r0 = rf(ctx, namespace)
 	} else {
 		r0 = ret.Error(0)
@@ -257,14 +318,14 @@ type NamespacesStorage_Save_Call struct {
 
 // Save is a helper method to define mock.On call
 //   - ctx context.Context
-//   - namespace string
+//   - namespace *models.Namespace
 func (_e *NamespacesStorage_Expecter) Save(ctx interface{}, namespace interface{}) *NamespacesStorage_Save_Call {
 	return &NamespacesStorage_Save_Call{Call: _e.mock.On("Save", ctx, namespace)}
 }
 
-func (_c *NamespacesStorage_Save_Call) Run(run func(ctx context.Context, namespace string)) *NamespacesStorage_Save_Call {
+func (_c *NamespacesStorage_Save_Call) Run(run func(ctx context.Context, namespace *models.Namespace)) *NamespacesStorage_Save_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string))
+		run(args[0].(context.Context), args[1].(*models.Namespace))
 	})
 	return _c
 }
@@ -274,7 +335,7 @@ func (_c *NamespacesStorage_Save_Call) Return(_a0 error) *NamespacesStorage_Save
 	return _c
 }
 
-func (_c *NamespacesStorage_Save_Call) RunAndReturn(run func(context.Context, string) error) *NamespacesStorage_Save_Call {
+func (_c *NamespacesStorage_Save_Call) RunAndReturn(run func(context.Context, *models.Namespace) error) *NamespacesStorage_Save_Call {
 	_c.Call.Return(run)
 	return _c
 }
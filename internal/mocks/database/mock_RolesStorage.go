@@ -293,6 +293,53 @@ func (_c *RolesStorage_Save_Call) RunAndReturn(run func(context.Context, *models
 	return _c
 }
 
+// Update provides a mock function with given fields: ctx, role
+func (_m *RolesStorage) Update(ctx context.Context, role *models.Role) error {
+	ret := _m.Called(ctx, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Role) error); ok {
+		r0 = rf(ctx, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RolesStorage_Update_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Update'
+type RolesStorage_Update_Call struct {
+	*mock.Call
+}
+
+// Update is a helper method to define mock.On call
+//   - ctx context.Context
+//   - role *models.Role
+func (_e *RolesStorage_Expecter) Update(ctx interface{}, role interface{}) *RolesStorage_Update_Call {
+	return &RolesStorage_Update_Call{Call: _e.mock.On("Update", ctx, role)}
+}
+
+func (_c *RolesStorage_Update_Call) Run(run func(ctx context.Context, role *models.Role)) *RolesStorage_Update_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*models.Role))
+	})
+	return _c
+}
+
+func (_c *RolesStorage_Update_Call) Return(_a0 error) *RolesStorage_Update_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *RolesStorage_Update_Call) RunAndReturn(run func(context.Context, *models.Role) error) *RolesStorage_Update_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewRolesStorage creates a new instance of RolesStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewRolesStorage(t interface {
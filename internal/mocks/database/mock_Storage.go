@@ -5,94 +5,866 @@ package mocks
 import (
 	context "context"
 
+	engine "github.com/neekrasov/kvdb/internal/database/storage/engine"
+
 	mock "github.com/stretchr/testify/mock"
 
-	storage "github.com/neekrasov/kvdb/internal/database/storage"
+	snapshot "github.com/neekrasov/kvdb/internal/database/storage/snapshot"
+
+	storage "github.com/neekrasov/kvdb/internal/database/storage"
+
+	sync "github.com/neekrasov/kvdb/pkg/sync"
+
+	time "time"
+
+	wal "github.com/neekrasov/kvdb/internal/database/storage/wal"
+)
+
+// Storage is an autogenerated mock type for the Storage type
+type Storage struct {
+	mock.Mock
+}
+
+type Storage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Storage) EXPECT() *Storage_Expecter {
+	return &Storage_Expecter{mock: &_m.Mock}
+}
+
+// CompactWAL provides a mock function with no fields
+func (_m *Storage) CompactWAL() (wal.CompactionResult, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for CompactWAL")
+	}
+
+	var r0 wal.CompactionResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (wal.CompactionResult, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() wal.CompactionResult); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(wal.CompactionResult)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_CompactWAL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CompactWAL'
+type Storage_CompactWAL_Call struct {
+	*mock.Call
+}
+
+// CompactWAL is a helper method to define mock.On call
+func (_e *Storage_Expecter) CompactWAL() *Storage_CompactWAL_Call {
+	return &Storage_CompactWAL_Call{Call: _e.mock.On("CompactWAL")}
+}
+
+func (_c *Storage_CompactWAL_Call) Run(run func()) *Storage_CompactWAL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Storage_CompactWAL_Call) Return(_a0 wal.CompactionResult, _a1 error) *Storage_CompactWAL_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_CompactWAL_Call) RunAndReturn(run func() (wal.CompactionResult, error)) *Storage_CompactWAL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CountByPrefix provides a mock function with given fields: prefix
+func (_m *Storage) CountByPrefix(prefix string) int {
+	ret := _m.Called(prefix)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountByPrefix")
+	}
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(string) int); ok {
+		r0 = rf(prefix)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
+// Storage_CountByPrefix_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountByPrefix'
+type Storage_CountByPrefix_Call struct {
+	*mock.Call
+}
+
+// CountByPrefix is a helper method to define mock.On call
+//   - prefix string
+func (_e *Storage_Expecter) CountByPrefix(prefix interface{}) *Storage_CountByPrefix_Call {
+	return &Storage_CountByPrefix_Call{Call: _e.mock.On("CountByPrefix", prefix)}
+}
+
+func (_c *Storage_CountByPrefix_Call) Run(run func(prefix string)) *Storage_CountByPrefix_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Storage_CountByPrefix_Call) Return(_a0 int) *Storage_CountByPrefix_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Storage_CountByPrefix_Call) RunAndReturn(run func(string) int) *Storage_CountByPrefix_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Del provides a mock function with given fields: ctx, key
+func (_m *Storage) Del(ctx context.Context, key string) (bool, error) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Del")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_Del_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Del'
+type Storage_Del_Call struct {
+	*mock.Call
+}
+
+// Del is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *Storage_Expecter) Del(ctx interface{}, key interface{}) *Storage_Del_Call {
+	return &Storage_Del_Call{Call: _e.mock.On("Del", ctx, key)}
+}
+
+func (_c *Storage_Del_Call) Run(run func(ctx context.Context, key string)) *Storage_Del_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Storage_Del_Call) Return(_a0 bool, _a1 error) *Storage_Del_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_Del_Call) RunAndReturn(run func(context.Context, string) (bool, error)) *Storage_Del_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DelByPrefix provides a mock function with given fields: ctx, prefix
+func (_m *Storage) DelByPrefix(ctx context.Context, prefix string) (int, error) {
+	ret := _m.Called(ctx, prefix)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DelByPrefix")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return rf(ctx, prefix)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, prefix)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, prefix)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_DelByPrefix_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DelByPrefix'
+type Storage_DelByPrefix_Call struct {
+	*mock.Call
+}
+
+// DelByPrefix is a helper method to define mock.On call
+//   - ctx context.Context
+//   - prefix string
+func (_e *Storage_Expecter) DelByPrefix(ctx interface{}, prefix interface{}) *Storage_DelByPrefix_Call {
+	return &Storage_DelByPrefix_Call{Call: _e.mock.On("DelByPrefix", ctx, prefix)}
+}
+
+func (_c *Storage_DelByPrefix_Call) Run(run func(ctx context.Context, prefix string)) *Storage_DelByPrefix_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Storage_DelByPrefix_Call) Return(_a0 int, _a1 error) *Storage_DelByPrefix_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_DelByPrefix_Call) RunAndReturn(run func(context.Context, string) (int, error)) *Storage_DelByPrefix_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DelMany provides a mock function with given fields: ctx, keys
+func (_m *Storage) DelMany(ctx context.Context, keys []string) (int, error) {
+	ret := _m.Called(ctx, keys)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DelMany")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) (int, error)); ok {
+		return rf(ctx, keys)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) int); ok {
+		r0 = rf(ctx, keys)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, keys)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_DelMany_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DelMany'
+type Storage_DelMany_Call struct {
+	*mock.Call
+}
+
+// DelMany is a helper method to define mock.On call
+//   - ctx context.Context
+//   - keys []string
+func (_e *Storage_Expecter) DelMany(ctx interface{}, keys interface{}) *Storage_DelMany_Call {
+	return &Storage_DelMany_Call{Call: _e.mock.On("DelMany", ctx, keys)}
+}
+
+func (_c *Storage_DelMany_Call) Run(run func(ctx context.Context, keys []string)) *Storage_DelMany_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]string))
+	})
+	return _c
+}
+
+func (_c *Storage_DelMany_Call) Return(_a0 int, _a1 error) *Storage_DelMany_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_DelMany_Call) RunAndReturn(run func(context.Context, []string) (int, error)) *Storage_DelMany_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Entry provides a mock function with given fields: ctx, key
+func (_m *Storage) Entry(ctx context.Context, key string) (engine.Entry, error) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Entry")
+	}
+
+	var r0 engine.Entry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (engine.Entry, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) engine.Entry); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(engine.Entry)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_Entry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Entry'
+type Storage_Entry_Call struct {
+	*mock.Call
+}
+
+// Entry is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *Storage_Expecter) Entry(ctx interface{}, key interface{}) *Storage_Entry_Call {
+	return &Storage_Entry_Call{Call: _e.mock.On("Entry", ctx, key)}
+}
+
+func (_c *Storage_Entry_Call) Run(run func(ctx context.Context, key string)) *Storage_Entry_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Storage_Entry_Call) Return(_a0 engine.Entry, _a1 error) *Storage_Entry_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_Entry_Call) RunAndReturn(run func(context.Context, string) (engine.Entry, error)) *Storage_Entry_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Expiring provides a mock function with given fields: prefix, within
+func (_m *Storage) Expiring(prefix string, within time.Duration) []string {
+	ret := _m.Called(prefix, within)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Expiring")
+	}
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(string, time.Duration) []string); ok {
+		r0 = rf(prefix, within)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	return r0
+}
+
+// Storage_Expiring_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Expiring'
+type Storage_Expiring_Call struct {
+	*mock.Call
+}
+
+// Expiring is a helper method to define mock.On call
+//   - prefix string
+//   - within time.Duration
+func (_e *Storage_Expecter) Expiring(prefix interface{}, within interface{}) *Storage_Expiring_Call {
+	return &Storage_Expiring_Call{Call: _e.mock.On("Expiring", prefix, within)}
+}
+
+func (_c *Storage_Expiring_Call) Run(run func(prefix string, within time.Duration)) *Storage_Expiring_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *Storage_Expiring_Call) Return(_a0 []string) *Storage_Expiring_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Storage_Expiring_Call) RunAndReturn(run func(string, time.Duration) []string) *Storage_Expiring_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Export provides a mock function with given fields: prefix
+func (_m *Storage) Export(prefix string) []snapshot.Entry {
+	ret := _m.Called(prefix)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Export")
+	}
+
+	var r0 []snapshot.Entry
+	if rf, ok := ret.Get(0).(func(string) []snapshot.Entry); ok {
+		r0 = rf(prefix)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]snapshot.Entry)
+		}
+	}
+
+	return r0
+}
+
+// Storage_Export_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Export'
+type Storage_Export_Call struct {
+	*mock.Call
+}
+
+// Export is a helper method to define mock.On call
+//   - prefix string
+func (_e *Storage_Expecter) Export(prefix interface{}) *Storage_Export_Call {
+	return &Storage_Export_Call{Call: _e.mock.On("Export", prefix)}
+}
+
+func (_c *Storage_Export_Call) Run(run func(prefix string)) *Storage_Export_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Storage_Export_Call) Return(_a0 []snapshot.Entry) *Storage_Export_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Storage_Export_Call) RunAndReturn(run func(string) []snapshot.Entry) *Storage_Export_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Get provides a mock function with given fields: ctx, key
+func (_m *Storage) Get(ctx context.Context, key string) (string, error) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
+type Storage_Get_Call struct {
+	*mock.Call
+}
+
+// Get is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *Storage_Expecter) Get(ctx interface{}, key interface{}) *Storage_Get_Call {
+	return &Storage_Get_Call{Call: _e.mock.On("Get", ctx, key)}
+}
+
+func (_c *Storage_Get_Call) Run(run func(ctx context.Context, key string)) *Storage_Get_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Storage_Get_Call) Return(_a0 string, _a1 error) *Storage_Get_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_Get_Call) RunAndReturn(run func(context.Context, string) (string, error)) *Storage_Get_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetDel provides a mock function with given fields: ctx, key
+func (_m *Storage) GetDel(ctx context.Context, key string) (string, error) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDel")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_GetDel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDel'
+type Storage_GetDel_Call struct {
+	*mock.Call
+}
+
+// GetDel is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *Storage_Expecter) GetDel(ctx interface{}, key interface{}) *Storage_GetDel_Call {
+	return &Storage_GetDel_Call{Call: _e.mock.On("GetDel", ctx, key)}
+}
+
+func (_c *Storage_GetDel_Call) Run(run func(ctx context.Context, key string)) *Storage_GetDel_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Storage_GetDel_Call) Return(_a0 string, _a1 error) *Storage_GetDel_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_GetDel_Call) RunAndReturn(run func(context.Context, string) (string, error)) *Storage_GetDel_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetSet provides a mock function with given fields: ctx, key, value
+func (_m *Storage) GetSet(ctx context.Context, key string, value string) (string, bool, error) {
+	ret := _m.Called(ctx, key, value)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSet")
+	}
+
+	var r0 string
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, bool, error)); ok {
+		return rf(ctx, key, value)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, key, value)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) bool); ok {
+		r1 = rf(ctx, key, value)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
+		r2 = rf(ctx, key, value)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Storage_GetSet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSet'
+type Storage_GetSet_Call struct {
+	*mock.Call
+}
+
+// GetSet is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - value string
+func (_e *Storage_Expecter) GetSet(ctx interface{}, key interface{}, value interface{}) *Storage_GetSet_Call {
+	return &Storage_GetSet_Call{Call: _e.mock.On("GetSet", ctx, key, value)}
+}
+
+func (_c *Storage_GetSet_Call) Run(run func(ctx context.Context, key string, value string)) *Storage_GetSet_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *Storage_GetSet_Call) Return(_a0 string, _a1 bool, _a2 error) *Storage_GetSet_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *Storage_GetSet_Call) RunAndReturn(run func(context.Context, string, string) (string, bool, error)) *Storage_GetSet_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LastAccess provides a mock function with given fields: ctx, key
+func (_m *Storage) LastAccess(ctx context.Context, key string) (time.Time, error) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LastAccess")
+	}
+
+	var r0 time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (time.Time, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) time.Time); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_LastAccess_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LastAccess'
+type Storage_LastAccess_Call struct {
+	*mock.Call
+}
+
+// LastAccess is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *Storage_Expecter) LastAccess(ctx interface{}, key interface{}) *Storage_LastAccess_Call {
+	return &Storage_LastAccess_Call{Call: _e.mock.On("LastAccess", ctx, key)}
+}
+
+func (_c *Storage_LastAccess_Call) Run(run func(ctx context.Context, key string)) *Storage_LastAccess_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Storage_LastAccess_Call) Return(_a0 time.Time, _a1 error) *Storage_LastAccess_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_LastAccess_Call) RunAndReturn(run func(context.Context, string) (time.Time, error)) *Storage_LastAccess_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MSetNX provides a mock function with given fields: ctx, keys, values
+func (_m *Storage) MSetNX(ctx context.Context, keys []string, values []string) (bool, error) {
+	ret := _m.Called(ctx, keys, values)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MSetNX")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string, []string) (bool, error)); ok {
+		return rf(ctx, keys, values)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string, []string) bool); ok {
+		r0 = rf(ctx, keys, values)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string, []string) error); ok {
+		r1 = rf(ctx, keys, values)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_MSetNX_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MSetNX'
+type Storage_MSetNX_Call struct {
+	*mock.Call
+}
+
+// MSetNX is a helper method to define mock.On call
+//   - ctx context.Context
+//   - keys []string
+//   - values []string
+func (_e *Storage_Expecter) MSetNX(ctx interface{}, keys interface{}, values interface{}) *Storage_MSetNX_Call {
+	return &Storage_MSetNX_Call{Call: _e.mock.On("MSetNX", ctx, keys, values)}
+}
+
+func (_c *Storage_MSetNX_Call) Run(run func(ctx context.Context, keys []string, values []string)) *Storage_MSetNX_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]string), args[2].([]string))
+	})
+	return _c
+}
+
+func (_c *Storage_MSetNX_Call) Return(_a0 bool, _a1 error) *Storage_MSetNX_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_MSetNX_Call) RunAndReturn(run func(context.Context, []string, []string) (bool, error)) *Storage_MSetNX_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MaxSize provides a mock function with given fields: key
+func (_m *Storage) MaxSize(key string) int {
+	ret := _m.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MaxSize")
+	}
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(string) int); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
+// Storage_MaxSize_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MaxSize'
+type Storage_MaxSize_Call struct {
+	*mock.Call
+}
 
-	sync "github.com/neekrasov/kvdb/pkg/sync"
-)
+// MaxSize is a helper method to define mock.On call
+//   - key string
+func (_e *Storage_Expecter) MaxSize(key interface{}) *Storage_MaxSize_Call {
+	return &Storage_MaxSize_Call{Call: _e.mock.On("MaxSize", key)}
+}
 
-// Storage is an autogenerated mock type for the Storage type
-type Storage struct {
-	mock.Mock
+func (_c *Storage_MaxSize_Call) Run(run func(key string)) *Storage_MaxSize_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
 }
 
-type Storage_Expecter struct {
-	mock *mock.Mock
+func (_c *Storage_MaxSize_Call) Return(_a0 int) *Storage_MaxSize_Call {
+	_c.Call.Return(_a0)
+	return _c
 }
 
-func (_m *Storage) EXPECT() *Storage_Expecter {
-	return &Storage_Expecter{mock: &_m.Mock}
+func (_c *Storage_MaxSize_Call) RunAndReturn(run func(string) int) *Storage_MaxSize_Call {
+	_c.Call.Return(run)
+	return _c
 }
 
-// Del provides a mock function with given fields: ctx, key
-func (_m *Storage) Del(ctx context.Context, key string) error {
-	ret := _m.Called(ctx, key)
+// Readiness provides a mock function with no fields
+func (_m *Storage) Readiness() storage.Readiness {
+	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for Del")
+		panic("no return value specified for Readiness")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, key)
+	var r0 storage.Readiness
+	if rf, ok := ret.Get(0).(func() storage.Readiness); ok {
+		r0 = rf()
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(storage.Readiness)
 	}
 
 	return r0
 }
 
-// Storage_Del_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Del'
-type Storage_Del_Call struct {
+// Storage_Readiness_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Readiness'
+type Storage_Readiness_Call struct {
 	*mock.Call
 }
 
-// Del is a helper method to define mock.On call
-//   - ctx context.Context
-//   - key string
-func (_e *Storage_Expecter) Del(ctx interface{}, key interface{}) *Storage_Del_Call {
-	return &Storage_Del_Call{Call: _e.mock.On("Del", ctx, key)}
+// Readiness is a helper method to define mock.On call
+func (_e *Storage_Expecter) Readiness() *Storage_Readiness_Call {
+	return &Storage_Readiness_Call{Call: _e.mock.On("Readiness")}
 }
 
-func (_c *Storage_Del_Call) Run(run func(ctx context.Context, key string)) *Storage_Del_Call {
+func (_c *Storage_Readiness_Call) Run(run func()) *Storage_Readiness_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string))
+		run()
 	})
 	return _c
 }
 
-func (_c *Storage_Del_Call) Return(_a0 error) *Storage_Del_Call {
+func (_c *Storage_Readiness_Call) Return(_a0 storage.Readiness) *Storage_Readiness_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *Storage_Del_Call) RunAndReturn(run func(context.Context, string) error) *Storage_Del_Call {
+func (_c *Storage_Readiness_Call) RunAndReturn(run func() storage.Readiness) *Storage_Readiness_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Get provides a mock function with given fields: ctx, key
-func (_m *Storage) Get(ctx context.Context, key string) (string, error) {
-	ret := _m.Called(ctx, key)
+// RenameNX provides a mock function with given fields: ctx, oldKey, newKey
+func (_m *Storage) RenameNX(ctx context.Context, oldKey string, newKey string) (bool, error) {
+	ret := _m.Called(ctx, oldKey, newKey)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Get")
+		panic("no return value specified for RenameNX")
 	}
 
-	var r0 string
+	var r0 bool
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
-		return rf(ctx, key)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (bool, error)); ok {
+		return rf(ctx, oldKey, newKey)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
-		r0 = rf(ctx, key)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
+		r0 = rf(ctx, oldKey, newKey)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(bool)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, key)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, oldKey, newKey)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -100,31 +872,100 @@ func (_m *Storage) Get(ctx context.Context, key string) (string, error) {
 	return r0, r1
 }
 
-// Storage_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
-type Storage_Get_Call struct {
+// Storage_RenameNX_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RenameNX'
+type Storage_RenameNX_Call struct {
 	*mock.Call
 }
 
-// Get is a helper method to define mock.On call
+// RenameNX is a helper method to define mock.On call
 //   - ctx context.Context
-//   - key string
-func (_e *Storage_Expecter) Get(ctx interface{}, key interface{}) *Storage_Get_Call {
-	return &Storage_Get_Call{Call: _e.mock.On("Get", ctx, key)}
+//   - oldKey string
+//   - newKey string
+func (_e *Storage_Expecter) RenameNX(ctx interface{}, oldKey interface{}, newKey interface{}) *Storage_RenameNX_Call {
+	return &Storage_RenameNX_Call{Call: _e.mock.On("RenameNX", ctx, oldKey, newKey)}
 }
 
-func (_c *Storage_Get_Call) Run(run func(ctx context.Context, key string)) *Storage_Get_Call {
+func (_c *Storage_RenameNX_Call) Run(run func(ctx context.Context, oldKey string, newKey string)) *Storage_RenameNX_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string))
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
 	})
 	return _c
 }
 
-func (_c *Storage_Get_Call) Return(_a0 string, _a1 error) *Storage_Get_Call {
+func (_c *Storage_RenameNX_Call) Return(_a0 bool, _a1 error) *Storage_RenameNX_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Storage_Get_Call) RunAndReturn(run func(context.Context, string) (string, error)) *Storage_Get_Call {
+func (_c *Storage_RenameNX_Call) RunAndReturn(run func(context.Context, string, string) (bool, error)) *Storage_RenameNX_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Scan provides a mock function with given fields: prefix, cursor, pattern, count
+func (_m *Storage) Scan(prefix string, cursor uint64, pattern string, count int) ([]string, uint64, error) {
+	ret := _m.Called(prefix, cursor, pattern, count)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Scan")
+	}
+
+	var r0 []string
+	var r1 uint64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string, uint64, string, int) ([]string, uint64, error)); ok {
+		return rf(prefix, cursor, pattern, count)
+	}
+	if rf, ok := ret.Get(0).(func(string, uint64, string, int) []string); ok {
+		r0 = rf(prefix, cursor, pattern, count)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, uint64, string, int) uint64); ok {
+		r1 = rf(prefix, cursor, pattern, count)
+	} else {
+		r1 = ret.Get(1).(uint64)
+	}
+
+	if rf, ok := ret.Get(2).(func(string, uint64, string, int) error); ok {
+		r2 = rf(prefix, cursor, pattern, count)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Storage_Scan_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Scan'
+type Storage_Scan_Call struct {
+	*mock.Call
+}
+
+// Scan is a helper method to define mock.On call
+//   - prefix string
+//   - cursor uint64
+//   - pattern string
+//   - count int
+func (_e *Storage_Expecter) Scan(prefix interface{}, cursor interface{}, pattern interface{}, count interface{}) *Storage_Scan_Call {
+	return &Storage_Scan_Call{Call: _e.mock.On("Scan", prefix, cursor, pattern, count)}
+}
+
+func (_c *Storage_Scan_Call) Run(run func(prefix string, cursor uint64, pattern string, count int)) *Storage_Scan_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(uint64), args[2].(string), args[3].(int))
+	})
+	return _c
+}
+
+func (_c *Storage_Scan_Call) Return(_a0 []string, _a1 uint64, _a2 error) *Storage_Scan_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *Storage_Scan_Call) RunAndReturn(run func(string, uint64, string, int) ([]string, uint64, error)) *Storage_Scan_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -177,6 +1018,54 @@ func (_c *Storage_Set_Call) RunAndReturn(run func(context.Context, string, strin
 	return _c
 }
 
+// SetMaxSize provides a mock function with given fields: ctx, pattern, size
+func (_m *Storage) SetMaxSize(ctx context.Context, pattern string, size int) error {
+	ret := _m.Called(ctx, pattern, size)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetMaxSize")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) error); ok {
+		r0 = rf(ctx, pattern, size)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Storage_SetMaxSize_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetMaxSize'
+type Storage_SetMaxSize_Call struct {
+	*mock.Call
+}
+
+// SetMaxSize is a helper method to define mock.On call
+//   - ctx context.Context
+//   - pattern string
+//   - size int
+func (_e *Storage_Expecter) SetMaxSize(ctx interface{}, pattern interface{}, size interface{}) *Storage_SetMaxSize_Call {
+	return &Storage_SetMaxSize_Call{Call: _e.mock.On("SetMaxSize", ctx, pattern, size)}
+}
+
+func (_c *Storage_SetMaxSize_Call) Run(run func(ctx context.Context, pattern string, size int)) *Storage_SetMaxSize_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *Storage_SetMaxSize_Call) Return(_a0 error) *Storage_SetMaxSize_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Storage_SetMaxSize_Call) RunAndReturn(run func(context.Context, string, int) error) *Storage_SetMaxSize_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Stats provides a mock function with no fields
 func (_m *Storage) Stats() (*storage.Stats, error) {
 	ret := _m.Called()
@@ -234,6 +1123,186 @@ func (_c *Storage_Stats_Call) RunAndReturn(run func() (*storage.Stats, error)) *
 	return _c
 }
 
+// SyncWAL provides a mock function with no fields
+func (_m *Storage) SyncWAL() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for SyncWAL")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Storage_SyncWAL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SyncWAL'
+type Storage_SyncWAL_Call struct {
+	*mock.Call
+}
+
+// SyncWAL is a helper method to define mock.On call
+func (_e *Storage_Expecter) SyncWAL() *Storage_SyncWAL_Call {
+	return &Storage_SyncWAL_Call{Call: _e.mock.On("SyncWAL")}
+}
+
+func (_c *Storage_SyncWAL_Call) Run(run func()) *Storage_SyncWAL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Storage_SyncWAL_Call) Return(_a0 error) *Storage_SyncWAL_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Storage_SyncWAL_Call) RunAndReturn(run func() error) *Storage_SyncWAL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WALCompactions provides a mock function with no fields
+func (_m *Storage) WALCompactions() wal.CompactionStats {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for WALCompactions")
+	}
+
+	var r0 wal.CompactionStats
+	if rf, ok := ret.Get(0).(func() wal.CompactionStats); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(wal.CompactionStats)
+	}
+
+	return r0
+}
+
+// Storage_WALCompactions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WALCompactions'
+type Storage_WALCompactions_Call struct {
+	*mock.Call
+}
+
+// WALCompactions is a helper method to define mock.On call
+func (_e *Storage_Expecter) WALCompactions() *Storage_WALCompactions_Call {
+	return &Storage_WALCompactions_Call{Call: _e.mock.On("WALCompactions")}
+}
+
+func (_c *Storage_WALCompactions_Call) Run(run func()) *Storage_WALCompactions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Storage_WALCompactions_Call) Return(_a0 wal.CompactionStats) *Storage_WALCompactions_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Storage_WALCompactions_Call) RunAndReturn(run func() wal.CompactionStats) *Storage_WALCompactions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WALLatency provides a mock function with no fields
+func (_m *Storage) WALLatency() wal.LatencyStats {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for WALLatency")
+	}
+
+	var r0 wal.LatencyStats
+	if rf, ok := ret.Get(0).(func() wal.LatencyStats); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(wal.LatencyStats)
+	}
+
+	return r0
+}
+
+// Storage_WALLatency_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WALLatency'
+type Storage_WALLatency_Call struct {
+	*mock.Call
+}
+
+// WALLatency is a helper method to define mock.On call
+func (_e *Storage_Expecter) WALLatency() *Storage_WALLatency_Call {
+	return &Storage_WALLatency_Call{Call: _e.mock.On("WALLatency")}
+}
+
+func (_c *Storage_WALLatency_Call) Run(run func()) *Storage_WALLatency_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Storage_WALLatency_Call) Return(_a0 wal.LatencyStats) *Storage_WALLatency_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Storage_WALLatency_Call) RunAndReturn(run func() wal.LatencyStats) *Storage_WALLatency_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WALWriteErrors provides a mock function with no fields
+func (_m *Storage) WALWriteErrors() int64 {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for WALWriteErrors")
+	}
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func() int64); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	return r0
+}
+
+// Storage_WALWriteErrors_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WALWriteErrors'
+type Storage_WALWriteErrors_Call struct {
+	*mock.Call
+}
+
+// WALWriteErrors is a helper method to define mock.On call
+func (_e *Storage_Expecter) WALWriteErrors() *Storage_WALWriteErrors_Call {
+	return &Storage_WALWriteErrors_Call{Call: _e.mock.On("WALWriteErrors")}
+}
+
+func (_c *Storage_WALWriteErrors_Call) Run(run func()) *Storage_WALWriteErrors_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Storage_WALWriteErrors_Call) Return(_a0 int64) *Storage_WALWriteErrors_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Storage_WALWriteErrors_Call) RunAndReturn(run func() int64) *Storage_WALWriteErrors_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Watch provides a mock function with given fields: ctx, key
 func (_m *Storage) Watch(ctx context.Context, key string) sync.Future[string] {
 	ret := _m.Called(ctx, key)
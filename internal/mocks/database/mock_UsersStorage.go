@@ -190,9 +190,9 @@ func (_c *UsersStorage_Authenticate_Call) RunAndReturn(run func(context.Context,
 	return _c
 }
 
-// Create provides a mock function with given fields: ctx, username, password
-func (_m *UsersStorage) Create(ctx context.Context, username string, password string) (*models.User, error) {
-	ret := _m.Called(ctx, username, password)
+// Create provides a mock function with given fields: ctx, username, password, namespace
+func (_m *UsersStorage) Create(ctx context.Context, username string, password string, namespace string) (*models.User, error) {
+	ret := _m.Called(ctx, username, password, namespace)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Create")
@@ -200,19 +200,19 @@ func (_m *UsersStorage) Create(ctx context.Context, username string, password st
 
 	var r0 *models.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*models.User, error)); ok {
-		return rf(ctx, username, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*models.User, error)); ok {
+		return rf(ctx, username, password, namespace)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) *models.User); ok {
-		r0 = rf(ctx, username, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *models.User); ok {
+		r0 = rf(ctx, username, password, namespace)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, username, password)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, username, password, namespace)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -229,13 +229,14 @@ type UsersStorage_Create_Call struct {
 //   - ctx context.Context
 //   - username string
 //   - password string
-func (_e *UsersStorage_Expecter) Create(ctx interface{}, username interface{}, password interface{}) *UsersStorage_Create_Call {
-	return &UsersStorage_Create_Call{Call: _e.mock.On("Create", ctx, username, password)}
+//   - namespace string
+func (_e *UsersStorage_Expecter) Create(ctx interface{}, username interface{}, password interface{}, namespace interface{}) *UsersStorage_Create_Call {
+	return &UsersStorage_Create_Call{Call: _e.mock.On("Create", ctx, username, password, namespace)}
 }
 
-func (_c *UsersStorage_Create_Call) Run(run func(ctx context.Context, username string, password string)) *UsersStorage_Create_Call {
+func (_c *UsersStorage_Create_Call) Run(run func(ctx context.Context, username string, password string, namespace string)) *UsersStorage_Create_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string), args[2].(string))
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string))
 	})
 	return _c
 }
@@ -245,7 +246,64 @@ func (_c *UsersStorage_Create_Call) Return(_a0 *models.User, _a1 error) *UsersSt
 	return _c
 }
 
-func (_c *UsersStorage_Create_Call) RunAndReturn(run func(context.Context, string, string) (*models.User, error)) *UsersStorage_Create_Call {
+func (_c *UsersStorage_Create_Call) RunAndReturn(run func(context.Context, string, string, string) (*models.User, error)) *UsersStorage_Create_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DedupRoles provides a mock function with given fields: ctx, username
+func (_m *UsersStorage) DedupRoles(ctx context.Context, username string) (bool, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DedupRoles")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UsersStorage_DedupRoles_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DedupRoles'
+type UsersStorage_DedupRoles_Call struct {
+	*mock.Call
+}
+
+// DedupRoles is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+func (_e *UsersStorage_Expecter) DedupRoles(ctx interface{}, username interface{}) *UsersStorage_DedupRoles_Call {
+	return &UsersStorage_DedupRoles_Call{Call: _e.mock.On("DedupRoles", ctx, username)}
+}
+
+func (_c *UsersStorage_DedupRoles_Call) Run(run func(ctx context.Context, username string)) *UsersStorage_DedupRoles_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *UsersStorage_DedupRoles_Call) Return(_a0 bool, _a1 error) *UsersStorage_DedupRoles_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *UsersStorage_DedupRoles_Call) RunAndReturn(run func(context.Context, string) (bool, error)) *UsersStorage_DedupRoles_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -297,6 +355,54 @@ func (_c *UsersStorage_Delete_Call) RunAndReturn(run func(context.Context, strin
 	return _c
 }
 
+// DivestRole provides a mock function with given fields: ctx, username, role
+func (_m *UsersStorage) DivestRole(ctx context.Context, username string, role string) error {
+	ret := _m.Called(ctx, username, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DivestRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, username, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UsersStorage_DivestRole_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DivestRole'
+type UsersStorage_DivestRole_Call struct {
+	*mock.Call
+}
+
+// DivestRole is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+//   - role string
+func (_e *UsersStorage_Expecter) DivestRole(ctx interface{}, username interface{}, role interface{}) *UsersStorage_DivestRole_Call {
+	return &UsersStorage_DivestRole_Call{Call: _e.mock.On("DivestRole", ctx, username, role)}
+}
+
+func (_c *UsersStorage_DivestRole_Call) Run(run func(ctx context.Context, username string, role string)) *UsersStorage_DivestRole_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *UsersStorage_DivestRole_Call) Return(_a0 error) *UsersStorage_DivestRole_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *UsersStorage_DivestRole_Call) RunAndReturn(run func(context.Context, string, string) error) *UsersStorage_DivestRole_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Get provides a mock function with given fields: ctx, username
 func (_m *UsersStorage) Get(ctx context.Context, username string) (*models.User, error) {
 	ret := _m.Called(ctx, username)
@@ -473,6 +579,65 @@ func (_c *UsersStorage_Remove_Call) RunAndReturn(run func(context.Context, strin
 	return _c
 }
 
+// RoleUsers provides a mock function with given fields: ctx, role
+func (_m *UsersStorage) RoleUsers(ctx context.Context, role string) ([]string, error) {
+	ret := _m.Called(ctx, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RoleUsers")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]string, error)); ok {
+		return rf(ctx, role)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []string); ok {
+		r0 = rf(ctx, role)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UsersStorage_RoleUsers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RoleUsers'
+type UsersStorage_RoleUsers_Call struct {
+	*mock.Call
+}
+
+// RoleUsers is a helper method to define mock.On call
+//   - ctx context.Context
+//   - role string
+func (_e *UsersStorage_Expecter) RoleUsers(ctx interface{}, role interface{}) *UsersStorage_RoleUsers_Call {
+	return &UsersStorage_RoleUsers_Call{Call: _e.mock.On("RoleUsers", ctx, role)}
+}
+
+func (_c *UsersStorage_RoleUsers_Call) Run(run func(ctx context.Context, role string)) *UsersStorage_RoleUsers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *UsersStorage_RoleUsers_Call) Return(_a0 []string, _a1 error) *UsersStorage_RoleUsers_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *UsersStorage_RoleUsers_Call) RunAndReturn(run func(context.Context, string) ([]string, error)) *UsersStorage_RoleUsers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // SaveRaw provides a mock function with given fields: ctx, user
 func (_m *UsersStorage) SaveRaw(ctx context.Context, user *models.User) error {
 	ret := _m.Called(ctx, user)
@@ -5,9 +5,12 @@ package mocks
 import (
 	context "context"
 
+	engine "github.com/neekrasov/kvdb/internal/database/storage/engine"
 	mock "github.com/stretchr/testify/mock"
 
 	sync "github.com/neekrasov/kvdb/pkg/sync"
+
+	time "time"
 )
 
 // Engine is an autogenerated mock type for the Engine type
@@ -23,19 +26,65 @@ func (_m *Engine) EXPECT() *Engine_Expecter {
 	return &Engine_Expecter{mock: &_m.Mock}
 }
 
+// CountByPrefix provides a mock function with given fields: prefix
+func (_m *Engine) CountByPrefix(prefix string) int {
+	ret := _m.Called(prefix)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountByPrefix")
+	}
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(string) int); ok {
+		r0 = rf(prefix)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
+// Engine_CountByPrefix_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountByPrefix'
+type Engine_CountByPrefix_Call struct {
+	*mock.Call
+}
+
+// CountByPrefix is a helper method to define mock.On call
+//   - prefix string
+func (_e *Engine_Expecter) CountByPrefix(prefix interface{}) *Engine_CountByPrefix_Call {
+	return &Engine_CountByPrefix_Call{Call: _e.mock.On("CountByPrefix", prefix)}
+}
+
+func (_c *Engine_CountByPrefix_Call) Run(run func(prefix string)) *Engine_CountByPrefix_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Engine_CountByPrefix_Call) Return(_a0 int) *Engine_CountByPrefix_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Engine_CountByPrefix_Call) RunAndReturn(run func(string) int) *Engine_CountByPrefix_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Del provides a mock function with given fields: ctx, key
-func (_m *Engine) Del(ctx context.Context, key string) error {
+func (_m *Engine) Del(ctx context.Context, key string) bool {
 	ret := _m.Called(ctx, key)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Del")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
 		r0 = rf(ctx, key)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(bool)
 	}
 
 	return r0
@@ -60,16 +109,248 @@ func (_c *Engine_Del_Call) Run(run func(ctx context.Context, key string)) *Engin
 	return _c
 }
 
-func (_c *Engine_Del_Call) Return(_a0 error) *Engine_Del_Call {
+func (_c *Engine_Del_Call) Return(_a0 bool) *Engine_Del_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Engine_Del_Call) RunAndReturn(run func(context.Context, string) bool) *Engine_Del_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DelExpired provides a mock function with given fields: ctx, key
+func (_m *Engine) DelExpired(ctx context.Context, key string) bool {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DelExpired")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Engine_DelExpired_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DelExpired'
+type Engine_DelExpired_Call struct {
+	*mock.Call
+}
+
+// DelExpired is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *Engine_Expecter) DelExpired(ctx interface{}, key interface{}) *Engine_DelExpired_Call {
+	return &Engine_DelExpired_Call{Call: _e.mock.On("DelExpired", ctx, key)}
+}
+
+func (_c *Engine_DelExpired_Call) Run(run func(ctx context.Context, key string)) *Engine_DelExpired_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Engine_DelExpired_Call) Return(_a0 bool) *Engine_DelExpired_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Engine_DelExpired_Call) RunAndReturn(run func(context.Context, string) bool) *Engine_DelExpired_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DelIdle provides a mock function with given fields: ctx, key, deadline
+func (_m *Engine) DelIdle(ctx context.Context, key string, deadline time.Time) bool {
+	ret := _m.Called(ctx, key, deadline)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DelIdle")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) bool); ok {
+		r0 = rf(ctx, key, deadline)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Engine_DelIdle_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DelIdle'
+type Engine_DelIdle_Call struct {
+	*mock.Call
+}
+
+// DelIdle is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - deadline time.Time
+func (_e *Engine_Expecter) DelIdle(ctx interface{}, key interface{}, deadline interface{}) *Engine_DelIdle_Call {
+	return &Engine_DelIdle_Call{Call: _e.mock.On("DelIdle", ctx, key, deadline)}
+}
+
+func (_c *Engine_DelIdle_Call) Run(run func(ctx context.Context, key string, deadline time.Time)) *Engine_DelIdle_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *Engine_DelIdle_Call) Return(_a0 bool) *Engine_DelIdle_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *Engine_Del_Call) RunAndReturn(run func(context.Context, string) error) *Engine_Del_Call {
+func (_c *Engine_DelIdle_Call) RunAndReturn(run func(context.Context, string, time.Time) bool) *Engine_DelIdle_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
+// Entry provides a mock function with given fields: ctx, key
+func (_m *Engine) Entry(ctx context.Context, key string) (engine.Entry, bool) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Entry")
+	}
+
+	var r0 engine.Entry
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string) (engine.Entry, bool)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) engine.Entry); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(engine.Entry)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) bool); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// Engine_Entry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Entry'
+type Engine_Entry_Call struct {
+	*mock.Call
+}
+
+// Entry is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *Engine_Expecter) Entry(ctx interface{}, key interface{}) *Engine_Entry_Call {
+	return &Engine_Entry_Call{Call: _e.mock.On("Entry", ctx, key)}
+}
+
+func (_c *Engine_Entry_Call) Run(run func(ctx context.Context, key string)) *Engine_Entry_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Engine_Entry_Call) Return(_a0 engine.Entry, _a1 bool) *Engine_Entry_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Engine_Entry_Call) RunAndReturn(run func(context.Context, string) (engine.Entry, bool)) *Engine_Entry_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Expired provides a mock function with given fields: ctx, key
+func (_m *Engine) Expired(ctx context.Context, key string) bool {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Expired")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Engine_Expired_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Expired'
+type Engine_Expired_Call struct {
+	*mock.Call
+}
+
+// Expired is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *Engine_Expecter) Expired(ctx interface{}, key interface{}) *Engine_Expired_Call {
+	return &Engine_Expired_Call{Call: _e.mock.On("Expired", ctx, key)}
+}
+
+func (_c *Engine_Expired_Call) Run(run func(ctx context.Context, key string)) *Engine_Expired_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Engine_Expired_Call) Return(_a0 bool) *Engine_Expired_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Engine_Expired_Call) RunAndReturn(run func(context.Context, string) bool) *Engine_Expired_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ForEach provides a mock function with given fields: action
+func (_m *Engine) ForEach(action func(string, string, int64)) {
+	_m.Called(action)
+}
+
+// Engine_ForEach_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ForEach'
+type Engine_ForEach_Call struct {
+	*mock.Call
+}
+
+// ForEach is a helper method to define mock.On call
+//   - action func(string , string , int64)
+func (_e *Engine_Expecter) ForEach(action interface{}) *Engine_ForEach_Call {
+	return &Engine_ForEach_Call{Call: _e.mock.On("ForEach", action)}
+}
+
+func (_c *Engine_ForEach_Call) Run(run func(action func(string, string, int64))) *Engine_ForEach_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(func(string, string, int64)))
+	})
+	return _c
+}
+
+func (_c *Engine_ForEach_Call) Return() *Engine_ForEach_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *Engine_ForEach_Call) RunAndReturn(run func(func(string, string, int64))) *Engine_ForEach_Call {
+	_c.Run(run)
+	return _c
+}
+
 // ForEachExpired provides a mock function with given fields: action
 func (_m *Engine) ForEachExpired(action func(string)) {
 	_m.Called(action)
@@ -103,6 +384,75 @@ func (_c *Engine_ForEachExpired_Call) RunAndReturn(run func(func(string))) *Engi
 	return _c
 }
 
+// ForEachExpiring provides a mock function with given fields: prefix, deadline, action
+func (_m *Engine) ForEachExpiring(prefix string, deadline int64, action func(string, int64)) {
+	_m.Called(prefix, deadline, action)
+}
+
+// Engine_ForEachExpiring_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ForEachExpiring'
+type Engine_ForEachExpiring_Call struct {
+	*mock.Call
+}
+
+// ForEachExpiring is a helper method to define mock.On call
+//   - prefix string
+//   - deadline int64
+//   - action func(string , int64)
+func (_e *Engine_Expecter) ForEachExpiring(prefix interface{}, deadline interface{}, action interface{}) *Engine_ForEachExpiring_Call {
+	return &Engine_ForEachExpiring_Call{Call: _e.mock.On("ForEachExpiring", prefix, deadline, action)}
+}
+
+func (_c *Engine_ForEachExpiring_Call) Run(run func(prefix string, deadline int64, action func(string, int64))) *Engine_ForEachExpiring_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(int64), args[2].(func(string, int64)))
+	})
+	return _c
+}
+
+func (_c *Engine_ForEachExpiring_Call) Return() *Engine_ForEachExpiring_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *Engine_ForEachExpiring_Call) RunAndReturn(run func(string, int64, func(string, int64))) *Engine_ForEachExpiring_Call {
+	_c.Run(run)
+	return _c
+}
+
+// ForEachIdle provides a mock function with given fields: deadline, action
+func (_m *Engine) ForEachIdle(deadline time.Time, action func(string)) {
+	_m.Called(deadline, action)
+}
+
+// Engine_ForEachIdle_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ForEachIdle'
+type Engine_ForEachIdle_Call struct {
+	*mock.Call
+}
+
+// ForEachIdle is a helper method to define mock.On call
+//   - deadline time.Time
+//   - action func(string)
+func (_e *Engine_Expecter) ForEachIdle(deadline interface{}, action interface{}) *Engine_ForEachIdle_Call {
+	return &Engine_ForEachIdle_Call{Call: _e.mock.On("ForEachIdle", deadline, action)}
+}
+
+func (_c *Engine_ForEachIdle_Call) Run(run func(deadline time.Time, action func(string))) *Engine_ForEachIdle_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(time.Time), args[1].(func(string)))
+	})
+	return _c
+}
+
+func (_c *Engine_ForEachIdle_Call) Return() *Engine_ForEachIdle_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *Engine_ForEachIdle_Call) RunAndReturn(run func(time.Time, func(string))) *Engine_ForEachIdle_Call {
+	_c.Run(run)
+	return _c
+}
+
 // Get provides a mock function with given fields: ctx, key
 func (_m *Engine) Get(ctx context.Context, key string) (string, bool) {
 	ret := _m.Called(ctx, key)
@@ -160,6 +510,230 @@ func (_c *Engine_Get_Call) RunAndReturn(run func(context.Context, string) (strin
 	return _c
 }
 
+// KeysByPrefix provides a mock function with given fields: prefix
+func (_m *Engine) KeysByPrefix(prefix string) []string {
+	ret := _m.Called(prefix)
+
+	if len(ret) == 0 {
+		panic("no return value specified for KeysByPrefix")
+	}
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(string) []string); ok {
+		r0 = rf(prefix)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	return r0
+}
+
+// Engine_KeysByPrefix_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'KeysByPrefix'
+type Engine_KeysByPrefix_Call struct {
+	*mock.Call
+}
+
+// KeysByPrefix is a helper method to define mock.On call
+//   - prefix string
+func (_e *Engine_Expecter) KeysByPrefix(prefix interface{}) *Engine_KeysByPrefix_Call {
+	return &Engine_KeysByPrefix_Call{Call: _e.mock.On("KeysByPrefix", prefix)}
+}
+
+func (_c *Engine_KeysByPrefix_Call) Run(run func(prefix string)) *Engine_KeysByPrefix_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Engine_KeysByPrefix_Call) Return(_a0 []string) *Engine_KeysByPrefix_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Engine_KeysByPrefix_Call) RunAndReturn(run func(string) []string) *Engine_KeysByPrefix_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LastAccess provides a mock function with given fields: ctx, key
+func (_m *Engine) LastAccess(ctx context.Context, key string) (time.Time, bool) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LastAccess")
+	}
+
+	var r0 time.Time
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string) (time.Time, bool)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) time.Time); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) bool); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// Engine_LastAccess_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LastAccess'
+type Engine_LastAccess_Call struct {
+	*mock.Call
+}
+
+// LastAccess is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *Engine_Expecter) LastAccess(ctx interface{}, key interface{}) *Engine_LastAccess_Call {
+	return &Engine_LastAccess_Call{Call: _e.mock.On("LastAccess", ctx, key)}
+}
+
+func (_c *Engine_LastAccess_Call) Run(run func(ctx context.Context, key string)) *Engine_LastAccess_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Engine_LastAccess_Call) Return(_a0 time.Time, _a1 bool) *Engine_LastAccess_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Engine_LastAccess_Call) RunAndReturn(run func(context.Context, string) (time.Time, bool)) *Engine_LastAccess_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RenameNX provides a mock function with given fields: ctx, oldKey, newKey
+func (_m *Engine) RenameNX(ctx context.Context, oldKey string, newKey string) (bool, bool) {
+	ret := _m.Called(ctx, oldKey, newKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RenameNX")
+	}
+
+	var r0 bool
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (bool, bool)); ok {
+		return rf(ctx, oldKey, newKey)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
+		r0 = rf(ctx, oldKey, newKey)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) bool); ok {
+		r1 = rf(ctx, oldKey, newKey)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// Engine_RenameNX_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RenameNX'
+type Engine_RenameNX_Call struct {
+	*mock.Call
+}
+
+// RenameNX is a helper method to define mock.On call
+//   - ctx context.Context
+//   - oldKey string
+//   - newKey string
+func (_e *Engine_Expecter) RenameNX(ctx interface{}, oldKey interface{}, newKey interface{}) *Engine_RenameNX_Call {
+	return &Engine_RenameNX_Call{Call: _e.mock.On("RenameNX", ctx, oldKey, newKey)}
+}
+
+func (_c *Engine_RenameNX_Call) Run(run func(ctx context.Context, oldKey string, newKey string)) *Engine_RenameNX_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *Engine_RenameNX_Call) Return(_a0 bool, _a1 bool) *Engine_RenameNX_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Engine_RenameNX_Call) RunAndReturn(run func(context.Context, string, string) (bool, bool)) *Engine_RenameNX_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Scan provides a mock function with given fields: prefix, cursor, count, match
+func (_m *Engine) Scan(prefix string, cursor uint64, count int, match func(string) bool) ([]string, uint64) {
+	ret := _m.Called(prefix, cursor, count, match)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Scan")
+	}
+
+	var r0 []string
+	var r1 uint64
+	if rf, ok := ret.Get(0).(func(string, uint64, int, func(string) bool) ([]string, uint64)); ok {
+		return rf(prefix, cursor, count, match)
+	}
+	if rf, ok := ret.Get(0).(func(string, uint64, int, func(string) bool) []string); ok {
+		r0 = rf(prefix, cursor, count, match)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, uint64, int, func(string) bool) uint64); ok {
+		r1 = rf(prefix, cursor, count, match)
+	} else {
+		r1 = ret.Get(1).(uint64)
+	}
+
+	return r0, r1
+}
+
+// Engine_Scan_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Scan'
+type Engine_Scan_Call struct {
+	*mock.Call
+}
+
+// Scan is a helper method to define mock.On call
+//   - prefix string
+//   - cursor uint64
+//   - count int
+//   - match func(string) bool
+func (_e *Engine_Expecter) Scan(prefix interface{}, cursor interface{}, count interface{}, match interface{}) *Engine_Scan_Call {
+	return &Engine_Scan_Call{Call: _e.mock.On("Scan", prefix, cursor, count, match)}
+}
+
+func (_c *Engine_Scan_Call) Run(run func(prefix string, cursor uint64, count int, match func(string) bool)) *Engine_Scan_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(uint64), args[2].(int), args[3].(func(string) bool))
+	})
+	return _c
+}
+
+func (_c *Engine_Scan_Call) Return(_a0 []string, _a1 uint64) *Engine_Scan_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Engine_Scan_Call) RunAndReturn(run func(string, uint64, int, func(string) bool) ([]string, uint64)) *Engine_Scan_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Set provides a mock function with given fields: ctx, key, value, ttl
 func (_m *Engine) Set(ctx context.Context, key string, value string, ttl int64) {
 	_m.Called(ctx, key, value, ttl)
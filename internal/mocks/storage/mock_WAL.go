@@ -23,6 +23,106 @@ func (_m *WAL) EXPECT() *WAL_Expecter {
 	return &WAL_Expecter{mock: &_m.Mock}
 }
 
+// Compact provides a mock function with no fields
+func (_m *WAL) Compact() (wal.CompactionResult, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Compact")
+	}
+
+	var r0 wal.CompactionResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (wal.CompactionResult, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() wal.CompactionResult); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(wal.CompactionResult)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WAL_Compact_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Compact'
+type WAL_Compact_Call struct {
+	*mock.Call
+}
+
+// Compact is a helper method to define mock.On call
+func (_e *WAL_Expecter) Compact() *WAL_Compact_Call {
+	return &WAL_Compact_Call{Call: _e.mock.On("Compact")}
+}
+
+func (_c *WAL_Compact_Call) Run(run func()) *WAL_Compact_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *WAL_Compact_Call) Return(_a0 wal.CompactionResult, _a1 error) *WAL_Compact_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *WAL_Compact_Call) RunAndReturn(run func() (wal.CompactionResult, error)) *WAL_Compact_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Compactions provides a mock function with no fields
+func (_m *WAL) Compactions() wal.CompactionStats {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Compactions")
+	}
+
+	var r0 wal.CompactionStats
+	if rf, ok := ret.Get(0).(func() wal.CompactionStats); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(wal.CompactionStats)
+	}
+
+	return r0
+}
+
+// WAL_Compactions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Compactions'
+type WAL_Compactions_Call struct {
+	*mock.Call
+}
+
+// Compactions is a helper method to define mock.On call
+func (_e *WAL_Expecter) Compactions() *WAL_Compactions_Call {
+	return &WAL_Compactions_Call{Call: _e.mock.On("Compactions")}
+}
+
+func (_c *WAL_Compactions_Call) Run(run func()) *WAL_Compactions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *WAL_Compactions_Call) Return(_a0 wal.CompactionStats) *WAL_Compactions_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *WAL_Compactions_Call) RunAndReturn(run func() wal.CompactionStats) *WAL_Compactions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Del provides a mock function with given fields: ctx, key
 func (_m *WAL) Del(ctx context.Context, key string) error {
 	ret := _m.Called(ctx, key)
@@ -116,9 +216,54 @@ func (_c *WAL_Flush_Call) RunAndReturn(run func([]wal.WriteEntry) error) *WAL_Fl
 	return _c
 }
 
-// Recover provides a mock function with given fields: applyFunc
-func (_m *WAL) Recover(applyFunc func(context.Context, []wal.LogEntry) error) (int64, error) {
-	ret := _m.Called(applyFunc)
+// Latency provides a mock function with no fields
+func (_m *WAL) Latency() wal.LatencyStats {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Latency")
+	}
+
+	var r0 wal.LatencyStats
+	if rf, ok := ret.Get(0).(func() wal.LatencyStats); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(wal.LatencyStats)
+	}
+
+	return r0
+}
+
+// WAL_Latency_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Latency'
+type WAL_Latency_Call struct {
+	*mock.Call
+}
+
+// Latency is a helper method to define mock.On call
+func (_e *WAL_Expecter) Latency() *WAL_Latency_Call {
+	return &WAL_Latency_Call{Call: _e.mock.On("Latency")}
+}
+
+func (_c *WAL_Latency_Call) Run(run func()) *WAL_Latency_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *WAL_Latency_Call) Return(_a0 wal.LatencyStats) *WAL_Latency_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *WAL_Latency_Call) RunAndReturn(run func() wal.LatencyStats) *WAL_Latency_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Recover provides a mock function with given fields: ctx, applyFunc
+func (_m *WAL) Recover(ctx context.Context, applyFunc func(context.Context, []wal.LogEntry) error) (int64, error) {
+	ret := _m.Called(ctx, applyFunc)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Recover")
@@ -126,17 +271,17 @@ func (_m *WAL) Recover(applyFunc func(context.Context, []wal.LogEntry) error) (i
 
 	var r0 int64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(func(context.Context, []wal.LogEntry) error) (int64, error)); ok {
-		return rf(applyFunc)
+	if rf, ok := ret.Get(0).(func(context.Context, func(context.Context, []wal.LogEntry) error) (int64, error)); ok {
+		return rf(ctx, applyFunc)
 	}
-	if rf, ok := ret.Get(0).(func(func(context.Context, []wal.LogEntry) error) int64); ok {
-		r0 = rf(applyFunc)
+	if rf, ok := ret.Get(0).(func(context.Context, func(context.Context, []wal.LogEntry) error) int64); ok {
+		r0 = rf(ctx, applyFunc)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
 
-	if rf, ok := ret.Get(1).(func(func(context.Context, []wal.LogEntry) error) error); ok {
-		r1 = rf(applyFunc)
+	if rf, ok := ret.Get(1).(func(context.Context, func(context.Context, []wal.LogEntry) error) error); ok {
+		r1 = rf(ctx, applyFunc)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -150,14 +295,15 @@ type WAL_Recover_Call struct {
 }
 
 // Recover is a helper method to define mock.On call
+//   - ctx context.Context
 //   - applyFunc func(context.Context , []wal.LogEntry) error
-func (_e *WAL_Expecter) Recover(applyFunc interface{}) *WAL_Recover_Call {
-	return &WAL_Recover_Call{Call: _e.mock.On("Recover", applyFunc)}
+func (_e *WAL_Expecter) Recover(ctx interface{}, applyFunc interface{}) *WAL_Recover_Call {
+	return &WAL_Recover_Call{Call: _e.mock.On("Recover", ctx, applyFunc)}
 }
 
-func (_c *WAL_Recover_Call) Run(run func(applyFunc func(context.Context, []wal.LogEntry) error)) *WAL_Recover_Call {
+func (_c *WAL_Recover_Call) Run(run func(ctx context.Context, applyFunc func(context.Context, []wal.LogEntry) error)) *WAL_Recover_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(func(context.Context, []wal.LogEntry) error))
+		run(args[0].(context.Context), args[1].(func(context.Context, []wal.LogEntry) error))
 	})
 	return _c
 }
@@ -167,7 +313,55 @@ func (_c *WAL_Recover_Call) Return(_a0 int64, _a1 error) *WAL_Recover_Call {
 	return _c
 }
 
-func (_c *WAL_Recover_Call) RunAndReturn(run func(func(context.Context, []wal.LogEntry) error) (int64, error)) *WAL_Recover_Call {
+func (_c *WAL_Recover_Call) RunAndReturn(run func(context.Context, func(context.Context, []wal.LogEntry) error) (int64, error)) *WAL_Recover_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RenameNX provides a mock function with given fields: ctx, oldKey, newKey
+func (_m *WAL) RenameNX(ctx context.Context, oldKey string, newKey string) error {
+	ret := _m.Called(ctx, oldKey, newKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RenameNX")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, oldKey, newKey)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WAL_RenameNX_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RenameNX'
+type WAL_RenameNX_Call struct {
+	*mock.Call
+}
+
+// RenameNX is a helper method to define mock.On call
+//   - ctx context.Context
+//   - oldKey string
+//   - newKey string
+func (_e *WAL_Expecter) RenameNX(ctx interface{}, oldKey interface{}, newKey interface{}) *WAL_RenameNX_Call {
+	return &WAL_RenameNX_Call{Call: _e.mock.On("RenameNX", ctx, oldKey, newKey)}
+}
+
+func (_c *WAL_RenameNX_Call) Run(run func(ctx context.Context, oldKey string, newKey string)) *WAL_RenameNX_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *WAL_RenameNX_Call) Return(_a0 error) *WAL_RenameNX_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *WAL_RenameNX_Call) RunAndReturn(run func(context.Context, string, string) error) *WAL_RenameNX_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -220,6 +414,152 @@ func (_c *WAL_Set_Call) RunAndReturn(run func(context.Context, string, string) e
 	return _c
 }
 
+// Sync provides a mock function with no fields
+func (_m *WAL) Sync() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Sync")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WAL_Sync_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Sync'
+type WAL_Sync_Call struct {
+	*mock.Call
+}
+
+// Sync is a helper method to define mock.On call
+func (_e *WAL_Expecter) Sync() *WAL_Sync_Call {
+	return &WAL_Sync_Call{Call: _e.mock.On("Sync")}
+}
+
+func (_c *WAL_Sync_Call) Run(run func()) *WAL_Sync_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *WAL_Sync_Call) Return(_a0 error) *WAL_Sync_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *WAL_Sync_Call) RunAndReturn(run func() error) *WAL_Sync_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Truncate provides a mock function with given fields: lsn
+func (_m *WAL) Truncate(lsn int64) (int, error) {
+	ret := _m.Called(lsn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Truncate")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64) (int, error)); ok {
+		return rf(lsn)
+	}
+	if rf, ok := ret.Get(0).(func(int64) int); ok {
+		r0 = rf(lsn)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(lsn)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WAL_Truncate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Truncate'
+type WAL_Truncate_Call struct {
+	*mock.Call
+}
+
+// Truncate is a helper method to define mock.On call
+//   - lsn int64
+func (_e *WAL_Expecter) Truncate(lsn interface{}) *WAL_Truncate_Call {
+	return &WAL_Truncate_Call{Call: _e.mock.On("Truncate", lsn)}
+}
+
+func (_c *WAL_Truncate_Call) Run(run func(lsn int64)) *WAL_Truncate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *WAL_Truncate_Call) Return(_a0 int, _a1 error) *WAL_Truncate_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *WAL_Truncate_Call) RunAndReturn(run func(int64) (int, error)) *WAL_Truncate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WriteErrors provides a mock function with no fields
+func (_m *WAL) WriteErrors() int64 {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for WriteErrors")
+	}
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func() int64); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	return r0
+}
+
+// WAL_WriteErrors_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WriteErrors'
+type WAL_WriteErrors_Call struct {
+	*mock.Call
+}
+
+// WriteErrors is a helper method to define mock.On call
+func (_e *WAL_Expecter) WriteErrors() *WAL_WriteErrors_Call {
+	return &WAL_WriteErrors_Call{Call: _e.mock.On("WriteErrors")}
+}
+
+func (_c *WAL_WriteErrors_Call) Run(run func()) *WAL_WriteErrors_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *WAL_WriteErrors_Call) Return(_a0 int64) *WAL_WriteErrors_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *WAL_WriteErrors_Call) RunAndReturn(run func() int64) *WAL_WriteErrors_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewWAL creates a new instance of WAL. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewWAL(t interface {
@@ -253,6 +253,51 @@ func (_c *Segment_Size_Call) RunAndReturn(run func() int) *Segment_Size_Call {
 	return _c
 }
 
+// Sync provides a mock function with no fields
+func (_m *Segment) Sync() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Sync")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Segment_Sync_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Sync'
+type Segment_Sync_Call struct {
+	*mock.Call
+}
+
+// Sync is a helper method to define mock.On call
+func (_e *Segment_Expecter) Sync() *Segment_Sync_Call {
+	return &Segment_Sync_Call{Call: _e.mock.On("Sync")}
+}
+
+func (_c *Segment_Sync_Call) Run(run func()) *Segment_Sync_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Segment_Sync_Call) Return(_a0 error) *Segment_Sync_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Segment_Sync_Call) RunAndReturn(run func() error) *Segment_Sync_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Write provides a mock function with given fields: data
 func (_m *Segment) Write(data []byte) (int, error) {
 	ret := _m.Called(data)
@@ -67,17 +67,17 @@ func (_c *SegmentManager_Close_Call) RunAndReturn(run func() error) *SegmentMana
 	return _c
 }
 
-// ForEach provides a mock function with given fields: action
-func (_m *SegmentManager) ForEach(action func(context.Context, []byte) error) error {
-	ret := _m.Called(action)
+// ForEach provides a mock function with given fields: ctx, action
+func (_m *SegmentManager) ForEach(ctx context.Context, action func(context.Context, []byte) error) error {
+	ret := _m.Called(ctx, action)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ForEach")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(func(context.Context, []byte) error) error); ok {
-		r0 = rf(action)
+	if rf, ok := ret.Get(0).(func(context.Context, func(context.Context, []byte) error) error); ok {
+		r0 = rf(ctx, action)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -91,14 +91,15 @@ type SegmentManager_ForEach_Call struct {
 }
 
 // ForEach is a helper method to define mock.On call
+//   - ctx context.Context
 //   - action func(context.Context , []byte) error
-func (_e *SegmentManager_Expecter) ForEach(action interface{}) *SegmentManager_ForEach_Call {
-	return &SegmentManager_ForEach_Call{Call: _e.mock.On("ForEach", action)}
+func (_e *SegmentManager_Expecter) ForEach(ctx interface{}, action interface{}) *SegmentManager_ForEach_Call {
+	return &SegmentManager_ForEach_Call{Call: _e.mock.On("ForEach", ctx, action)}
 }
 
-func (_c *SegmentManager_ForEach_Call) Run(run func(action func(context.Context, []byte) error)) *SegmentManager_ForEach_Call {
+func (_c *SegmentManager_ForEach_Call) Run(run func(ctx context.Context, action func(context.Context, []byte) error)) *SegmentManager_ForEach_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(func(context.Context, []byte) error))
+		run(args[0].(context.Context), args[1].(func(context.Context, []byte) error))
 	})
 	return _c
 }
@@ -108,7 +109,7 @@ func (_c *SegmentManager_ForEach_Call) Return(_a0 error) *SegmentManager_ForEach
 	return _c
 }
 
-func (_c *SegmentManager_ForEach_Call) RunAndReturn(run func(func(context.Context, []byte) error) error) *SegmentManager_ForEach_Call {
+func (_c *SegmentManager_ForEach_Call) RunAndReturn(run func(context.Context, func(context.Context, []byte) error) error) *SegmentManager_ForEach_Call {
 	_c.Call.Return(run)
 	return _c
 }
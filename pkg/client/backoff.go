@@ -0,0 +1,35 @@
+package client
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// defaultMaxReconnectDelay - cap of the reconnect delay if it's not configured.
+const defaultMaxReconnectDelay = 30 * time.Second
+
+// reconnectDelay - returns the delay before the reconnect attempt, attempts start from 1.
+// The exponential delay is drawn uniformly from zero to the base delay doubled on every attempt (full jitter),
+// the linear delay grows by the base delay on every attempt. Both are capped with the maximum delay.
+func reconnectDelay(base, maxDelay time.Duration, attempt int, linear bool) time.Duration {
+	if base <= 0 || attempt <= 0 {
+		return 0
+	}
+
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxReconnectDelay
+	}
+
+	if linear {
+		return min(base*time.Duration(attempt), maxDelay)
+	}
+
+	// The delay stops doubling at the cap, so it does not overflow.
+	ceiling := base
+	for i := 1; i < attempt && ceiling < maxDelay; i++ {
+		ceiling *= 2
+	}
+	ceiling = min(ceiling, maxDelay)
+
+	return time.Duration(rand.Int64N(int64(ceiling) + 1))
+}
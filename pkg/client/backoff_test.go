@@ -0,0 +1,52 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconnectDelay(t *testing.T) {
+	t.Parallel()
+
+	const (
+		base     = 10 * time.Millisecond
+		maxDelay = 100 * time.Millisecond
+	)
+
+	t.Run("Exponential with jitter", func(t *testing.T) {
+		ceilings := []time.Duration{base, 2 * base, 4 * base, 8 * base, maxDelay, maxDelay}
+		for i, ceiling := range ceilings {
+			attempt := i + 1
+
+			var maxSeen time.Duration
+			for range 200 {
+				delay := reconnectDelay(base, maxDelay, attempt, false)
+				assert.GreaterOrEqual(t, delay, time.Duration(0))
+				assert.LessOrEqual(t, delay, ceiling, "attempt %d", attempt)
+				maxSeen = max(maxSeen, delay)
+			}
+
+			// The delays are spread over the whole range, not pinned to the lower bound.
+			assert.Greater(t, maxSeen, ceiling/2, "attempt %d", attempt)
+		}
+	})
+
+	t.Run("Large attempt respects the cap", func(t *testing.T) {
+		for range 100 {
+			assert.LessOrEqual(t, reconnectDelay(time.Hour, 0, 1000, false), defaultMaxReconnectDelay)
+		}
+	})
+
+	t.Run("Linear", func(t *testing.T) {
+		assert.Equal(t, base, reconnectDelay(base, maxDelay, 1, true))
+		assert.Equal(t, 3*base, reconnectDelay(base, maxDelay, 3, true))
+		assert.Equal(t, maxDelay, reconnectDelay(base, maxDelay, 20, true))
+	})
+
+	t.Run("Zero base delay", func(t *testing.T) {
+		assert.Zero(t, reconnectDelay(0, maxDelay, 5, false))
+		assert.Zero(t, reconnectDelay(0, maxDelay, 5, true))
+	})
+}
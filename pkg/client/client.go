@@ -1,11 +1,16 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -13,6 +18,8 @@ import (
 	"github.com/neekrasov/kvdb/internal/database"
 	"github.com/neekrasov/kvdb/internal/database/compression"
 	"github.com/neekrasov/kvdb/internal/database/compute"
+	"github.com/neekrasov/kvdb/internal/database/pubsub"
+	"github.com/neekrasov/kvdb/internal/database/storage/wal"
 	"github.com/neekrasov/kvdb/internal/delivery/tcp"
 	"github.com/neekrasov/kvdb/pkg/sizeutil"
 )
@@ -23,8 +30,12 @@ var (
 	ErrAuthenticationRequired = errors.New("authentication required")
 	ErrInvalidResponseFormat  = errors.New("invalid response format")
 	ErrKeyNotFound            = errors.New("key not found")
+	ErrIncompatibleServer     = errors.New("incompatible server")
 )
 
+// SupportedProtocolMajor - major version of the client-server protocol supported by the client.
+const SupportedProtocolMajor = 2
+
 type (
 	// NetClientFactory - interface for creating a new client.
 	NetClientFactory interface {
@@ -52,6 +63,11 @@ func buildCommandString(
 	return strings.Join(parts, " ")
 }
 
+// quoteArg - quotes the argument, so the server takes it as a value even if it matches a parameter name.
+func quoteArg(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
 // Config - holds the configuration settings for the KVDB client.
 type Config struct {
 	Username             string        `json:"username"`
@@ -64,6 +80,19 @@ type Config struct {
 	ReconnectBaseDelay   time.Duration `json:"reconnectBaseDelay"`
 	KeepAliveInterval    time.Duration `json:"keepAliveInterval"`
 	Namespace            string        `json:"namespace"`
+	// UseToken - requests a session token after the login and re-authenticates with it on reconnect.
+	UseToken bool `json:"useToken"`
+	// ResponseCompression - codec the server compresses the responses with, empty disables the compression.
+	ResponseCompression string `json:"responseCompression"`
+	// DefaultTimeout - limits every call including the reconnects, unless it's overridden with WithTimeout.
+	DefaultTimeout time.Duration `json:"defaultTimeout"`
+	// MaxReconnectDelay - caps the reconnect delay, zero means 30 seconds.
+	MaxReconnectDelay time.Duration `json:"maxReconnectDelay"`
+	// LinearReconnect - grows the reconnect delay linearly instead of the exponential backoff with jitter.
+	LinearReconnect bool `json:"linearReconnect"`
+	// ServerIdleTimeout - idle timeout requested from the server for the connection, e.g. longer for the watches.
+	// The server clamps it to its network.max_idle_timeout, zero keeps the server idle timeout.
+	ServerIdleTimeout time.Duration `json:"serverIdleTimeout"`
 }
 
 // Client - represents a client for interacting with a KVDB server.
@@ -73,6 +102,8 @@ type Client struct {
 	clientFactory NetClientFactory
 	mu            sync.Mutex
 	client        NetClient
+	token         string
+	serverVersion database.VersionInfo
 }
 
 // New - creates and returns a new Client with the provided configuration.
@@ -113,9 +144,47 @@ func New(
 		return nil, fmt.Errorf("authentication failed: %w", err)
 	}
 
+	if err := client.checkServerVersion(ctx); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+
 	return client, nil
 }
 
+// checkServerVersion - fetches the server version and checks that the protocol major version is supported.
+// Servers predating the version command reject it as unknown, the version is left unknown for them.
+func (k *Client) checkServerVersion(ctx context.Context) error {
+	resp, err := k.sendRetry(ctx, compute.CommandVERSION.String(), k.cfg.DefaultTimeout)
+	if err != nil {
+		if strings.Contains(err.Error(), compute.ErrInvalidCommand.Error()) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to get server version: %w", err)
+	}
+
+	var info database.VersionInfo
+	if err := json.Unmarshal([]byte(resp), &info); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidResponseFormat, err)
+	}
+
+	major, _, _ := strings.Cut(info.Protocol, ".")
+	if v, err := strconv.Atoi(major); err != nil || v != SupportedProtocolMajor {
+		return fmt.Errorf("%w: server protocol '%s', supported major version %d",
+			ErrIncompatibleServer, info.Protocol, SupportedProtocolMajor)
+	}
+	k.serverVersion = info
+
+	return nil
+}
+
+// ServerVersion - returns the versions reported by the server on connect,
+// empty if the server does not support the version command.
+func (k *Client) ServerVersion() database.VersionInfo {
+	return k.serverVersion
+}
+
 // connect - establishes a new connection to the server.
 func (k *Client) connect() error {
 	k.mu.Lock()
@@ -138,6 +207,14 @@ func (k *Client) connect() error {
 		tcpClientOpts = append(tcpClientOpts, tcp.WithClientBufferSize(uint(size)))
 	}
 
+	if k.cfg.ResponseCompression != "" {
+		tcpClientOpts = append(tcpClientOpts, tcp.WithClientCompression(k.cfg.ResponseCompression))
+	}
+
+	if k.cfg.ServerIdleTimeout > 0 {
+		tcpClientOpts = append(tcpClientOpts, tcp.WithClientServerIdleTimeout(k.cfg.ServerIdleTimeout))
+	}
+
 	client, err := k.clientFactory.Make(k.cfg.Address, tcpClientOpts...)
 	if err != nil {
 		return err
@@ -159,9 +236,66 @@ func (k *Client) auth(ctx context.Context) error {
 		return ErrAuthenticationRequired
 	}
 
+	if k.cfg.UseToken {
+		k.refreshToken(ctx)
+	}
+
+	return nil
+}
+
+// refreshToken - requests a new session token, the client falls back
+// to the password on reconnect if the token can't be issued.
+func (k *Client) refreshToken(ctx context.Context) {
+	res, err := k.client.Send(ctx, []byte(compute.CommandTOKEN.String()))
+
+	var token string
+	if val, ok := database.CutOK(string(res)); err == nil && ok {
+		token = strings.TrimSpace(val)
+	}
+
+	k.mu.Lock()
+	k.token = token
+	k.mu.Unlock()
+}
+
+// authToken - performs authentication with the session token.
+func (k *Client) authToken(ctx context.Context, token string) error {
+	cmd := buildCommandString(compute.CommandLOGINTOKEN, []string{token}, nil)
+	res, err := k.client.Send(ctx, []byte(cmd))
+	if err != nil {
+		return fmt.Errorf("token authentication failed: %w", err)
+	}
+
+	if database.IsError(string(res)) {
+		return ErrAuthenticationFailed
+	}
+
 	return nil
 }
 
+// reauth - authenticates the new connection preferring the session token.
+// The server reads the login once per connection, so a rejected token
+// is followed by a new connection authenticated with the password.
+func (k *Client) reauth(ctx context.Context) error {
+	k.mu.Lock()
+	token := k.token
+	k.mu.Unlock()
+
+	if token != "" {
+		err := k.authToken(ctx, token)
+		if err == nil {
+			k.refreshToken(ctx)
+			return nil
+		}
+
+		if err := k.connect(); err != nil {
+			return fmt.Errorf("connect failed: %w", err)
+		}
+	}
+
+	return k.auth(ctx)
+}
+
 // sendWithRetries - sends a request to the server with retries on failure.
 func (k *Client) sendWithRetries(ctx context.Context, request []byte) (string, error) {
 	attempt := 0
@@ -181,14 +315,17 @@ func (k *Client) sendWithRetries(ctx context.Context, request []byte) (string, e
 		resBytes, err := k.client.Send(ctx, request)
 		if err == nil {
 			resString := string(resBytes)
-			if strings.Contains(resString,
-				database.ErrAuthenticationRequired.Error(),
-			) {
+			// Application errors are returned as is, only the lost session is restored.
+			if authenticationRequired(resString) {
 				if err := k.auth(ctx); err != nil {
 					return "", fmt.Errorf("re-authentication failed: %w", err)
 				}
 
-				time.Sleep(time.Second)
+				select {
+				case <-time.After(time.Second):
+				case <-ctx.Done():
+					return "", ctx.Err()
+				}
 				continue
 			}
 
@@ -199,42 +336,83 @@ func (k *Client) sendWithRetries(ctx context.Context, request []byte) (string, e
 			continue
 		}
 
-		if errors.Is(err, context.Canceled) {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 			return "", ctx.Err()
 		}
 
+		if isPermanent(err) {
+			return "", err
+		}
+
 		if err := k.reconnect(ctx, attempt); err != nil {
 			return "", fmt.Errorf("reconnect failed: %w", err)
 		}
 	}
 }
 
-func (k *Client) sendRetry(ctx context.Context, query string) (string, error) {
+// authenticationRequired - reports whether the response is the error of the missing authentication.
+// Successful responses are not checked, the value may contain the error text.
+func authenticationRequired(res string) bool {
+	msg, ok := database.CutError(res)
+	return ok && strings.Contains(msg, database.ErrAuthenticationRequired.Error())
+}
+
+// isPermanent - reports whether the send error is caused by the response rather than by the network,
+// the reconnect does not fix such errors, so they are returned without it.
+func isPermanent(err error) bool {
+	return errors.Is(err, tcp.ErrSmallBufferSize) || errors.Is(err, tcp.ErrInvalidPayload)
+}
+
+// sendRetry - sends the query bounded by the timeout, zero timeout doesn't limit the call.
+func (k *Client) sendRetry(ctx context.Context, query string, timeout time.Duration) (string, error) {
+	val, _, err := k.sendRetryTyped(ctx, query, timeout)
+	return val, err
+}
+
+// sendRetryTyped - sends the query like sendRetry and returns the content type of the response.
+func (k *Client) sendRetryTyped(
+	ctx context.Context, query string, timeout time.Duration,
+) (string, database.ContentType, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	res, err := k.sendWithRetries(ctx, []byte(query))
 	if err != nil {
-		return "", fmt.Errorf("send query failed: %w", err)
+		return "", "", fmt.Errorf("send query failed: %w", err)
 	}
 
 	if database.IsError(res) {
 		val, ok := database.CutError(res)
 		if !ok {
-			return "", ErrInvalidResponseFormat
+			return "", "", ErrInvalidResponseFormat
 		}
 
-		return "", errors.New(val)
+		return "", "", errors.New(val)
 	}
 
-	val, ok := database.CutOK(res)
+	val, contentType, ok := database.CutOKType(res)
 	if !ok {
-		return "", ErrInvalidResponseFormat
+		return "", "", ErrInvalidResponseFormat
+	}
+
+	return strings.TrimLeft(val, " "), contentType, nil
+}
+
+// timeout - returns the timeout of the call, the default timeout is used if it's not set.
+func (k *Client) timeout(options callOptions) time.Duration {
+	if options.timeout > 0 {
+		return options.timeout
 	}
 
-	return strings.TrimLeft(val, " "), nil
+	return k.cfg.DefaultTimeout
 }
 
-// reconnect - attempts to reconnect with lineal backoff.
+// reconnect - attempts to reconnect with exponential backoff with jitter or linear backoff if it's configured.
 func (k *Client) reconnect(ctx context.Context, attempt int) error {
-	delay := k.cfg.ReconnectBaseDelay * time.Duration(attempt)
+	delay := reconnectDelay(k.cfg.ReconnectBaseDelay, k.cfg.MaxReconnectDelay, attempt, k.cfg.LinearReconnect)
 
 	select {
 	case <-time.After(delay):
@@ -246,29 +424,62 @@ func (k *Client) reconnect(ctx context.Context, attempt int) error {
 		return fmt.Errorf("connect failed: %w", err)
 	}
 
-	if err := k.auth(ctx); err != nil {
+	if err := k.reauth(ctx); err != nil {
 		return fmt.Errorf("re-authentication failed: %w", err)
 	}
 
 	return nil
 }
 
+// Login - authenticates the connection as another user, the credentials are used on the following reconnects.
+// The current session is kept if the authentication fails.
+func (k *Client) Login(ctx context.Context, username, password string) error {
+	query := buildCommandString(compute.CommandAUTH, []string{username, password}, nil)
+	if _, err := k.sendRetry(ctx, query, k.cfg.DefaultTimeout); err != nil {
+		return fmt.Errorf("%w: %w", ErrAuthenticationFailed, err)
+	}
+
+	k.mu.Lock()
+	k.cfg.Username, k.cfg.Password = username, password
+	k.token = ""
+	k.mu.Unlock()
+
+	if k.cfg.UseToken {
+		k.refreshToken(ctx)
+	}
+
+	return nil
+}
+
+// Logout - closes the session of the connection, the following calls on the connection fail until Login is called.
+func (k *Client) Logout(ctx context.Context) error {
+	if _, err := k.sendRetry(ctx, compute.CommandLOGOUT.String(), k.cfg.DefaultTimeout); err != nil {
+		return fmt.Errorf("logout failed: %w", err)
+	}
+
+	k.mu.Lock()
+	k.token = ""
+	k.mu.Unlock()
+
+	return nil
+}
+
 // Send - sends a query to the KVDB server and returns the result or an error.
-func (k *Client) Raw(ctx context.Context, query string) (string, error) {
-	return k.sendRetry(ctx, query)
+func (k *Client) Raw(ctx context.Context, query string, opts ...Option) (string, error) {
+	return k.sendRetry(ctx, query, k.timeout(applyOptions(opts)))
+}
+
+// RawTyped - sends a query like Raw and returns the content type the server tagged the result with.
+func (k *Client) RawTyped(ctx context.Context, query string, opts ...Option) (string, database.ContentType, error) {
+	return k.sendRetryTyped(ctx, query, k.timeout(applyOptions(opts)))
 }
 
 // Set - stores a value for a given key.
 func (k *Client) Set(ctx context.Context, key, value string, opts ...Option) error {
 	options := applyOptions(opts)
-	processedValue := value
-
-	if options.compressor != nil {
-		compressed, err := options.compressor.Compress([]byte(value))
-		if err != nil {
-			return fmt.Errorf("failed to compress value for key '%s': %w", key, err)
-		}
-		processedValue = base64.StdEncoding.EncodeToString(compressed)
+	processedValue, err := encodeValue(options, key, value)
+	if err != nil {
+		return err
 	}
 
 	args := make(map[string]string)
@@ -283,7 +494,57 @@ func (k *Client) Set(ctx context.Context, key, value string, opts ...Option) err
 	}
 
 	query := buildCommandString(compute.CommandSET, []string{key, processedValue}, args)
-	if _, err := k.sendRetry(ctx, query); err != nil {
+	if options.prev != nil {
+		query += " " + compute.GetFlagArg
+	}
+	if options.binary {
+		query += " " + compute.Base64FlagArg
+	}
+
+	resp, err := k.sendRetry(ctx, query, k.timeout(options))
+	if err != nil {
+		return fmt.Errorf("failed to set key '%s': %w", key, err)
+	}
+
+	if options.prev == nil {
+		return nil
+	}
+
+	var prev database.PrevValue
+	if err := json.Unmarshal([]byte(resp), &prev); err != nil {
+		return ErrInvalidResponseFormat
+	}
+
+	if prev.Exists {
+		if prev.Value, err = decodeValue(options, key, prev.Value); err != nil {
+			return err
+		}
+	}
+	*options.prev = prev
+
+	return nil
+}
+
+// SetEX - stores a value for a given key expiring after the ttl, the ttl is rounded up to whole seconds.
+// The namespace and timeout options are applied like in Set.
+func (k *Client) SetEX(ctx context.Context, key, value string, ttl time.Duration, opts ...Option) error {
+	if ttl <= 0 {
+		return fmt.Errorf("failed to set key '%s': ttl must be positive", key)
+	}
+	seconds := int64((ttl + time.Second - 1) / time.Second)
+	options := applyOptions(opts)
+
+	args := make(map[string]string)
+	if strings.TrimSpace(k.cfg.Namespace) != "" {
+		args[compute.NSArg] = k.cfg.Namespace
+	}
+	if options.namespace != "" {
+		args[compute.NSArg] = options.namespace
+	}
+
+	positional := []string{key, strconv.FormatInt(seconds, 10), value}
+	query := buildCommandString(compute.CommandSETEX, positional, args)
+	if _, err := k.sendRetry(ctx, query, k.timeout(options)); err != nil {
 		return fmt.Errorf("failed to set key '%s': %w", key, err)
 	}
 
@@ -302,8 +563,23 @@ func (k *Client) Get(ctx context.Context, key string, opts ...Option) (string, e
 		args[compute.NSArg] = options.namespace
 	}
 
+	if options.def != nil {
+		def, err := encodeValue(options, key, *options.def)
+		if err != nil {
+			return "", err
+		}
+		args[compute.DefaultArg] = def
+	}
+
 	query := buildCommandString(compute.CommandGET, []string{key}, args)
-	responsePayload, err := k.sendRetry(ctx, query)
+	if options.def != nil && options.setDefault {
+		query += " " + compute.SetDefaultFlagArg
+	}
+	if options.binary {
+		query += " " + compute.Base64FlagArg
+	}
+
+	responsePayload, err := k.sendRetry(ctx, query, k.timeout(options))
 	if err != nil {
 		if strings.Contains(err.Error(), compute.ErrKeyNotFound.Error()) {
 			return "", ErrKeyNotFound
@@ -312,24 +588,101 @@ func (k *Client) Get(ctx context.Context, key string, opts ...Option) (string, e
 		return "", fmt.Errorf("failed to get key '%s': %w", key, err)
 	}
 
-	if options.compressor != nil {
-		compressedValue, err := base64.StdEncoding.DecodeString(responsePayload)
-		if err != nil {
-			return "", fmt.Errorf("failed to decode base64 for key '%s': %w", key, err)
+	return decodeValue(options, key, responsePayload)
+}
+
+// Type - returns how the value of the key is interpreted: database.TypeString, database.TypeInt
+// or database.TypeNone if the key does not exist.
+func (k *Client) Type(ctx context.Context, key string, opts ...Option) (string, error) {
+	options := applyOptions(opts)
+
+	args := make(map[string]string)
+	if k.cfg.Namespace != "" {
+		args[compute.NSArg] = k.cfg.Namespace
+	}
+	if options.namespace != "" {
+		args[compute.NSArg] = options.namespace
+	}
+
+	query := buildCommandString(compute.CommandTYPE, []string{key}, args)
+	resp, err := k.sendRetry(ctx, query, k.timeout(options))
+	if err != nil {
+		return "", fmt.Errorf("failed to get type of key '%s': %w", key, err)
+	}
+
+	return resp, nil
+}
+
+// GetDel - retrieves the value of the key and removes the key in one operation.
+func (k *Client) GetDel(ctx context.Context, key string, opts ...Option) (string, error) {
+	options := applyOptions(opts)
+
+	args := make(map[string]string)
+	if k.cfg.Namespace != "" {
+		args[compute.NSArg] = k.cfg.Namespace
+	}
+	if options.namespace != "" {
+		args[compute.NSArg] = options.namespace
+	}
+
+	query := buildCommandString(compute.CommandGETDEL, []string{key}, args)
+	if options.binary {
+		query += " " + compute.Base64FlagArg
+	}
+	responsePayload, err := k.sendRetry(ctx, query, k.timeout(options))
+	if err != nil {
+		if strings.Contains(err.Error(), compute.ErrKeyNotFound.Error()) {
+			return "", ErrKeyNotFound
 		}
-		decompressedValue, err := options.compressor.Decompress(compressedValue)
+
+		return "", fmt.Errorf("failed to getdel key '%s': %w", key, err)
+	}
+
+	return decodeValue(options, key, responsePayload)
+}
+
+// encodeValue - compresses the value with the compressor of the call or encodes the binary value in base64.
+func encodeValue(options callOptions, key, value string) (string, error) {
+	if options.compressor != nil {
+		compressed, err := options.compressor.Compress([]byte(value))
 		if err != nil {
-			return "", fmt.Errorf("failed to decompress value for key '%s': %w", key, err)
+			return "", fmt.Errorf("failed to compress value for key '%s': %w", key, err)
 		}
+		return base64.StdEncoding.EncodeToString(compressed), nil
+	}
 
-		return string(decompressedValue), nil
+	if options.binary {
+		return base64.StdEncoding.EncodeToString([]byte(value)), nil
 	}
 
-	return responsePayload, nil
+	return value, nil
+}
+
+// decodeValue - decodes the base64 value of the key if the binary option is set
+// and decompresses it if the compressor option is set.
+func decodeValue(options callOptions, key, payload string) (string, error) {
+	if options.compressor == nil && !options.binary {
+		return payload, nil
+	}
+
+	compressedValue, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode base64 for key '%s': %w", key, err)
+	}
+	if options.compressor == nil {
+		return string(compressedValue), nil
+	}
+
+	decompressedValue, err := options.compressor.Decompress(compressedValue)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress value for key '%s': %w", key, err)
+	}
+
+	return string(decompressedValue), nil
 }
 
 // Del - removes a key and its value from the storage.
-func (k *Client) Del(ctx context.Context, key string, opts ...Option) error {
+func (k *Client) Del(ctx context.Context, key string, opts ...Option) (bool, error) {
 	options := applyOptions(opts)
 
 	args := make(map[string]string)
@@ -340,16 +693,28 @@ func (k *Client) Del(ctx context.Context, key string, opts ...Option) error {
 		args[compute.NSArg] = options.namespace
 	}
 
-	query := buildCommandString(compute.CommandDEL, []string{key}, args)
-	if _, err := k.sendRetry(ctx, query); err != nil {
-		return fmt.Errorf("failed to delete key '%s': %w", key, err)
+	query := buildCommandString(compute.CommandDEL, []string{key, compute.CountArg}, args)
+	resp, err := k.sendRetry(ctx, query, k.timeout(options))
+	if err != nil {
+		return false, fmt.Errorf("failed to delete key '%s': %w", key, err)
 	}
 
-	return nil
+	switch resp {
+	case "1":
+		return true, nil
+	case "0":
+		return false, nil
+	default:
+		return false, ErrInvalidResponseFormat
+	}
 }
 
-// Watch - watches the key and returns the value if it has changed.
-func (k *Client) Watch(ctx context.Context, key string, opts ...Option) (string, error) {
+// DelMany - removes the keys in one command and returns the number of removed existing keys.
+func (k *Client) DelMany(ctx context.Context, keys []string, opts ...Option) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
 	options := applyOptions(opts)
 
 	args := make(map[string]string)
@@ -360,54 +725,792 @@ func (k *Client) Watch(ctx context.Context, key string, opts ...Option) (string,
 		args[compute.NSArg] = options.namespace
 	}
 
-	query := buildCommandString(compute.CommandWATCH, []string{key}, args)
-	responsePayload, err := k.sendRetry(ctx, query)
+	// The keys are quoted, a key named as a parameter, e.g. count, would end the key list.
+	// The count flag makes the response of a single key the count as well.
+	positional := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		positional = append(positional, quoteArg(key))
+	}
+	query := buildCommandString(compute.CommandDEL, append(positional, compute.CountArg), args)
+	resp, err := k.sendRetry(ctx, query, k.timeout(options))
 	if err != nil {
-		return "", fmt.Errorf("failed to watch key '%s': %w", key, err)
+		return 0, fmt.Errorf("failed to delete keys: %w", err)
 	}
 
-	if options.compressor != nil {
-		compressedValue, err := base64.StdEncoding.DecodeString(responsePayload)
-		if err != nil {
-			return "", fmt.Errorf("failed to decode base64 for watched key '%s': %w", key, err)
-		}
-		decompressedValue, err := options.compressor.Decompress(compressedValue)
-		if err != nil {
-			return "", fmt.Errorf("failed to decompress value for watched key '%s': %w", key, err)
-		}
-
-		return string(decompressedValue), nil
+	deleted, err := strconv.Atoi(resp)
+	if err != nil {
+		return 0, ErrInvalidResponseFormat
 	}
 
-	return responsePayload, nil
+	return deleted, nil
 }
 
-// Stats - returns the collected database statistics.
-func (k *Client) Stats(ctx context.Context, key string) (*database.Stats, error) {
-	resp, err := k.sendRetry(ctx, compute.CommandSTAT.Make())
+// DelPrefix - removes all keys of the namespace starting with the prefix.
+// Returns the number of removed keys.
+func (k *Client) DelPrefix(ctx context.Context, prefix string, opts ...Option) (int, error) {
+	if prefix == "" {
+		return 0, errors.New("failed to delete keys by prefix: empty prefix")
+	}
+	options := applyOptions(opts)
+
+	args := make(map[string]string)
+	if k.cfg.Namespace != "" {
+		args[compute.NSArg] = k.cfg.Namespace
+	}
+	if options.namespace != "" {
+		args[compute.NSArg] = options.namespace
+	}
+
+	query := buildCommandString(compute.CommandDELPREFIX, []string{prefix}, args)
+	resp, err := k.sendRetry(ctx, query, k.timeout(options))
 	if err != nil {
-		return nil, fmt.Errorf("failed to watch key '%s': %w", key, err)
+		return 0, fmt.Errorf("failed to delete keys by prefix '%s': %w", prefix, err)
 	}
 
-	var stats database.Stats
-	if err := json.Unmarshal([]byte(resp), &stats); err != nil {
-		return nil, err
+	deleted, err := strconv.Atoi(resp)
+	if err != nil {
+		return 0, ErrInvalidResponseFormat
 	}
 
-	return &stats, nil
+	return deleted, nil
 }
 
-// Close - closes all kvdb client connections.
-func (k *Client) Close() error {
-	k.mu.Lock()
-	defer k.mu.Unlock()
+// RenameNX - renames the key only if the new key does not exist.
+// Returns whether the rename happened.
+func (k *Client) RenameNX(ctx context.Context, oldKey, newKey string, opts ...Option) (bool, error) {
+	options := applyOptions(opts)
 
-	if k.client != nil {
-		if err := k.client.Close(); err != nil {
-			return fmt.Errorf("error closing connection: %w", err)
+	args := make(map[string]string)
+	if k.cfg.Namespace != "" {
+		args[compute.NSArg] = k.cfg.Namespace
+	}
+	if options.namespace != "" {
+		args[compute.NSArg] = options.namespace
+	}
+
+	query := buildCommandString(compute.CommandRENAMENX, []string{oldKey, newKey}, args)
+	responsePayload, err := k.sendRetry(ctx, query, k.timeout(options))
+	if err != nil {
+		if strings.Contains(err.Error(), compute.ErrKeyNotFound.Error()) {
+			return false, ErrKeyNotFound
 		}
-		k.client = nil
+
+		return false, fmt.Errorf("failed to rename key '%s': %w", oldKey, err)
 	}
 
-	return nil
+	renamed, err := strconv.ParseBool(responsePayload)
+	if err != nil {
+		return false, ErrInvalidResponseFormat
+	}
+
+	return renamed, nil
+}
+
+// MSetNX - stores all the key-value pairs only if none of the keys exist.
+// Returns whether the pairs are stored.
+func (k *Client) MSetNX(ctx context.Context, pairs map[string]string, opts ...Option) (bool, error) {
+	options := applyOptions(opts)
+
+	args := make(map[string]string)
+	if k.cfg.Namespace != "" {
+		args[compute.NSArg] = k.cfg.Namespace
+	}
+	if options.namespace != "" {
+		args[compute.NSArg] = options.namespace
+	}
+
+	keys := slices.Sorted(maps.Keys(pairs))
+	positional := make([]string, 0, len(pairs)*2)
+	for _, key := range keys {
+		positional = append(positional, key, pairs[key])
+	}
+
+	query := buildCommandString(compute.CommandMSETNX, positional, args)
+	responsePayload, err := k.sendRetry(ctx, query, k.timeout(options))
+	if err != nil {
+		return false, fmt.Errorf("failed to set keys: %w", err)
+	}
+
+	stored, err := strconv.ParseBool(responsePayload)
+	if err != nil {
+		return false, ErrInvalidResponseFormat
+	}
+
+	return stored, nil
+}
+
+// Scan - returns a batch of keys starting from the cursor and the cursor of the next batch.
+// The iteration starts with zero cursor and is done when zero cursor is returned.
+func (k *Client) Scan(ctx context.Context, cursor uint64, opts ...Option) ([]string, uint64, error) {
+	options := applyOptions(opts)
+
+	args := make(map[string]string)
+	if k.cfg.Namespace != "" {
+		args[compute.NSArg] = k.cfg.Namespace
+	}
+	if options.namespace != "" {
+		args[compute.NSArg] = options.namespace
+	}
+	if options.match != "" {
+		args[compute.MatchArg] = options.match
+	}
+	if options.count > 0 {
+		args[compute.CountArg] = strconv.Itoa(options.count)
+	}
+
+	cursorArg := strconv.FormatUint(cursor, 10)
+	query := buildCommandString(compute.CommandSCAN, []string{cursorArg}, args)
+	responsePayload, err := k.sendRetry(ctx, query, k.timeout(options))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to scan keys from cursor %d: %w", cursor, err)
+	}
+
+	var res database.ScanResult
+	if err := json.Unmarshal([]byte(responsePayload), &res); err != nil {
+		return nil, 0, ErrInvalidResponseFormat
+	}
+
+	return res.Keys, res.Cursor, nil
+}
+
+// Expiring - returns the keys expiring within the duration, the soonest first.
+func (k *Client) Expiring(ctx context.Context, within time.Duration, opts ...Option) ([]string, error) {
+	options := applyOptions(opts)
+
+	args := make(map[string]string)
+	if k.cfg.Namespace != "" {
+		args[compute.NSArg] = k.cfg.Namespace
+	}
+	if options.namespace != "" {
+		args[compute.NSArg] = options.namespace
+	}
+
+	query := buildCommandString(compute.CommandEXPIRING, []string{within.String()}, args)
+	responsePayload, err := k.sendRetry(ctx, query, k.timeout(options))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys expiring within %s: %w", within, err)
+	}
+
+	var keys []string
+	if err := json.Unmarshal([]byte(responsePayload), &keys); err != nil {
+		return nil, ErrInvalidResponseFormat
+	}
+
+	return keys, nil
+}
+
+// LastAccess - returns the time the key was last read or written, the server must track the access.
+func (k *Client) LastAccess(ctx context.Context, key string, opts ...Option) (time.Time, error) {
+	options := applyOptions(opts)
+
+	args := make(map[string]string)
+	if k.cfg.Namespace != "" {
+		args[compute.NSArg] = k.cfg.Namespace
+	}
+	if options.namespace != "" {
+		args[compute.NSArg] = options.namespace
+	}
+
+	query := buildCommandString(compute.CommandLASTACCESS, []string{key}, args)
+	responsePayload, err := k.sendRetry(ctx, query, k.timeout(options))
+	if err != nil {
+		if strings.Contains(err.Error(), compute.ErrKeyNotFound.Error()) {
+			return time.Time{}, ErrKeyNotFound
+		}
+
+		return time.Time{}, fmt.Errorf("failed to get last access of key '%s': %w", key, err)
+	}
+
+	accessed, err := time.Parse(time.RFC3339, responsePayload)
+	if err != nil {
+		return time.Time{}, ErrInvalidResponseFormat
+	}
+
+	return accessed, nil
+}
+
+// Watch - watches the key and returns the value if it has changed.
+// The default timeout does not apply to the long-polling watch, only the WithTimeout option limits it.
+func (k *Client) Watch(ctx context.Context, key string, opts ...Option) (string, error) {
+	options := applyOptions(opts)
+
+	args := make(map[string]string)
+	if k.cfg.Namespace != "" {
+		args[compute.NSArg] = k.cfg.Namespace
+	}
+	if options.namespace != "" {
+		args[compute.NSArg] = options.namespace
+	}
+
+	query := buildCommandString(compute.CommandWATCH, []string{key}, args)
+	responsePayload, err := k.sendRetry(ctx, query, options.timeout)
+	if err != nil {
+		return "", fmt.Errorf("failed to watch key '%s': %w", key, err)
+	}
+
+	if options.compressor != nil {
+		compressedValue, err := base64.StdEncoding.DecodeString(responsePayload)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode base64 for watched key '%s': %w", key, err)
+		}
+		decompressedValue, err := options.compressor.Decompress(compressedValue)
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress value for watched key '%s': %w", key, err)
+		}
+
+		return string(decompressedValue), nil
+	}
+
+	return responsePayload, nil
+}
+
+// Subscribe - streams the values of the key until the context is canceled. The key is watched
+// on a dedicated connection authenticated with the credentials of the client, so the stream
+// does not block the other calls.
+//
+// The delivery is at-most-once: every value is delivered once, the changes made while the watch
+// is re-registered are coalesced into the current value, which is read after every change and
+// delivered if it differs from the last delivered one. The changes made while the dedicated
+// connection is restored are delivered with the next change of the key.
+// The channel is closed when the context is canceled or the watch fails after the reconnect attempts.
+func (k *Client) Subscribe(ctx context.Context, key string, opts ...Option) (<-chan string, error) {
+	k.mu.Lock()
+	cfg := *k.cfg
+	k.mu.Unlock()
+
+	sub, err := New(ctx, &cfg, k.clientFactory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open subscription connection: %w", err)
+	}
+
+	last, err := sub.Get(ctx, key, opts...)
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		_ = sub.Close()
+		return nil, fmt.Errorf("failed to subscribe to key '%s': %w", key, err)
+	}
+
+	values := make(chan string)
+	go func() {
+		defer close(values)
+		defer sub.Close()
+
+		deliver := func(value string) bool {
+			select {
+			case values <- value:
+				last = value
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			value, err := sub.Watch(ctx, key, opts...)
+			if err != nil || ctx.Err() != nil {
+				return
+			}
+
+			if !deliver(value) {
+				return
+			}
+
+			// The changes made before the next watch is registered are caught by the current value.
+			current, err := sub.Get(ctx, key, opts...)
+			if err == nil && current != last && !deliver(current) {
+				return
+			}
+		}
+	}()
+
+	return values, nil
+}
+
+// Publish - sends the message to the current subscribers of the channel and returns the number of subscribers.
+func (k *Client) Publish(ctx context.Context, channel, message string, opts ...Option) (int, error) {
+	options := applyOptions(opts)
+
+	args := make(map[string]string)
+	if k.cfg.Namespace != "" {
+		args[compute.NSArg] = k.cfg.Namespace
+	}
+	if options.namespace != "" {
+		args[compute.NSArg] = options.namespace
+	}
+
+	query := buildCommandString(compute.CommandPUBLISH, []string{channel, message}, args)
+	resp, err := k.sendRetry(ctx, query, k.timeout(options))
+	if err != nil {
+		return 0, fmt.Errorf("failed to publish to channel '%s': %w", channel, err)
+	}
+
+	subscribers, err := strconv.Atoi(resp)
+	if err != nil {
+		return 0, ErrInvalidResponseFormat
+	}
+
+	return subscribers, nil
+}
+
+// SubscribeChannel - streams the messages published to the channel until the context is canceled.
+// The channel is listened on a dedicated connection authenticated with the credentials of the client,
+// so the stream does not block the other calls. The subscription is registered before it returns,
+// the messages published later are delivered.
+//
+// The messages are not persisted: the messages published while the dedicated connection is restored are lost.
+// The returned channel is closed when the context is canceled or the listen fails after the reconnect attempts.
+func (k *Client) SubscribeChannel(ctx context.Context, channel string, opts ...Option) (<-chan string, error) {
+	k.mu.Lock()
+	cfg := *k.cfg
+	k.mu.Unlock()
+
+	sub, err := New(ctx, &cfg, k.clientFactory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open subscription connection: %w", err)
+	}
+
+	options := applyOptions(opts)
+	args := make(map[string]string)
+	if cfg.Namespace != "" {
+		args[compute.NSArg] = cfg.Namespace
+	}
+	if options.namespace != "" {
+		args[compute.NSArg] = options.namespace
+	}
+
+	subscribe := buildCommandString(compute.CommandSUBSCRIBE, []string{channel}, args)
+	if _, err := sub.sendRetry(ctx, subscribe, sub.timeout(options)); err != nil {
+		_ = sub.Close()
+		return nil, fmt.Errorf("failed to subscribe to channel '%s': %w", channel, err)
+	}
+
+	listen := buildCommandString(compute.CommandLISTEN, []string{channel}, args)
+	messages := make(chan string)
+	go func() {
+		defer close(messages)
+		defer sub.Close()
+
+		for {
+			resp, err := sub.sendRetry(ctx, listen, options.timeout)
+			if ctx.Err() != nil {
+				return
+			}
+
+			// The reconnected session is not subscribed, so the subscription is registered again.
+			if err != nil && strings.Contains(err.Error(), pubsub.ErrNotSubscribed.Error()) {
+				if _, err := sub.sendRetry(ctx, subscribe, sub.timeout(options)); err != nil {
+					return
+				}
+				continue
+			}
+			if err != nil {
+				return
+			}
+
+			var batch []string
+			if err := json.Unmarshal([]byte(resp), &batch); err != nil {
+				return
+			}
+
+			for _, message := range batch {
+				select {
+				case messages <- message:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return messages, nil
+}
+
+// Stats - returns the collected database statistics.
+func (k *Client) Stats(ctx context.Context, key string) (*database.Stats, error) {
+	resp, err := k.sendRetry(ctx, compute.CommandSTAT.Make(), k.cfg.DefaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch key '%s': %w", key, err)
+	}
+
+	var stats database.Stats
+	if err := json.Unmarshal([]byte(resp), &stats); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// TypedStats - snapshot of the server statistics along with the metrics derived on the client.
+type TypedStats struct {
+	database.Stats
+	// OpsPerSecond - average number of the executed commands per second since the server start,
+	// nil if the server does not collect the storage statistics.
+	OpsPerSecond *float64
+	// HitRatio - share of the GET, GETSET and GETDEL commands that found the key, nil until the hits or misses are counted.
+	HitRatio *float64
+}
+
+// StatsTyped - returns the server statistics with the derived ops per second and hit ratio.
+func (k *Client) StatsTyped(ctx context.Context) (*TypedStats, error) {
+	resp, err := k.sendRetry(ctx, compute.CommandSTAT.Make(), k.cfg.DefaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+
+	var stats TypedStats
+	if err := json.Unmarshal([]byte(resp), &stats.Stats); err != nil {
+		return nil, err
+	}
+
+	// The storage counters are zero while the server does not collect them.
+	if stats.Uptime > 0 {
+		ops := float64(stats.TotalCommands) / stats.Uptime
+		stats.OpsPerSecond = &ops
+	}
+
+	if stats.Hits+stats.Misses > 0 {
+		ratio := float64(stats.Hits) / float64(stats.Hits+stats.Misses)
+		stats.HitRatio = &ratio
+	}
+
+	return &stats, nil
+}
+
+// SessionInfo - returns the information about the current session.
+func (k *Client) SessionInfo(ctx context.Context) (*database.SessionInfo, error) {
+	resp, err := k.sendRetry(ctx, compute.CommandSESSIONINFO.String(), k.cfg.DefaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session info: %w", err)
+	}
+
+	var info database.SessionInfo
+	if err := json.Unmarshal([]byte(resp), &info); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// Dump - returns the stored value of the key with its expiry and engine metadata, requires the admin.
+func (k *Client) Dump(ctx context.Context, key string, opts ...Option) (*database.EntryDump, error) {
+	options := applyOptions(opts)
+
+	args := make(map[string]string)
+	if k.cfg.Namespace != "" {
+		args[compute.NSArg] = k.cfg.Namespace
+	}
+	if options.namespace != "" {
+		args[compute.NSArg] = options.namespace
+	}
+
+	query := buildCommandString(compute.CommandDUMP, []string{key}, args)
+	resp, err := k.sendRetry(ctx, query, k.timeout(options))
+	if err != nil {
+		if strings.Contains(err.Error(), compute.ErrKeyNotFound.Error()) {
+			return nil, ErrKeyNotFound
+		}
+
+		return nil, fmt.Errorf("failed to dump key '%s': %w", key, err)
+	}
+
+	var dump database.EntryDump
+	if err := json.Unmarshal([]byte(resp), &dump); err != nil {
+		return nil, ErrInvalidResponseFormat
+	}
+
+	return &dump, nil
+}
+
+// Caps - returns the commands the current session may execute.
+func (k *Client) Caps(ctx context.Context) ([]string, error) {
+	resp, err := k.sendRetry(ctx, compute.CommandCAPS.String(), k.cfg.DefaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get caps: %w", err)
+	}
+
+	var commands []string
+	if err := json.Unmarshal([]byte(resp), &commands); err != nil {
+		return nil, ErrInvalidResponseFormat
+	}
+
+	return commands, nil
+}
+
+// Connections - returns the client connections of the server, requires the admin user.
+func (k *Client) Connections(ctx context.Context) ([]database.ConnInfo, error) {
+	resp, err := k.sendRetry(ctx, compute.CommandCONNECTIONS.String(), k.cfg.DefaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connections: %w", err)
+	}
+
+	var conns []database.ConnInfo
+	if err := json.Unmarshal([]byte(resp), &conns); err != nil {
+		return nil, ErrInvalidResponseFormat
+	}
+
+	return conns, nil
+}
+
+// Health - returns the health and readiness of the database.
+func (k *Client) Health(ctx context.Context) (*database.Health, error) {
+	resp, err := k.sendRetry(ctx, compute.CommandHEALTH.String(), k.cfg.DefaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get health: %w", err)
+	}
+
+	readiness, ok := strings.CutPrefix(resp, database.HealthyStatus)
+	if !ok {
+		return nil, ErrInvalidResponseFormat
+	}
+
+	var health database.Health
+	if err := json.Unmarshal([]byte(readiness), &health); err != nil {
+		return nil, ErrInvalidResponseFormat
+	}
+
+	return &health, nil
+}
+
+// DBSize - returns the number of keys per namespace.
+func (k *Client) DBSize(ctx context.Context) (map[string]int, error) {
+	resp, err := k.sendRetry(ctx, compute.CommandDBSIZE.String(), k.cfg.DefaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db size: %w", err)
+	}
+
+	var sizes map[string]int
+	if err := json.Unmarshal([]byte(resp), &sizes); err != nil {
+		return nil, err
+	}
+
+	return sizes, nil
+}
+
+// FlushNamespace - deletes all keys in the namespace.
+// Returns the number of deleted keys.
+func (k *Client) FlushNamespace(ctx context.Context, namespace string) (int, error) {
+	query := buildCommandString(compute.CommandFLUSHNS, []string{namespace}, nil)
+	resp, err := k.sendRetry(ctx, query, k.cfg.DefaultTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("failed to flush namespace '%s': %w", namespace, err)
+	}
+
+	deleted, err := strconv.Atoi(resp)
+	if err != nil {
+		return 0, ErrInvalidResponseFormat
+	}
+
+	return deleted, nil
+}
+
+// Users - returns the usernames, the WithOffset and WithLimit options select a page of the list.
+func (k *Client) Users(ctx context.Context, opts ...Option) ([]string, error) {
+	options := applyOptions(opts)
+
+	resp, err := k.sendRetry(ctx, buildCommandString(compute.CommandUSERS, nil, pageArgs(options)), k.timeout(options))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	var users []string
+	if err := json.Unmarshal([]byte(resp), &users); err != nil {
+		return nil, ErrInvalidResponseFormat
+	}
+
+	return users, nil
+}
+
+// Roles - returns the role names, the WithOffset and WithLimit options select a page of the list.
+func (k *Client) Roles(ctx context.Context, opts ...Option) ([]string, error) {
+	options := applyOptions(opts)
+
+	resp, err := k.sendRetry(ctx, buildCommandString(compute.CommandROLES, nil, pageArgs(options)), k.timeout(options))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	var roles []string
+	if err := json.Unmarshal([]byte(resp), &roles); err != nil {
+		return nil, ErrInvalidResponseFormat
+	}
+
+	return roles, nil
+}
+
+// pageArgs - returns the offset and limit args of the listing commands.
+func pageArgs(options callOptions) map[string]string {
+	args := make(map[string]string)
+	if options.offset > 0 {
+		args[compute.OffsetArg] = strconv.Itoa(options.offset)
+	}
+	if options.limit > 0 {
+		args[compute.LimitArg] = strconv.Itoa(options.limit)
+	}
+
+	return args
+}
+
+// UpdateRole - changes the permissions of the role in place, the empty namespace keeps the namespace of the role.
+func (k *Client) UpdateRole(ctx context.Context, role, permissions, namespace string) error {
+	args := []string{role, permissions}
+	if namespace != "" {
+		args = append(args, namespace)
+	}
+
+	query := buildCommandString(compute.CommandUPDATEROLE, args, nil)
+	if _, err := k.sendRetry(ctx, query, k.cfg.DefaultTimeout); err != nil {
+		return fmt.Errorf("failed to update role '%s': %w", role, err)
+	}
+
+	return nil
+}
+
+// SetRole - activates the role assigned to the user for the current session.
+func (k *Client) SetRole(ctx context.Context, role string) error {
+	query := buildCommandString(compute.CommandSETROLE, []string{role}, nil)
+	if _, err := k.sendRetry(ctx, query, k.cfg.DefaultTimeout); err != nil {
+		return fmt.Errorf("failed to set role '%s': %w", role, err)
+	}
+
+	return nil
+}
+
+// RoleUsers - returns the usernames assigned the role.
+func (k *Client) RoleUsers(ctx context.Context, role string) ([]string, error) {
+	query := buildCommandString(compute.CommandROLEUSERS, []string{role}, nil)
+	resp, err := k.sendRetry(ctx, query, k.cfg.DefaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users of role '%s': %w", role, err)
+	}
+
+	var users []string
+	if err := json.Unmarshal([]byte(resp), &users); err != nil {
+		return nil, ErrInvalidResponseFormat
+	}
+
+	return users, nil
+}
+
+// Export - returns the dump of all keys of the namespace, the dump is loaded back with Import.
+// The dump is sent in a single message, so it's limited by the max message size of the server.
+func (k *Client) Export(ctx context.Context, namespace string) (io.Reader, error) {
+	query := buildCommandString(compute.CommandEXPORT, []string{namespace}, nil)
+	resp, err := k.sendRetry(ctx, query, k.cfg.DefaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export namespace '%s': %w", namespace, err)
+	}
+
+	dump, err := base64.StdEncoding.DecodeString(resp)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidResponseFormat, err)
+	}
+
+	return bytes.NewReader(dump), nil
+}
+
+// Import - loads the dump created by Export into the namespace.
+func (k *Client) Import(ctx context.Context, namespace string, dump io.Reader) error {
+	data, err := io.ReadAll(dump)
+	if err != nil {
+		return fmt.Errorf("failed to read dump: %w", err)
+	}
+
+	query := buildCommandString(compute.CommandIMPORT, []string{namespace, base64.StdEncoding.EncodeToString(data)}, nil)
+	if _, err := k.sendRetry(ctx, query, k.cfg.DefaultTimeout); err != nil {
+		return fmt.Errorf("failed to import namespace '%s': %w", namespace, err)
+	}
+
+	return nil
+}
+
+// WALLatency - returns the WAL write latency percentiles.
+func (k *Client) WALLatency(ctx context.Context) (*wal.LatencyStats, error) {
+	resp, err := k.sendRetry(ctx, compute.CommandWALLATENCY.String(), k.cfg.DefaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wal latency: %w", err)
+	}
+
+	var latency wal.LatencyStats
+	if err := json.Unmarshal([]byte(resp), &latency); err != nil {
+		return nil, err
+	}
+
+	return &latency, nil
+}
+
+// Compact - runs a WAL compaction pass and returns the reclaimed bytes and the removed segments.
+func (k *Client) Compact(ctx context.Context) (*wal.CompactionResult, error) {
+	resp, err := k.sendRetry(ctx, compute.CommandCOMPACT.String(), k.cfg.DefaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compact wal: %w", err)
+	}
+
+	var result wal.CompactionResult
+	if err := json.Unmarshal([]byte(resp), &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Sync - writes the pending WAL batch of the server and commits the log to the disk, requires the admin user.
+// It returns once the acknowledged writes are durable.
+func (k *Client) Sync(ctx context.Context) error {
+	if _, err := k.sendRetry(ctx, compute.CommandFSYNC.String(), k.cfg.DefaultTimeout); err != nil {
+		return fmt.Errorf("failed to sync wal: %w", err)
+	}
+
+	return nil
+}
+
+// Close - closes all kvdb client connections.
+func (k *Client) Close() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.client != nil {
+		if err := k.client.Close(); err != nil {
+			return fmt.Errorf("error closing connection: %w", err)
+		}
+		k.client = nil
+	}
+
+	return nil
+}
+
+// ScopedClient - handle of the client running the commands against the namespace,
+// it shares the connection of the client.
+type ScopedClient struct {
+	client    *Client
+	namespace string
+}
+
+// WithNamespace - returns a handle whose commands default to the namespace,
+// the client itself and its config are not changed.
+func (k *Client) WithNamespace(namespace string) *ScopedClient {
+	return &ScopedClient{client: k, namespace: namespace}
+}
+
+// Namespace - returns the namespace of the scoped client.
+func (s *ScopedClient) Namespace() string {
+	return s.namespace
+}
+
+// Set - stores a value for a given key in the namespace.
+func (s *ScopedClient) Set(ctx context.Context, key, value string, opts ...Option) error {
+	return s.client.Set(ctx, key, value, s.options(opts)...)
+}
+
+// Get - retrieves the value associated with a given key in the namespace.
+func (s *ScopedClient) Get(ctx context.Context, key string, opts ...Option) (string, error) {
+	return s.client.Get(ctx, key, s.options(opts)...)
+}
+
+// Del - removes a key and its value from the namespace.
+func (s *ScopedClient) Del(ctx context.Context, key string, opts ...Option) (bool, error) {
+	return s.client.Del(ctx, key, s.options(opts)...)
+}
+
+// options - puts the namespace first, so WithNamespace of the call still overrides it.
+func (s *ScopedClient) options(opts []Option) []Option {
+	return append([]Option{WithNamespace(s.namespace)}, opts...)
 }
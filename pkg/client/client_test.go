@@ -1,13 +1,19 @@
 package client_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"io"
 	"testing"
 	"time"
 
 	"github.com/neekrasov/kvdb/internal/database"
 	"github.com/neekrasov/kvdb/internal/database/compute"
+	"github.com/neekrasov/kvdb/internal/database/storage/wal"
+	"github.com/neekrasov/kvdb/internal/delivery/tcp"
 	mocks "github.com/neekrasov/kvdb/internal/mocks/client"
 	"github.com/neekrasov/kvdb/pkg/client"
 	"github.com/stretchr/testify/assert"
@@ -20,6 +26,12 @@ const (
 	okPrefix  = "[ok]"
 )
 
+// expectServerVersion - expects the version request sent by the client after the authentication.
+func expectServerVersion(mockClient *mocks.NetClient) {
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandVERSION.String())).
+		Return([]byte(database.WrapOKType(database.ContentJSON, `{"version":"1.4.0","protocol":"2.0"}`)), nil).Once()
+}
+
 func TestNewNetClient_Success(t *testing.T) {
 	cfg := &client.Config{
 		Address:              "localhost:8080",
@@ -38,9 +50,11 @@ func TestNewNetClient_Success(t *testing.T) {
 		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
 		Return([]byte(okPrefix), nil)
 
+	expectServerVersion(mockClient)
 	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
 	require.NoError(t, err, "NewNetClient should not return an error")
 	assert.NotNil(t, kvdbClient, "Client should not be nil")
+	assert.Equal(t, database.VersionInfo{Version: "1.4.0", Protocol: "2.0"}, kvdbClient.ServerVersion())
 
 	mockClientFactory.AssertExpectations(t)
 	mockClient.AssertExpectations(t)
@@ -67,6 +81,61 @@ func TestNewNetClient_ConnectionError(t *testing.T) {
 	mockClientFactory.AssertExpectations(t)
 }
 
+func TestNewNetClient_UnknownServerVersion(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil).Once()
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandVERSION.String())).
+		Return([]byte(database.WrapError(fmt.Errorf("parse input failed: %w: unknown command", compute.ErrInvalidCommand))), nil).Once()
+
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+	assert.Equal(t, database.VersionInfo{}, kvdbClient.ServerVersion())
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestNewNetClient_IncompatibleServer(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil).Once()
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandVERSION.String())).
+		Return([]byte(database.WrapOKType(database.ContentJSON, `{"version":"3.0.0","protocol":"3.1"}`)), nil).Once()
+	mockClient.On("Close").Return(nil).Once()
+
+	_, err := client.New(ctx, cfg, mockClientFactory)
+	require.ErrorIs(t, err, client.ErrIncompatibleServer)
+	assert.ErrorContains(t, err, "server protocol '3.1'")
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
 func TestRawWithRetries_Success(t *testing.T) {
 	cfg := &client.Config{
 		Address:              "localhost:8080",
@@ -84,6 +153,7 @@ func TestRawWithRetries_Success(t *testing.T) {
 		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
 		Return([]byte(okPrefix), nil)
 
+	expectServerVersion(mockClient)
 	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
 	require.NoError(t, err, "NewNetClient should not return an error")
 
@@ -100,6 +170,52 @@ func TestRawWithRetries_Success(t *testing.T) {
 	mockClient.AssertExpectations(t)
 }
 
+func TestRawTyped(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	tests := []struct {
+		query       string
+		response    string
+		payload     string
+		contentType database.ContentType
+	}{
+		{compute.CommandGET.Make("key"), database.WrapOK(`{"a":1}`), `{"a":1}`, database.ContentText},
+		{compute.CommandDBSIZE.String(), database.WrapOKType(database.ContentJSON, `{"default":1}`), `{"default":1}`, database.ContentJSON},
+		{compute.CommandPUBLISH.Make("events", "msg"), database.WrapOKType(database.ContentInt, "2"), "2", database.ContentInt},
+		{compute.CommandNAMESPACES.String(), database.WrapOKType(database.ContentList, `["default"]`), `["default"]`, database.ContentList},
+	}
+
+	for _, tt := range tests {
+		mockClient.On("Send", mock.Anything, []byte(tt.query)).Return([]byte(tt.response), nil).Once()
+
+		res, contentType, err := kvdbClient.RawTyped(ctx, tt.query)
+		require.NoError(t, err)
+		assert.Equal(t, tt.payload, res)
+		assert.Equal(t, tt.contentType, contentType)
+	}
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
 func TestRawWithRetries_MaxReconnects(t *testing.T) {
 	cfg := &client.Config{
 		Address:              "localhost:8080",
@@ -126,6 +242,7 @@ func TestRawWithRetries_MaxReconnects(t *testing.T) {
 		[]byte(authCmd)).
 		Return([]byte(okPrefix), nil).Once()
 
+	expectServerVersion(mockClient)
 	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
 	require.NoError(t, err, "NewNetClient should not return an error")
 
@@ -136,6 +253,137 @@ func TestRawWithRetries_MaxReconnects(t *testing.T) {
 	mockClient.AssertExpectations(t)
 }
 
+func TestSend_PermanentErrors(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 3,
+		ReconnectBaseDelay:   time.Microsecond,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	// The client is connected once, the errors below must not reconnect.
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil).Once()
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	t.Run("Application error", func(t *testing.T) {
+		mockClient.On("Send", mock.Anything, []byte(compute.CommandGET.Make("secret"))).
+			Return([]byte(database.WrapError(database.ErrPermissionDenied)), nil).Once()
+
+		_, err := kvdbClient.Get(ctx, "secret")
+		require.ErrorContains(t, err, database.ErrPermissionDenied.Error())
+	})
+
+	t.Run("Value containing the authentication error", func(t *testing.T) {
+		mockClient.On("Send", mock.Anything, []byte(compute.CommandGET.Make("note"))).
+			Return([]byte(database.WrapOK("authentication required")), nil).Once()
+
+		value, err := kvdbClient.Get(ctx, "note")
+		require.NoError(t, err)
+		assert.Equal(t, "authentication required", value)
+	})
+
+	t.Run("Response larger than the buffer", func(t *testing.T) {
+		mockClient.On("Send", mock.Anything, []byte(compute.CommandGET.Make("large"))).
+			Return(nil, tcp.ErrSmallBufferSize).Once()
+
+		_, err := kvdbClient.Get(ctx, "large")
+		require.ErrorIs(t, err, tcp.ErrSmallBufferSize)
+	})
+
+	t.Run("Invalid payload", func(t *testing.T) {
+		mockClient.On("Send", mock.Anything, []byte(compute.CommandGET.Make("broken"))).
+			Return(nil, tcp.ErrInvalidPayload).Once()
+
+		_, err := kvdbClient.Get(ctx, "broken")
+		require.ErrorIs(t, err, tcp.ErrInvalidPayload)
+	})
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestReconnect_Token(t *testing.T) {
+	tests := []struct {
+		name         string
+		prepareMocks func(mockClientFactory *mocks.NetClientFactory, mockClient *mocks.NetClient, authCmd string)
+	}{
+		{
+			name: "Re-login with the token",
+			prepareMocks: func(mockClientFactory *mocks.NetClientFactory, mockClient *mocks.NetClient, authCmd string) {
+				mockClientFactory.On("Make", mock.Anything, mock.Anything).Return(mockClient, nil).Once()
+				mockClient.On("Send", mock.Anything, []byte(compute.CommandLOGINTOKEN.Make("token1"))).
+					Return([]byte(okPrefix), nil).Once()
+				mockClient.On("Send", mock.Anything, []byte(compute.CommandTOKEN.String())).
+					Return([]byte(database.WrapOK("token2")), nil).Once()
+			},
+		},
+		{
+			name: "Fallback to the password on rejected token",
+			prepareMocks: func(mockClientFactory *mocks.NetClientFactory, mockClient *mocks.NetClient, authCmd string) {
+				mockClientFactory.On("Make", mock.Anything, mock.Anything).Return(mockClient, nil).Twice()
+				mockClient.On("Send", mock.Anything, []byte(compute.CommandLOGINTOKEN.Make("token1"))).
+					Return([]byte(database.WrapError(database.ErrInvalidToken)), nil).Once()
+				mockClient.On("Send", mock.Anything, []byte(authCmd)).
+					Return([]byte(okPrefix), nil).Once()
+				mockClient.On("Send", mock.Anything, []byte(compute.CommandTOKEN.String())).
+					Return([]byte(database.WrapOK("token2")), nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &client.Config{
+				Address:              "localhost:8080",
+				Username:             "user",
+				Password:             "pass",
+				MaxReconnectAttempts: 2,
+				ReconnectBaseDelay:   time.Microsecond,
+				UseToken:             true,
+			}
+
+			ctx := context.Background()
+			mockClientFactory := mocks.NewNetClientFactory(t)
+			mockClient := mocks.NewNetClient(t)
+
+			authCmd := compute.CommandAUTH.Make(cfg.Username, cfg.Password)
+			mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil).Once()
+			mockClient.On("Send", mock.Anything, []byte(authCmd)).Return([]byte(okPrefix), nil).Once()
+			mockClient.On("Send", mock.Anything, []byte(compute.CommandTOKEN.String())).
+				Return([]byte(database.WrapOK("token1")), nil).Once()
+
+			expectServerVersion(mockClient)
+			kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+			require.NoError(t, err)
+
+			getCmd := compute.CommandGET.Make("key")
+			mockClient.On("Send", mock.Anything, []byte(getCmd)).
+				Return(nil, errors.New("connection failed")).Once()
+			mockClient.On("Close").Return(nil)
+			tt.prepareMocks(mockClientFactory, mockClient, authCmd)
+			mockClient.On("Send", mock.Anything, []byte(getCmd)).
+				Return([]byte(database.WrapOK("value")), nil).Once()
+
+			res, err := kvdbClient.Raw(ctx, getCmd)
+			require.NoError(t, err)
+			assert.Equal(t, "value", res)
+
+			mockClientFactory.AssertExpectations(t)
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
 func TestAuthenticationFailure(t *testing.T) {
 	cfg := &client.Config{
 		Address:  "localhost:8080",
@@ -177,6 +425,7 @@ func TestClose(t *testing.T) {
 		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
 		Return([]byte(okPrefix), nil)
 
+	expectServerVersion(mockClient)
 	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
 	require.NoError(t, err, "NewNetClient should not return an error")
 
@@ -187,3 +436,1495 @@ func TestClose(t *testing.T) {
 	mockClientFactory.AssertExpectations(t)
 	mockClient.AssertExpectations(t)
 }
+
+func TestGetDel(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandGETDEL.Make("job"))).
+		Return([]byte(database.WrapOK("payload")), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandGETDEL.Make("job"))).
+		Return([]byte(database.WrapError(compute.ErrKeyNotFound)), nil).Once()
+
+	val, err := kvdbClient.GetDel(ctx, "job")
+	require.NoError(t, err)
+	assert.Equal(t, "payload", val)
+
+	_, err = kvdbClient.GetDel(ctx, "job")
+	assert.ErrorIs(t, err, client.ErrKeyNotFound)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGet_Default(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandGET.Make("missing", compute.DefaultArg, "fallback"))).
+		Return([]byte(database.WrapOK("fallback")), nil).Once()
+	val, err := kvdbClient.Get(ctx, "missing", client.WithDefault("fallback"))
+	require.NoError(t, err)
+	assert.Equal(t, "fallback", val)
+
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandGET.Make("missing", compute.DefaultArg, "fallback", compute.SetDefaultFlagArg))).
+		Return([]byte(database.WrapOK("fallback")), nil).Once()
+	val, err = kvdbClient.Get(ctx, "missing", client.WithSetDefault("fallback"))
+	require.NoError(t, err)
+	assert.Equal(t, "fallback", val)
+
+	// The binary default is sent base64-encoded.
+	encoded := base64.StdEncoding.EncodeToString([]byte("a b"))
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandGET.Make("missing", compute.DefaultArg, encoded, compute.Base64FlagArg))).
+		Return([]byte(database.WrapOK(encoded)), nil).Once()
+	val, err = kvdbClient.Get(ctx, "missing", client.WithDefault("a b"), client.WithBinary())
+	require.NoError(t, err)
+	assert.Equal(t, "a b", val)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSetGet_Binary(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	blob := "\x00binary\nvalue with spaces\r\n\xff"
+	encoded := base64.StdEncoding.EncodeToString([]byte(blob))
+
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandSET.Make("blob", encoded, compute.Base64FlagArg))).
+		Return([]byte(okPrefix), nil).Once()
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandGET.Make("blob", compute.Base64FlagArg))).
+		Return([]byte(database.WrapOK(encoded)), nil).Once()
+
+	require.NoError(t, kvdbClient.Set(ctx, "blob", blob, client.WithBinary()))
+
+	val, err := kvdbClient.Get(ctx, "blob", client.WithBinary())
+	require.NoError(t, err)
+	assert.Equal(t, blob, val)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSet_ReturnOld(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandSET.Make("key", "v1", compute.GetFlagArg))).
+		Return([]byte(database.WrapOK(`{"value":"","exists":false}`)), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandSET.Make("key", "v2", compute.GetFlagArg))).
+		Return([]byte(database.WrapOK(`{"value":"v1","exists":true}`)), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandSET.Make("key", "v3"))).
+		Return([]byte(okPrefix), nil).Once()
+
+	var prev database.PrevValue
+	require.NoError(t, kvdbClient.Set(ctx, "key", "v1", client.WithReturnOld(&prev)))
+	assert.Equal(t, database.PrevValue{}, prev)
+
+	require.NoError(t, kvdbClient.Set(ctx, "key", "v2", client.WithReturnOld(&prev)))
+	assert.Equal(t, database.PrevValue{Value: "v1", Exists: true}, prev)
+
+	require.NoError(t, kvdbClient.Set(ctx, "key", "v3"))
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestType(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandTYPE.Make("counter"))).
+		Return([]byte(database.WrapOK(database.TypeInt)), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandTYPE.Make("name"))).
+		Return([]byte(database.WrapOK(database.TypeString)), nil).Once()
+
+	kind, err := kvdbClient.Type(ctx, "counter")
+	require.NoError(t, err)
+	assert.Equal(t, database.TypeInt, kind)
+
+	kind, err = kvdbClient.Type(ctx, "name")
+	require.NoError(t, err)
+	assert.Equal(t, database.TypeString, kind)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestDel(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	query := []byte(compute.CommandDEL.Make("key", compute.CountArg))
+	mockClient.On("Send", mock.Anything, query).Return([]byte(database.WrapOK("1")), nil).Once()
+	mockClient.On("Send", mock.Anything, query).Return([]byte(database.WrapOK("0")), nil).Once()
+
+	deleted, err := kvdbClient.Del(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = kvdbClient.Del(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestDelMany(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandDEL.Make(`"a"`, `"b"`, `"missing"`, compute.CountArg))).
+		Return([]byte(database.WrapOK("2")), nil).Once()
+
+	keys := []string{"a", "b", "missing"}
+	deleted, err := kvdbClient.DelMany(ctx, keys)
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	assert.Equal(t, []string{"a", "b", "missing"}, keys)
+
+	// The keys named as the parameters are sent quoted, so they are not taken as the count flag or namespace.
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandDEL.Make(`"count"`, `"ns"`, `"say \"hi\""`, compute.CountArg))).
+		Return([]byte(database.WrapOK("3")), nil).Once()
+
+	deleted, err = kvdbClient.DelMany(ctx, []string{"count", "ns", `say "hi"`})
+	require.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+
+	deleted, err = kvdbClient.DelMany(ctx, nil)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestRenameNX(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandRENAMENX.Make("old", "new"))).
+		Return([]byte(database.WrapOK("true")), nil).Once()
+	renamed, err := kvdbClient.RenameNX(ctx, "old", "new")
+	require.NoError(t, err)
+	assert.True(t, renamed)
+
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandRENAMENX.Make("old", "exists"))).
+		Return([]byte(database.WrapOK("false")), nil).Once()
+	renamed, err = kvdbClient.RenameNX(ctx, "old", "exists")
+	require.NoError(t, err)
+	assert.False(t, renamed)
+
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandRENAMENX.Make("missing", "new"))).
+		Return([]byte(errPrefix+" key not found"), nil).Once()
+	_, err = kvdbClient.RenameNX(ctx, "missing", "new")
+	assert.ErrorIs(t, err, client.ErrKeyNotFound)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestMSetNX(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandMSETNX.Make("a", "1", "b", "2"))).
+		Return([]byte(database.WrapOK("true")), nil).Once()
+	stored, err := kvdbClient.MSetNX(ctx, map[string]string{"b": "2", "a": "1"})
+	require.NoError(t, err)
+	assert.True(t, stored)
+
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandMSETNX.Make("a", "1"))).
+		Return([]byte(database.WrapOK("false")), nil).Once()
+	stored, err = kvdbClient.MSetNX(ctx, map[string]string{"a": "1"})
+	require.NoError(t, err)
+	assert.False(t, stored)
+}
+
+func TestDump(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "admin",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandDUMP.Make("key"))).
+		Return([]byte(database.WrapOK(
+			`{"key":"default:key","value":"val","raw":"dmFs","expiry":1700000000,"partition":0}`)), nil).Once()
+	dump, err := kvdbClient.Dump(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, &database.EntryDump{
+		Key: "default:key", Value: "val", Raw: []byte("val"), Expiry: 1700000000,
+	}, dump)
+
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandDUMP.Make("missing"))).
+		Return([]byte(errPrefix+" key not found"), nil).Once()
+	_, err = kvdbClient.Dump(ctx, "missing")
+	assert.ErrorIs(t, err, client.ErrKeyNotFound)
+}
+
+func TestSessionInfo(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandSESSIONINFO.String())).
+		Return([]byte(database.WrapOK(`{"username":"user","roles":["reader"],"namespace":"ns1",`+
+			`"get":true,"set":false,"del":false,"created_at":"2025-04-14T00:00:00Z"}`)), nil).Once()
+
+	info, err := kvdbClient.SessionInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &database.SessionInfo{
+		Username:  "user",
+		Roles:     []string{"reader"},
+		Namespace: "ns1",
+		Get:       true,
+		CreatedAt: time.Date(2025, 4, 14, 0, 0, 0, 0, time.UTC),
+	}, info)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestScan(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandSCAN.Make("0", compute.CountArg, "2"))).
+		Return([]byte(database.WrapOK(`{"keys":["a","b"],"cursor":42}`)), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandSCAN.Make("42", compute.CountArg, "2"))).
+		Return([]byte(database.WrapOK(`{"keys":["c"],"cursor":0}`)), nil).Once()
+
+	var (
+		keys   []string
+		cursor uint64
+	)
+	for {
+		page, next, err := kvdbClient.Scan(ctx, cursor, client.WithCount(2))
+		require.NoError(t, err)
+		keys = append(keys, page...)
+
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+
+	assert.Equal(t, []string{"a", "b", "c"}, keys)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestHealth(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandHEALTH.String())).
+		Return([]byte(database.WrapOK(`healthy {"ready":false,"wal_recovered":true,"replica_synced":false}`)), nil).Once()
+
+	health, err := kvdbClient.Health(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &database.Health{WALRecovered: true}, health)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestDBSize(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandDBSIZE.String())).
+		Return([]byte(database.WrapOK(`{"default":0,"ns1":2}`)), nil).Once()
+
+	sizes, err := kvdbClient.DBSize(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"default": 0, "ns1": 2}, sizes)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestFlushNamespace(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandFLUSHNS.Make("ns1"))).
+		Return([]byte(database.WrapOK("2")), nil).Once()
+
+	deleted, err := kvdbClient.FlushNamespace(ctx, "ns1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestUsersAndRoles(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandUSERS.String())).
+		Return([]byte(database.WrapOK(`["a","b","c"]`)), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandUSERS.Make(compute.LimitArg, "2"))).
+		Return([]byte(database.WrapOK(`["a","b"]`)), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandROLES.Make(compute.OffsetArg, "2"))).
+		Return([]byte(database.WrapOK(`["c"]`)), nil).Once()
+
+	users, err := kvdbClient.Users(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, users)
+
+	users, err = kvdbClient.Users(ctx, client.WithLimit(2))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, users)
+
+	roles, err := kvdbClient.Roles(ctx, client.WithOffset(2))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, roles)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestUpdateRole(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandUPDATEROLE.Make("writer", "rw"))).
+		Return([]byte(okPrefix), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandUPDATEROLE.Make("writer", "r", "ns2"))).
+		Return([]byte(database.WrapError(errors.New("namespace not found"))), nil).Once()
+
+	require.NoError(t, kvdbClient.UpdateRole(ctx, "writer", "rw", ""))
+
+	err = kvdbClient.UpdateRole(ctx, "writer", "r", "ns2")
+	assert.ErrorContains(t, err, "namespace not found")
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSetRole(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandSETROLE.Make("writer"))).
+		Return([]byte(okPrefix), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandSETROLE.Make("admin"))).
+		Return([]byte(database.WrapError(database.ErrRoleNotAssigned)), nil).Once()
+
+	require.NoError(t, kvdbClient.SetRole(ctx, "writer"))
+
+	err = kvdbClient.SetRole(ctx, "admin")
+	assert.ErrorContains(t, err, database.ErrRoleNotAssigned.Error())
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestRoleUsers(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandROLEUSERS.Make("reader"))).
+		Return([]byte(database.WrapOK(`["alice","bob"]`)), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandROLEUSERS.Make("missing"))).
+		Return([]byte(database.WrapError(errors.New("role not found"))), nil).Once()
+
+	users, err := kvdbClient.RoleUsers(ctx, "reader")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, users)
+
+	_, err = kvdbClient.RoleUsers(ctx, "missing")
+	assert.ErrorContains(t, err, "role not found")
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestConnections(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandCONNECTIONS.String())).
+		Return([]byte(database.WrapOK(`[{"session":"s1","username":"root",`+
+			`"remote_addr":"127.0.0.1:5000","connected_at":"2024-01-02T03:04:05Z",`+
+			`"bytes_read":10,"bytes_written":20,"command":"connections"}]`)), nil).Once()
+
+	conns, err := kvdbClient.Connections(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []database.ConnInfo{{
+		SessionID:    "s1",
+		Username:     "root",
+		RemoteAddr:   "127.0.0.1:5000",
+		ConnectedAt:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		BytesRead:    10,
+		BytesWritten: 20,
+		Command:      "connections",
+	}}, conns)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestCaps(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandCAPS.String())).
+		Return([]byte(database.WrapOK(`["caps","get","set"]`)), nil).Once()
+
+	caps, err := kvdbClient.Caps(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"caps", "get", "set"}, caps)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandCAPS.String())).
+		Return([]byte(database.WrapOK("caps")), nil).Once()
+
+	_, err = kvdbClient.Caps(ctx)
+	assert.ErrorIs(t, err, client.ErrInvalidResponseFormat)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestExpiring(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandEXPIRING.Make("30s", compute.NSArg, "cache"))).
+		Return([]byte(database.WrapOK(`["a","b"]`)), nil).Once()
+
+	keys, err := kvdbClient.Expiring(ctx, 30*time.Second, client.WithNamespace("cache"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, keys)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestLastAccess(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandLASTACCESS.Make("key"))).
+		Return([]byte(database.WrapOK("2026-10-14T08:30:00Z")), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandLASTACCESS.Make("missing"))).
+		Return([]byte(database.WrapError(compute.ErrKeyNotFound)), nil).Once()
+
+	accessed, err := kvdbClient.LastAccess(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, time.October, 14, 8, 30, 0, 0, time.UTC), accessed)
+
+	_, err = kvdbClient.LastAccess(ctx, "missing")
+	require.ErrorIs(t, err, client.ErrKeyNotFound)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestWALLatency(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandWALLATENCY.String())).
+		Return([]byte(database.WrapOK(`{"count":2,"p50":1000,"p95":2000,"p99":2000,"max":2000}`)), nil).Once()
+
+	latency, err := kvdbClient.WALLatency(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &wal.LatencyStats{
+		Count: 2, P50: time.Microsecond, P95: 2 * time.Microsecond,
+		P99: 2 * time.Microsecond, Max: 2 * time.Microsecond,
+	}, latency)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestStatsTyped(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandSTAT.Make())).
+		Return([]byte(database.WrapOK(`{"uptime":10,"total_commands":50,"hits":30,"misses":10}`)), nil).Once()
+
+	stats, err := kvdbClient.StatsTyped(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, stats.OpsPerSecond)
+	assert.Positive(t, *stats.OpsPerSecond)
+	assert.InDelta(t, 5.0, *stats.OpsPerSecond, 1e-9)
+	require.NotNil(t, stats.HitRatio)
+	assert.InDelta(t, 0.75, *stats.HitRatio, 1e-9)
+
+	// The derived metrics are not reported while the storage statistics are disabled.
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandSTAT.Make())).
+		Return([]byte(database.WrapOK(`{"active_sessions":1,"unavailable":["uptime","total_commands"]}`)), nil).Once()
+
+	stats, err = kvdbClient.StatsTyped(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, stats.OpsPerSecond)
+	assert.Nil(t, stats.HitRatio)
+	assert.Equal(t, int64(1), stats.ActiveSessions)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestCompact(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandCOMPACT.String())).
+		Return([]byte(database.WrapOK(`{"bytes_reclaimed":512,"segments_removed":3}`)), nil).Once()
+
+	result, err := kvdbClient.Compact(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &wal.CompactionResult{BytesReclaimed: 512, SegmentsRemoved: 3}, result)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandCOMPACT.String())).
+		Return([]byte(database.WrapError(wal.ErrCompactionDisabled)), nil).Once()
+
+	_, err = kvdbClient.Compact(ctx)
+	require.Error(t, err)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSync(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandFSYNC.String())).
+		Return([]byte(okPrefix), nil).Once()
+	require.NoError(t, kvdbClient.Sync(ctx))
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandFSYNC.String())).
+		Return([]byte(database.WrapError(wal.ErrSyncUnavailable)), nil).Once()
+	assert.ErrorContains(t, kvdbClient.Sync(ctx), wal.ErrSyncUnavailable.Error())
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestTimeout(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 3,
+		ReconnectBaseDelay:   10 * time.Second,
+		DefaultTimeout:       50 * time.Millisecond,
+		// The jittered delay may be shorter than the deadline, the linear one is not.
+		LinearReconnect: true,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil).Once()
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil).Once()
+
+	// The slow server answers only when the operation is canceled.
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandGET.Make("slow"))).
+		Return(nil, func(ctx context.Context, _ []byte) error {
+			<-ctx.Done()
+			return fmt.Errorf("operation canceled: %w", ctx.Err())
+		}).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandGET.Make("broken"))).
+		Return(nil, errors.New("connection failed")).Once()
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = kvdbClient.Get(ctx, "slow")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// The reconnect backoff is interrupted by the deadline of the call.
+	start = time.Now()
+	_, err = kvdbClient.Get(ctx, "broken", client.WithTimeout(100*time.Millisecond))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	assert.Less(t, time.Since(start), time.Second)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestLogin(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 2,
+		ReconnectBaseDelay:   time.Microsecond,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil).Twice()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandAUTH.Make("user", "pass"))).
+		Return([]byte(okPrefix), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandAUTH.Make("other", "wrong"))).
+		Return([]byte(database.WrapError(errors.New("authentication failed"))), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandAUTH.Make("other", "secret"))).
+		Return([]byte(database.WrapOK("authentication successful")), nil).Twice()
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	err = kvdbClient.Login(ctx, "other", "wrong")
+	require.ErrorIs(t, err, client.ErrAuthenticationFailed)
+	assert.Equal(t, "user", cfg.Username)
+
+	require.NoError(t, kvdbClient.Login(ctx, "other", "secret"))
+	assert.Equal(t, "other", cfg.Username)
+
+	// The reconnect authenticates with the credentials of the last login.
+	getCmd := compute.CommandGET.Make("key")
+	mockClient.On("Send", mock.Anything, []byte(getCmd)).
+		Return(nil, errors.New("connection failed")).Once()
+	mockClient.On("Close").Return(nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(getCmd)).
+		Return([]byte(database.WrapOK("value")), nil).Once()
+
+	value, err := kvdbClient.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestLogout(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandAUTH.Make("user", "pass"))).
+		Return([]byte(okPrefix), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandLOGOUT.String())).
+		Return([]byte(okPrefix), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandGET.Make("key"))).
+		Return([]byte(database.WrapError(errors.New("get current session failed: session expired"))), nil).Once()
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	require.NoError(t, kvdbClient.Logout(ctx))
+
+	_, err = kvdbClient.Get(ctx, "key")
+	assert.ErrorContains(t, err, "session expired")
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSubscribe(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+	subClient := mocks.NewNetClient(t)
+
+	authCmd := []byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil).Once()
+	mockClient.On("Send", mock.Anything, authCmd).Return([]byte(okPrefix), nil).Once()
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	getCmd := []byte(compute.CommandGET.Make("key"))
+	watchCmd := []byte(compute.CommandWATCH.Make("key"))
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(subClient, nil).Once()
+	subClient.On("Send", mock.Anything, authCmd).Return([]byte(okPrefix), nil).Once()
+	subClient.On("Send", mock.Anything, getCmd).Return([]byte(database.WrapOK("v0")), nil).Once()
+
+	subClient.On("Send", mock.Anything, watchCmd).Return([]byte(database.WrapOK("v1")), nil).Once()
+	subClient.On("Send", mock.Anything, getCmd).Return([]byte(database.WrapOK("v1")), nil).Once()
+	subClient.On("Send", mock.Anything, watchCmd).Return([]byte(database.WrapOK("v2")), nil).Once()
+	// The key is changed again before the next watch is registered.
+	subClient.On("Send", mock.Anything, getCmd).Return([]byte(database.WrapOK("v3")), nil).Once()
+	subClient.On("Send", mock.Anything, watchCmd).
+		Return(nil, func(ctx context.Context, _ []byte) error {
+			<-ctx.Done()
+			return fmt.Errorf("operation canceled: %w", ctx.Err())
+		}).Once()
+	subClient.On("Close").Return(nil).Once()
+
+	expectServerVersion(subClient)
+	values, err := kvdbClient.Subscribe(ctx, "key")
+	require.NoError(t, err)
+
+	for _, expected := range []string{"v1", "v2", "v3"} {
+		select {
+		case value := <-values:
+			assert.Equal(t, expected, value)
+		case <-time.After(time.Second):
+			t.Fatalf("value %q is not delivered", expected)
+		}
+	}
+
+	cancel()
+	select {
+	case _, ok := <-values:
+		assert.False(t, ok, "channel must be closed after the cancellation")
+	case <-time.After(time.Second):
+		t.Fatal("channel is not closed after the cancellation")
+	}
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+	subClient.AssertExpectations(t)
+}
+
+func TestPublishSubscribeChannel(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+	subClient := mocks.NewNetClient(t)
+
+	authCmd := []byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil).Once()
+	mockClient.On("Send", mock.Anything, authCmd).Return([]byte(okPrefix), nil).Once()
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandPUBLISH.Make("events", "hello"))).
+		Return([]byte(database.WrapOK("2")), nil).Once()
+	subscribers, err := kvdbClient.Publish(ctx, "events", "hello")
+	require.NoError(t, err)
+	assert.Equal(t, 2, subscribers)
+
+	subscribeCmd := []byte(compute.CommandSUBSCRIBE.Make("events"))
+	listenCmd := []byte(compute.CommandLISTEN.Make("events"))
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(subClient, nil).Once()
+	subClient.On("Send", mock.Anything, authCmd).Return([]byte(okPrefix), nil).Once()
+	subClient.On("Send", mock.Anything, subscribeCmd).Return([]byte(okPrefix), nil).Once()
+	subClient.On("Send", mock.Anything, listenCmd).Return([]byte(database.WrapOK(`["a","b"]`)), nil).Once()
+	// The listen timed out before a message was published.
+	subClient.On("Send", mock.Anything, listenCmd).Return([]byte(database.WrapOK(`[]`)), nil).Once()
+	// The session lost the subscription, so it's registered again.
+	subClient.On("Send", mock.Anything, listenCmd).Return([]byte("[error] not subscribed"), nil).Once()
+	subClient.On("Send", mock.Anything, subscribeCmd).Return([]byte(okPrefix), nil).Once()
+	subClient.On("Send", mock.Anything, listenCmd).Return([]byte(database.WrapOK(`["c"]`)), nil).Once()
+	subClient.On("Send", mock.Anything, listenCmd).
+		Return(nil, func(ctx context.Context, _ []byte) error {
+			<-ctx.Done()
+			return fmt.Errorf("operation canceled: %w", ctx.Err())
+		}).Once()
+	subClient.On("Close").Return(nil).Once()
+
+	expectServerVersion(subClient)
+	messages, err := kvdbClient.SubscribeChannel(ctx, "events")
+	require.NoError(t, err)
+
+	for _, expected := range []string{"a", "b", "c"} {
+		select {
+		case message := <-messages:
+			assert.Equal(t, expected, message)
+		case <-time.After(time.Second):
+			t.Fatalf("message %q is not delivered", expected)
+		}
+	}
+
+	cancel()
+	select {
+	case _, ok := <-messages:
+		assert.False(t, ok, "channel must be closed after the cancellation")
+	case <-time.After(time.Second):
+		t.Fatal("channel is not closed after the cancellation")
+	}
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+	subClient.AssertExpectations(t)
+}
+
+func TestExportImport(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	dump := base64.StdEncoding.EncodeToString([]byte("\x01\x04gzip dump"))
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandEXPORT.Make("ns1"))).
+		Return([]byte(database.WrapOK(dump)), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandIMPORT.Make("ns2", dump))).
+		Return([]byte(database.WrapOK("3")), nil).Once()
+
+	reader, err := kvdbClient.Export(ctx, "ns1")
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("\x01\x04gzip dump"), data)
+
+	require.NoError(t, kvdbClient.Import(ctx, "ns2", bytes.NewReader(data)))
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestScopedClient(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil).Once()
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	scoped := kvdbClient.WithNamespace("tenant")
+	assert.Equal(t, "tenant", scoped.Namespace())
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandSET.Make("key", "value", compute.NSArg, "tenant"))).
+		Return([]byte(okPrefix), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandGET.Make("key", compute.NSArg, "tenant"))).
+		Return([]byte(database.WrapOK("value")), nil).Once()
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandGET.Make("key", compute.NSArg, "other"))).
+		Return([]byte(database.WrapOK("other value")), nil).Once()
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandDEL.Make("key", compute.CountArg, compute.NSArg, "tenant"))).
+		Return([]byte(database.WrapOKType(database.ContentInt, "1")), nil).Once()
+
+	require.NoError(t, scoped.Set(ctx, "key", "value"))
+
+	value, err := scoped.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	// The namespace of the call overrides the namespace of the scoped client.
+	value, err = scoped.Get(ctx, "key", client.WithNamespace("other"))
+	require.NoError(t, err)
+	assert.Equal(t, "other value", value)
+
+	deleted, err := scoped.Del(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	// The parent client keeps running the commands without the namespace.
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandGET.Make("key"))).
+		Return([]byte(database.WrapError(compute.ErrKeyNotFound)), nil).Once()
+
+	_, err = kvdbClient.Get(ctx, "key")
+	assert.ErrorIs(t, err, client.ErrKeyNotFound)
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSetEX(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandSETEX.Make("key", "10", "value"))).
+		Return([]byte(okPrefix), nil).Once()
+	// The ttl is rounded up to whole seconds.
+	mockClient.On("Send", mock.Anything, []byte(compute.CommandSETEX.Make("key", "2", "value"))).
+		Return([]byte(okPrefix), nil).Once()
+
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandSETEX.Make("key", "10", "value", compute.NSArg, "cache"))).
+		Return([]byte(okPrefix), nil).Once()
+
+	require.NoError(t, kvdbClient.SetEX(ctx, "key", "value", 10*time.Second))
+	require.NoError(t, kvdbClient.SetEX(ctx, "key", "value", 1500*time.Millisecond))
+	require.NoError(t, kvdbClient.SetEX(ctx, "key", "value", 10*time.Second,
+		client.WithNamespace("cache"), client.WithTimeout(time.Second)))
+
+	err = kvdbClient.SetEX(ctx, "key", "value", 0)
+	assert.ErrorContains(t, err, "ttl must be positive")
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestDelPrefix(t *testing.T) {
+	cfg := &client.Config{
+		Address:              "localhost:8080",
+		Username:             "user",
+		Password:             "pass",
+		MaxReconnectAttempts: 1,
+	}
+
+	ctx := context.Background()
+	mockClientFactory := mocks.NewNetClientFactory(t)
+	mockClient := mocks.NewNetClient(t)
+
+	mockClientFactory.On("Make", cfg.Address, mock.Anything).Return(mockClient, nil)
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandAUTH.Make(cfg.Username, cfg.Password))).
+		Return([]byte(okPrefix), nil)
+
+	expectServerVersion(mockClient)
+	kvdbClient, err := client.New(ctx, cfg, mockClientFactory)
+	require.NoError(t, err)
+
+	mockClient.On("Send", mock.Anything,
+		[]byte(compute.CommandDELPREFIX.Make("session:", compute.NSArg, "cache"))).
+		Return([]byte(database.WrapOKType(database.ContentInt, "3")), nil).Once()
+
+	deleted, err := kvdbClient.DelPrefix(ctx, "session:", client.WithNamespace("cache"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+
+	_, err = kvdbClient.DelPrefix(ctx, "")
+	assert.ErrorContains(t, err, "empty prefix")
+
+	mockClientFactory.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
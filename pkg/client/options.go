@@ -3,6 +3,7 @@ package client
 import (
 	"time"
 
+	"github.com/neekrasov/kvdb/internal/database"
 	"github.com/neekrasov/kvdb/internal/database/compression"
 )
 
@@ -11,6 +12,15 @@ type callOptions struct {
 	compressor compression.Compressor
 	ttl        *time.Duration
 	namespace  string
+	match      string
+	count      int
+	offset     int
+	limit      int
+	timeout    time.Duration
+	prev       *database.PrevValue
+	binary     bool
+	def        *string
+	setDefault bool
 }
 
 // Option - общий тип для опций методов клиента.
@@ -31,6 +41,40 @@ func WithTTL(duration time.Duration) Option {
 	}
 }
 
+// WithReturnOld - опция для получения предыдущего значения ключа в prev (только для Set).
+// Значение перезаписывается за один запрос к серверу.
+func WithReturnOld(prev *database.PrevValue) Option {
+	return func(o *callOptions) {
+		o.prev = prev
+	}
+}
+
+// WithBinary - опция для передачи значения в base64 (только для Set, Get и GetDel).
+// Сервер хранит декодированное значение, поэтому бинарные данные с пробелами,
+// переводами строк и нулевыми байтами сохраняются без искажений.
+func WithBinary() Option {
+	return func(o *callOptions) {
+		o.binary = true
+	}
+}
+
+// WithDefault - опция для получения значения по умолчанию вместо ErrKeyNotFound (только для Get).
+// Значение по умолчанию не сохраняется.
+func WithDefault(value string) Option {
+	return func(o *callOptions) {
+		o.def = &value
+	}
+}
+
+// WithSetDefault - опция для сохранения значения по умолчанию, если ключ отсутствует (только для Get).
+// Если ключ сохранён параллельно, возвращается сохранённое значение.
+func WithSetDefault(value string) Option {
+	return func(o *callOptions) {
+		o.def = &value
+		o.setDefault = true
+	}
+}
+
 // WithNamespace - опция для указания пространства имен для операции.
 // Предварительно инициализированное пространство имён игнорируется.
 func WithNamespace(namespace string) Option {
@@ -39,6 +83,42 @@ func WithNamespace(namespace string) Option {
 	}
 }
 
+// WithMatch - опция для фильтрации ключей по шаблону (только для Scan).
+func WithMatch(pattern string) Option {
+	return func(o *callOptions) {
+		o.match = pattern
+	}
+}
+
+// WithCount - опция для указания размера пачки ключей (только для Scan).
+func WithCount(count int) Option {
+	return func(o *callOptions) {
+		o.count = count
+	}
+}
+
+// WithOffset - опция для пропуска первых элементов списка (только для Users и Roles).
+func WithOffset(offset int) Option {
+	return func(o *callOptions) {
+		o.offset = offset
+	}
+}
+
+// WithLimit - опция для ограничения количества элементов списка (только для Users и Roles).
+func WithLimit(limit int) Option {
+	return func(o *callOptions) {
+		o.limit = limit
+	}
+}
+
+// WithTimeout - опция для ограничения времени выполнения вызова, включая переподключения.
+// Переопределяет Config.DefaultTimeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *callOptions) {
+		o.timeout = timeout
+	}
+}
+
 func applyOptions(opts []Option) callOptions {
 	co := callOptions{}
 	for _, opt := range opts {
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"fmt"
 	"os"
 	"path"
 
@@ -12,6 +13,7 @@ import (
 
 var (
 	logger *zap.Logger
+	level  = zap.NewAtomicLevel()
 
 	defaultLoggerFilename        = "kvdb.log"
 	defaultLoggerMaxSizeMb       = 10
@@ -19,14 +21,37 @@ var (
 	defaultLoggerMaxAgeDays      = 7
 )
 
+// Formats of the console output.
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
+)
+
 // MockLogger - mocks logger
 func MockLogger() {
 	logger = zap.NewNop()
 }
 
-// InitLogger - initializes logger with level
-func InitLogger(level, ouput string) {
-	Init(getCore(getAtomicLevel(level), ouput))
+// InitLogger - initializes logger with level, the console output is written in the format (console by default or json).
+func InitLogger(logLevel, ouput, format string) {
+	level = getAtomicLevel(logLevel)
+	Init(getCore(level, ouput, format, zapcore.AddSync(os.Stdout)))
+}
+
+// SetLevel - changes the level of the logger initialized by InitLogger at runtime.
+func SetLevel(logLevel string) error {
+	var lvl zapcore.Level
+	if err := lvl.Set(logLevel); err != nil {
+		return fmt.Errorf("failed to set log level: %w", err)
+	}
+
+	level.SetLevel(lvl)
+	return nil
+}
+
+// Level - returns the current level of the logger initialized by InitLogger.
+func Level() zapcore.Level {
+	return level.Level()
 }
 
 // Init - initializes new logger
@@ -73,13 +98,9 @@ func getAtomicLevel(logLevel string) zap.AtomicLevel {
 	return zap.NewAtomicLevelAt(level)
 }
 
-func getCore(level zap.AtomicLevel, output string) zapcore.Core {
+func getCore(level zap.AtomicLevel, output, format string, console zapcore.WriteSyncer) zapcore.Core {
 	var tee []zapcore.Core
 	if output != "" {
-		productionCfg := zap.NewProductionEncoderConfig()
-		productionCfg.TimeKey = "timestamp"
-		productionCfg.EncodeTime = zapcore.ISO8601TimeEncoder
-
 		file := zapcore.AddSync(
 			&lumberjack.Logger{
 				Filename:   path.Join(output, defaultLoggerFilename),
@@ -87,15 +108,32 @@ func getCore(level zap.AtomicLevel, output string) zapcore.Core {
 				MaxBackups: defaultLoggerMaxBackupsCount,
 				MaxAge:     defaultLoggerMaxAgeDays,
 			})
-		fileEncoder := zapcore.NewJSONEncoder(productionCfg)
-		tee = append(tee, zapcore.NewCore(fileEncoder, file, level))
+		tee = append(tee, zapcore.NewCore(getJSONEncoder(), file, level))
 	}
 
-	developmentCfg := zap.NewDevelopmentEncoderConfig()
-	developmentCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
-	consoleEncoder := zapcore.NewConsoleEncoder(developmentCfg)
-	tee = append(tee, zapcore.NewCore(
-		consoleEncoder, zapcore.AddSync(os.Stdout), level))
+	tee = append(tee, zapcore.NewCore(getConsoleEncoder(format), console, level))
 
 	return zapcore.NewTee(tee...)
 }
+
+func getJSONEncoder() zapcore.Encoder {
+	productionCfg := zap.NewProductionEncoderConfig()
+	productionCfg.TimeKey = "timestamp"
+	productionCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	return zapcore.NewJSONEncoder(productionCfg)
+}
+
+func getConsoleEncoder(format string) zapcore.Encoder {
+	switch format {
+	case "", FormatConsole:
+		developmentCfg := zap.NewDevelopmentEncoderConfig()
+		developmentCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		return zapcore.NewConsoleEncoder(developmentCfg)
+	case FormatJSON:
+		return getJSONEncoder()
+	default:
+		Fatal("unknown log format", zap.String("format", format))
+		return nil
+	}
+}
@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestGetCore_JSONFormat(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	log := zap.New(getCore(zap.NewAtomicLevelAt(zap.DebugLevel), "", FormatJSON, zapcore.AddSync(&buf)))
+
+	log.Debug("execute command",
+		zap.String("session", "abc"),
+		zap.String("cmd_type", "set"),
+		zap.Strings("args", []string{"key", "va\"lue"}),
+	)
+	require.NoError(t, log.Sync())
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry), buf.String())
+	assert.Equal(t, "debug", entry["level"])
+	assert.Equal(t, "execute command", entry["msg"])
+	assert.Equal(t, "abc", entry["session"])
+	assert.Equal(t, "set", entry["cmd_type"])
+	assert.Equal(t, []any{"key", "va\"lue"}, entry["args"])
+	assert.Contains(t, entry, "timestamp")
+}
+
+func TestGetCore_ConsoleFormat(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	log := zap.New(getCore(zap.NewAtomicLevelAt(zap.InfoLevel), "", FormatConsole, zapcore.AddSync(&buf)))
+
+	log.Info("started", zap.String("addr", "127.0.0.1:3223"))
+	require.NoError(t, log.Sync())
+
+	assert.Error(t, json.Unmarshal(buf.Bytes(), &map[string]any{}))
+	assert.Contains(t, buf.String(), "started")
+}